@@ -10,13 +10,14 @@ import (
 )
 
 type Collection struct {
-	ID          uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
-	HouseholdID uuid.UUID `gorm:"type:char(36);index" json:"-"`
-	UserID      uuid.UUID `gorm:"type:char(36);index" json:"user_id,omitempty"`
-	Name        string    `json:"name" validate:"required,min=2,max=255"`
-	Description string    `json:"description,omitempty" validate:"omitempty,max=1000"`
-	Updated     time.Time `gorm:"autoUpdateTime" json:"-"`
-	Created     time.Time `gorm:"autoCreateTime" json:"-"`
+	ID          uuid.UUID      `gorm:"type:char(36);primaryKey" json:"id"`
+	HouseholdID uuid.UUID      `gorm:"type:char(36);index" json:"-"`
+	UserID      uuid.UUID      `gorm:"type:char(36);index" json:"user_id,omitempty"`
+	Name        string         `json:"name" validate:"required,min=2,max=255"`
+	Description string         `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Updated     time.Time      `gorm:"autoUpdateTime" json:"-"`
+	Created     time.Time      `gorm:"autoCreateTime" json:"-"`
+	Deleted     gorm.DeletedAt `gorm:"index" json:"-"`
 
 	TotalRecipes *int64     `gorm:"->;-:migration" json:"total_recipes,omitempty"`
 	Household    *Household `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
@@ -55,4 +56,6 @@ type CollectionService interface {
 	ListRecipes(collectionID uuid.UUID, userID uuid.UUID, householdID uuid.UUID, opts types.SearchOptions) ([]Recipe, int64, error)
 	AddRecipe(collectionID uuid.UUID, recipeID uuid.UUID, householdID uuid.UUID) error
 	RemoveRecipe(collectionID uuid.UUID, recipeID uuid.UUID, householdID uuid.UUID) error
+	// Export serializes all recipes of the collection as a single document.
+	Export(collectionID uuid.UUID, userID uuid.UUID, householdID uuid.UUID, opts ExportOptions) ([]byte, error)
 }
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/internal/storage"
+)
+
+type CookbookFormat string
+
+const (
+	CookbookFormatHTML CookbookFormat = "html"
+	CookbookFormatPDF  CookbookFormat = "pdf"
+)
+
+// Cookbook is a printable document rendered from the recipes of a collection.
+// Small collections are rendered right away, larger ones are left pending for the background job.
+type Cookbook struct {
+	ID           uuid.UUID      `gorm:"type:char(36);primaryKey" json:"id"`
+	HouseholdID  uuid.UUID      `gorm:"type:char(36);index" json:"-"`
+	UserID       uuid.UUID      `gorm:"type:char(36);index" json:"user_id"`
+	CollectionID uuid.UUID      `gorm:"type:char(36);index" json:"collection_id"`
+	Format       CookbookFormat `json:"format" example:"pdf"`
+	Status       string         `gorm:"index" json:"status" example:"pending"` // "pending", "running", "success", "error"
+	FilePath     *storage.Path  `json:"file_url,omitempty"`
+	ErrorMessage string         `json:"error_message,omitempty"`
+	Updated      time.Time      `gorm:"autoUpdateTime" json:"updated"`
+	Created      time.Time      `gorm:"autoCreateTime" json:"created"`
+
+	Household  *Household  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Collection *Collection `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+func (c *Cookbook) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		var err error
+		c.ID, err = uuid.NewV7()
+		return err
+	}
+	return nil
+}
+
+type CookbookRepository interface {
+	ByID(id uuid.UUID) (*Cookbook, error)
+	// ListRunnable returns pending cookbooks and cookbooks left running since before staleBefore, oldest first.
+	ListRunnable(staleBefore time.Time) ([]Cookbook, error)
+	// ListByCollection returns the cookbooks of a collection in the format, oldest first.
+	ListByCollection(collectionID uuid.UUID, format CookbookFormat) ([]Cookbook, error)
+	Create(cookbook *Cookbook) error
+	Update(cookbook *Cookbook) error
+	Delete(id uuid.UUID) error
+}
+
+type CookbookService interface {
+	ByID(id uuid.UUID, householdID uuid.UUID) (*Cookbook, error)
+	// Create renders the cookbook of a collection, or queues it for Generate when the collection is large.
+	Create(ctx context.Context, collectionID uuid.UUID, userID uuid.UUID, householdID uuid.UUID, format CookbookFormat) (*Cookbook, error)
+	// Generate renders a cookbook, stores the file and records the outcome on the cookbook.
+	// The earlier finished cookbooks of the collection in the same format are deleted with their files.
+	Generate(ctx context.Context, cookbook *Cookbook) error
+	// Delete removes the cookbook and its file.
+	Delete(id uuid.UUID, householdID uuid.UUID) error
+}
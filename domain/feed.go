@@ -21,14 +21,21 @@ type Feed struct {
 	ErrorCount      int                  `json:"-"`
 	LastSyncAt      time.Time            `json:"last_sync_at"`
 	LastSyncSuccess bool                 `json:"last_sync_success"`
+	NextFetchAt     time.Time            `gorm:"index" json:"next_fetch_at"`
+	FetchInterval   time.Duration        `json:"-"`
+	ETag            string               `gorm:"column:etag" json:"-"`
+	LastModified    string               `json:"-"`
+	ContentHash     string               `json:"-"`
 	Discovered      *krip.DiscoveredFeed `gorm:"serializer:json" json:"-"`
 	Updated         time.Time            `gorm:"autoUpdateTime" json:"-"`
 	Created         time.Time            `gorm:"autoCreateTime" json:"-"`
 
-	TotalRecipes *int64       `gorm:"->;-:migration" json:"total_recipes,omitempty"`
-	Publisher    *Publisher   `json:"publisher,omitempty"`
-	Recipes      []*Recipe    `json:"recipes,omitempty"`
-	Households   []*Household `gorm:"many2many:feed_subscriptions;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	TotalRecipes  *int64       `gorm:"->;-:migration" json:"total_recipes,omitempty"`
+	UnreadRecipes *int64       `gorm:"->;-:migration" json:"unread_recipes,omitempty"`
+	Filter        *FeedFilter  `gorm:"->;-:migration;serializer:json" json:"filter,omitempty"`
+	Publisher     *Publisher   `json:"publisher,omitempty"`
+	Recipes       []*Recipe    `json:"recipes,omitempty"`
+	Households    []*Household `gorm:"many2many:feed_subscriptions;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 }
 
 func (f *Feed) BeforeCreate(_ *gorm.DB) error {
@@ -46,22 +53,39 @@ func (f *Feed) BeforeCreate(_ *gorm.DB) error {
 type FeedRepository interface {
 	ByIDForHousehold(id uuid.UUID, householdID uuid.UUID) (*Feed, error)
 	ByUrl(url string) (*Feed, error)
-	Search(householdID uuid.UUID, opts types.SearchOptions) ([]Feed, int64, error)
-	ListActive() ([]Feed, error)
+	Search(userID uuid.UUID, householdID uuid.UUID, opts types.SearchOptions) ([]Feed, int64, error)
+	// ListDue returns subscribed feeds due to be fetched at the given time, inactive ones included, soonest first.
+	// Feeds never scheduled come first.
+	ListDue(now time.Time) ([]Feed, error)
 	Create(recipe *Feed) error
 	Update(recipe *Feed) error
 	Delete(id uuid.UUID) error
 
 	AddFeed(householdID uuid.UUID, feed *Feed) error
 	DeleteFeed(householdID uuid.UUID, feedID uuid.UUID) error
+	// SetFilter replaces the filter of the household's subscription to the feed, nil clears it.
+	SetFilter(householdID uuid.UUID, feedID uuid.UUID, filter *FeedFilter) error
+
+	SetStreamFlag(userID uuid.UUID, householdID uuid.UUID, recipeID uuid.UUID, flag StreamFlag, value bool) error
+	// MarkFeedSeen marks every recipe of the feed as seen by the user.
+	MarkFeedSeen(userID uuid.UUID, householdID uuid.UUID, feedID uuid.UUID) error
 }
 
 type FeedService interface {
-	Search(householdID uuid.UUID, opts types.SearchOptions) ([]Feed, int64, error)
+	Search(userID uuid.UUID, householdID uuid.UUID, opts types.SearchOptions) ([]Feed, int64, error)
 	Subscribe(ctx context.Context, householdID uuid.UUID, url string, scraped *Feed) (*Feed, error)
 	Sync(ctx context.Context, householdID uuid.UUID, feedID uuid.UUID) (int, int, error)
 	Unsubscribe(householdID uuid.UUID, feedID uuid.UUID) error
+	// SetFilter replaces the filter of the household's subscription to the feed, nil or an empty filter clears it.
+	SetFilter(householdID uuid.UUID, feedID uuid.UUID, filter *FeedFilter) (*Feed, error)
+	// ExportOPML lists the subscriptions of the household as an OPML document, as read by RSS readers.
+	ExportOPML(userID uuid.UUID, householdID uuid.UUID) ([]byte, error)
 
-	Stream(userID uuid.UUID, householdID uuid.UUID, opts types.SearchOptions) ([]Recipe, int64, error)
+	Stream(userID uuid.UUID, householdID uuid.UUID, opts StreamOptions) ([]Recipe, int64, error)
+	// SetStreamFlag sets or clears a flag of a stream recipe for the user, e.g. marks it as read.
+	SetStreamFlag(userID uuid.UUID, householdID uuid.UUID, recipeID uuid.UUID, flag StreamFlag, value bool) error
+	// MarkFeedRead marks every recipe of a subscribed feed as read by the user.
+	MarkFeedRead(userID uuid.UUID, householdID uuid.UUID, feedID uuid.UUID) error
+	// FetchFeed imports the new recipes of a feed and schedules its next fetch.
 	FetchFeed(ctx context.Context, feed *Feed) (int, int, error)
 }
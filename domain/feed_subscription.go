@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/internal/types"
+)
+
+// FeedSubscription is the subscription of a household to a shared feed, with the household's own filter of its recipes.
+type FeedSubscription struct {
+	HouseholdID uuid.UUID   `gorm:"type:char(36);primaryKey"`
+	FeedID      uuid.UUID   `gorm:"type:char(36);primaryKey"`
+	Filter      *FeedFilter `gorm:"serializer:json"`
+}
+
+func (FeedSubscription) TableName() string {
+	return "feed_subscriptions"
+}
+
+// FeedFilter decides which recipes of a subscribed feed the household sees in its stream and unread counts.
+// A recipe is shown when it matches every rule that is set. Recipes without a total time or language pass those rules,
+// as there is nothing to judge them by.
+type FeedFilter struct {
+	// IncludeKeywords keeps only recipes with any of the keywords in the name.
+	IncludeKeywords []string `json:"include_keywords,omitempty" validate:"max=50,dive,min=2,max=100" example:"soup"`
+	// ExcludeKeywords leaves out recipes with any of the keywords in the name.
+	ExcludeKeywords []string `json:"exclude_keywords,omitempty" validate:"max=50,dive,min=2,max=100" example:"cocktail"`
+	// IncludeTaxonomies keeps only recipes with any of the taxonomies.
+	IncludeTaxonomies []uuid.UUID `json:"include_taxonomies,omitempty" validate:"max=50"`
+	// ExcludeTaxonomies leaves out recipes with any of the taxonomies.
+	ExcludeTaxonomies []uuid.UUID `json:"exclude_taxonomies,omitempty" validate:"max=50"`
+	// MinIngredients leaves out recipes with fewer ingredients.
+	MinIngredients int `json:"min_ingredients,omitempty" validate:"min=0,max=100" example:"3"`
+	// MaxTotalTime leaves out recipes that take longer.
+	MaxTotalTime *types.Duration `json:"max_total_time,omitempty" swaggertype:"integer" example:"3600"`
+	// Languages keeps only recipes in any of the languages.
+	Languages []string `json:"languages,omitempty" validate:"max=20,dive,len=2" example:"en"`
+}
+
+// IsEmpty reports whether the filter has no rules and so shows every recipe.
+func (f *FeedFilter) IsEmpty() bool {
+	return f == nil || len(f.IncludeKeywords) == 0 && len(f.ExcludeKeywords) == 0 &&
+		len(f.IncludeTaxonomies) == 0 && len(f.ExcludeTaxonomies) == 0 &&
+		f.MinIngredients == 0 && f.MaxTotalTime == nil && len(f.Languages) == 0
+}
@@ -45,6 +45,8 @@ type FoodRepository interface {
 	ByID(id uuid.UUID) (*Food, error)
 	ByIDs(ids []uuid.UUID) (map[uuid.UUID]*Food, error)
 	FindOrCreate(food *Food) error
+	// Find fills food with the stored food of the same slug or name, ErrNotFound when there is none.
+	Find(food *Food) error
 	Search(query string, offset, limit int) ([]Food, int64, error)
 	Merge(keepID, mergeID uuid.UUID) error
 	AddTaxonomy(foodID uuid.UUID, taxonomy *Taxonomy) error
@@ -60,6 +62,8 @@ type FoodService interface {
 	ByID(id uuid.UUID) (*Food, error)
 	ByIDs(ids []uuid.UUID) (map[uuid.UUID]*Food, error)
 	FindOrCreate(ctx context.Context, food *Food) error
+	// Find fills food with the stored food of the same slug or name without creating one.
+	Find(food *Food) error
 	Search(query string, offset, limit int) ([]Food, int64, error)
 	Merge(keepID, mergeID uuid.UUID) error
 	AddTaxonomy(foodID uuid.UUID, taxonomy *Taxonomy) error
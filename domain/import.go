@@ -10,19 +10,137 @@ const (
 	ImportTypeAuto   = "auto"
 	ImportTypeRecipe = "recipe"
 	ImportTypeFeed   = "feed"
+	// ImportTypeFile marks the import jobs of export files, it cannot be requested for a URL.
+	ImportTypeFile = "file"
+)
+
+// Export formats of other recipe managers accepted by ImportService.ImportFile, Cooklang as a zip of .cook files.
+const (
+	ImportSourcePaprika  = "paprika"
+	ImportSourceMealie   = "mealie"
+	ImportSourceTandoor  = "tandoor"
+	ImportSourceCooklang = "cooklang"
 )
 
 type ImportResult struct {
 	Created bool    `json:"created"`
 	Recipe  *Recipe `json:"recipe,omitempty"`
 	Feed    *Feed   `json:"feed,omitempty"`
+	// Preview is set instead of Recipe or Feed by a dry run that had to scrape the page.
+	Preview *ImportPreview `json:"preview,omitempty"`
+}
+
+// ImportPreview is what an import would store, nothing of it has been persisted.
+type ImportPreview struct {
+	ScrapeResult
+	Foods []ImportHint `json:"foods,omitempty"`
+	Units []ImportHint `json:"units,omitempty"`
+}
+
+// ImportHint tells whether a food or unit of a previewed recipe is already known or would be created.
+type ImportHint struct {
+	Name   string     `json:"name" example:"beetroot"`
+	Slug   string     `json:"slug" example:"beetroot"`
+	Exists bool       `json:"exists"`
+	ID     *uuid.UUID `json:"id,omitempty"` // of the stored food or unit when it exists
+}
+
+// FileImportItem is the outcome of importing a single recipe from an export file.
+type FileImportItem struct {
+	Name   string  `json:"name"`
+	Recipe *Recipe `json:"recipe,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// FileImportReport lists what happened to every recipe found in an export file.
+type FileImportReport struct {
+	Source   string           `json:"source" example:"paprika"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Items    []FileImportItem `json:"items"`
+}
+
+// RecipeChange is a field of a stored recipe that differs from its source, found when refreshing it.
+type RecipeChange struct {
+	Field string `json:"field" example:"ingredients"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+const (
+	BulkImportQueued   = "queued"
+	BulkImportCreated  = "created"
+	BulkImportExisting = "existing"
+	BulkImportFailed   = "failed"
+)
+
+// BulkImportItem is the outcome of one URL of a bulk import, a queued URL is still waiting for its import job.
+type BulkImportItem struct {
+	Url    string    `json:"url" example:"https://example.com/recipes/borscht"`
+	Status string    `json:"status" example:"created"` // "queued", "created", "existing", "failed"
+	JobID  uuid.UUID `json:"job_id"`
+	Recipe *Recipe   `json:"recipe,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// BulkImportReport lists what happened to every URL of a bulk import, in the order they were given.
+// It is rolled up from the import jobs of the bulk, poll it by ID until nothing is queued.
+type BulkImportReport struct {
+	ID       uuid.UUID        `json:"id"`
+	Queued   int              `json:"queued"`
+	Created  int              `json:"created"`
+	Existing int              `json:"existing"`
+	Failed   int              `json:"failed"`
+	Items    []BulkImportItem `json:"items"`
+}
+
+const (
+	FeedImportQueued   = "queued"
+	FeedImportExisting = "existing"
+	FeedImportInvalid  = "invalid"
+)
+
+// FeedImportItem is the outcome of one feed outline of an OPML import.
+// A queued feed is subscribed by its import job, which reports whether it was added or turned out invalid.
+type FeedImportItem struct {
+	Title  string     `json:"title,omitempty" example:"Smitten Kitchen"`
+	Url    string     `json:"url" example:"https://example.com/feed"`
+	Status string     `json:"status" example:"queued"` // "queued", "existing", "invalid"
+	Job    *ImportJob `json:"job,omitempty"`
+	Feed   *Feed      `json:"feed,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// FeedImportReport lists what happened to every feed outline of an OPML import, in document order.
+type FeedImportReport struct {
+	Queued   int              `json:"queued"`
+	Existing int              `json:"existing"`
+	Invalid  int              `json:"invalid"`
+	Items    []FeedImportItem `json:"items"`
 }
 
 type ImportService interface {
 	// ImportFromURL scrapes the URL and imports it as a recipe. Returns an error if the URL points to a feed.
 	ImportFromURL(ctx context.Context, url string, forceUpdate bool, userID uuid.UUID, householdID uuid.UUID) (*Recipe, error)
 	// DetectAndImport scrapes the URL and auto-detects whether it is a recipe or a feed, importing accordingly.
-	DetectAndImport(ctx context.Context, url string, requestedType string, forceUpdate bool, userID uuid.UUID, householdID uuid.UUID) (*ImportResult, error)
+	// A dry run stores nothing and returns a preview with the foods and units the import would create.
+	DetectAndImport(ctx context.Context, url string, requestedType string, forceUpdate bool, dryRun bool, userID uuid.UUID, householdID uuid.UUID) (*ImportResult, error)
+	// ImportHtml is DetectAndImport for a page the client already fetched, e.g. one the server is blocked from.
+	// The recipe is stored for the household only, feeds are fetched by the server, and forceUpdate is rejected.
+	ImportHtml(ctx context.Context, html []byte, url string, requestedType string, forceUpdate bool, dryRun bool, userID uuid.UUID, householdID uuid.UUID) (*ImportResult, error)
+	// ImportFile imports every recipe of a Paprika, Mealie or Tandoor export into the household.
+	// A recipe that fails is reported in the returned report and does not stop the others.
+	// The recipes already listed in report, by an import of the file that was interrupted, are skipped.
+	// progress, if set, is called with the report after each recipe, an error stops the import.
+	ImportFile(ctx context.Context, data []byte, report *FileImportReport, progress func(*FileImportReport) error, userID uuid.UUID, householdID uuid.UUID) (*FileImportReport, error)
+	// RefreshRecipe scrapes a global recipe from its source again and stores what changed.
+	// Returns the changes, none when the source still matches the stored recipe.
+	RefreshRecipe(ctx context.Context, recipe *Recipe) ([]RecipeChange, error)
+	// ImportCooklang imports a recipe written in Cooklang, name is its title unless the recipe sets one.
+	ImportCooklang(ctx context.Context, name string, text string, userID uuid.UUID, householdID uuid.UUID) (*Recipe, error)
+	// ImportText splits a pasted recipe into title, ingredients and instructions and returns it as a draft.
+	// The draft is created in the household only when save is set.
+	ImportText(ctx context.Context, text string, save bool, userID uuid.UUID, householdID uuid.UUID) (*Recipe, error)
 }
 
 type RecipeIngestService interface {
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/internal/storage"
+)
+
+// ImportJob is a URL or export file import queued to run in the background, so clients don't wait on the scrape.
+// Jobs are stored, a job that was running when the server stopped is picked up again once it is stale.
+type ImportJob struct {
+	ID            uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	HouseholdID   uuid.UUID `gorm:"type:char(36);index" json:"-"`
+	UserID        uuid.UUID `gorm:"type:char(36);index" json:"user_id"`
+	Url           string    `json:"url" example:"https://example.com/recipes/borscht"`
+	RequestedType string    `json:"type" example:"auto"`
+	ForceUpdate   bool      `json:"update"`
+	// BulkID groups the jobs of a bulk import, see ImportJobService.BulkByID.
+	BulkID *uuid.UUID `gorm:"type:char(36);index" json:"bulk_id,omitempty"`
+	// HTML is the page sent by the client, dropped once the job has run.
+	HTML *string `json:"-"`
+	// FilePath is the uploaded export file of a file import, deleted once the job has run.
+	FilePath *storage.Path `json:"-"`
+	// Report lists the outcome of every recipe of a file import, with the id and name of the imported ones.
+	Report       *FileImportReport `gorm:"serializer:json" json:"report,omitempty"`
+	Status       string            `gorm:"index" json:"status" example:"pending"` // "pending", "running", "success", "error"
+	ErrorMessage string            `json:"error_message,omitempty"`
+	Existing     bool              `json:"existing"` // the recipe was found already imported
+	RecipeID     *uuid.UUID        `gorm:"type:char(36);index" json:"recipe_id,omitempty"`
+	FeedID       *uuid.UUID        `gorm:"type:char(36);index" json:"feed_id,omitempty"`
+	Updated      time.Time         `gorm:"autoUpdateTime" json:"updated"`
+	Created      time.Time         `gorm:"autoCreateTime" json:"created"`
+
+	Household *Household `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Recipe    *Recipe    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"recipe,omitempty"`
+	Feed      *Feed      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"feed,omitempty"`
+}
+
+func (j *ImportJob) BeforeCreate(_ *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		var err error
+		j.ID, err = uuid.NewV7()
+		return err
+	}
+	return nil
+}
+
+type ImportJobRepository interface {
+	// ByID returns the job with its resulting recipe or feed.
+	ByID(id uuid.UUID) (*ImportJob, error)
+	// ListRunnable returns pending jobs and jobs left running since before staleBefore, oldest first.
+	ListRunnable(staleBefore time.Time, limit int) ([]ImportJob, error)
+	// ListByBulk returns the jobs of a bulk import with their recipes, in the order they were queued.
+	ListByBulk(bulkID uuid.UUID) ([]ImportJob, error)
+	Create(job *ImportJob) error
+	// CreateBatch stores the jobs all at once, or none of them.
+	CreateBatch(jobs []*ImportJob) error
+	Update(job *ImportJob) error
+	// DeleteFinishedBefore removes succeeded and failed jobs last updated before the given time.
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+type ImportJobService interface {
+	ByID(id uuid.UUID, householdID uuid.UUID) (*ImportJob, error)
+	// Enqueue stores an import for Run to process later, html is the page sent by the client, if any.
+	Enqueue(url string, requestedType string, forceUpdate bool, html string, userID uuid.UUID, householdID uuid.UUID) (*ImportJob, error)
+	// EnqueueFile stores an export file of another recipe manager for Run to import, see ImportService.ImportFile.
+	EnqueueFile(data []byte, userID uuid.UUID, householdID uuid.UUID) (*ImportJob, error)
+	// EnqueueOPML queues a feed subscription for every outline of an OPML document the household isn't subscribed to yet.
+	EnqueueOPML(data []byte, userID uuid.UUID, householdID uuid.UUID) (*FeedImportReport, error)
+	// EnqueueURLs queues a recipe import for every web URL, duplicates once. URLs already imported are only saved for the user.
+	EnqueueURLs(urls []string, userID uuid.UUID, householdID uuid.UUID) (*BulkImportReport, error)
+	// BulkByID rolls up the jobs of a bulk import into its report.
+	BulkByID(id uuid.UUID, householdID uuid.UUID) (*BulkImportReport, error)
+	// EnqueueBookmarks is EnqueueURLs for the links of a Netscape bookmarks file, as exported by browsers.
+	EnqueueBookmarks(data []byte, userID uuid.UUID, householdID uuid.UUID) (*BulkImportReport, error)
+	// Run imports the URL or file of a job with ImportService and records the outcome on the job.
+	Run(ctx context.Context, job *ImportJob) error
+}
@@ -8,15 +8,16 @@ import (
 )
 
 type MealPlan struct {
-	ID          uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
-	HouseholdID uuid.UUID  `gorm:"type:char(36);index" json:"-"`
-	Date        time.Time  `gorm:"index" json:"date"`
-	MealType    string     `json:"meal_type"` // breakfast, lunch, dinner
-	RecipeID    *uuid.UUID `gorm:"type:char(36);index" json:"recipe_id,omitempty"`
-	Servings    *int       `json:"servings,omitempty"`
-	Description *string    `json:"description,omitempty"`
-	Updated     time.Time  `gorm:"autoUpdateTime" json:"-"`
-	Created     time.Time  `gorm:"autoCreateTime" json:"-"`
+	ID          uuid.UUID      `gorm:"type:char(36);primaryKey" json:"id"`
+	HouseholdID uuid.UUID      `gorm:"type:char(36);index" json:"-"`
+	Date        time.Time      `gorm:"index" json:"date"`
+	MealType    string         `json:"meal_type"` // breakfast, lunch, dinner
+	RecipeID    *uuid.UUID     `gorm:"type:char(36);index" json:"recipe_id,omitempty"`
+	Servings    *int           `json:"servings,omitempty"`
+	Description *string        `json:"description,omitempty"`
+	Updated     time.Time      `gorm:"autoUpdateTime" json:"-"`
+	Created     time.Time      `gorm:"autoCreateTime" json:"-"`
+	Deleted     gorm.DeletedAt `gorm:"index" json:"-"`
 
 	Household *Household `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	Recipe    *Recipe    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"recipe,omitempty"`
@@ -1,7 +1,9 @@
 package domain
 
 import (
+	"strings"
 	"time"
+	"unicode"
 
 	"borscht.app/smetana/internal/storage"
 	"borscht.app/smetana/internal/types"
@@ -10,12 +12,14 @@ import (
 )
 
 type Recipe struct {
-	ID          uuid.UUID       `gorm:"type:char(36);primaryKey" json:"id"`
-	ParentID    *uuid.UUID      `gorm:"type:char(36);index" json:"-"`
-	HouseholdID *uuid.UUID      `gorm:"type:char(36);index" json:"-"`
-	UserID      *uuid.UUID      `gorm:"type:char(36);index" json:"user_id,omitempty"`
-	SourceUrl   *string         `gorm:"index" json:"source_url,omitempty" validate:"omitempty,url"`
-	Name        *string         `json:"name,omitempty" example:"Spaghetti Carbonara" validate:"required,min=2,max=255"`
+	ID          uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	ParentID    *uuid.UUID `gorm:"type:char(36);index" json:"-"`
+	HouseholdID *uuid.UUID `gorm:"type:char(36);index" json:"-"`
+	UserID      *uuid.UUID `gorm:"type:char(36);index" json:"user_id,omitempty"`
+	SourceUrl   *string    `gorm:"index" json:"source_url,omitempty" validate:"omitempty,url"`
+	Name        *string    `json:"name,omitempty" example:"Spaghetti Carbonara" validate:"required,min=2,max=255"`
+	// TitleKey is the name normalized by NormalizeFingerprintText, duplicates are grouped on it. Set whenever Name is.
+	TitleKey    *string         `gorm:"size:255;index" json:"-"`
 	ImagePath   *storage.Path   `json:"image_url,omitempty"`
 	Description *string         `json:"description,omitempty" example:"A classic Italian pasta dish made with eggs, cheese, pancetta, and pepper." validate:"omitempty,max=10000"`
 	Language    *string         `json:"language,omitempty" example:"en" validate:"omitempty,len=2"`
@@ -28,12 +32,19 @@ type Recipe struct {
 	TotalTime   *types.Duration `json:"total_time,omitempty" swaggertype:"integer" example:"2100" validate:"omitempty,gt=0"`
 	Difficulty  *string         `json:"difficulty,omitempty" example:"Medium"`
 	Method      *string         `json:"method,omitempty" example:"Stovetop"`
-	Yield       *int            `json:"yield,omitempty" example:"4" validate:"omitempty,gt=0"`
-	Rating      *Rating         `gorm:"embedded;embeddedPrefix:rating_" json:"rating,omitempty"`
-	Video       *Video          `gorm:"serializer:json" json:"video,omitempty"`
-	Published   *time.Time      `json:"published,omitempty" swaggertype:"string" format:"date-time"`
-	Updated     time.Time       `gorm:"autoUpdateTime" json:"-"`
-	Created     time.Time       `gorm:"autoCreateTime" json:"-"`
+	Yield       *float64        `json:"yield,omitempty" example:"12" validate:"omitempty,gt=0"`
+	// YieldMax is the upper bound of a yield range (e.g. "12–16 cookies").
+	YieldMax *float64 `json:"yield_max,omitempty" example:"16" validate:"omitempty,gtfield=Yield"`
+	// YieldUnit is what the recipe yields (e.g. "cookies", "loaf", "20cm cakes"), nil when it yields servings.
+	YieldUnit *string `json:"yield_unit,omitempty" example:"cookies" validate:"omitempty,max=255"`
+	// YieldText is the yield as written in the source (e.g. "Makes 12-16 cookies").
+	YieldText *string        `json:"yield_text,omitempty" example:"Makes 12-16 cookies" validate:"omitempty,max=255"`
+	Rating    *Rating        `gorm:"embedded;embeddedPrefix:rating_" json:"rating,omitempty"`
+	Video     *Video         `gorm:"serializer:json" json:"video,omitempty"`
+	Published *time.Time     `json:"published,omitempty" swaggertype:"string" format:"date-time"`
+	Updated   time.Time      `gorm:"autoUpdateTime" json:"-"`
+	Created   time.Time      `gorm:"autoCreateTime" json:"-"`
+	Deleted   gorm.DeletedAt `gorm:"index" json:"-"`
 
 	SavedBy      []*RecipeSavedUser   `gorm:"-" json:"saved_by,omitempty"`
 	Parent       *Recipe              `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
@@ -63,6 +74,11 @@ type Video struct {
 	ThumbnailUrl *string `json:"thumbnail_url,omitempty" validate:"omitempty,url"`
 }
 
+// YieldsServings reports whether the recipe yield is a number of servings rather than e.g. cookies or loaves.
+func (r *Recipe) YieldsServings() bool {
+	return r.Yield != nil && *r.Yield > 0 && r.YieldUnit == nil
+}
+
 func (r *Recipe) BeforeCreate(_ *gorm.DB) error {
 	if r.ID == uuid.Nil {
 		var err error
@@ -72,11 +88,72 @@ func (r *Recipe) BeforeCreate(_ *gorm.DB) error {
 	return nil
 }
 
+func (r *Recipe) BeforeSave(_ *gorm.DB) error {
+	if r.Name != nil {
+		key := NormalizeFingerprintText(*r.Name)
+		r.TitleKey = &key
+	}
+	return nil
+}
+
+// NormalizeFingerprintText lowercases text and drops punctuation, so "Borscht!" and "borscht" compare equal.
+func NormalizeFingerprintText(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// MaxSubRecipeDepth limits how deep sub-recipes are preloaded and expanded.
+const MaxSubRecipeDepth = 5
+
+// RecipeScale describes how ingredient amounts of a recipe are scaled.
+// Servings is the target yield in the unit of the recipe yield (e.g. 24 to double "12 cookies"),
+// it takes precedence over Factor when the recipe has a yield.
+type RecipeScale struct {
+	Factor   float64
+	Servings int
+}
+
+// ExportFormat is a serialization of a recipe understood by other tools.
+type ExportFormat string
+
+const (
+	ExportFormatJSONLD   ExportFormat = "jsonld"   // schema.org Recipe as JSON-LD
+	ExportFormatMarkdown ExportFormat = "markdown" // for pasting into chats and notes
+	ExportFormatText     ExportFormat = "text"     // plain text for printing
+	ExportFormatCooklang ExportFormat = "cooklang" // Cooklang markup, single recipes only
+)
+
+// ExportOptions controls how recipes are exported.
+type ExportOptions struct {
+	Format ExportFormat
+	Scale  RecipeScale
+	// UnitSystem, when set, converts ingredient amounts and temperatures into it.
+	UnitSystem *UnitSystem
+}
+
 type RecipeSearchOptions struct {
 	types.SearchOptions
 
 	// CollectionID, when non-nil, restricts results to a specific collection.
 	CollectionID uuid.UUID
+	// HideStream leaves out recipes the user marked in the feed stream with any of the flags.
+	HideStream []StreamFlag
+	// FeedFilters leaves out feed recipes the household filtered out of its subscriptions.
+	FeedFilters bool
+}
+
+// RecipeDuplicates is a group of recipes that are likely the same recipe, e.g. imported from different URLs.
+// Recipes are ordered oldest first, the first one is the suggested recipe to keep when merging.
+type RecipeDuplicates struct {
+	Fingerprint string   `json:"fingerprint" example:"3f1c9a0be27d4e51"`
+	Recipes     []Recipe `json:"recipes"`
+}
+
+// SimilarRecipe is a recipe with its similarity to another one, from 0 (nothing shared) to 1 (the same foods, taxonomies and equipment).
+type SimilarRecipe struct {
+	Recipe
+	Score float64 `json:"score" example:"0.42"`
 }
 
 type RecipeRepository interface {
@@ -89,6 +166,21 @@ type RecipeRepository interface {
 	Import(recipe *Recipe) error
 	Update(recipe *Recipe) error
 	Delete(id uuid.UUID) error
+	// ListStale returns global recipes with a source URL not updated since updatedBefore, least recently updated first,
+	// with their ingredients, instructions and images. Recipes in exclude are skipped.
+	ListStale(updatedBefore time.Time, exclude []uuid.UUID, limit int) ([]Recipe, error)
+	// MarkRefreshed records that the recipe still matches its source, without changing anything else.
+	MarkRefreshed(id uuid.UUID) error
+	// DuplicateCandidates returns the recipes visible to the household whose TitleKey is shared by another one, oldest
+	// first, with the food and name of their ingredients. The shared titles are paged, total counts all of them.
+	DuplicateCandidates(householdID uuid.UUID, offset, limit int) ([]Recipe, int64, error)
+	// TasteProfile weighs what the household cooked (past meal plans), saved and collected.
+	TasteProfile(householdID uuid.UUID) (*TasteProfile, error)
+	// Similar returns the recipes visible to the household that share foods, taxonomies or equipment with the recipe,
+	// most similar first, with their images.
+	Similar(recipeID uuid.UUID, householdID uuid.UUID, limit int) ([]SimilarRecipe, error)
+	// TrashedByID loads a soft-deleted recipe together with its images.
+	TrashedByID(id uuid.UUID) (*Recipe, error)
 
 	UserSave(recipeID uuid.UUID, userID uuid.UUID, householdID uuid.UUID) error
 	UserUnsave(recipeID uuid.UUID, userID uuid.UUID) error
@@ -96,6 +188,8 @@ type RecipeRepository interface {
 	CreateIngredient(ingredient *RecipeIngredient) error
 	UpdateIngredient(ingredient *RecipeIngredient) error
 	DeleteIngredient(id uuid.UUID, recipeID uuid.UUID) error
+	// SubRecipeIDs returns the sub-recipes referenced by ingredients of the given recipes, keyed by recipe.
+	SubRecipeIDs(recipeIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
 
 	AddEquipment(recipeID uuid.UUID, equipmentID uuid.UUID) error
 	RemoveEquipment(recipeID uuid.UUID, equipmentID uuid.UUID) error
@@ -103,6 +197,9 @@ type RecipeRepository interface {
 	CreateInstruction(instruction *RecipeInstruction) error
 	UpdateInstruction(instruction *RecipeInstruction) error
 	DeleteInstruction(id uuid.UUID, recipeID uuid.UUID) error
+	// LinkInstructionIngredient links an instruction to an ingredient, both of which must belong to the recipe.
+	LinkInstructionIngredient(recipeID, instructionID, ingredientID uuid.UUID) error
+	UnlinkInstructionIngredient(recipeID, instructionID, ingredientID uuid.UUID) error
 
 	Transaction(fn func(txRepo RecipeRepository) error) error
 	ReplaceRecipePointers(oldRecipeID, newRecipeID, householdID uuid.UUID) error
@@ -110,7 +207,7 @@ type RecipeRepository interface {
 
 // RecipeIngredientCost is a
 type RecipeIngredientCost struct {
-	IngredientID uuid.UUID  `json:"ingredient_id"`        // references Recipe.Ingredients[].ID
+	IngredientID uuid.UUID  `json:"ingredient_id"`        // references Recipe.Ingredients[].ID, or an ingredient of a sub-recipe
 	FoodPrice    *FoodPrice `json:"food_price,omitempty"` // the price used (nil if not calculated)
 	Cost         *float64   `json:"cost,omitempty"`       // cost for this ingredient in the recipe (nil if not calculated)
 	Status       string     `json:"status"`               // "calculated" | "pantry" | "missing_price" | "incompatible_unit"
@@ -125,6 +222,8 @@ type RecipeCostEstimate struct {
 
 type RecipeService interface {
 	ByID(id uuid.UUID, householdID uuid.UUID) (*Recipe, error)
+	// ByIDPreload converts the instruction temperatures to the unit system of the user. Without a user (uuid.Nil),
+	// as for internal reads that don't show instructions, they are returned as written.
 	ByIDPreload(id, userID, householdID uuid.UUID, preload types.PreloadOptions) (*Recipe, error)
 	ByUrl(url string, householdID uuid.UUID) (*Recipe, error)
 	ByParentIDsAndHousehold(parentIDs []uuid.UUID, householdID uuid.UUID, preload types.PreloadOptions) ([]Recipe, error)
@@ -132,6 +231,19 @@ type RecipeService interface {
 	Create(recipe *Recipe, userID uuid.UUID, householdID uuid.UUID) error
 	Import(recipe *Recipe) error
 	SetFeedID(recipeID, feedID uuid.UUID) error
+	// ListStale returns global recipes last updated before updatedBefore, see RecipeRepository.ListStale.
+	ListStale(updatedBefore time.Time, exclude []uuid.UUID, limit int) ([]Recipe, error)
+	MarkRefreshed(id uuid.UUID) error
+	// Duplicates groups the recipes visible to the household by title and ingredient foods, groups of one are left out.
+	// offset and limit page the titles shared by several recipes and total counts them, a title whose recipes differ
+	// in their foods gives fewer or more groups.
+	Duplicates(householdID uuid.UUID, offset, limit int) ([]RecipeDuplicates, int64, error)
+	// Merge moves the saves, collection entries and meal plans of the household from mergeID to keepID.
+	// mergeID is moved to the trash when the household owns it.
+	Merge(keepID, mergeID uuid.UUID, householdID uuid.UUID) error
+	// Similar returns up to limit recipes visible to the household that are similar to the recipe, most similar first.
+	Similar(recipeID uuid.UUID, householdID uuid.UUID, limit int) ([]SimilarRecipe, error)
+	TasteProfile(householdID uuid.UUID) (*TasteProfile, error)
 	Update(recipe *Recipe, userID uuid.UUID, householdID uuid.UUID) error
 	Delete(id uuid.UUID, householdID uuid.UUID) error
 
@@ -141,6 +253,8 @@ type RecipeService interface {
 	CreateIngredient(ingredient *RecipeIngredient, householdID uuid.UUID) error
 	UpdateIngredient(ingredient *RecipeIngredient, householdID uuid.UUID) error
 	DeleteIngredient(id uuid.UUID, recipeID uuid.UUID, householdID uuid.UUID) error
+	// ExpandIngredients returns the scaled ingredients of a recipe with sub-recipes recursively replaced by their own ingredients.
+	ExpandIngredients(recipeID uuid.UUID, householdID uuid.UUID, scale RecipeScale) ([]*RecipeIngredient, error)
 
 	AddEquipment(recipeID uuid.UUID, equipmentID uuid.UUID, householdID uuid.UUID) error
 	RemoveEquipment(recipeID uuid.UUID, equipmentID uuid.UUID, householdID uuid.UUID) error
@@ -148,6 +262,10 @@ type RecipeService interface {
 	CreateInstruction(instruction *RecipeInstruction, householdID uuid.UUID) error
 	UpdateInstruction(instruction *RecipeInstruction, householdID uuid.UUID) error
 	DeleteInstruction(id uuid.UUID, recipeID uuid.UUID, householdID uuid.UUID) error
+	LinkInstructionIngredient(recipeID, instructionID, ingredientID uuid.UUID, householdID uuid.UUID) error
+	UnlinkInstructionIngredient(recipeID, instructionID, ingredientID uuid.UUID, householdID uuid.UUID) error
 
 	EstimatePrice(recipeID uuid.UUID, householdID uuid.UUID) (*RecipeCostEstimate, error)
+	// Export serializes the recipe with all its details in the given format.
+	Export(id, userID, householdID uuid.UUID, opts ExportOptions) ([]byte, error)
 }
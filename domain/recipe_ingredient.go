@@ -14,6 +14,9 @@ type RecipeIngredient struct {
 	MaxAmount *float64   `json:"max_amount,omitempty" validate:"omitempty,gt=0"` // upper bound for range quantities (e.g. "1–2 cups")
 	UnitID    *uuid.UUID `gorm:"type:char(36);index" json:"unit_id,omitempty"`
 	FoodID    *uuid.UUID `gorm:"type:char(36);index" json:"food_id,omitempty"`
+	// SubRecipeID references another recipe used as this ingredient (e.g. "1 batch pizza dough"), instead of a Food.
+	// Without a unit, Amount counts batches of the sub-recipe.
+	SubRecipeID *uuid.UUID `gorm:"type:char(36);index" json:"sub_recipe_id,omitempty" validate:"omitempty,excluded_with=FoodID"`
 	// Name is the ingredient name as written in this recipe (e.g. "carrots", "all-purpose flour").
 	// May differ from Food.Name, which holds the deduplicated canonical form (e.g. "carrot").
 	Name *string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
@@ -29,6 +32,8 @@ type RecipeIngredient struct {
 	Recipe *Recipe `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	Unit   *Unit   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"unit,omitempty"`
 	Food   *Food   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"food,omitempty"`
+
+	SubRecipe *Recipe `gorm:"foreignKey:SubRecipeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"sub_recipe,omitempty"`
 }
 
 func (ri *RecipeIngredient) BeforeCreate(_ *gorm.DB) error {
@@ -39,3 +44,15 @@ func (ri *RecipeIngredient) BeforeCreate(_ *gorm.DB) error {
 	}
 	return nil
 }
+
+// Scaled returns a copy of the ingredient with Amount and MaxAmount multiplied by factor.
+func (ri *RecipeIngredient) Scaled(factor float64) *RecipeIngredient {
+	scaled := *ri
+	if ri.Amount != nil {
+		scaled.Amount = new(*ri.Amount * factor)
+	}
+	if ri.MaxAmount != nil {
+		scaled.MaxAmount = new(*ri.MaxAmount * factor)
+	}
+	return &scaled
+}
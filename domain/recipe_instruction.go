@@ -1,9 +1,11 @@
 package domain
 
 import (
+	"math"
 	"time"
 
 	"borscht.app/smetana/internal/storage"
+	"borscht.app/smetana/internal/types"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -18,12 +20,61 @@ type RecipeInstruction struct {
 	Url       *string       `json:"url,omitempty" validate:"omitempty,url"`
 	ImagePath *storage.Path `json:"image_url,omitempty"`
 	VideoUrl  *string       `json:"video_url,omitempty" validate:"omitempty,url"`
-	Updated   time.Time     `gorm:"autoUpdateTime" json:"-"`
-	Created   time.Time     `gorm:"autoCreateTime" json:"-"`
+	// SubRecipeID links a step or section to the sub-recipe it prepares (e.g. "Make the dough").
+	SubRecipeID *uuid.UUID `gorm:"type:char(36);index" json:"sub_recipe_id,omitempty"`
+	// Timers and Temperatures are parsed from Text whenever it is imported or edited.
+	Timers       []InstructionTimer `gorm:"serializer:json" json:"timers,omitempty"`
+	Temperatures []Temperature      `gorm:"serializer:json" json:"temperatures,omitempty"`
+	Updated      time.Time          `gorm:"autoUpdateTime" json:"-"`
+	Created      time.Time          `gorm:"autoCreateTime" json:"-"`
 
-	Recipe *Recipe            `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
-	Images []*Image           `gorm:"polymorphic:Entity;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"images,omitempty"`
-	Parent *RecipeInstruction `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"parent,omitempty"`
+	Recipe    *Recipe            `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Images    []*Image           `gorm:"polymorphic:Entity;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"images,omitempty"`
+	Parent    *RecipeInstruction `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"parent,omitempty"`
+	SubRecipe *Recipe            `gorm:"foreignKey:SubRecipeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+	// Ingredients are the ingredients of the same recipe used in this step (e.g. "Add the flour").
+	Ingredients []*RecipeIngredient `gorm:"many2many:recipe_instruction_ingredients;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"ingredients,omitempty"`
+}
+
+// InstructionTimer is a duration mentioned in an instruction, e.g. "simmer for 20–25 minutes".
+type InstructionTimer struct {
+	Duration    types.Duration  `json:"duration" swaggertype:"integer" example:"1200"`
+	MaxDuration *types.Duration `json:"max_duration,omitempty" swaggertype:"integer" example:"1500"`
+	Text        string          `json:"text" example:"20–25 minutes"` // as written in the instruction
+}
+
+type TemperatureUnit string
+
+const (
+	Celsius    TemperatureUnit = "C"
+	Fahrenheit TemperatureUnit = "F"
+)
+
+// Temperature is a cooking temperature mentioned in an instruction, e.g. "bake at 180°C".
+type Temperature struct {
+	Value    float64         `json:"value" example:"180"`
+	MaxValue *float64        `json:"max_value,omitempty" example:"200"`
+	Unit     TemperatureUnit `json:"unit" enums:"C,F"`
+}
+
+// In returns the temperature converted to the given unit, rounded to 5 degrees like oven dials.
+func (t Temperature) In(unit TemperatureUnit) Temperature {
+	if t.Unit == unit {
+		return t
+	}
+	convert := func(v float64) float64 {
+		if unit == Fahrenheit {
+			v = v*9/5 + 32
+		} else {
+			v = (v - 32) * 5 / 9
+		}
+		return math.Round(v/5) * 5
+	}
+	converted := Temperature{Value: convert(t.Value), Unit: unit}
+	if t.MaxValue != nil {
+		converted.MaxValue = new(convert(*t.MaxValue))
+	}
+	return converted
 }
 
 func (ri *RecipeInstruction) BeforeCreate(_ *gorm.DB) error {
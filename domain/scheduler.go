@@ -8,6 +8,7 @@ import (
 )
 
 const (
+	JobStatusPending = "pending"
 	JobStatusRunning = "running"
 	JobStatusSuccess = "success"
 	JobStatusError   = "error"
@@ -38,4 +39,6 @@ func (l *SchedulerLog) BeforeCreate(_ *gorm.DB) error {
 type SchedulerRepository interface {
 	CreateLog(log *SchedulerLog) error
 	UpdateLog(log *SchedulerLog) error
+	// RecentFailures returns the logs of a job since the given time for the entities that failed in that time, newest first.
+	RecentFailures(jobType string, since time.Time) ([]SchedulerLog, error)
 }
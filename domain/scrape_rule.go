@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScrapeRule corrects how recipes of one site are scraped, for sites krip gets wrong until it is fixed upstream.
+// A selector is a CSS selector, or a JSON path starting with $ into the JSON scripts of the page (e.g. JSON-LD).
+// What a selector finds replaces what krip scraped, fields without a selector are left as krip scraped them.
+type ScrapeRule struct {
+	ID uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	// Domain the rule applies to, including its subdomains. The most specific rule wins.
+	Domain string `gorm:"uniqueIndex;not null" json:"domain" example:"example.com"`
+	// Disabled refuses to scrape the domain at all.
+	Disabled bool `json:"disabled"`
+	// SkipKrip builds the recipe from the selectors alone, for pages where krip's result is unusable.
+	SkipKrip     bool   `json:"skip_krip"`
+	Title        string `json:"title,omitempty" example:"h1.recipe-title"`
+	Ingredients  string `json:"ingredients,omitempty" example:"$..recipeIngredient[*]"`
+	Instructions string `json:"instructions,omitempty" example:".method li"`
+	Image        string `json:"image,omitempty" example:"meta[property='og:image']"`
+	// IngredientCleanup are regular expressions removed from every ingredient line, e.g. "\\s*\\(affiliate link\\)".
+	IngredientCleanup []string  `gorm:"serializer:json" json:"ingredient_cleanup,omitempty"`
+	Updated           time.Time `gorm:"autoUpdateTime" json:"updated"`
+	Created           time.Time `gorm:"autoCreateTime" json:"created"`
+}
+
+func (r *ScrapeRule) BeforeCreate(_ *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		var err error
+		r.ID, err = uuid.NewV7()
+		return err
+	}
+	return nil
+}
+
+type ScrapeRuleRepository interface {
+	ByID(id uuid.UUID) (*ScrapeRule, error)
+	// ByDomains returns the rules stored for any of the given domains.
+	ByDomains(domains []string) ([]ScrapeRule, error)
+	List() ([]ScrapeRule, error)
+	Create(rule *ScrapeRule) error
+	Update(rule *ScrapeRule) error
+	Delete(id uuid.UUID) error
+}
+
+type ScrapeRuleService interface {
+	ByID(id uuid.UUID) (*ScrapeRule, error)
+	List() ([]ScrapeRule, error)
+	// Create and Update normalize the domain and reject selectors and cleanup expressions that don't compile.
+	Create(rule *ScrapeRule) error
+	Update(rule *ScrapeRule) error
+	Delete(id uuid.UUID) error
+	// Test scrapes a saved copy of a page with the stored rule, so a rule can be checked before it meets real imports.
+	Test(ctx context.Context, id uuid.UUID, html []byte, url string) (*Recipe, error)
+}
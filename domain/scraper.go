@@ -22,8 +22,17 @@ type ScrapeResult struct {
 // ScraperService fetches and converts external recipe data into domain objects.
 type ScraperService interface {
 	ScrapeUrl(ctx context.Context, url string, requestedType string) (*ScrapeResult, error)
+	// ScrapeHtml is ScrapeUrl for a page the client fetched itself, url is the address of the page.
+	ScrapeHtml(ctx context.Context, html []byte, url string, requestedType string) (*ScrapeResult, error)
 	ScrapeRecipe(ctx context.Context, url string) (*Recipe, error)
+	// ScrapeHtmlWithRule scrapes a recipe from a saved page with the given rule instead of the one stored for its domain.
+	ScrapeHtmlWithRule(ctx context.Context, html []byte, url string, rule *ScrapeRule) (*Recipe, error)
 	// ScrapeFeed scrapes all recipe entries from the feed URL and back-populates
 	// feed metadata fields (Name, Url, Publisher, Description, Discovered) in-place.
 	ScrapeFeed(ctx context.Context, feed *Feed, opts krip.FeedOptions) ([]*Recipe, error)
+	// ScrapeFeedChanges is ScrapeFeed for a feed fetched before: the feed is requested conditionally on the ETag,
+	// Last-Modified and content hash stored on it, which are updated in-place, and only entries isKnown rejects are
+	// scraped. unchanged is true, with no recipes, when the feed did not change since the last fetch.
+	// The validators are left as they were when new entries were left unscraped past the limit.
+	ScrapeFeedChanges(ctx context.Context, feed *Feed, opts krip.FeedOptions, isKnown func(url string) bool) (recipes []*Recipe, unchanged bool, err error)
 }
@@ -9,12 +9,13 @@ import (
 )
 
 type ShoppingList struct {
-	ID          uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
-	HouseholdID uuid.UUID `gorm:"type:char(36);index" json:"-"`
-	Name        string    `json:"name" validate:"required,min=2,max=255"`
-	IsDefault   bool      `gorm:"default:false;uniqueIndex:idx_household_default,where:is_default = true" json:"is_default"`
-	Updated     time.Time `gorm:"autoUpdateTime" json:"-"`
-	Created     time.Time `gorm:"autoCreateTime" json:"-"`
+	ID          uuid.UUID      `gorm:"type:char(36);primaryKey" json:"id"`
+	HouseholdID uuid.UUID      `gorm:"type:char(36);index" json:"-"`
+	Name        string         `json:"name" validate:"required,min=2,max=255"`
+	IsDefault   bool           `gorm:"default:false;uniqueIndex:idx_household_default,where:is_default = true" json:"is_default"`
+	Updated     time.Time      `gorm:"autoUpdateTime" json:"-"`
+	Created     time.Time      `gorm:"autoCreateTime" json:"-"`
+	Deleted     gorm.DeletedAt `gorm:"index" json:"-"`
 
 	Household *Household      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	Items     []*ShoppingItem `gorm:"foreignKey:ShoppingListID" json:"items,omitempty"`
@@ -77,6 +78,8 @@ type ShoppingListService interface {
 
 	Items(listID uuid.UUID, householdID uuid.UUID, offset, limit int) ([]ShoppingItem, int64, error)
 	AddItems(ctx context.Context, items []*ShoppingItem, listID uuid.UUID, householdID uuid.UUID) error
+	// AddRecipe adds the scaled ingredients of a recipe, including those of its sub-recipes, to the list.
+	AddRecipe(ctx context.Context, recipeID uuid.UUID, scale RecipeScale, listID uuid.UUID, householdID uuid.UUID) ([]*ShoppingItem, error)
 	GetItem(itemID uuid.UUID, listID uuid.UUID, householdID uuid.UUID) (*ShoppingItem, error)
 	UpdateItem(item *ShoppingItem, listID uuid.UUID, householdID uuid.UUID) (*ShoppingItem, error)
 	DeleteItem(itemID uuid.UUID, listID uuid.UUID, householdID uuid.UUID) error
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/internal/types"
+)
+
+// StreamState is what a household member did with a recipe of the feed stream.
+type StreamState struct {
+	UserID      uuid.UUID `gorm:"type:char(36);primaryKey" json:"-"`
+	RecipeID    uuid.UUID `gorm:"type:char(36);primaryKey" json:"recipe_id"`
+	HouseholdID uuid.UUID `gorm:"type:char(36);index" json:"-"`
+	Seen        bool      `json:"seen"`
+	Dismissed   bool      `json:"dismissed"`
+	Hidden      bool      `json:"hidden"`
+	Updated     time.Time `gorm:"autoUpdateTime" json:"-"`
+
+	User      *User      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Recipe    *Recipe    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Household *Household `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// StreamFlag is a column of StreamState a household member can set or clear.
+type StreamFlag string
+
+const (
+	// StreamFlagSeen marks a recipe as read, streams requested with unread leave it out.
+	StreamFlagSeen StreamFlag = "seen"
+	// StreamFlagDismissed marks a recipe as not interesting, streams requested with hide_dismissed leave it out.
+	StreamFlagDismissed StreamFlag = "dismissed"
+	// StreamFlagHidden leaves a recipe out of every stream of the member.
+	StreamFlagHidden StreamFlag = "hidden"
+)
+
+// StreamOptions controls the feed stream on top of the usual recipe search options.
+type StreamOptions struct {
+	types.SearchOptions
+
+	// Ranked orders the stream by how well recipes match the household's taste, mixing publishers, instead of by date.
+	Ranked bool
+	// HideDismissed leaves out recipes the user dismissed.
+	HideDismissed bool
+	// Unread leaves out recipes the user has seen.
+	Unread bool
+}
+
+// TasteProfile weighs the taxonomies, foods and publishers of the recipes a household saved, cooked or collected.
+type TasteProfile struct {
+	Taxonomies map[uuid.UUID]float64
+	Foods      map[uuid.UUID]float64
+	Publishers map[uuid.UUID]float64
+}
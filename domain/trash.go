@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrashItemType identifies the kind of soft-deleted entity in the household trash.
+type TrashItemType string
+
+const (
+	TrashRecipe       TrashItemType = "recipe"
+	TrashCollection   TrashItemType = "collection"
+	TrashMealPlan     TrashItemType = "mealplan"
+	TrashShoppingList TrashItemType = "shoppinglist"
+)
+
+func (t TrashItemType) Valid() bool {
+	switch t {
+	case TrashRecipe, TrashCollection, TrashMealPlan, TrashShoppingList:
+		return true
+	}
+	return false
+}
+
+// TrashItem is a lightweight view over a soft-deleted recipe, collection, meal plan or shopping list.
+type TrashItem struct {
+	ID          uuid.UUID     `json:"id"`
+	Type        TrashItemType `json:"type"`
+	Name        string        `json:"name"`
+	HouseholdID uuid.UUID     `json:"-"`
+	Deleted     time.Time     `json:"deleted"`
+}
+
+type TrashRepository interface {
+	List(householdID uuid.UUID, offset, limit int) ([]TrashItem, int64, error)
+	ByID(itemType TrashItemType, id uuid.UUID, householdID uuid.UUID) (*TrashItem, error)
+	// Expired lists trashed items of all households deleted before the given time, oldest first. Items in exclude are skipped.
+	Expired(before time.Time, exclude []uuid.UUID, limit int) ([]TrashItem, error)
+	Restore(itemType TrashItemType, id uuid.UUID, householdID uuid.UUID) error
+	// Purge permanently removes a trashed item, cascading to its dependent rows.
+	Purge(itemType TrashItemType, id uuid.UUID, householdID uuid.UUID) error
+}
+
+type TrashService interface {
+	List(householdID uuid.UUID, offset, limit int) ([]TrashItem, int64, error)
+	Restore(itemType TrashItemType, id uuid.UUID, householdID uuid.UUID) error
+	Purge(itemType TrashItemType, id uuid.UUID, householdID uuid.UUID) error
+	// PurgeExpired permanently removes items that have been in the trash longer than retention.
+	PurgeExpired(retention time.Duration) (int, error)
+}
@@ -25,6 +25,22 @@ type Unit struct {
 	Taxonomies    []*Taxonomy `gorm:"many2many:unit_taxonomies;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"taxonomies,omitempty"`
 }
 
+// UnitSystem is a user's preferred system of measurement.
+type UnitSystem string
+
+const (
+	UnitSystemMetric   UnitSystem = "metric"
+	UnitSystemImperial UnitSystem = "imperial"
+)
+
+// TemperatureUnit returns the temperature unit used by the unit system.
+func (s UnitSystem) TemperatureUnit() TemperatureUnit {
+	if s == UnitSystemImperial {
+		return Fahrenheit
+	}
+	return Celsius
+}
+
 func (u *Unit) BeforeCreate(_ *gorm.DB) error {
 	if u.ID == uuid.Nil {
 		var err error
@@ -61,6 +77,8 @@ func (u *Unit) ToBaseFactor() (float64, error) {
 
 type UnitRepository interface {
 	FindOrCreate(unit *Unit) error
+	// Find fills unit with the stored unit of the same slug or name, ErrNotFound when there is none.
+	Find(unit *Unit) error
 	ByID(id uuid.UUID) (*Unit, error)
 	ByBase(baseUnitID uuid.UUID, imperial bool) ([]Unit, error)
 	Search(query string, imperial *bool, offset, limit int) ([]Unit, int64, error)
@@ -72,6 +90,8 @@ type UnitRepository interface {
 type UnitService interface {
 	ByID(id uuid.UUID) (*Unit, error)
 	FindOrCreate(unit *Unit) error
+	// Find fills unit with the stored unit of the same slug or name without creating one.
+	Find(unit *Unit) error
 	Search(query string, imperial *bool, offset, limit int) ([]Unit, int64, error)
 	Merge(keepID, mergeID uuid.UUID) error
 	Update(unit *Unit) error
@@ -16,8 +16,10 @@ type User struct {
 	EmailVerified bool          `gorm:"default:false" json:"-"`
 	Password      string        `json:"-" validate:"omitempty,min=8"`
 	ImagePath     *storage.Path `json:"image_url,omitempty"`
-	Updated       time.Time     `gorm:"autoUpdateTime" json:"-"`
-	Created       time.Time     `gorm:"autoCreateTime" json:"-"`
+	// UnitSystem controls which units are shown to the user, e.g. temperatures in °C or °F.
+	UnitSystem UnitSystem `gorm:"default:metric" json:"unit_system" validate:"omitempty,oneof=metric imperial"`
+	Updated    time.Time  `gorm:"autoUpdateTime" json:"-"`
+	Created    time.Time  `gorm:"autoCreateTime" json:"-"`
 
 	Household *Household   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 	Tokens    []*UserToken `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
@@ -51,6 +53,6 @@ type UserRepository interface {
 
 type UserService interface {
 	ByID(id uuid.UUID, requesterID uuid.UUID) (*User, error)
-	Update(id uuid.UUID, requesterID uuid.UUID, name, email, currentPassword, newPassword *string) (*User, error)
+	Update(id uuid.UUID, requesterID uuid.UUID, name, email, currentPassword, newPassword *string, unitSystem *UnitSystem) (*User, error)
 	Delete(id uuid.UUID, requesterID uuid.UUID) error
 }
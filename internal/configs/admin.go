@@ -0,0 +1,27 @@
+package configs
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+)
+
+// AdminUserIDs returns the users allowed to manage instance-wide settings, from the comma-separated ADMIN_USER_IDS.
+func AdminUserIDs() map[uuid.UUID]bool {
+	admins := map[uuid.UUID]bool{}
+	for raw := range strings.SplitSeq(os.Getenv("ADMIN_USER_IDS"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			log.Warnw("ignoring invalid admin user id", "id", raw)
+			continue
+		}
+		admins[id] = true
+	}
+	return admins
+}
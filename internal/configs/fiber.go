@@ -10,6 +10,16 @@ import (
 	"borscht.app/smetana/internal/utils"
 )
 
+// BodyLimit is the largest request body accepted by the routes without an upload limit of their own.
+func BodyLimit() int {
+	return utils.GetenvInt("SERVER_BODY_LIMIT_MB", 8) * 1024 * 1024
+}
+
+// ImportFileLimit is the largest export file accepted by the file import, exports with photos can be large.
+func ImportFileLimit() int {
+	return utils.GetenvInt("IMPORT_FILE_LIMIT_MB", 64) * 1024 * 1024
+}
+
 // FiberConfig func for configuration Fiber app.
 // See: https://docs.gofiber.io/api/fiber#config
 func FiberConfig() fiber.Config {
@@ -17,7 +27,10 @@ func FiberConfig() fiber.Config {
 		ReadTimeout:  time.Second * time.Duration(utils.GetenvInt("SERVER_READ_TIMEOUT", 15)),
 		WriteTimeout: time.Second * time.Duration(utils.GetenvInt("SERVER_WRITE_TIMEOUT", 30)),
 		IdleTimeout:  time.Second * time.Duration(utils.GetenvInt("SERVER_IDLE_TIMEOUT", 120)),
-		BodyLimit:    8 * 1024 * 1024, // 8 MB
+		// bodies are streamed and read by middlewares.BodyLimit, which holds each route to its own limit
+		BodyLimit:                    BodyLimit(),
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 
 		ErrorHandler: func(ctx fiber.Ctx, err error) error {
 			var se *sentinels.Error
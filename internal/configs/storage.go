@@ -16,9 +16,13 @@ type StorageConfig struct {
 	Storage     storage.FileStorage
 	BaseURL     string
 	StorageRoot string // non-empty only for local storage; use to register a static file route
+	// Private keeps files that are never served, such as uploaded exports waiting to be imported
+	Private storage.FileStorage
 }
 
 func NewStorage(serverHost string, serverPort int) StorageConfig {
+	private := storage.NewLocalStorage(utils.Getenv("PRIVATE_STORAGE_ROOT", "./data/private"), "")
+
 	if os.Getenv("S3_BUCKET") != "" {
 		s3Endpoint := utils.Getenv("S3_ENDPOINT", os.Getenv("S3_HOST"))
 		if s3Endpoint != "" && !strings.Contains(s3Endpoint, "://") {
@@ -38,6 +42,7 @@ func NewStorage(serverHost string, serverPort int) StorageConfig {
 				},
 			}, baseURL),
 			BaseURL: baseURL,
+			Private: private,
 		}
 	}
 
@@ -56,5 +61,6 @@ func NewStorage(serverHost string, serverPort int) StorageConfig {
 		Storage:     storage.NewLocalStorage(storageRoot, baseURL),
 		BaseURL:     baseURL,
 		StorageRoot: storageRoot,
+		Private:     private,
 	}
 }
@@ -34,12 +34,54 @@ func Migrate(db *gorm.DB) error {
 		&domain.RecipeInstruction{},
 		&domain.RecipeIngredient{},
 		&domain.RecipeSaved{},
+		&domain.StreamState{},
 		&domain.MealPlan{},
 		&domain.Collection{},
+		&domain.Cookbook{},
 		&domain.ShoppingList{},
 		&domain.ShoppingItem{},
 		&domain.Feed{},
+		&domain.FeedSubscription{},
+		&domain.ImportJob{},
+		&domain.ScrapeRule{},
 		&domain.SchedulerLog{},
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := indexJoinTableFeatures(db); err != nil {
+		return err
+	}
+	return backfillRecipeTitleKeys(db)
+}
+
+// indexJoinTableFeatures indexes the second column of the recipe join tables, their primary keys start with
+// recipe_id, so looking up the recipes of a taxonomy or a piece of equipment would scan the whole table.
+func indexJoinTableFeatures(db *gorm.DB) error {
+	for table, column := range map[string]string{"recipe_taxonomies": "taxonomy_id", "recipe_equipment": "equipment_id"} {
+		name := "idx_" + table + "_" + column
+		if db.Migrator().HasIndex(table, name) {
+			continue
+		}
+		if err := db.Exec("CREATE INDEX " + name + " ON " + table + " (" + column + ")").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillRecipeTitleKeys sets the title key of recipes saved before it existed, it is set on every save since.
+func backfillRecipeTitleKeys(db *gorm.DB) error {
+	var recipes []domain.Recipe
+	return db.Unscoped().Select("id", "name").
+		Where("title_key IS NULL AND name IS NOT NULL").
+		FindInBatches(&recipes, 500, func(*gorm.DB, int) error {
+			for _, recipe := range recipes {
+				key := domain.NormalizeFingerprintText(*recipe.Name)
+				if err := db.Unscoped().Model(&recipe).UpdateColumn("title_key", key).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
 }
@@ -169,6 +169,41 @@ func (h *CollectionHandler) UpdateCollection(c fiber.Ctx) error {
 	return c.JSON(collection)
 }
 
+// ExportCollection godoc
+// @Summary Export all recipes of a collection as a single document.
+// @Description Concatenates the recipe exports, see the recipe export for the options. JSON-LD is returned as an array of recipes.
+// @Tags collections
+// @Produce application/ld+json,text/markdown,text/plain
+// @Param id path string true "Collection ID"
+// @Param format query string false "Export format: jsonld, markdown or text (default: jsonld)"
+// @Param scale query number false "Multiplier for ingredient amounts (default: 1)"
+// @Param servings query int false "Target yield of each recipe, takes precedence over scale"
+// @Param units query string false "Convert ingredient amounts and temperatures: metric or imperial"
+// @Success 200 {string} string
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/collections/{id}/export [get]
+func (h *CollectionHandler) ExportCollection(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+	opts, err := exportOptions(c)
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	data, err := h.collectionService.Export(id, tokenData.ID, tokenData.HouseholdID, opts)
+	if err != nil {
+		return err
+	}
+	return sendExport(c, opts.Format, data)
+}
+
 // ListRecipes godoc
 // @Summary List recipes in a collection.
 // @Description Returns recipes in a collection with optional search, pagination and extras.
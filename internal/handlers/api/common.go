@@ -5,6 +5,7 @@ import (
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 
+	"borscht.app/smetana/domain"
 	"borscht.app/smetana/internal/sentinels"
 )
 
@@ -14,6 +15,52 @@ type mergeRequest struct {
 	MergeInto uuid.UUID `json:"merge_into" validate:"required"`
 }
 
+// recipeScale parses the optional "scale" and "servings" query parameters.
+func recipeScale(c fiber.Ctx) (domain.RecipeScale, error) {
+	scale := domain.RecipeScale{
+		Factor:   fiber.Query[float64](c, "scale", 1),
+		Servings: fiber.Query[int](c, "servings", 0),
+	}
+	if scale.Factor <= 0 || scale.Servings < 0 {
+		return scale, sentinels.BadRequest("scale and servings must be positive")
+	}
+	return scale, nil
+}
+
+// exportContentTypes maps the export formats to the Content-Type of the response.
+var exportContentTypes = map[domain.ExportFormat]string{
+	domain.ExportFormatJSONLD:   "application/ld+json",
+	domain.ExportFormatMarkdown: "text/markdown; charset=utf-8",
+	domain.ExportFormatText:     fiber.MIMETextPlainCharsetUTF8,
+	domain.ExportFormatCooklang: fiber.MIMETextPlainCharsetUTF8,
+}
+
+// exportOptions parses the "format" (default jsonld), "units", "scale" and "servings" query parameters.
+func exportOptions(c fiber.Ctx) (domain.ExportOptions, error) {
+	opts := domain.ExportOptions{Format: domain.ExportFormat(c.Query("format", string(domain.ExportFormatJSONLD)))}
+	if _, ok := exportContentTypes[opts.Format]; !ok {
+		return opts, sentinels.BadRequest("format must be one of jsonld, markdown, text or cooklang")
+	}
+
+	switch units := domain.UnitSystem(c.Query("units")); units {
+	case "":
+	case domain.UnitSystemMetric, domain.UnitSystemImperial:
+		opts.UnitSystem = &units
+	default:
+		return opts, sentinels.BadRequest("units must be metric or imperial")
+	}
+
+	var err error
+	opts.Scale, err = recipeScale(c)
+	return opts, err
+}
+
+// sendExport writes an exported document with the Content-Type of its format.
+func sendExport(c fiber.Ctx, format domain.ExportFormat, data []byte) error {
+	c.Set(fiber.HeaderContentType, exportContentTypes[format])
+	return c.Send(data)
+}
+
 // bindBody binds the request body to dst and validates it.
 func bindBody[T any](c fiber.Ctx, dst *T) error {
 	if err := c.Bind().Body(dst); err != nil {
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/tokens"
+	"borscht.app/smetana/internal/types"
+)
+
+type CookbookHandler struct {
+	cookbookService domain.CookbookService
+}
+
+func NewCookbookHandler(cookbookService domain.CookbookService) *CookbookHandler {
+	return &CookbookHandler{
+		cookbookService: cookbookService,
+	}
+}
+
+// CreateCookbook godoc
+// @Summary Generate a printable cookbook from a collection.
+// @Description Renders a cover, table of contents, one recipe per page and an index by taxonomy.
+// @Description Small collections are rendered right away (201), large ones in the background (202): poll the cookbook until its status is success and download the file_url.
+// @Tags collections
+// @Accept */*
+// @Produce json
+// @Param id path string true "Collection ID"
+// @Param format query string false "Cookbook format: pdf or html (default: pdf)"
+// @Success 201 {object} domain.Cookbook
+// @Success 202 {object} domain.Cookbook
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/collections/{id}/cookbook [post]
+func (h *CookbookHandler) CreateCookbook(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+	format := domain.CookbookFormat(c.Query("format", string(domain.CookbookFormatPDF)))
+
+	tokenData := tokens.MustClaims(c)
+	cookbook, err := h.cookbookService.Create(c.Context(), id, tokenData.ID, tokenData.HouseholdID, format)
+	if err != nil {
+		return err
+	}
+
+	if cookbook.Status == domain.JobStatusPending {
+		return c.Status(fiber.StatusAccepted).JSON(cookbook)
+	}
+	return c.Status(fiber.StatusCreated).JSON(cookbook)
+}
+
+// GetCookbook godoc
+// @Summary Returns the generation status of a cookbook and the URL of its file once it is ready.
+// @Tags collections
+// @Accept */*
+// @Produce json
+// @Param id path string true "Cookbook ID"
+// @Success 200 {object} domain.Cookbook
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/cookbooks/{id} [get]
+func (h *CookbookHandler) GetCookbook(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	cookbook, err := h.cookbookService.ByID(id, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(cookbook)
+}
+
+// DeleteCookbook godoc
+// @Summary Delete a cookbook and its file.
+// @Tags collections
+// @Accept */*
+// @Produce json
+// @Param id path string true "Cookbook ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/cookbooks/{id} [delete]
+func (h *CookbookHandler) DeleteCookbook(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if err := h.cookbookService.Delete(id, tokenData.HouseholdID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"strings"
+
 	"borscht.app/smetana/domain"
 	"borscht.app/smetana/internal/tokens"
 	"borscht.app/smetana/internal/types"
@@ -80,7 +82,7 @@ func (h *FeedHandler) Unsubscribe(c fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param q query string false "Text search"
-// @Param preload query string false "Comma-separated extras to include: publisher, last3_recipes and total_recipes"
+// @Param preload query string false "Comma-separated extras to include: publisher, last3_recipes, total_recipes and unread_recipes"
 // @Param sort query string false "Sort by field: id, name, created, updated (default: id)"
 // @Param order query string false "Sort order: asc or desc (default: desc)"
 // @Param offset query int false "Number of records to skip (default: 0)"
@@ -92,13 +94,13 @@ func (h *FeedHandler) Unsubscribe(c fiber.Ctx) error {
 func (h *FeedHandler) ListSubscriptions(c fiber.Ctx) error {
 	tokenData := tokens.MustClaims(c)
 	opts, err := types.GetSearchOptions(c, types.SearchConfig{
-		AllowedPreloads: []string{"publisher", "last3_recipes", "total_recipes"},
+		AllowedPreloads: []string{"publisher", "last3_recipes", "total_recipes", "unread_recipes"},
 	})
 	if err != nil {
 		return err
 	}
 
-	feeds, total, err := h.feedService.Search(tokenData.HouseholdID, opts)
+	feeds, total, err := h.feedService.Search(tokenData.ID, tokenData.HouseholdID, opts)
 	if err != nil {
 		return err
 	}
@@ -112,6 +114,27 @@ func (h *FeedHandler) ListSubscriptions(c fiber.Ctx) error {
 	})
 }
 
+// ExportOPML godoc
+// @Summary Export subscriptions as OPML
+// @Description Lists every feed the household is subscribed to as an OPML 2.0 document, to be imported into an RSS reader.
+// @Tags feeds
+// @Produce text/x-opml
+// @Success 200 {string} string
+// @Failure 401 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/opml [get]
+func (h *FeedHandler) ExportOPML(c fiber.Ctx) error {
+	tokenData := tokens.MustClaims(c)
+	data, err := h.feedService.ExportOPML(tokenData.ID, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+
+	c.Attachment("subscriptions.opml")
+	c.Set(fiber.HeaderContentType, "text/x-opml; charset=utf-8")
+	return c.Send(data)
+}
+
 // Sync godoc
 // @Summary Sync a feed
 // @Description Trigger an immediate synchronization of the feed, importing any new recipes. This call is synchronous and blocks until the sync completes. If the connection drops, the sync continues server-side.
@@ -143,6 +166,89 @@ func (h *FeedHandler) Sync(c fiber.Ctx) error {
 	})
 }
 
+// MarkFeedRead godoc
+// @Summary Mark all recipes of a feed as read
+// @Description Marks every recipe the feed has imported so far as read by the user, streams requested with unread leave them out.
+// @Tags feeds
+// @Accept */*
+// @Produce json
+// @Param id path string true "Feed ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/{id}/read [post]
+func (h *FeedHandler) MarkFeedRead(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if err := h.feedService.MarkFeedRead(tokenData.ID, tokenData.HouseholdID, id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SetFilter godoc
+// @Summary Set the filter of a subscription
+// @Description Replaces the household's filter of the feed's recipes, applied to the stream and unread counts. A recipe is shown when it matches every rule that is set, an empty filter shows every recipe.
+// @Tags feeds
+// @Accept json
+// @Produce json
+// @Param id path string true "Feed ID"
+// @Param request body domain.FeedFilter true "Feed filter"
+// @Success 200 {object} domain.Feed
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/{id}/filter [put]
+func (h *FeedHandler) SetFilter(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var filter domain.FeedFilter
+	if err := bindBody(c, &filter); err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	feed, err := h.feedService.SetFilter(tokenData.HouseholdID, id, &filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(feed)
+}
+
+// ClearFilter godoc
+// @Summary Clear the filter of a subscription
+// @Description Removes the household's filter of the feed's recipes, so every recipe is shown again.
+// @Tags feeds
+// @Accept */*
+// @Produce json
+// @Param id path string true "Feed ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/{id}/filter [delete]
+func (h *FeedHandler) ClearFilter(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if _, err := h.feedService.SetFilter(tokenData.HouseholdID, id, nil); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
 // ListStream godoc
 // @Summary List a timeline of recipes from subscribed feeds.
 // @Tags feeds
@@ -156,8 +262,10 @@ func (h *FeedHandler) Sync(c fiber.Ctx) error {
 // @Param cook_time_max query int false "Max cook time in seconds (e.g. 1800 = 30 min)"
 // @Param total_time_max query int false "Max total time in seconds (e.g. 3600 = 1 hour)"
 // @Param preload query string false "Comma-separated extras to include: publisher, author, feed, images, ingredients, equipment, instructions, nutrition, taxonomies, collections and saved"
-// @Param sort query string false "Sort by field: id, name, created, updated (default: id)"
+// @Param sort query string false "Sort by field: id, name, created, updated, or rank to rank the newest recipes by what the household cooked, saved and collected, mixing publishers (default: id)"
 // @Param order query string false "Sort order: asc or desc (default: desc)"
+// @Param hide_dismissed query bool false "Leave out recipes the user dismissed"
+// @Param unread query bool false "Leave out recipes the user has read"
 // @Param offset query int false "Number of records to skip (default: 0)"
 // @Param limit query int false "Maximum number of records to return (default: 10)"
 // @Success 200 {object} types.ListResponse[domain.Recipe]
@@ -167,13 +275,19 @@ func (h *FeedHandler) Sync(c fiber.Ctx) error {
 func (h *FeedHandler) ListStream(c fiber.Ctx) error {
 	tokenData := tokens.MustClaims(c)
 	opts, err := types.GetSearchOptions(c, types.SearchConfig{
+		AllowedSorts:    []string{"rank"},
 		AllowedPreloads: []string{"publisher", "author", "feed", "images", "ingredients", "equipment", "instructions", "nutrition", "taxonomies", "collections", "saved"},
 	})
 	if err != nil {
 		return err
 	}
 
-	recipes, total, err := h.feedService.Stream(tokenData.ID, tokenData.HouseholdID, opts)
+	recipes, total, err := h.feedService.Stream(tokenData.ID, tokenData.HouseholdID, domain.StreamOptions{
+		SearchOptions: opts,
+		Ranked:        strings.EqualFold(opts.Sort, "rank"),
+		HideDismissed: fiber.Query[bool](c, "hide_dismissed"),
+		Unread:        fiber.Query[bool](c, "unread"),
+	})
 	if err != nil {
 		return err
 	}
@@ -186,3 +300,115 @@ func (h *FeedHandler) ListStream(c fiber.Ctx) error {
 		},
 	})
 }
+
+// MarkStreamRecipeRead godoc
+// @Summary Mark a recipe of the stream as read.
+// @Description Streams requested with unread leave the recipe out for the user.
+// @Tags feeds
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/stream/{id}/read [post]
+func (h *FeedHandler) MarkStreamRecipeRead(c fiber.Ctx) error {
+	return h.setStreamFlag(c, domain.StreamFlagSeen, true)
+}
+
+// MarkStreamRecipeUnread godoc
+// @Summary Mark a recipe of the stream as unread.
+// @Tags feeds
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/stream/{id}/read [delete]
+func (h *FeedHandler) MarkStreamRecipeUnread(c fiber.Ctx) error {
+	return h.setStreamFlag(c, domain.StreamFlagSeen, false)
+}
+
+// DismissStreamRecipe godoc
+// @Summary Dismiss a recipe of the stream.
+// @Description Marks the recipe as not interesting, streams requested with hide_dismissed leave it out for the user.
+// @Tags feeds
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/stream/{id}/dismiss [post]
+func (h *FeedHandler) DismissStreamRecipe(c fiber.Ctx) error {
+	return h.setStreamFlag(c, domain.StreamFlagDismissed, true)
+}
+
+// UndismissStreamRecipe godoc
+// @Summary Undo dismissing a recipe of the stream.
+// @Tags feeds
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/stream/{id}/dismiss [delete]
+func (h *FeedHandler) UndismissStreamRecipe(c fiber.Ctx) error {
+	return h.setStreamFlag(c, domain.StreamFlagDismissed, false)
+}
+
+// HideStreamRecipe godoc
+// @Summary Hide a recipe from the stream.
+// @Description Leaves the recipe out of every stream of the user until it is unhidden.
+// @Tags feeds
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/stream/{id}/hide [post]
+func (h *FeedHandler) HideStreamRecipe(c fiber.Ctx) error {
+	return h.setStreamFlag(c, domain.StreamFlagHidden, true)
+}
+
+// UnhideStreamRecipe godoc
+// @Summary Show a hidden recipe in the stream again.
+// @Tags feeds
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/feeds/stream/{id}/hide [delete]
+func (h *FeedHandler) UnhideStreamRecipe(c fiber.Ctx) error {
+	return h.setStreamFlag(c, domain.StreamFlagHidden, false)
+}
+
+func (h *FeedHandler) setStreamFlag(c fiber.Ctx, flag domain.StreamFlag, value bool) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if err := h.feedService.SetStreamFlag(tokenData.ID, tokenData.HouseholdID, id, flag, value); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -1,27 +1,43 @@
 package api
 
 import (
+	"io"
+	"mime/multipart"
 	"net/http"
+	"path/filepath"
+	"strings"
 
 	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
 	"borscht.app/smetana/internal/tokens"
+	"borscht.app/smetana/internal/types"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/log"
 )
 
 type ImportHandler struct {
-	importService domain.ImportService
+	importService    domain.ImportService
+	importJobService domain.ImportJobService
 }
 
-func NewImportHandler(importService domain.ImportService) *ImportHandler {
+func NewImportHandler(importService domain.ImportService, importJobService domain.ImportJobService) *ImportHandler {
 	return &ImportHandler{
-		importService: importService,
+		importService:    importService,
+		importJobService: importJobService,
 	}
 }
 
 type ImportRequest struct {
 	URL    string `json:"url" validate:"required,url"`
-	Update bool   `json:"update"`
+	Update bool   `json:"update" validate:"excluded_with=HTML"`
 	Type   string `json:"type" validate:"omitempty,oneof=auto recipe feed"` // "auto", "recipe", "feed"
+	// DryRun previews the import without storing anything.
+	DryRun bool `json:"dry_run"`
+	// Async queues the import and answers right away with a job to poll.
+	Async bool `json:"async" validate:"excluded_with=DryRun"`
+	// HTML is the page as the client sees it, scraped instead of fetching URL (e.g. from a browser extension).
+	// The recipe is kept in the household and can't be updated this way.
+	HTML string `json:"html,omitempty"`
 }
 
 // FIXME: this method is deprecated and should be removed in the future
@@ -55,12 +71,14 @@ func (h *ImportHandler) Import(c fiber.Ctx) error {
 
 // DetectAndImport godoc
 // @Summary Import a recipe or subscribe to a feed from a URL.
-// @Description Detects whether the URL points to a single recipe or a feed/listing. Returns the imported recipe or the feed subscription.
+// @Description Detects whether the URL points to a single recipe or a feed/listing. Returns the imported recipe or the feed subscription. When html is given, that page is scraped instead of fetching the URL, for sites that block the server or need a login. With dry_run nothing is stored, a preview lists which foods and units already exist and which would be created. With async the import is queued (202): poll the job until its status is success or error.
 // @Tags import
 // @Accept json
 // @Produce json
 // @Param import body ImportRequest true "Import request (type can be auto, recipe, or feed)"
 // @Success 201 {object} domain.ImportResult
+// @Success 200 {object} domain.ImportResult "Already imported, or a dry run"
+// @Success 202 {object} domain.ImportJob "Queued, with async"
 // @Failure 400 {object} sentinels.Error
 // @Failure 401 {object} sentinels.Error
 // @Security ApiKeyAuth
@@ -72,7 +90,21 @@ func (h *ImportHandler) DetectAndImport(c fiber.Ctx) error {
 	}
 
 	tokenData := tokens.MustClaims(c)
-	result, err := h.importService.DetectAndImport(c.Context(), request.URL, request.Type, request.Update, tokenData.ID, tokenData.HouseholdID)
+	if request.Async {
+		job, err := h.importJobService.Enqueue(request.URL, request.Type, request.Update, request.HTML, tokenData.ID, tokenData.HouseholdID)
+		if err != nil {
+			return err
+		}
+		return c.Status(http.StatusAccepted).JSON(job)
+	}
+
+	var result *domain.ImportResult
+	var err error
+	if request.HTML != "" {
+		result, err = h.importService.ImportHtml(c.Context(), []byte(request.HTML), request.URL, request.Type, request.Update, request.DryRun, tokenData.ID, tokenData.HouseholdID)
+	} else {
+		result, err = h.importService.DetectAndImport(c.Context(), request.URL, request.Type, request.Update, request.DryRun, tokenData.ID, tokenData.HouseholdID)
+	}
 	if err != nil {
 		return err
 	}
@@ -83,3 +115,253 @@ func (h *ImportHandler) DetectAndImport(c fiber.Ctx) error {
 	}
 	return c.Status(status).JSON(result)
 }
+
+// GetImportJob godoc
+// @Summary Returns the status of a queued import.
+// @Description Once the status is success the job holds the imported recipe or feed, on error the error message.
+// @Tags import
+// @Accept */*
+// @Produce json
+// @Param id path string true "Import job ID"
+// @Success 200 {object} domain.ImportJob
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/import/jobs/{id} [get]
+func (h *ImportHandler) GetImportJob(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	job, err := h.importJobService.ByID(id, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(job)
+}
+
+// ImportFile godoc
+// @Summary Import recipes from another recipe manager.
+// @Description Accepts a Paprika (.paprikarecipes), Mealie backup, Tandoor export or zipped Cooklang folder and queues the import of every recipe in it into the household (202). Poll the job until its status is success or error, its report lists the recipes that failed, the others are still imported.
+// @Tags import
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Export file"
+// @Success 202 {object} domain.ImportJob
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 413 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/import/file [post]
+func (h *ImportHandler) ImportFile(c fiber.Ctx) error {
+	data, _, err := formFile(c, "file")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	job, err := h.importJobService.EnqueueFile(data, tokenData.ID, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusAccepted).JSON(job)
+}
+
+type BulkImportRequest struct {
+	URLs []string `json:"urls" validate:"required,min=1"`
+}
+
+// ImportBulk godoc
+// @Summary Import many recipe URLs at once.
+// @Description Accepts a JSON list of URLs, or a Netscape bookmarks file (as exported by browsers) uploaded as file. URLs already imported are only saved for the user and reported as existing, the others are imported in the background (202): poll the report by its id until no URL is queued, each one is then created, existing or failed with the reason.
+// @Tags import
+// @Accept json,multipart/form-data
+// @Produce json
+// @Param import body BulkImportRequest false "URLs to import"
+// @Param file formData file false "Bookmarks file"
+// @Success 202 {object} domain.BulkImportReport
+// @Success 200 {object} domain.BulkImportReport "No URL was queued"
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/import/bulk [post]
+func (h *ImportHandler) ImportBulk(c fiber.Ctx) error {
+	tokenData := tokens.MustClaims(c)
+
+	var report *domain.BulkImportReport
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		data, _, err := formFile(c, "file")
+		if err != nil {
+			return err
+		}
+		if report, err = h.importJobService.EnqueueBookmarks(data, tokenData.ID, tokenData.HouseholdID); err != nil {
+			return err
+		}
+	} else {
+		var request BulkImportRequest
+		if err := bindBody(c, &request); err != nil {
+			return err
+		}
+		var err error
+		if report, err = h.importJobService.EnqueueURLs(request.URLs, tokenData.ID, tokenData.HouseholdID); err != nil {
+			return err
+		}
+	}
+
+	status := http.StatusOK
+	if report.Queued > 0 {
+		status = http.StatusAccepted
+	}
+	return c.Status(status).JSON(report)
+}
+
+// GetBulkImport godoc
+// @Summary Returns the report of a bulk import.
+// @Description Rolls up the import of every URL of the bulk: queued, created, existing, or failed with the reason.
+// @Tags import
+// @Accept */*
+// @Produce json
+// @Param id path string true "Bulk import ID"
+// @Success 200 {object} domain.BulkImportReport
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/import/bulk/{id} [get]
+func (h *ImportHandler) GetBulkImport(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	report, err := h.importJobService.BulkByID(id, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(report)
+}
+
+// ImportOPML godoc
+// @Summary Subscribe to the feeds of an OPML file.
+// @Description Accepts an OPML subscription list, as exported by RSS readers, uploaded as file. Folders are flattened. Feeds the household already follows are reported as existing and outlines without a web URL as invalid, the others are subscribed in the background (202): poll the job of each queued feed until its status is success, with the added feed, or error.
+// @Tags import
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "OPML file"
+// @Success 202 {object} domain.FeedImportReport
+// @Success 200 {object} domain.FeedImportReport "No feed was queued"
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/import/opml [post]
+func (h *ImportHandler) ImportOPML(c fiber.Ctx) error {
+	data, _, err := formFile(c, "file")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	report, err := h.importJobService.EnqueueOPML(data, tokenData.ID, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	if report.Queued > 0 {
+		status = http.StatusAccepted
+	}
+	return c.Status(status).JSON(report)
+}
+
+// ImportCooklang godoc
+// @Summary Import a Cooklang recipe.
+// @Description Imports a single .cook file into the household. The file name is the title unless the recipe sets one in its metadata. Ingredients are linked to canonical foods and units, cookware becomes equipment.
+// @Tags import
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Cooklang file"
+// @Success 201 {object} domain.Recipe
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 422 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/import/cooklang [post]
+func (h *ImportHandler) ImportCooklang(c fiber.Ctx) error {
+	data, filename, err := formFile(c, "file")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	recipe, err := h.importService.ImportCooklang(c.Context(), name, string(data), tokenData.ID, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(recipe)
+}
+
+type ImportTextRequest struct {
+	Text string `json:"text" validate:"required,max=100000"`
+	Save bool   `json:"save"` // false returns a draft for preview without storing it
+}
+
+// ImportText godoc
+// @Summary Import a recipe from pasted text.
+// @Description Splits free text (e.g. from an email or a cookbook) into title, ingredients and instructions and parses every ingredient line. Returns the draft for preview, or creates the recipe in the household when save is set.
+// @Tags import
+// @Accept json
+// @Produce json
+// @Param import body ImportTextRequest true "Recipe text"
+// @Success 201 {object} domain.Recipe
+// @Success 200 {object} domain.Recipe "Draft, not saved"
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/import/text [post]
+func (h *ImportHandler) ImportText(c fiber.Ctx) error {
+	var request ImportTextRequest
+	if err := bindBody(c, &request); err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	recipe, err := h.importService.ImportText(c.Context(), request.Text, request.Save, tokenData.ID, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	if request.Save {
+		status = http.StatusCreated
+	}
+	return c.Status(status).JSON(recipe)
+}
+
+// formFile reads an uploaded file, the request body limit bounds its size.
+func formFile(c fiber.Ctx, field string) ([]byte, string, error) {
+	file, err := c.FormFile(field)
+	if err != nil {
+		return nil, "", sentinels.BadRequest("Missing file")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, "", sentinels.BadRequest("Failed to open file")
+	}
+	defer func(src multipart.File) {
+		if err := src.Close(); err != nil {
+			log.Warnw("failed to close uploaded file", "error", err.Error())
+		}
+	}(src)
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, "", sentinels.BadRequest("Failed to read file")
+	}
+	return data, file.Filename, nil
+}
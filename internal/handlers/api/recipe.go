@@ -2,6 +2,7 @@ package api
 
 import (
 	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
 	"borscht.app/smetana/internal/tokens"
 	"borscht.app/smetana/internal/types"
 	"github.com/gofiber/fiber/v3"
@@ -40,7 +41,7 @@ func NewRecipeHandler(recipeService domain.RecipeService) *RecipeHandler {
 func (h *RecipeHandler) GetRecipes(c fiber.Ctx) error {
 	tokenData := tokens.MustClaims(c)
 	opts, err := types.GetSearchOptions(c, types.SearchConfig{
-		AllowedPreloads: []string{"publisher", "author", "feed", "images", "ingredients", "equipment", "instructions", "nutrition", "taxonomies", "collections", "saved"},
+		AllowedPreloads: []string{"publisher", "author", "feed", "images", "ingredients", "subrecipes", "equipment", "instructions", "nutrition", "taxonomies", "collections", "saved"},
 	})
 	if err != nil {
 		return err
@@ -178,6 +179,100 @@ func (h *RecipeHandler) DeleteRecipe(c fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// GetDuplicateRecipes godoc
+// @Summary List likely duplicate recipes.
+// @Description Groups the recipes visible to the household that share a title and the foods of their ingredients, e.g. the same recipe imported from an AMP page and the original. Recipes of a group are ordered oldest first. The pages are of titles shared by several recipes and the total counts them, a title whose recipes differ in their foods gives fewer or more groups.
+// @Tags recipes
+// @Accept */*
+// @Produce json
+// @Param offset query int false "Number of shared titles to skip (default: 0)"
+// @Param limit query int false "Maximum number of shared titles to return (default: 10)"
+// @Success 200 {object} types.ListResponse[domain.RecipeDuplicates]
+// @Failure 401 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/recipes/duplicates [get]
+func (h *RecipeHandler) GetDuplicateRecipes(c fiber.Ctx) error {
+	tokenData := tokens.MustClaims(c)
+	p := types.GetPagination(c)
+	groups, total, err := h.recipeService.Duplicates(tokenData.HouseholdID, p.Offset, p.Limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(types.ListResponse[domain.RecipeDuplicates]{
+		Data: groups,
+		Meta: types.Meta{
+			Pagination: p,
+			Total:      int(total),
+		},
+	})
+}
+
+// MergeRecipe godoc
+// @Summary Merge a duplicate recipe into another
+// @Description Moves the household's saves, collection entries and meal plans from the recipe at {id} to merge_into. The recipe at {id} is moved to the trash when the household owns it.
+// @Tags recipes
+// @Accept json
+// @Produce json
+// @Param id path string true "Recipe UUID to merge away"
+// @Param body body mergeRequest true "Target recipe to keep"
+// @Success 204
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/recipes/{id}/merge [post]
+func (h *RecipeHandler) MergeRecipe(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req mergeRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if err := h.recipeService.Merge(req.MergeInto, id, tokenData.HouseholdID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetSimilarRecipes godoc
+// @Summary List recipes similar to a recipe.
+// @Description Returns recipes visible to the household that share foods, taxonomies (cuisines count most) and equipment with the recipe, most similar first, with a score from 0 to 1.
+// @Tags recipes
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param limit query int false "Maximum number of recipes to return (default: 10, max: 50)"
+// @Success 200 {array} domain.SimilarRecipe
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/recipes/{id}/similar [get]
+func (h *RecipeHandler) GetSimilarRecipes(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+	limit := fiber.Query[int](c, "limit", 10)
+	if limit < 1 || limit > 50 {
+		return sentinels.BadRequest("limit must be between 1 and 50")
+	}
+
+	tokenData := tokens.MustClaims(c)
+	similar, err := h.recipeService.Similar(id, tokenData.HouseholdID, limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(similar)
+}
+
 // SaveRecipe godoc
 // @Summary Save a recipe.
 // @Description Adds the recipe to the user's personal "Favorites" list.
@@ -228,6 +323,39 @@ func (h *RecipeHandler) UnsaveRecipe(c fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// GetIngredients godoc
+// @Summary List the ingredients of a recipe, with sub-recipes expanded.
+// @Description Returns a flat list where every sub-recipe ingredient is replaced by the ingredients of the sub-recipe, scaled to the requested amount.
+// @Tags recipes
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param scale query number false "Multiplier for ingredient amounts (default: 1)"
+// @Param servings query int false "Target number of servings, takes precedence over scale"
+// @Success 200 {array} domain.RecipeIngredient
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/recipes/{id}/ingredients [get]
+func (h *RecipeHandler) GetIngredients(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+	scale, err := recipeScale(c)
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	ingredients, err := h.recipeService.ExpandIngredients(id, tokenData.HouseholdID, scale)
+	if err != nil {
+		return err
+	}
+	return c.JSON(ingredients)
+}
+
 // CreateIngredient godoc
 // @Summary Create a recipe ingredient.
 // @Description Add a new ingredient to a recipe.
@@ -447,6 +575,72 @@ func (h *RecipeHandler) UpdateInstruction(c fiber.Ctx) error {
 	return c.JSON(instruction)
 }
 
+// LinkInstructionIngredient godoc
+// @Summary Link an ingredient to a recipe instruction.
+// @Description Mark an ingredient of the recipe as used in the given step.
+// @Tags recipes
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param instructionId path string true "Instruction ID"
+// @Param ingredientId path string true "Ingredient ID"
+// @Success 204
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/recipes/{id}/instructions/{instructionId}/ingredients/{ingredientId} [post]
+func (h *RecipeHandler) LinkInstructionIngredient(c fiber.Ctx) error {
+	id, instructionID, err := types.UuidParams(c, "id", "instructionId")
+	if err != nil {
+		return err
+	}
+	ingredientID, err := types.UuidParam(c, "ingredientId")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if err := h.recipeService.LinkInstructionIngredient(id, instructionID, ingredientID, tokenData.HouseholdID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// UnlinkInstructionIngredient godoc
+// @Summary Unlink an ingredient from a recipe instruction.
+// @Description Remove the association between a step and an ingredient of the recipe.
+// @Tags recipes
+// @Accept */*
+// @Produce json
+// @Param id path string true "Recipe ID"
+// @Param instructionId path string true "Instruction ID"
+// @Param ingredientId path string true "Ingredient ID"
+// @Success 204
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/recipes/{id}/instructions/{instructionId}/ingredients/{ingredientId} [delete]
+func (h *RecipeHandler) UnlinkInstructionIngredient(c fiber.Ctx) error {
+	id, instructionID, err := types.UuidParams(c, "id", "instructionId")
+	if err != nil {
+		return err
+	}
+	ingredientID, err := types.UuidParam(c, "ingredientId")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if err := h.recipeService.UnlinkInstructionIngredient(id, instructionID, ingredientID, tokenData.HouseholdID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
 // DeleteInstruction godoc
 // @Summary Delete a recipe instruction.
 // @Description Delete a specific recipe instruction.
@@ -498,3 +692,38 @@ func (h *RecipeHandler) GetRecipeCost(c fiber.Ctx) error {
 	}
 	return c.JSON(estimate)
 }
+
+// ExportRecipe godoc
+// @Summary Export a recipe for other tools
+// @Description Serialize a recipe with all its details as schema.org JSON-LD, markdown, plain text or Cooklang. Ingredient amounts can be scaled and converted to another unit system.
+// @Tags recipes
+// @Produce application/ld+json,text/markdown,text/plain
+// @Param id path string true "Recipe UUID"
+// @Param format query string false "Export format: jsonld, markdown, text or cooklang (default: jsonld)"
+// @Param scale query number false "Multiplier for ingredient amounts (default: 1)"
+// @Param servings query int false "Target yield, takes precedence over scale"
+// @Param units query string false "Convert ingredient amounts and temperatures: metric or imperial"
+// @Success 200 {string} string
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/recipes/{id}/export [get]
+func (h *RecipeHandler) ExportRecipe(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+	opts, err := exportOptions(c)
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	data, err := h.recipeService.Export(id, tokenData.ID, tokenData.HouseholdID, opts)
+	if err != nil {
+		return err
+	}
+	return sendExport(c, opts.Format, data)
+}
@@ -38,6 +38,7 @@ type stubRecipeService struct {
 	removeEquipmentFn  func(uuid.UUID, uuid.UUID, uuid.UUID) error
 	createIngredientFn func(*domain.RecipeIngredient, uuid.UUID) error
 	deleteIngredientFn func(uuid.UUID, uuid.UUID, uuid.UUID) error
+	exportFn           func(uuid.UUID, uuid.UUID, uuid.UUID, domain.ExportOptions) ([]byte, error)
 }
 
 func (s *stubRecipeService) ByID(id, hid uuid.UUID) (*domain.Recipe, error) {
@@ -109,6 +110,12 @@ func (s *stubRecipeService) DeleteIngredient(id, rid, hid uuid.UUID) error {
 	}
 	return nil
 }
+func (s *stubRecipeService) Export(id, uid, hid uuid.UUID, opts domain.ExportOptions) ([]byte, error) {
+	if s.exportFn != nil {
+		return s.exportFn(id, uid, hid, opts)
+	}
+	return nil, nil
+}
 
 // buildApp creates a Fiber app with a stubbed RecipeService already wired in.
 func buildApp(t *testing.T, svc *stubRecipeService) *fiber.App {
@@ -131,6 +138,7 @@ func buildApp(t *testing.T, svc *stubRecipeService) *fiber.App {
 	protected.Delete("/recipes/:id/equipment/:equipmentId", handler.RemoveEquipment)
 	protected.Post("/recipes/:id/ingredients", handler.CreateIngredient)
 	protected.Delete("/recipes/:id/ingredients/:ingredientId", handler.DeleteIngredient)
+	protected.Get("/recipes/:id/export", handler.ExportRecipe)
 
 	return app
 }
@@ -532,3 +540,55 @@ func TestRecipeHandler_DeleteIngredient_Returns204(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 }
+
+func TestRecipeHandler_ExportRecipe_DefaultsToJSONLD(t *testing.T) {
+	recipeID := uuid.New()
+	svc := &stubRecipeService{
+		exportFn: func(id, _, _ uuid.UUID, opts domain.ExportOptions) ([]byte, error) {
+			assert.Equal(t, recipeID, id)
+			assert.Equal(t, domain.ExportFormatJSONLD, opts.Format)
+			assert.Nil(t, opts.UnitSystem)
+			return []byte(`{"@type":"Recipe"}`), nil
+		},
+	}
+	app := buildApp(t, svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/recipes/"+recipeID.String()+"/export", nil)
+	req.Header.Set("Authorization", makeToken(t, uuid.New(), uuid.New()))
+	resp, err := app.Test(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/ld+json", resp.Header.Get("Content-Type"))
+}
+
+func TestRecipeHandler_ExportRecipe_Markdown_ParsesOptions(t *testing.T) {
+	svc := &stubRecipeService{
+		exportFn: func(_, _, _ uuid.UUID, opts domain.ExportOptions) ([]byte, error) {
+			assert.Equal(t, domain.ExportFormatMarkdown, opts.Format)
+			assert.Equal(t, domain.RecipeScale{Factor: 1, Servings: 6}, opts.Scale)
+			assert.Equal(t, new(domain.UnitSystemImperial), opts.UnitSystem)
+			return []byte("# Borsch\n"), nil
+		},
+	}
+	app := buildApp(t, svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/recipes/"+uuid.New().String()+"/export?format=markdown&servings=6&units=imperial", nil)
+	req.Header.Set("Authorization", makeToken(t, uuid.New(), uuid.New()))
+	resp, err := app.Test(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/markdown; charset=utf-8", resp.Header.Get("Content-Type"))
+}
+
+func TestRecipeHandler_ExportRecipe_UnknownUnits_Returns400(t *testing.T) {
+	app := buildApp(t, &stubRecipeService{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/recipes/"+uuid.New().String()+"/export?format=text&units=nautical", nil)
+	req.Header.Set("Authorization", makeToken(t, uuid.New(), uuid.New()))
+	resp, err := app.Test(req)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/types"
+)
+
+type ScrapeRuleHandler struct {
+	service domain.ScrapeRuleService
+}
+
+func NewScrapeRuleHandler(service domain.ScrapeRuleService) *ScrapeRuleHandler {
+	return &ScrapeRuleHandler{service: service}
+}
+
+type scrapeRuleRequest struct {
+	Domain            *string  `json:"domain" validate:"omitempty,min=3,max=255"`
+	Disabled          *bool    `json:"disabled"`
+	SkipKrip          *bool    `json:"skip_krip"`
+	Title             *string  `json:"title" validate:"omitempty,max=1000"`
+	Ingredients       *string  `json:"ingredients" validate:"omitempty,max=1000"`
+	Instructions      *string  `json:"instructions" validate:"omitempty,max=1000"`
+	Image             *string  `json:"image" validate:"omitempty,max=1000"`
+	IngredientCleanup []string `json:"ingredient_cleanup" validate:"omitempty,max=50,dive,max=1000"`
+}
+
+func (r *scrapeRuleRequest) apply(rule *domain.ScrapeRule) {
+	if r.Domain != nil {
+		rule.Domain = *r.Domain
+	}
+	if r.Disabled != nil {
+		rule.Disabled = *r.Disabled
+	}
+	if r.SkipKrip != nil {
+		rule.SkipKrip = *r.SkipKrip
+	}
+	if r.Title != nil {
+		rule.Title = *r.Title
+	}
+	if r.Ingredients != nil {
+		rule.Ingredients = *r.Ingredients
+	}
+	if r.Instructions != nil {
+		rule.Instructions = *r.Instructions
+	}
+	if r.Image != nil {
+		rule.Image = *r.Image
+	}
+	if r.IngredientCleanup != nil {
+		rule.IngredientCleanup = r.IngredientCleanup
+	}
+}
+
+// GetScrapeRules godoc
+// @Summary List the scraping rules.
+// @Description Lists the per-domain rules that correct or disable scraping, ordered by domain. Admin only.
+// @Tags admin
+// @Accept */*
+// @Produce json
+// @Success 200 {array} domain.ScrapeRule
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/scraperules [get]
+func (h *ScrapeRuleHandler) GetScrapeRules(c fiber.Ctx) error {
+	rules, err := h.service.List()
+	if err != nil {
+		return err
+	}
+	return c.JSON(rules)
+}
+
+// CreateScrapeRule godoc
+// @Summary Create a scraping rule.
+// @Description Adds a rule for a domain and its subdomains. Selectors are CSS selectors, or JSON paths starting with $ into the JSON scripts of the page; what they find replaces what krip scraped. Ingredient cleanup expressions are removed from every ingredient line. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param rule body scrapeRuleRequest true "Scraping rule"
+// @Success 201 {object} domain.ScrapeRule
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 409 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/scraperules [post]
+func (h *ScrapeRuleHandler) CreateScrapeRule(c fiber.Ctx) error {
+	var req scrapeRuleRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	rule := &domain.ScrapeRule{}
+	req.apply(rule)
+	if err := h.service.Create(rule); err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// UpdateScrapeRule godoc
+// @Summary Update a scraping rule.
+// @Description Updates the given fields of a rule. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Scrape rule ID"
+// @Param rule body scrapeRuleRequest true "Fields to update"
+// @Success 200 {object} domain.ScrapeRule
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/scraperules/{id} [patch]
+func (h *ScrapeRuleHandler) UpdateScrapeRule(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req scrapeRuleRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	rule, err := h.service.ByID(id)
+	if err != nil {
+		return err
+	}
+
+	req.apply(rule)
+	if err := h.service.Update(rule); err != nil {
+		return err
+	}
+	return c.JSON(rule)
+}
+
+// DeleteScrapeRule godoc
+// @Summary Delete a scraping rule.
+// @Description The domain is scraped by krip alone again. Admin only.
+// @Tags admin
+// @Accept */*
+// @Produce json
+// @Param id path string true "Scrape rule ID"
+// @Success 204
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/scraperules/{id} [delete]
+func (h *ScrapeRuleHandler) DeleteScrapeRule(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+type testScrapeRuleRequest struct {
+	URL  string `json:"url" validate:"required,url"`
+	HTML string `json:"html" validate:"required"`
+}
+
+// TestScrapeRule godoc
+// @Summary Test a scraping rule on a saved page.
+// @Description Scrapes the given HTML, e.g. a saved copy of a page krip gets wrong, with the rule and returns the recipe without storing it. The rule applies even while disabled. Admin only.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Scrape rule ID"
+// @Param page body testScrapeRuleRequest true "Page to scrape"
+// @Success 200 {object} domain.Recipe
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/scraperules/{id}/test [post]
+func (h *ScrapeRuleHandler) TestScrapeRule(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req testScrapeRuleRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	recipe, err := h.service.Test(c.Context(), id, []byte(req.HTML), req.URL)
+	if err != nil {
+		return err
+	}
+	return c.JSON(recipe)
+}
@@ -262,3 +262,37 @@ func (h *ShoppingListHandler) DeleteShoppingItem(c fiber.Ctx) error {
 	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// AddRecipe godoc
+// @Summary Add the ingredients of a recipe to a shopping list.
+// @Description Ingredients of sub-recipes are expanded recursively. Items are merged with existing ones by food.
+// @Tags shopping-lists
+// @Produce json
+// @Param id path string true "List ID"
+// @Param recipeId path string true "Recipe ID"
+// @Param scale query number false "Multiplier for ingredient amounts (default: 1)"
+// @Param servings query int false "Target number of servings, takes precedence over scale"
+// @Success 201 {array} domain.ShoppingItem
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 403 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/shoppinglists/{id}/recipes/{recipeId} [post]
+func (h *ShoppingListHandler) AddRecipe(c fiber.Ctx) error {
+	id, recipeID, err := types.UuidParams(c, "id", "recipeId")
+	if err != nil {
+		return err
+	}
+	scale, err := recipeScale(c)
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	items, err := h.service.AddRecipe(c.Context(), recipeID, scale, id, tokenData.HouseholdID)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(items)
+}
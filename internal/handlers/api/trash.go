@@ -0,0 +1,93 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/tokens"
+	"borscht.app/smetana/internal/types"
+)
+
+type TrashHandler struct {
+	service domain.TrashService
+}
+
+func NewTrashHandler(service domain.TrashService) *TrashHandler {
+	return &TrashHandler{service: service}
+}
+
+// GetTrash godoc
+// @Summary List deleted items in the household trash.
+// @Description Returns soft-deleted recipes, collections, meal plans and shopping lists, most recently deleted first.
+// @Tags trash
+// @Produce json
+// @Param offset query int false "Number of records to skip (default: 0)"
+// @Param limit query int false "Maximum number of records to return (default: 10)"
+// @Success 200 {object} types.ListResponse[domain.TrashItem]
+// @Failure 401 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/trash [get]
+func (h *TrashHandler) GetTrash(c fiber.Ctx) error {
+	tokenData := tokens.MustClaims(c)
+	p := types.GetPagination(c)
+
+	items, total, err := h.service.List(tokenData.HouseholdID, p.Offset, p.Limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(types.ListResponse[domain.TrashItem]{
+		Data: items,
+		Meta: types.Meta{
+			Pagination: p,
+			Total:      int(total),
+		},
+	})
+}
+
+// RestoreTrashItem godoc
+// @Summary Restore an item from the trash.
+// @Tags trash
+// @Param type path string true "Item type" Enums(recipe, collection, mealplan, shoppinglist)
+// @Param id path string true "Item ID"
+// @Success 204
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/trash/{type}/{id}/restore [post]
+func (h *TrashHandler) RestoreTrashItem(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if err := h.service.Restore(domain.TrashItemType(c.Params("type")), id, tokenData.HouseholdID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// PurgeTrashItem godoc
+// @Summary Permanently delete an item from the trash.
+// @Tags trash
+// @Param type path string true "Item type" Enums(recipe, collection, mealplan, shoppinglist)
+// @Param id path string true "Item ID"
+// @Success 204
+// @Failure 400 {object} sentinels.Error
+// @Failure 401 {object} sentinels.Error
+// @Failure 404 {object} sentinels.Error
+// @Security ApiKeyAuth
+// @Router /api/v1/trash/{type}/{id} [delete]
+func (h *TrashHandler) PurgeTrashItem(c fiber.Ctx) error {
+	id, err := types.UuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tokenData := tokens.MustClaims(c)
+	if err := h.service.Purge(domain.TrashItemType(c.Params("type")), id, tokenData.HouseholdID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
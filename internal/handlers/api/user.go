@@ -46,11 +46,12 @@ type UpdateUserForm struct {
 	Email           *string `validate:"omitempty,email,min=6" json:"email" format:"email" example:"john@example.com"`
 	NewPassword     *string `validate:"omitempty,min=8" json:"new_password" example:"newpassword123"`
 	CurrentPassword *string `validate:"required_if=Email !nil,required_if=NewPassword !nil" json:"current_password" example:"password123"`
+	UnitSystem      *string `validate:"omitempty,oneof=metric imperial" json:"unit_system" example:"metric"`
 }
 
 // UpdateUser godoc
 // @Summary Update user by ID.
-// @Description Update name or email of a specific user.
+// @Description Update name, email, password or unit system of a specific user.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -74,7 +75,11 @@ func (h *UserHandler) UpdateUser(c fiber.Ctx) error {
 	}
 
 	tokenData := tokens.MustClaims(c)
-	user, err := h.userService.Update(id, tokenData.ID, body.Name, body.Email, body.CurrentPassword, body.NewPassword)
+	var unitSystem *domain.UnitSystem
+	if body.UnitSystem != nil {
+		unitSystem = new(domain.UnitSystem(*body.UnitSystem))
+	}
+	user, err := h.userService.Update(id, tokenData.ID, body.Name, body.Email, body.CurrentPassword, body.NewPassword, unitSystem)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+
+	"borscht.app/smetana/domain"
+)
+
+// cookbookStaleAfter is how long a cookbook may stay running before it is taken for interrupted, e.g. by a restart.
+// Large collections with many images take a few minutes to render.
+const cookbookStaleAfter = 30 * time.Minute
+
+// CookbookJob renders the cookbooks of large collections that were left pending on creation,
+// and the ones whose rendering was interrupted.
+type CookbookJob struct {
+	service       domain.CookbookService
+	repo          domain.CookbookRepository
+	schedulerRepo domain.SchedulerRepository
+}
+
+func NewCookbookJob(service domain.CookbookService, repo domain.CookbookRepository, schedulerRepo domain.SchedulerRepository) *CookbookJob {
+	return &CookbookJob{
+		service:       service,
+		repo:          repo,
+		schedulerRepo: schedulerRepo,
+	}
+}
+
+func (j *CookbookJob) JobType() string {
+	return "cookbook"
+}
+
+func (j *CookbookJob) Run(ctx context.Context) (any, error) {
+	cookbooks, err := j.repo.ListRunnable(time.Now().Add(-cookbookStaleAfter))
+	if err != nil {
+		return nil, err
+	}
+
+	generated := 0
+	for i := range cookbooks {
+		if ctx.Err() != nil {
+			return generated, ctx.Err()
+		}
+		if j.generateOne(ctx, &cookbooks[i]) == nil {
+			generated++
+		}
+	}
+	return generated, nil
+}
+
+func (j *CookbookJob) generateOne(ctx context.Context, cookbook *domain.Cookbook) error {
+	logRecord := &domain.SchedulerLog{
+		JobType:   j.JobType(),
+		EntityID:  &cookbook.ID,
+		StartedAt: time.Now(),
+		Status:    domain.JobStatusRunning,
+	}
+	if err := j.schedulerRepo.CreateLog(logRecord); err != nil {
+		log.Errorw("failed to create scheduler log, skipping cookbook", "cookbook", cookbook.ID, "error", err.Error())
+		return err
+	}
+
+	genErr := j.service.Generate(ctx, cookbook)
+
+	logRecord.CompletedAt = new(time.Now())
+	if genErr != nil {
+		logRecord.Status = domain.JobStatusError
+		logRecord.ErrorMessage = genErr.Error()
+		log.Warnw("cookbook generation failed", "cookbook", cookbook.ID, "collection", cookbook.CollectionID, "error", genErr.Error())
+	} else {
+		logRecord.Status = domain.JobStatusSuccess
+		log.Infow("cookbook generated", "cookbook", cookbook.ID, "collection", cookbook.CollectionID, "format", cookbook.Format)
+	}
+
+	if err := j.schedulerRepo.UpdateLog(logRecord); err != nil {
+		log.Warnw("failed to update scheduler log", "cookbook", cookbook.ID, "error", err.Error())
+	}
+	return genErr
+}
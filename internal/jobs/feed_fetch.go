@@ -11,6 +11,7 @@ import (
 	"borscht.app/smetana/internal/utils"
 )
 
+// FeedFetchJob fetches the feeds that are due, every feed keeps its own schedule.
 type FeedFetchJob struct {
 	service          domain.FeedService
 	repo             domain.FeedRepository
@@ -32,12 +33,15 @@ func (j *FeedFetchJob) JobType() string {
 }
 
 func (j *FeedFetchJob) Run(ctx context.Context) (any, error) {
-	feeds, err := j.repo.ListActive()
+	feeds, err := j.repo.ListDue(time.Now())
 	if err != nil {
 		return nil, err
 	}
+	if len(feeds) == 0 {
+		return nil, nil
+	}
 
-	log.Infow("checking feeds for updates", "count", len(feeds))
+	log.Infow("checking due feeds for updates", "count", len(feeds))
 
 	var g errgroup.Group
 	g.SetLimit(j.fetchConcurrency)
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+	"golang.org/x/sync/errgroup"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/utils"
+)
+
+// importJobStaleAfter is how long a job may stay running before it is taken for interrupted, e.g. by a restart.
+// A scrape times out after 30 seconds, image downloads take a few more.
+const importJobStaleAfter = 10 * time.Minute
+
+// importBatchPerWorker bounds how many jobs a run takes, the rest wait for the next run.
+const importBatchPerWorker = 8
+
+// ImportQueueJob runs the queued URL imports on a pool of workers and removes old finished jobs.
+// Export files import on their own, one at a time, so a large file does not hold up the URL imports of later runs.
+type ImportQueueJob struct {
+	service   domain.ImportJobService
+	repo      domain.ImportJobRepository
+	workers   int
+	retention time.Duration
+	files     chan struct{}
+}
+
+func NewImportQueueJob(service domain.ImportJobService, repo domain.ImportJobRepository) *ImportQueueJob {
+	return &ImportQueueJob{
+		service:   service,
+		repo:      repo,
+		workers:   max(utils.GetenvInt("IMPORT_WORKERS", 4), 1),
+		retention: utils.GetenvDuration("IMPORT_JOB_RETENTION", 7*24*time.Hour),
+		files:     make(chan struct{}, 1),
+	}
+}
+
+func (j *ImportQueueJob) JobType() string {
+	return "import_queue"
+}
+
+func (j *ImportQueueJob) Run(ctx context.Context) (any, error) {
+	if deleted, err := j.repo.DeleteFinishedBefore(time.Now().Add(-j.retention)); err != nil {
+		log.Warnw("failed to delete finished import jobs", "error", err.Error())
+	} else if deleted > 0 {
+		log.Infow("finished import jobs deleted", "deleted", deleted)
+	}
+
+	queued, err := j.repo.ListRunnable(time.Now().Add(-importJobStaleAfter), j.workers*importBatchPerWorker)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported atomic.Int64
+	g := new(errgroup.Group)
+	g.SetLimit(j.workers)
+	for i := range queued {
+		job := &queued[i]
+		if job.FilePath != nil {
+			j.runFile(ctx, job)
+			continue
+		}
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil // left pending for the next run
+			}
+			if err := j.service.Run(ctx, job); err != nil {
+				log.Warnw("import job failed", "job", job.ID, "url", job.Url, "error", err.Error())
+			} else {
+				imported.Add(1)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return imported.Load(), ctx.Err()
+}
+
+// runFile starts the import of an export file unless one is already running, the job then stays pending.
+func (j *ImportQueueJob) runFile(ctx context.Context, job *domain.ImportJob) {
+	select {
+	case j.files <- struct{}{}:
+	default:
+		return
+	}
+	go func() {
+		defer func() { <-j.files }()
+		if err := j.service.Run(ctx, job); err != nil {
+			log.Warnw("import file job failed", "job", job.ID, "error", err.Error())
+		}
+	}()
+}
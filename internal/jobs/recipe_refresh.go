@@ -0,0 +1,205 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/utils"
+)
+
+const (
+	refreshBackoffBase = 24 * time.Hour
+	refreshBackoffMax  = 32 * 24 * time.Hour
+)
+
+// RecipeRefreshJob re-scrapes global recipes that were not refreshed for a while, so imports follow their source.
+// Sources that fail are retried with an exponential back-off, derived from the scheduler logs of the job.
+type RecipeRefreshJob struct {
+	importService    domain.ImportService
+	recipeService    domain.RecipeService
+	schedulerRepo    domain.SchedulerRepository
+	maxAge           time.Duration
+	batchSize        int
+	fetchConcurrency int
+	domainLimit      int
+}
+
+func NewRecipeRefreshJob(importService domain.ImportService, recipeService domain.RecipeService, schedulerRepo domain.SchedulerRepository) *RecipeRefreshJob {
+	return &RecipeRefreshJob{
+		importService:    importService,
+		recipeService:    recipeService,
+		schedulerRepo:    schedulerRepo,
+		maxAge:           utils.GetenvDuration("REFRESH_MAX_AGE", 30*24*time.Hour),
+		batchSize:        max(utils.GetenvInt("REFRESH_BATCH", 50), 1),
+		fetchConcurrency: max(utils.GetenvInt("FETCH_CONCURRENCY", 5), 1),
+		domainLimit:      max(utils.GetenvInt("REFRESH_PER_DOMAIN", 1), 1),
+	}
+}
+
+func (j *RecipeRefreshJob) JobType() string {
+	return "recipe_refresh"
+}
+
+func (j *RecipeRefreshJob) Run(ctx context.Context) (any, error) {
+	now := time.Now()
+	failures, err := j.schedulerRepo.RecentFailures(j.JobType(), now.Add(-refreshBackoffMax))
+	if err != nil {
+		return nil, err
+	}
+
+	recipes, err := j.recipeService.ListStale(now.Add(-j.maxAge), backedOff(failures, now), j.batchSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, nil
+	}
+
+	log.Infow("refreshing stale recipes", "count", len(recipes))
+
+	var (
+		mu      sync.Mutex
+		domains = map[string]chan struct{}{}
+		changed atomic.Int32
+		failed  atomic.Int32
+	)
+	slot := func(host string) chan struct{} {
+		mu.Lock()
+		defer mu.Unlock()
+		if domains[host] == nil {
+			domains[host] = make(chan struct{}, j.domainLimit)
+		}
+		return domains[host]
+	}
+
+	var g errgroup.Group
+	g.SetLimit(j.fetchConcurrency)
+
+	for i := range recipes {
+		recipe := &recipes[i]
+		g.Go(func() error {
+			sem := slot(sourceHost(*recipe.SourceUrl))
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-sem }()
+
+			changes, err := j.refreshOne(ctx, recipe)
+			switch {
+			case err != nil:
+				failed.Add(1)
+			case len(changes) > 0:
+				changed.Add(1)
+			}
+			return nil // a failing source is backed off, it doesn't fail the batch
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	log.Infow("stale recipes refreshed", "count", len(recipes), "changed", changed.Load(), "failed", failed.Load())
+	return map[string]int{"refreshed": len(recipes), "changed": int(changed.Load()), "failed": int(failed.Load())}, nil
+}
+
+func (j *RecipeRefreshJob) refreshOne(ctx context.Context, recipe *domain.Recipe) ([]domain.RecipeChange, error) {
+	logRecord := &domain.SchedulerLog{
+		JobType:   j.JobType(),
+		EntityID:  &recipe.ID,
+		StartedAt: time.Now(),
+		Status:    domain.JobStatusRunning,
+	}
+	if err := j.schedulerRepo.CreateLog(logRecord); err != nil {
+		log.Errorw("failed to create scheduler log, skipping refresh", "recipe", recipe.ID, "error", err.Error())
+		return nil, err
+	}
+
+	changes, refreshErr := j.importService.RefreshRecipe(ctx, recipe)
+
+	logRecord.CompletedAt = new(time.Now())
+	if refreshErr != nil {
+		logRecord.Status = domain.JobStatusError
+		logRecord.ErrorMessage = refreshErr.Error()
+		log.Warnw("recipe refresh failed", "recipe", recipe.ID, "url", *recipe.SourceUrl, "error", refreshErr.Error())
+	} else {
+		logRecord.Status = domain.JobStatusSuccess
+		if len(changes) > 0 {
+			if metadata, err := json.Marshal(map[string]any{"changes": changes}); err == nil {
+				logRecord.Metadata = string(metadata)
+			}
+			log.Infow("recipe refreshed", "recipe", recipe.ID, "url", *recipe.SourceUrl, "changes", len(changes))
+		}
+	}
+
+	if err := j.schedulerRepo.UpdateLog(logRecord); err != nil {
+		log.Warnw("failed to update scheduler log", "recipe", recipe.ID, "error", err.Error())
+	}
+	return changes, refreshErr
+}
+
+// backedOff returns the recipes to skip for now: after n failures in a row a recipe waits base * 2^(n-1) since the last one.
+func backedOff(logs []domain.SchedulerLog, now time.Time) []uuid.UUID {
+	type streak struct {
+		failures int
+		last     time.Time
+		done     bool
+	}
+	streaks := map[uuid.UUID]*streak{}
+	for _, l := range logs { // newest first
+		if l.EntityID == nil {
+			continue
+		}
+		s := streaks[*l.EntityID]
+		if s == nil {
+			s = &streak{}
+			streaks[*l.EntityID] = s
+		}
+		if s.done {
+			continue
+		}
+		switch l.Status {
+		case domain.JobStatusError:
+			if s.failures == 0 {
+				s.last = l.StartedAt
+			}
+			s.failures++
+		case domain.JobStatusSuccess:
+			s.done = true
+		}
+	}
+
+	var exclude []uuid.UUID
+	for id, s := range streaks {
+		if s.failures == 0 {
+			continue
+		}
+		wait := refreshBackoffMax
+		if s.failures <= 6 {
+			wait = min(refreshBackoffBase<<(s.failures-1), refreshBackoffMax)
+		}
+		if s.last.Add(wait).After(now) {
+			exclude = append(exclude, id)
+		}
+	}
+	return exclude
+}
+
+func sourceHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/utils"
+)
+
+type TrashPurgeJob struct {
+	service       domain.TrashService
+	schedulerRepo domain.SchedulerRepository
+	retention     time.Duration
+}
+
+func NewTrashPurgeJob(service domain.TrashService, schedulerRepo domain.SchedulerRepository) *TrashPurgeJob {
+	return &TrashPurgeJob{
+		service:       service,
+		schedulerRepo: schedulerRepo,
+		retention:     utils.GetenvDuration("TRASH_RETENTION", 30*24*time.Hour),
+	}
+}
+
+func (j *TrashPurgeJob) JobType() string {
+	return "trash_purge"
+}
+
+func (j *TrashPurgeJob) Run(_ context.Context) (any, error) {
+	logRecord := &domain.SchedulerLog{
+		JobType:   j.JobType(),
+		StartedAt: time.Now(),
+		Status:    domain.JobStatusRunning,
+	}
+	if err := j.schedulerRepo.CreateLog(logRecord); err != nil {
+		log.Errorw("failed to create scheduler log, skipping trash purge", "error", err.Error())
+		return nil, err
+	}
+
+	purged, purgeErr := j.service.PurgeExpired(j.retention)
+
+	logRecord.CompletedAt = new(time.Now())
+	if metadata, err := json.Marshal(map[string]int{"purged": purged}); err == nil {
+		logRecord.Metadata = string(metadata)
+	}
+	if purgeErr != nil {
+		logRecord.Status = domain.JobStatusError
+		logRecord.ErrorMessage = purgeErr.Error()
+		log.Warnw("trash purge failed", "purged", purged, "error", purgeErr.Error())
+	} else {
+		logRecord.Status = domain.JobStatusSuccess
+		log.Infow("trash purged", "purged", purged, "retention", j.retention.String())
+	}
+
+	if err := j.schedulerRepo.UpdateLog(logRecord); err != nil {
+		log.Warnw("failed to update scheduler log", "error", err.Error())
+	}
+	return purged, purgeErr
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"borscht.app/smetana/internal/configs"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/tokens"
+)
+
+// AdminOnly restricts a route group to the users listed in ADMIN_USER_IDS, it must come after Protected().
+func AdminOnly() fiber.Handler {
+	admins := configs.AdminUserIDs()
+	return func(c fiber.Ctx) error {
+		if !admins[tokens.MustClaims(c).ID] {
+			return sentinels.ErrForbidden
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"errors"
+	"io"
+	"slices"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/valyala/fasthttp"
+)
+
+// BodyLimit rejects requests with a body larger than limit, or uploadLimit on the upload paths given.
+// The app must stream request bodies (StreamRequestBody, DisablePreParseMultipartForm), the body is then only read
+// here and up to the limit: a declared Content-Length is checked up front, a chunked body while it is read.
+func BodyLimit(limit int, uploadLimit int, uploadPaths ...string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if slices.Contains(uploadPaths, c.Path()) {
+			return readBody(c, uploadLimit)
+		}
+		return readBody(c, limit)
+	}
+}
+
+// readBody reads the body of the request if it is at most limit bytes. Multipart forms are parsed right away,
+// their files are buffered on disk past a few KB.
+func readBody(c fiber.Ctx, limit int) error {
+	req := c.Request()
+	if req.Header.ContentLength() > limit {
+		return tooLarge(c)
+	}
+	if !req.IsBodyStream() {
+		if len(req.Body()) > limit {
+			return tooLarge(c)
+		}
+		return c.Next()
+	}
+
+	if len(req.Header.MultipartFormBoundary()) > 0 {
+		if _, err := req.MultipartFormWithLimit(limit); errors.Is(err, fasthttp.ErrBodyTooLarge) {
+			return tooLarge(c)
+		} else if err != nil {
+			return fiber.ErrBadRequest
+		}
+		// the parser stops at the closing boundary, what follows (e.g. the last chunk) is left on the connection
+		if n, err := io.Copy(io.Discard, io.LimitReader(req.BodyStream(), int64(limit)+1)); err != nil || n > int64(limit) {
+			return tooLarge(c)
+		}
+		return c.Next()
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+	if err != nil {
+		return fiber.ErrBadRequest
+	}
+	if len(body) > limit {
+		return tooLarge(c)
+	}
+	req.SetBody(body)
+	return c.Next()
+}
+
+// tooLarge rejects the request and closes the connection, the rest of the body is never read.
+func tooLarge(c fiber.Ctx) error {
+	c.Response().SetConnectionClose()
+	return fiber.ErrRequestEntityTooLarge
+}
@@ -0,0 +1,155 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+	Italic
+)
+
+// fonts are the Go fonts, which cover Latin, Greek and Cyrillic scripts.
+var fonts = [...]*ttfFont{
+	Regular: parseFont("GoRegular", goregular.TTF, 0),
+	Bold:    parseFont("GoBold", gobold.TTF, 0),
+	Italic:  parseFont("GoItalic", goitalic.TTF, 1<<6),
+}
+
+// ttfFont is a TrueType font embedded whole, glyphs are looked up once and kept.
+type ttfFont struct {
+	name       string
+	data       []byte
+	descriptor string // the FontDescriptor entries besides the name and the font file
+
+	mu     sync.Mutex
+	sfnt   *sfnt.Font
+	buf    sfnt.Buffer
+	glyphs map[rune]glyph
+
+	compressOnce sync.Once
+	deflated     []byte
+	deflateErr   error
+}
+
+type glyph struct {
+	index uint16
+	width int // in thousandths of the font size
+}
+
+func parseFont(name string, data []byte, flags int) *ttfFont {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		panic(fmt.Sprintf("pdf: parse font %s: %v", name, err))
+	}
+	t := &ttfFont{name: name, data: data, sfnt: f, glyphs: make(map[rune]glyph)}
+
+	ppem := fixed.I(int(f.UnitsPerEm()))
+	metrics, err := f.Metrics(&t.buf, ppem, font.HintingNone)
+	if err != nil {
+		panic(fmt.Sprintf("pdf: metrics of font %s: %v", name, err))
+	}
+	bounds, err := f.Bounds(&t.buf, ppem, font.HintingNone)
+	if err != nil {
+		panic(fmt.Sprintf("pdf: bounds of font %s: %v", name, err))
+	}
+	var italicAngle float64
+	if post := f.PostTable(); post != nil {
+		italicAngle = post.ItalicAngle
+	}
+	// sfnt measures y downwards, PDF upwards
+	t.descriptor = fmt.Sprintf("/Flags %d /FontBBox [%d %d %d %d] /ItalicAngle %s /Ascent %d /Descent %d /CapHeight %d /StemV 80",
+		1<<5|flags, t.scale(bounds.Min.X), -t.scale(bounds.Max.Y), t.scale(bounds.Max.X), -t.scale(bounds.Min.Y),
+		num(italicAngle), t.scale(metrics.Ascent), -t.scale(metrics.Descent), t.scale(metrics.CapHeight))
+	return t
+}
+
+// scale converts a length in font units to thousandths of the font size.
+func (t *ttfFont) scale(v fixed.Int26_6) int {
+	return int(int64(v) * 1000 / 64 / int64(t.sfnt.UnitsPerEm()))
+}
+
+// glyph returns the glyph of r, index 0 (the missing glyph box) when the font has none.
+func (t *ttfFont) glyph(r rune) glyph {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if g, ok := t.glyphs[r]; ok {
+		return g
+	}
+
+	index, err := t.sfnt.GlyphIndex(&t.buf, r)
+	if err != nil {
+		index = 0
+	}
+	advance, err := t.sfnt.GlyphAdvance(&t.buf, index, fixed.I(int(t.sfnt.UnitsPerEm())), font.HintingNone)
+	if err != nil {
+		advance = 0
+	}
+	g := glyph{index: uint16(index), width: t.scale(advance)}
+	t.glyphs[r] = g
+	return g
+}
+
+// widthArray lists the widths of the used glyphs, for the W entry of the CID font.
+func (t *ttfFont) widthArray(used map[uint16]rune) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for _, index := range sortedGlyphs(used) {
+		fmt.Fprintf(&b, " %d [%d]", index, t.glyph(used[index]).width)
+	}
+	b.WriteString(" ]")
+	return b.String()
+}
+
+// compressed returns the deflated font file, compressed once for all documents.
+func (t *ttfFont) compressed() ([]byte, error) {
+	t.compressOnce.Do(func() {
+		t.deflated, t.deflateErr = deflate(t.data)
+	})
+	return t.deflated, t.deflateErr
+}
+
+// toUnicode maps the used glyphs back to their characters, so that text can be searched and copied.
+func toUnicode(used map[uint16]rune) []byte {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for chunk := range slices.Chunk(sortedGlyphs(used), 100) {
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, index := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", index)
+			for _, c := range utf16.Encode([]rune{used[index]}) {
+				fmt.Fprintf(&b, "%04X", c)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return []byte(b.String())
+}
+
+func sortedGlyphs(used map[uint16]rune) []uint16 {
+	indexes := make([]uint16, 0, len(used))
+	for index := range used {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+	return indexes
+}
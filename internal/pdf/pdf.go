@@ -0,0 +1,335 @@
+// Package pdf writes simple PDF documents: text in the embedded Go fonts, lines and JPEG images.
+// It covers what the printable exports need without depending on a third-party PDF library.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Document is a PDF being built page by page, it is written out at once with WriteTo.
+type Document struct {
+	Title  string
+	pages  []*Page
+	images []*Image
+}
+
+func New(title string) *Document {
+	return &Document{Title: title}
+}
+
+// Page collects the drawing operators of a single page. Coordinates are in points from the top-left corner.
+type Page struct {
+	content     bytes.Buffer
+	used        [len(fonts)]map[uint16]rune // glyphs drawn in each font, with the character each one prints
+	unsupported []rune
+}
+
+// UnsupportedTextError is returned by WriteTo when the document has text the embedded fonts cannot print,
+// such as CJK characters. They would otherwise come out as empty boxes.
+type UnsupportedTextError struct {
+	Chars []rune
+}
+
+func (e *UnsupportedTextError) Error() string {
+	return fmt.Sprintf("text has characters outside of the embedded PDF fonts: %q", string(e.Chars))
+}
+
+// Image is an embedded JPEG that can be drawn on any page of its document.
+type Image struct {
+	Width      int
+	Height     int
+	data       []byte
+	colorSpace string
+	index      int
+}
+
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Page returns the n-th page, counting from 1.
+func (d *Document) Page(n int) *Page {
+	return d.pages[n-1]
+}
+
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// AddImage embeds a JPEG as is, other formats registered with the image package are re-encoded as JPEG.
+func (d *Document) AddImage(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image config: %w", err)
+	}
+
+	if format != "jpeg" || cfg.ColorModel == color.CMYKModel {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decode image: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+		data = buf.Bytes()
+		if cfg, err = jpeg.DecodeConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("decode jpeg config: %w", err)
+		}
+	}
+
+	img := &Image{Width: cfg.Width, Height: cfg.Height, data: data, colorSpace: "DeviceRGB", index: len(d.images)}
+	if cfg.ColorModel == color.GrayModel {
+		img.colorSpace = "DeviceGray"
+	}
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// Text draws s with its baseline at y.
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	if p.used[font] == nil {
+		p.used[font] = make(map[uint16]rune)
+	}
+	var glyphs strings.Builder
+	for _, r := range textRunes(s) {
+		g := fonts[font].glyph(r)
+		if g.index == 0 && !slices.Contains(p.unsupported, r) {
+			p.unsupported = append(p.unsupported, r)
+		}
+		p.used[font][g.index] = r
+		fmt.Fprintf(&glyphs, "%04X", g.index)
+	}
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td <%s> Tj ET\n", font+1, num(size), num(x), num(PageHeight-y), glyphs.String())
+}
+
+// Line draws a straight line of the given width.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// Image draws img scaled into the box whose top-left corner is at x, y.
+func (p *Page) Image(img *Image, x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(PageHeight-y-h), img.index+1)
+}
+
+// WriteTo serializes the document: catalog, page tree, fonts, images, then every page with its content stream.
+// Only the fonts drawn with are embedded, each as a Type 0 font of its TrueType glyphs.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var unsupported []rune
+	var used [len(fonts)]map[uint16]rune
+	for _, page := range d.pages {
+		for _, r := range page.unsupported {
+			if !slices.Contains(unsupported, r) {
+				unsupported = append(unsupported, r)
+			}
+		}
+		for font, glyphs := range page.used {
+			for index, r := range glyphs {
+				if used[font] == nil {
+					used[font] = make(map[uint16]rune)
+				}
+				used[font][index] = r
+			}
+		}
+	}
+	if len(unsupported) > 0 {
+		return 0, &UnsupportedTextError{Chars: unsupported}
+	}
+
+	var usedFonts []Font
+	for font := range fonts {
+		if used[font] != nil {
+			usedFonts = append(usedFonts, Font(font))
+		}
+	}
+
+	const objectsPerFont = 5 // font, CID font, descriptor, font file, ToUnicode map
+	fontsStart := 3
+	imagesStart := fontsStart + objectsPerFont*len(usedFonts)
+	pagesStart := imagesStart + len(d.images)
+	infoObj := pagesStart + 2*len(d.pages)
+
+	var b bytes.Buffer
+	offsets := make([]int, infoObj+1)
+	object := func(n int, body string) {
+		offsets[n] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", n, body)
+	}
+	stream := func(n int, dict string, data []byte) {
+		offsets[n] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n<< %s /Length %d >>\nstream\n", n, dict, len(data))
+		b.Write(data)
+		b.WriteString("\nendstream\nendobj\n")
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pagesStart+2*i)
+	}
+	object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	var resources strings.Builder
+	resources.WriteString("<< /Font <<")
+	for i, font := range usedFonts {
+		n := fontsStart + objectsPerFont*i
+		f := fonts[font]
+		object(n, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+			f.name, n+1, n+4))
+		object(n+1, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W %s /CIDToGIDMap /Identity >>",
+			f.name, n+2, f.widthArray(used[font])))
+		object(n+2, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s %s /FontFile2 %d 0 R >>", f.name, f.descriptor, n+3))
+		compressed, err := f.compressed()
+		if err != nil {
+			return 0, err
+		}
+		stream(n+3, fmt.Sprintf("/Filter /FlateDecode /Length1 %d", len(f.data)), compressed)
+		stream(n+4, "", toUnicode(used[font]))
+		fmt.Fprintf(&resources, " /F%d %d 0 R", font+1, n)
+	}
+	resources.WriteString(" >>")
+	if len(d.images) > 0 {
+		resources.WriteString(" /XObject <<")
+		for i, img := range d.images {
+			stream(imagesStart+i, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /DCTDecode",
+				img.Width, img.Height, img.colorSpace), img.data)
+			fmt.Fprintf(&resources, " /Im%d %d 0 R", i+1, imagesStart+i)
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+
+	for i, page := range d.pages {
+		n := pagesStart + 2*i
+		object(n, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), resources.String(), n+1))
+
+		content, err := deflate(page.content.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		stream(n+1, "/Filter /FlateDecode", content)
+	}
+	object(infoObj, fmt.Sprintf("<< /Title %s >>", textString(d.Title)))
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets))
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets), infoObj, xref)
+
+	return b.WriteTo(w)
+}
+
+// TextWidth returns the width of s in points.
+func TextWidth(font Font, size float64, s string) float64 {
+	total := 0
+	for _, r := range textRunes(s) {
+		total += fonts[font].glyph(r).width
+	}
+	return float64(total) * size / 1000
+}
+
+// Wrap breaks s into lines no wider than width, keeping the line breaks of s.
+// Words longer than width are put on a line of their own.
+func Wrap(font Font, size float64, s string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line == "" {
+				line = word
+			} else if TextWidth(font, size, line+" "+word) <= width {
+				line += " " + word
+			} else {
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// textRunes returns the characters of s to draw, tabs and line breaks become spaces.
+func textRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == '\t' || r == '\r' || r == '\n' {
+			runes[i] = ' '
+		}
+	}
+	return runes
+}
+
+// textString encodes s as a PDF text string, literal when it is ASCII and UTF-16 otherwise.
+func textString(s string) string {
+	ascii := true
+	for _, r := range s {
+		ascii = ascii && r < 128
+	}
+	if !ascii {
+		var b strings.Builder
+		b.WriteString("<FEFF")
+		for _, c := range utf16.Encode([]rune(s)) {
+			fmt.Fprintf(&b, "%04X", c)
+		}
+		return b.String() + ">"
+	}
+
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range []byte(s) {
+		switch c {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\r', '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 32)
+}
@@ -0,0 +1,103 @@
+package pdf_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/internal/pdf"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var pngData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, img))
+
+	doc := pdf.New("Family (favourites)")
+	embedded, err := doc.AddImage(pngData.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 4, embedded.Width)
+	assert.Equal(t, 2, embedded.Height)
+
+	first := doc.AddPage()
+	first.Text(50, 60, pdf.Bold, 20, "Crème brûlée")
+	first.Image(embedded, 50, 80, 200, 100)
+	doc.AddPage().Line(50, 50, 200, 50, 0.5)
+	assert.Equal(t, 2, doc.PageCount())
+
+	var out bytes.Buffer
+	_, err = doc.WriteTo(&out)
+	require.NoError(t, err)
+	data := out.Bytes()
+
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+	assert.Contains(t, out.String(), "/Count 2")
+	assert.Contains(t, out.String(), "/Subtype /Type0 /BaseFont /GoBold /Encoding /Identity-H")
+	assert.NotContains(t, out.String(), "/GoRegular", "unused fonts are not embedded")
+	assert.Contains(t, out.String(), "/Subtype /Image /Width 4 /Height 2 /ColorSpace /DeviceRGB")
+	assert.Contains(t, out.String(), `/Title (Family \(favourites\))`)
+
+	// every cross-reference entry points at the start of its object
+	xref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	require.NotNil(t, xref)
+	start, _ := strconv.Atoi(string(xref[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[start:], -1)
+	require.Len(t, entries, 2+5+1+2*2+1) // catalog and page tree, the bold font, image, two pages with content, info
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(data[offset:], fmt.Appendf(nil, "%d 0 obj", i+1)), "object %d", i+1)
+	}
+}
+
+func TestAddImage_RejectsUnknownFormat(t *testing.T) {
+	_, err := pdf.New("").AddImage([]byte("not an image"))
+	assert.Error(t, err)
+}
+
+func TestWrap(t *testing.T) {
+	lines := pdf.Wrap(pdf.Regular, 10, "Whisk the eggs with sugar\nthen fold in flour", pdf.TextWidth(pdf.Regular, 10, "Whisk the eggs"))
+	assert.Equal(t, []string{"Whisk the eggs", "with sugar", "then fold in", "flour"}, lines)
+}
+
+func TestTextWidth(t *testing.T) {
+	assert.InDelta(t, 6.66, pdf.TextWidth(pdf.Regular, 10, "A")+pdf.TextWidth(pdf.Regular, 10, ""), 0.001)
+	assert.Greater(t, pdf.TextWidth(pdf.Regular, 10, "Борщ"), pdf.TextWidth(pdf.Regular, 10, "Бор"))
+	assert.Greater(t, pdf.TextWidth(pdf.Bold, 10, "flour"), pdf.TextWidth(pdf.Regular, 10, "flour"))
+}
+
+func TestDocument_WriteTo_Cyrillic(t *testing.T) {
+	doc := pdf.New("Борщ")
+	doc.AddPage().Text(50, 60, pdf.Regular, 12, "Борщ • 180°C")
+
+	var out bytes.Buffer
+	_, err := doc.WriteTo(&out)
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "/Title <FEFF0411043E04400449>")
+	assert.Contains(t, out.String(), "<0411>", "the glyphs map back to their characters")
+}
+
+func TestDocument_WriteTo_UnsupportedText(t *testing.T) {
+	doc := pdf.New("Суши")
+	page := doc.AddPage()
+	page.Text(50, 60, pdf.Regular, 12, "Crème brûlée • 180°C")
+	page.Text(50, 80, pdf.Regular, 12, "Суши with 寿司")
+
+	var out bytes.Buffer
+	_, err := doc.WriteTo(&out)
+
+	var unsupported *pdf.UnsupportedTextError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, []rune("寿司"), unsupported.Chars)
+	assert.Zero(t, out.Len())
+}
@@ -122,6 +122,9 @@ func TestAuthorRepository_Search_PreloadTotalRecipes(t *testing.T) {
 	r1, r2 := &domain.Recipe{AuthorID: &a.ID}, &domain.Recipe{AuthorID: &a.ID}
 	seedRecipe(t, db, r1)
 	seedRecipe(t, db, r2)
+	trashed := &domain.Recipe{AuthorID: &a.ID}
+	seedRecipe(t, db, trashed)
+	require.NoError(t, db.Delete(&domain.Recipe{}, trashed.ID).Error)
 
 	opts := types.SearchOptions{
 		Sort:           "id",
@@ -133,7 +136,7 @@ func TestAuthorRepository_Search_PreloadTotalRecipes(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, results, 1)
 	require.NotNil(t, results[0].TotalRecipes, "TotalRecipes must be populated when preloaded")
-	assert.EqualValues(t, 2, *results[0].TotalRecipes)
+	assert.EqualValues(t, 2, *results[0].TotalRecipes, "trashed recipes are not counted")
 }
 
 func TestAuthorRepository_Search_ScopeFeeds_FiltersToSubscribedFeed(t *testing.T) {
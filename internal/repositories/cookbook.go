@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+)
+
+type cookbookRepository struct {
+	db *gorm.DB
+}
+
+func NewCookbookRepository(db *gorm.DB) domain.CookbookRepository {
+	return &cookbookRepository{db: db}
+}
+
+func (r *cookbookRepository) ByID(id uuid.UUID) (*domain.Cookbook, error) {
+	var cookbook domain.Cookbook
+	if err := r.db.First(&cookbook, id).Error; err != nil {
+		return nil, fmt.Errorf("cookbook by id %s: %w", id, mapErr(err))
+	}
+	return &cookbook, nil
+}
+
+func (r *cookbookRepository) ListRunnable(staleBefore time.Time) ([]domain.Cookbook, error) {
+	var cookbooks []domain.Cookbook
+	err := r.db.Where("status = ?", domain.JobStatusPending).
+		Or("status = ? AND updated < ?", domain.JobStatusRunning, staleBefore).
+		Order("id").Find(&cookbooks).Error
+	if err != nil {
+		return nil, fmt.Errorf("list runnable cookbooks: %w", mapErr(err))
+	}
+	return cookbooks, nil
+}
+
+func (r *cookbookRepository) ListByCollection(collectionID uuid.UUID, format domain.CookbookFormat) ([]domain.Cookbook, error) {
+	var cookbooks []domain.Cookbook
+	if err := r.db.Where("collection_id = ? AND format = ?", collectionID, format).Order("id").Find(&cookbooks).Error; err != nil {
+		return nil, fmt.Errorf("list cookbooks of collection %s: %w", collectionID, mapErr(err))
+	}
+	return cookbooks, nil
+}
+
+func (r *cookbookRepository) Create(cookbook *domain.Cookbook) error {
+	if err := r.db.Create(cookbook).Error; err != nil {
+		return fmt.Errorf("create cookbook: %w", mapErr(err))
+	}
+	return nil
+}
+
+func (r *cookbookRepository) Update(cookbook *domain.Cookbook) error {
+	if err := r.db.Model(cookbook).Select("status", "file_path", "error_message").Updates(cookbook).Error; err != nil {
+		return fmt.Errorf("update cookbook %s: %w", cookbook.ID, mapErr(err))
+	}
+	return nil
+}
+
+func (r *cookbookRepository) Delete(id uuid.UUID) error {
+	if err := r.db.Delete(&domain.Cookbook{}, id).Error; err != nil {
+		return fmt.Errorf("delete cookbook %s: %w", id, mapErr(err))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/repositories"
+)
+
+func seedCookbook(t *testing.T, db *gorm.DB, hid uuid.UUID, status string, updated time.Time) *domain.Cookbook {
+	t.Helper()
+	cookbook := &domain.Cookbook{HouseholdID: hid, UserID: uuid.New(), CollectionID: uuid.New(), Format: domain.CookbookFormatPDF, Status: status}
+	require.NoError(t, db.Create(cookbook).Error)
+	require.NoError(t, db.Model(cookbook).UpdateColumn("updated", updated).Error)
+	return cookbook
+}
+
+func TestCookbookRepository_ListRunnable_PendingAndStaleRunning(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewCookbookRepository(db)
+	hid := seedHousehold(t, db)
+	now := time.Now()
+
+	pending := seedCookbook(t, db, hid, domain.JobStatusPending, now)
+	interrupted := seedCookbook(t, db, hid, domain.JobStatusRunning, now.Add(-time.Hour))
+	seedCookbook(t, db, hid, domain.JobStatusRunning, now)
+	seedCookbook(t, db, hid, domain.JobStatusSuccess, now.Add(-time.Hour))
+	seedCookbook(t, db, hid, domain.JobStatusError, now.Add(-time.Hour))
+
+	cookbooks, err := repo.ListRunnable(now.Add(-30 * time.Minute))
+	require.NoError(t, err)
+
+	ids := make([]uuid.UUID, 0, len(cookbooks))
+	for _, cookbook := range cookbooks {
+		ids = append(ids, cookbook.ID)
+	}
+	assert.Equal(t, []uuid.UUID{pending.ID, interrupted.ID}, ids)
+}
+
+func TestCookbookRepository_ListByCollection_SameFormatOldestFirst(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewCookbookRepository(db)
+	hid := seedHousehold(t, db)
+	now := time.Now()
+
+	first := seedCookbook(t, db, hid, domain.JobStatusSuccess, now)
+	second := &domain.Cookbook{HouseholdID: hid, CollectionID: first.CollectionID, Format: domain.CookbookFormatPDF, Status: domain.JobStatusPending}
+	require.NoError(t, repo.Create(second))
+	html := &domain.Cookbook{HouseholdID: hid, CollectionID: first.CollectionID, Format: domain.CookbookFormatHTML, Status: domain.JobStatusPending}
+	require.NoError(t, repo.Create(html))
+	seedCookbook(t, db, hid, domain.JobStatusSuccess, now)
+
+	cookbooks, err := repo.ListByCollection(first.CollectionID, domain.CookbookFormatPDF)
+	require.NoError(t, err)
+	require.Len(t, cookbooks, 2)
+	assert.Equal(t, first.ID, cookbooks[0].ID)
+	assert.Equal(t, second.ID, cookbooks[1].ID)
+
+	require.NoError(t, repo.Delete(first.ID))
+	_, err = repo.ByID(first.ID)
+	assert.Error(t, err)
+}
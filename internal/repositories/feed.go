@@ -3,6 +3,7 @@ package repositories
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -16,13 +17,6 @@ type feedRepository struct {
 	db *gorm.DB
 }
 
-// junction table for feeds and households
-type feedSubscription struct{}
-
-func (feedSubscription) TableName() string {
-	return "feed_subscriptions"
-}
-
 func NewFeedRepository(db *gorm.DB) domain.FeedRepository {
 	return &feedRepository{db: db}
 }
@@ -30,7 +24,7 @@ func NewFeedRepository(db *gorm.DB) domain.FeedRepository {
 func (r *feedRepository) ByIDForHousehold(id uuid.UUID, householdID uuid.UUID) (*domain.Feed, error) {
 	var feed domain.Feed
 	if err := r.db.
-		Select("feeds.*").
+		Select("feeds.*, feed_subscriptions.filter").
 		Scopes(FeedSubscribedByHousehold(householdID)).
 		First(&feed, id).Error; err != nil {
 		return nil, fmt.Errorf("feed by id %s for household %s: %w", id, householdID, mapErr(err))
@@ -46,15 +40,20 @@ func (r *feedRepository) ByUrl(url string) (*domain.Feed, error) {
 	return &feed, nil
 }
 
-func (r *feedRepository) ListActive() ([]domain.Feed, error) {
+func (r *feedRepository) ListDue(now time.Time) ([]domain.Feed, error) {
 	var feeds []domain.Feed
-	if err := r.db.Select("feeds.*").Scopes(ActiveFeed).Find(&feeds).Error; err != nil {
-		return nil, fmt.Errorf("list active feeds: %w", mapErr(err))
+	if err := r.db.Select("feeds.*").
+		// feeds added before fetches were scheduled per feed have no next_fetch_at until their first fetch
+		Where("next_fetch_at IS NULL OR next_fetch_at <= ?", now).
+		Where("EXISTS (SELECT 1 FROM feed_subscriptions WHERE feed_subscriptions.feed_id = feeds.id)").
+		Order("next_fetch_at IS NOT NULL, next_fetch_at").
+		Find(&feeds).Error; err != nil {
+		return nil, fmt.Errorf("list due feeds: %w", mapErr(err))
 	}
 	return feeds, nil
 }
 
-func (r *feedRepository) Search(householdID uuid.UUID, opts types.SearchOptions) ([]domain.Feed, int64, error) {
+func (r *feedRepository) Search(userID uuid.UUID, householdID uuid.UUID, opts types.SearchOptions) ([]domain.Feed, int64, error) {
 	var feeds []domain.Feed
 
 	q := r.db.Model(&domain.Feed{}).
@@ -71,19 +70,38 @@ func (r *feedRepository) Search(householdID uuid.UUID, opts types.SearchOptions)
 		return nil, 0, nil
 	}
 
-	q = q.Select("feeds.*")
+	q = q.Select("feeds.*, feed_subscriptions.filter")
 
 	if len(opts.Preload) != 0 {
 		if opts.Has("publisher") {
 			q = q.Preload("Publisher")
 		}
 
+		columns, args := []string{"feeds.*", "feed_subscriptions.filter"}, []any{}
 		if opts.Has("total_recipes") {
-			q = q.Select(`feeds.*, (
+			columns = append(columns, `(
 					SELECT COUNT(*) FROM recipes
-					WHERE recipes.feed_id = feeds.id
+					WHERE recipes.feed_id = feeds.id AND recipes.deleted IS NULL
 				) AS total_recipes`)
 		}
+		if opts.Has("unread_recipes") {
+			columns = append(columns, `(
+					SELECT COUNT(*) FROM recipes
+					WHERE recipes.feed_id = feeds.id AND recipes.household_id IS NULL AND recipes.deleted IS NULL
+					AND NOT EXISTS (SELECT 1 FROM stream_states WHERE stream_states.recipe_id = recipes.id
+						AND stream_states.user_id = ? AND (stream_states.seen = ? OR stream_states.hidden = ?))`)
+			args = append(args, userID, true, true)
+			filterWhere, filterArgs, err := feedFilterWhereArgs(r.db, householdID)
+			if err != nil {
+				return nil, 0, err
+			}
+			if filterWhere != "" {
+				columns[len(columns)-1] += " AND " + filterWhere
+				args = append(args, filterArgs...)
+			}
+			columns[len(columns)-1] += ") AS unread_recipes"
+		}
+		q = q.Select(strings.Join(columns, ", "), args...)
 	}
 
 	q = q.Offset(opts.Offset).Limit(opts.Limit)
@@ -130,7 +148,7 @@ func (r *feedRepository) DeleteFeed(householdID uuid.UUID, feedID uuid.UUID) err
 	}
 
 	var count int64
-	if err := r.db.Model(&feedSubscription{}).Where("feed_id = ?", feedID).Count(&count).Error; err != nil {
+	if err := r.db.Model(&domain.FeedSubscription{}).Where("feed_id = ?", feedID).Count(&count).Error; err != nil {
 		return fmt.Errorf("count subscriptions for feed %s: %w", feedID, mapErr(err))
 	}
 	if count == 0 {
@@ -141,6 +159,16 @@ func (r *feedRepository) DeleteFeed(householdID uuid.UUID, feedID uuid.UUID) err
 	return nil
 }
 
+func (r *feedRepository) SetFilter(householdID uuid.UUID, feedID uuid.UUID, filter *domain.FeedFilter) error {
+	if err := r.db.Model(&domain.FeedSubscription{}).
+		Where("household_id = ? AND feed_id = ?", householdID, feedID).
+		Select("filter").
+		Updates(&domain.FeedSubscription{Filter: filter}).Error; err != nil {
+		return fmt.Errorf("set filter of feed %s for household %s: %w", feedID, householdID, mapErr(err))
+	}
+	return nil
+}
+
 func (r *feedRepository) Create(feed *domain.Feed) error {
 	if err := r.db.Create(feed).Error; err != nil {
 		return fmt.Errorf("create feed: %w", mapErr(err))
@@ -158,6 +186,11 @@ func (r *feedRepository) Update(feed *domain.Feed) error {
 		"error_count",
 		"last_sync_at",
 		"last_sync_success",
+		"next_fetch_at",
+		"fetch_interval",
+		"etag",
+		"last_modified",
+		"content_hash",
 		"discovered",
 	).Updates(feed).Error; err != nil {
 		return fmt.Errorf("update feed %s: %w", feed.ID, mapErr(err))
@@ -171,3 +204,45 @@ func (r *feedRepository) Delete(id uuid.UUID) error {
 	}
 	return nil
 }
+
+func (r *feedRepository) SetStreamFlag(userID uuid.UUID, householdID uuid.UUID, recipeID uuid.UUID, flag domain.StreamFlag, value bool) error {
+	if err := r.db.Model(&domain.StreamState{}).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "recipe_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{string(flag), "updated"}),
+	}).Create(map[string]any{
+		"user_id": userID, "recipe_id": recipeID, "household_id": householdID, string(flag): value, "updated": time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("set stream flag %s of recipe %s for user %s: %w", flag, recipeID, userID, mapErr(err))
+	}
+	return nil
+}
+
+func (r *feedRepository) MarkFeedSeen(userID uuid.UUID, householdID uuid.UUID, feedID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		feedRecipes := tx.Model(&domain.Recipe{}).Select("id").Where("feed_id = ? AND household_id IS NULL", feedID)
+
+		if err := tx.Model(&domain.StreamState{}).
+			Where("user_id = ? AND recipe_id IN (?)", userID, feedRecipes).
+			Updates(map[string]any{"seen": true, "updated": time.Now()}).Error; err != nil {
+			return fmt.Errorf("mark feed %s seen for user %s (update): %w", feedID, userID, mapErr(err))
+		}
+
+		var unseen []uuid.UUID
+		if err := feedRecipes.Session(&gorm.Session{}).
+			Where("NOT EXISTS (SELECT 1 FROM stream_states WHERE stream_states.recipe_id = recipes.id AND stream_states.user_id = ?)", userID).
+			Pluck("id", &unseen).Error; err != nil {
+			return fmt.Errorf("mark feed %s seen for user %s (list): %w", feedID, userID, mapErr(err))
+		}
+		if len(unseen) == 0 {
+			return nil
+		}
+		states := make([]domain.StreamState, len(unseen))
+		for i, id := range unseen {
+			states[i] = domain.StreamState{UserID: userID, RecipeID: id, HouseholdID: householdID, Seen: true}
+		}
+		if err := tx.Omit(clause.Associations).CreateInBatches(states, 100).Error; err != nil {
+			return fmt.Errorf("mark feed %s seen for user %s (create): %w", feedID, userID, mapErr(err))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+)
+
+// feedFilterWhereArgs returns a SQL fragment and bind args that leave out the feed recipes the household filtered out
+// of its subscriptions, or an empty fragment when it has no filters. Like scopeWhereArgs, the outer table is `recipes`.
+func feedFilterWhereArgs(db *gorm.DB, householdID uuid.UUID) (string, []any, error) {
+	var subscriptions []domain.FeedSubscription
+	if err := db.Where("household_id = ? AND filter IS NOT NULL", householdID).Find(&subscriptions).Error; err != nil {
+		return "", nil, fmt.Errorf("feed filters of household %s: %w", householdID, mapErr(err))
+	}
+
+	var clauses []string
+	var args []any
+	for _, subscription := range subscriptions {
+		where, whereArgs := feedFilterWhere(subscription.Filter)
+		if where == "" {
+			continue
+		}
+		clauses = append(clauses, "(recipes.feed_id IS NULL OR recipes.feed_id <> ? OR ("+where+"))")
+		args = append(args, subscription.FeedID)
+		args = append(args, whereArgs...)
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// feedFilterWhere returns the conditions a recipe must meet to pass the filter, joined with AND.
+func feedFilterWhere(filter *domain.FeedFilter) (string, []any) {
+	if filter.IsEmpty() {
+		return "", nil
+	}
+
+	var conds []string
+	var args []any
+	keywords := func(words []string) string {
+		likes := make([]string, len(words))
+		for i, word := range words {
+			likes[i] = "LOWER(COALESCE(recipes.name, '')) LIKE ?"
+			args = append(args, "%"+strings.ToLower(word)+"%")
+		}
+		return "(" + strings.Join(likes, " OR ") + ")"
+	}
+	const hasTaxonomy = "EXISTS (SELECT 1 FROM recipe_taxonomies WHERE recipe_taxonomies.recipe_id = recipes.id AND recipe_taxonomies.taxonomy_id IN ?)"
+
+	if len(filter.IncludeKeywords) != 0 {
+		conds = append(conds, keywords(filter.IncludeKeywords))
+	}
+	if len(filter.ExcludeKeywords) != 0 {
+		conds = append(conds, "NOT "+keywords(filter.ExcludeKeywords))
+	}
+	if len(filter.IncludeTaxonomies) != 0 {
+		conds = append(conds, hasTaxonomy)
+		args = append(args, filter.IncludeTaxonomies)
+	}
+	if len(filter.ExcludeTaxonomies) != 0 {
+		conds = append(conds, "NOT "+hasTaxonomy)
+		args = append(args, filter.ExcludeTaxonomies)
+	}
+	if filter.MinIngredients > 0 {
+		conds = append(conds, "(SELECT COUNT(*) FROM recipe_ingredients WHERE recipe_ingredients.recipe_id = recipes.id) >= ?")
+		args = append(args, filter.MinIngredients)
+	}
+	if filter.MaxTotalTime != nil {
+		conds = append(conds, "(recipes.total_time IS NULL OR recipes.total_time <= ?)")
+		args = append(args, int64(*filter.MaxTotalTime))
+	}
+	if len(filter.Languages) != 0 {
+		conds = append(conds, "(recipes.language IS NULL OR recipes.language IN ?)")
+		args = append(args, filter.Languages)
+	}
+	return strings.Join(conds, " AND "), args
+}
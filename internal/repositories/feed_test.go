@@ -2,6 +2,7 @@ package repositories_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -16,7 +17,7 @@ import (
 
 func seedFeedSubscription(t *testing.T, db *gorm.DB, householdID, feedID uuid.UUID) {
 	t.Helper()
-	require.NoError(t, db.Model(&feedSubscription{}).Create(map[string]any{
+	require.NoError(t, db.Model(&domain.FeedSubscription{}).Create(map[string]any{
 		"household_id": householdID, "feed_id": feedID,
 	}).Error)
 }
@@ -69,23 +70,51 @@ func TestFeedRepository_ByUrl_NotFound_ReturnsNotFound(t *testing.T) {
 	require.ErrorIs(t, err, sentinels.ErrNotFound)
 }
 
-func TestFeedRepository_ListActive_ReturnsOnlyActiveFeeds(t *testing.T) {
+func TestFeedRepository_ListDue_ReturnsSubscribedFeedsPastTheirFetchTime(t *testing.T) {
 	db := openPrivateTestDB(t)
 	repo := repositories.NewFeedRepository(db)
+	hid := seedHousehold(t, db)
+	now := time.Now()
 
-	active := seedFeed(t, db)
-	inactive := seedFeed(t, db)
-	require.NoError(t, db.Model(inactive).Update("active", false).Error)
+	overdue, due, notYet, unsubscribed, deactivated := seedFeed(t, db), seedFeed(t, db), seedFeed(t, db), seedFeed(t, db), seedFeed(t, db)
+	for _, f := range []*domain.Feed{overdue, due, notYet, deactivated} {
+		seedFeedSubscription(t, db, hid, f.ID)
+	}
+	require.NoError(t, db.Model(overdue).Update("next_fetch_at", now.Add(-time.Hour)).Error)
+	require.NoError(t, db.Model(due).Update("next_fetch_at", now.Add(-time.Minute)).Error)
+	require.NoError(t, db.Model(notYet).Update("next_fetch_at", now.Add(time.Hour)).Error)
+	require.NoError(t, db.Model(unsubscribed).Updates(map[string]any{"active": false, "next_fetch_at": now.Add(-time.Hour)}).Error)
+	require.NoError(t, db.Model(deactivated).Updates(map[string]any{"active": false, "next_fetch_at": now.Add(-2 * time.Hour)}).Error)
 
-	feeds, err := repo.ListActive()
+	feeds, err := repo.ListDue(now)
 
 	require.NoError(t, err)
 	ids := make([]uuid.UUID, len(feeds))
 	for i, f := range feeds {
 		ids[i] = f.ID
 	}
-	assert.Contains(t, ids, active.ID)
-	assert.NotContains(t, ids, inactive.ID)
+	assert.Equal(t, []uuid.UUID{deactivated.ID, overdue.ID, due.ID}, ids, "failing feeds are still probed, soonest first")
+}
+
+func TestFeedRepository_ListDue_IncludesNeverScheduledFeeds(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewFeedRepository(db)
+	hid := seedHousehold(t, db)
+	now := time.Now()
+
+	unscheduled, due := seedFeed(t, db), seedFeed(t, db)
+	seedFeedSubscription(t, db, hid, unscheduled.ID)
+	seedFeedSubscription(t, db, hid, due.ID)
+	require.NoError(t, db.Model(unscheduled).Update("next_fetch_at", gorm.Expr("NULL")).Error)
+	require.NoError(t, db.Model(due).Update("next_fetch_at", now.Add(-time.Hour)).Error)
+
+	feeds, err := repo.ListDue(now)
+
+	require.NoError(t, err)
+	require.Len(t, feeds, 2)
+	assert.Equal(t, unscheduled.ID, feeds[0].ID, "a feed stored before per-feed schedules is fetched first")
+	assert.True(t, feeds[0].NextFetchAt.IsZero())
+	assert.Equal(t, due.ID, feeds[1].ID)
 }
 
 func TestFeedRepository_Search_ReturnsOnlySubscribedFeeds(t *testing.T) {
@@ -97,7 +126,7 @@ func TestFeedRepository_Search_ReturnsOnlySubscribedFeeds(t *testing.T) {
 	_ = seedFeed(t, db) // not subscribed
 	seedFeedSubscription(t, db, hid, subscribed.ID)
 
-	results, total, err := repo.Search(hid, defaultSearchOpts())
+	results, total, err := repo.Search(uuid.New(), hid, defaultSearchOpts())
 
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, total)
@@ -120,7 +149,7 @@ func TestFeedRepository_Search_QueryFilters(t *testing.T) {
 	seedFeedSubscription(t, db, hid, f2.ID)
 
 	opts := types.SearchOptions{SearchQuery: "baking", Sort: "id", Pagination: types.Pagination{Limit: 10}}
-	results, total, err := repo.Search(hid, opts)
+	results, total, err := repo.Search(uuid.New(), hid, opts)
 
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, total)
@@ -138,18 +167,21 @@ func TestFeedRepository_Search_PreloadTotalRecipes(t *testing.T) {
 	r1, r2 := &domain.Recipe{FeedID: &feed.ID}, &domain.Recipe{FeedID: &feed.ID}
 	seedRecipe(t, db, r1)
 	seedRecipe(t, db, r2)
+	trashed := &domain.Recipe{FeedID: &feed.ID}
+	seedRecipe(t, db, trashed)
+	require.NoError(t, db.Delete(&domain.Recipe{}, trashed.ID).Error)
 
 	opts := types.SearchOptions{
 		Sort:           "id",
 		Pagination:     types.Pagination{Limit: 10},
 		PreloadOptions: types.Preload("total_recipes"),
 	}
-	results, _, err := repo.Search(hid, opts)
+	results, _, err := repo.Search(uuid.New(), hid, opts)
 
 	require.NoError(t, err)
 	require.Len(t, results, 1)
 	require.NotNil(t, results[0].TotalRecipes, "TotalRecipes must be populated when preloaded")
-	assert.EqualValues(t, 2, *results[0].TotalRecipes)
+	assert.EqualValues(t, 2, *results[0].TotalRecipes, "trashed recipes are not counted")
 }
 
 func TestFeedRepository_AddFeed_CreatesSubscription(t *testing.T) {
@@ -162,7 +194,7 @@ func TestFeedRepository_AddFeed_CreatesSubscription(t *testing.T) {
 	require.NoError(t, repo.AddFeed(hid, feed))
 
 	var count int64
-	db.Model(&feedSubscription{}).Where("household_id = ? AND feed_id = ?", hid, feed.ID).Count(&count)
+	db.Model(&domain.FeedSubscription{}).Where("household_id = ? AND feed_id = ?", hid, feed.ID).Count(&count)
 	assert.EqualValues(t, 1, count)
 }
 
@@ -193,7 +225,7 @@ func TestFeedRepository_DeleteFeed_RemovesSubscription(t *testing.T) {
 	require.NoError(t, repo.DeleteFeed(hid, feed.ID))
 
 	var count int64
-	db.Model(&feedSubscription{}).Where("household_id = ? AND feed_id = ?", hid, feed.ID).Count(&count)
+	db.Model(&domain.FeedSubscription{}).Where("household_id = ? AND feed_id = ?", hid, feed.ID).Count(&count)
 	assert.EqualValues(t, 0, count)
 }
 
@@ -228,3 +260,188 @@ func TestFeedRepository_DeleteFeed_KeepsFeedActiveWithOtherSubscribers(t *testin
 	require.NoError(t, db.First(&got, feed.ID).Error)
 	assert.True(t, got.Active, "feed must stay active while other households are still subscribed")
 }
+
+func TestFeedRepository_SetStreamFlag_HidesRecipeFromUserUntilCleared(t *testing.T) {
+	db := openPrivateTestDB(t)
+	feedRepo := repositories.NewFeedRepository(db)
+	recipeRepo := repositories.NewRecipeRepository(db)
+
+	hid := seedHousehold(t, db)
+	user, member := seedUser(t, db, hid), seedUser(t, db, hid)
+	feed := seedFeed(t, db)
+	seedFeedSubscription(t, db, hid, feed.ID)
+	dismissed := &domain.Recipe{FeedID: &feed.ID}
+	seedRecipe(t, db, dismissed)
+	kept := &domain.Recipe{FeedID: &feed.ID}
+	seedRecipe(t, db, kept)
+
+	opts := domain.RecipeSearchOptions{SearchOptions: defaultSearchOpts(), HideStream: []domain.StreamFlag{domain.StreamFlagDismissed}}
+	opts.Scope = "feeds"
+
+	require.NoError(t, feedRepo.SetStreamFlag(user.ID, hid, dismissed.ID, domain.StreamFlagDismissed, true))
+	got, total, err := recipeRepo.Search(user.ID, hid, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, got, 1)
+	assert.Equal(t, kept.ID, got[0].ID)
+
+	_, total, err = recipeRepo.Search(member.ID, hid, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total, "other members of the household still see the recipe")
+
+	// flags are independent, setting one keeps the others of the same row
+	require.NoError(t, feedRepo.SetStreamFlag(user.ID, hid, dismissed.ID, domain.StreamFlagSeen, true))
+	require.NoError(t, feedRepo.SetStreamFlag(user.ID, hid, dismissed.ID, domain.StreamFlagDismissed, false))
+	_, total, err = recipeRepo.Search(user.ID, hid, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+
+	var states []domain.StreamState
+	require.NoError(t, db.Find(&states).Error)
+	require.Len(t, states, 1)
+	assert.True(t, states[0].Seen)
+	assert.False(t, states[0].Dismissed)
+}
+
+func TestFeedRepository_MarkFeedSeen_ClearsUnreadCount(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewFeedRepository(db)
+
+	hid := seedHousehold(t, db)
+	user := seedUser(t, db, hid)
+	feed, other := seedFeed(t, db), seedFeed(t, db)
+	seedFeedSubscription(t, db, hid, feed.ID)
+	seedFeedSubscription(t, db, hid, other.ID)
+	read, unread, hidden := &domain.Recipe{FeedID: &feed.ID}, &domain.Recipe{FeedID: &feed.ID}, &domain.Recipe{FeedID: &feed.ID}
+	seedRecipe(t, db, read)
+	seedRecipe(t, db, unread)
+	seedRecipe(t, db, hidden)
+	seedRecipe(t, db, &domain.Recipe{FeedID: &other.ID})
+	require.NoError(t, repo.SetStreamFlag(user.ID, hid, read.ID, domain.StreamFlagSeen, true))
+	require.NoError(t, repo.SetStreamFlag(user.ID, hid, hidden.ID, domain.StreamFlagHidden, true))
+
+	opts := types.SearchOptions{Sort: "id", Pagination: types.Pagination{Limit: 10}, PreloadOptions: types.Preload("total_recipes", "unread_recipes")}
+	unreadCounts := func() map[uuid.UUID]int64 {
+		t.Helper()
+		feeds, _, err := repo.Search(user.ID, hid, opts)
+		require.NoError(t, err)
+		counts := map[uuid.UUID]int64{}
+		for _, f := range feeds {
+			require.NotNil(t, f.UnreadRecipes, "UnreadRecipes must be populated when preloaded")
+			require.NotNil(t, f.TotalRecipes, "TotalRecipes must be populated alongside")
+			counts[f.ID] = *f.UnreadRecipes
+		}
+		return counts
+	}
+	assert.Equal(t, map[uuid.UUID]int64{feed.ID: 1, other.ID: 1}, unreadCounts())
+
+	require.NoError(t, repo.MarkFeedSeen(user.ID, hid, feed.ID))
+	assert.Equal(t, map[uuid.UUID]int64{feed.ID: 0, other.ID: 1}, unreadCounts())
+
+	var hiddenState domain.StreamState
+	require.NoError(t, db.First(&hiddenState, "recipe_id = ?", hidden.ID).Error)
+	assert.True(t, hiddenState.Hidden, "marking a feed read keeps the other flags")
+}
+
+func TestFeedRepository_SetFilter_FiltersStreamAndUnreadCountPerHousehold(t *testing.T) {
+	db := openPrivateTestDB(t)
+	feedRepo := repositories.NewFeedRepository(db)
+	recipeRepo := repositories.NewRecipeRepository(db)
+
+	hid, neighbour := seedHousehold(t, db), seedHousehold(t, db)
+	user := seedUser(t, db, hid)
+	feed := seedFeed(t, db)
+	seedFeedSubscription(t, db, hid, feed.ID)
+	seedFeedSubscription(t, db, neighbour, feed.ID)
+	dessert := seedTaxonomy(t, db, "Dessert", "course")
+
+	kept := &domain.Recipe{FeedID: &feed.ID, Name: new("Beet Borscht"), Language: new("en"), TotalTime: new(types.Duration(time.Hour))}
+	unknown := &domain.Recipe{FeedID: &feed.ID, Name: new("Cabbage Soup")}
+	cocktail := &domain.Recipe{FeedID: &feed.ID, Name: new("Negroni COCKTAIL")}
+	cake := &domain.Recipe{FeedID: &feed.ID, Name: new("Honey Cake")}
+	stew := &domain.Recipe{FeedID: &feed.ID, Name: new("Slow Stew"), TotalTime: new(types.Duration(3 * time.Hour))}
+	quiche := &domain.Recipe{FeedID: &feed.ID, Name: new("Quiche"), Language: new("fr")}
+	for _, r := range []*domain.Recipe{kept, unknown, cocktail, cake, stew, quiche} {
+		seedRecipe(t, db, r)
+	}
+	linkRecipeTaxonomy(t, db, cake.ID, dessert.ID)
+
+	require.NoError(t, feedRepo.SetFilter(hid, feed.ID, &domain.FeedFilter{
+		ExcludeKeywords:   []string{"cocktail"},
+		ExcludeTaxonomies: []uuid.UUID{dessert.ID},
+		MaxTotalTime:      new(types.Duration(2 * time.Hour)),
+		Languages:         []string{"en"},
+	}))
+
+	opts := domain.RecipeSearchOptions{SearchOptions: defaultSearchOpts(), FeedFilters: true}
+	opts.Scope = "feeds"
+	got, total, err := recipeRepo.Search(user.ID, hid, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.ElementsMatch(t, []uuid.UUID{kept.ID, unknown.ID}, []uuid.UUID{got[0].ID, got[1].ID})
+
+	_, total, err = recipeRepo.Search(uuid.New(), neighbour, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, total, "the filter of one household does not apply to another")
+
+	feeds, _, err := feedRepo.Search(user.ID, hid, types.SearchOptions{Sort: "id", Pagination: types.Pagination{Limit: 10}, PreloadOptions: types.Preload("unread_recipes")})
+	require.NoError(t, err)
+	require.Len(t, feeds, 1)
+	require.NotNil(t, feeds[0].UnreadRecipes)
+	assert.EqualValues(t, 2, *feeds[0].UnreadRecipes, "filtered out recipes are not unread")
+	require.NotNil(t, feeds[0].Filter)
+	assert.Equal(t, []string{"cocktail"}, feeds[0].Filter.ExcludeKeywords)
+
+	require.NoError(t, feedRepo.SetFilter(hid, feed.ID, nil))
+	_, total, err = recipeRepo.Search(user.ID, hid, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, total)
+	byID, err := feedRepo.ByIDForHousehold(feed.ID, hid)
+	require.NoError(t, err)
+	assert.Nil(t, byID.Filter)
+}
+
+func TestFeedRepository_SetFilter_IncludeRulesAndMinIngredients(t *testing.T) {
+	db := openPrivateTestDB(t)
+	feedRepo := repositories.NewFeedRepository(db)
+	recipeRepo := repositories.NewRecipeRepository(db)
+
+	hid := seedHousehold(t, db)
+	feed, other := seedFeed(t, db), seedFeed(t, db)
+	seedFeedSubscription(t, db, hid, feed.ID)
+	seedFeedSubscription(t, db, hid, other.ID)
+	soup := seedTaxonomy(t, db, "Soup", "course")
+
+	rich := &domain.Recipe{FeedID: &feed.ID, Name: new("Beet Borscht")}
+	plain := &domain.Recipe{FeedID: &feed.ID, Name: new("Borscht Shot")}
+	tagged := &domain.Recipe{FeedID: &feed.ID, Name: new("Solyanka")}
+	review := &domain.Recipe{FeedID: &feed.ID, Name: new("Blender review")}
+	unfiltered := &domain.Recipe{FeedID: &other.ID, Name: new("Blender review")}
+	for _, r := range []*domain.Recipe{rich, plain, tagged, review, unfiltered} {
+		seedRecipe(t, db, r)
+	}
+	linkRecipeTaxonomy(t, db, tagged.ID, soup.ID)
+	for _, r := range []*domain.Recipe{rich, rich, tagged, tagged, review, review} {
+		require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: r.ID, RawText: "1 beet"}).Error)
+	}
+
+	// every rule that is set applies, the name alone is not enough
+	require.NoError(t, feedRepo.SetFilter(hid, feed.ID, &domain.FeedFilter{IncludeKeywords: []string{"borscht"}, MinIngredients: 2}))
+	opts := domain.RecipeSearchOptions{SearchOptions: defaultSearchOpts(), FeedFilters: true}
+	opts.Scope = "feeds"
+	got, total, err := recipeRepo.Search(uuid.New(), hid, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.ElementsMatch(t, []uuid.UUID{rich.ID, unfiltered.ID}, []uuid.UUID{got[0].ID, got[1].ID})
+
+	require.NoError(t, feedRepo.SetFilter(hid, feed.ID, &domain.FeedFilter{IncludeTaxonomies: []uuid.UUID{soup.ID}}))
+	got, total, err = recipeRepo.Search(uuid.New(), hid, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.ElementsMatch(t, []uuid.UUID{tagged.ID, unfiltered.ID}, []uuid.UUID{got[0].ID, got[1].ID})
+
+	opts.FeedFilters = false
+	_, total, err = recipeRepo.Search(uuid.New(), hid, opts)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, total, "filters apply only to searches that ask for them")
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
 
 	"borscht.app/smetana/internal/types"
@@ -9,6 +10,7 @@ import (
 	"gorm.io/gorm/clause"
 
 	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
 	"borscht.app/smetana/internal/utils"
 )
 
@@ -46,16 +48,8 @@ func (r *foodRepository) ByIDs(ids []uuid.UUID) (map[uuid.UUID]*domain.Food, err
 }
 
 func (r *foodRepository) FindOrCreate(food *domain.Food) error {
-	if food.Slug == "" {
-		food.Slug = utils.CreateTag(food.Name)
-	}
-
-	if err := r.db.First(food, "slug = ?", food.Slug).Error; err == nil {
-		return r.resolveAlias(food)
-	}
-
-	if err := r.db.First(food, "lower(name) = lower(?)", food.Name).Error; err == nil {
-		return r.resolveAlias(food)
+	if err := r.Find(food); err == nil || !errors.Is(err, sentinels.ErrNotFound) {
+		return err
 	}
 
 	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(food)
@@ -73,6 +67,22 @@ func (r *foodRepository) FindOrCreate(food *domain.Food) error {
 	return nil
 }
 
+// Find fills food with the stored food of the same slug or name, without creating one.
+func (r *foodRepository) Find(food *domain.Food) error {
+	if food.Slug == "" {
+		food.Slug = utils.CreateTag(food.Name)
+	}
+
+	if err := r.db.First(food, "slug = ?", food.Slug).Error; err == nil {
+		return r.resolveAlias(food)
+	}
+
+	if err := r.db.First(food, "lower(name) = lower(?)", food.Name).Error; err != nil {
+		return fmt.Errorf("find food %s: %w", food.Slug, mapErr(err))
+	}
+	return r.resolveAlias(food)
+}
+
 // resolveAlias replaces food with its canonical target when the row is an alias.
 func (r *foodRepository) resolveAlias(food *domain.Food) error {
 	if food.CanonicalFoodID == nil {
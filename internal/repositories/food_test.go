@@ -27,6 +27,25 @@ func TestFoodRepository_FindOrCreate_CreatesNewFood(t *testing.T) {
 	assert.EqualValues(t, 1, count)
 }
 
+func TestFoodRepository_Find_DoesNotCreate(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewFoodRepository(db)
+
+	stored := &domain.Food{Name: "Beetroot"}
+	require.NoError(t, repo.FindOrCreate(stored))
+
+	found := &domain.Food{Name: "beetroot"}
+	require.NoError(t, repo.Find(found))
+	assert.Equal(t, stored.ID, found.ID)
+
+	err := repo.Find(&domain.Food{Name: "Dragon fruit"})
+	require.ErrorIs(t, err, sentinels.ErrNotFound)
+
+	var count int64
+	db.Model(&domain.Food{}).Count(&count)
+	assert.EqualValues(t, 1, count, "Find must never insert")
+}
+
 func TestFoodRepository_FindOrCreate_ExistingFood_ReturnsExistingID(t *testing.T) {
 	db := openPrivateTestDB(t)
 	repo := repositories.NewFoodRepository(db)
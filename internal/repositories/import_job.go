@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+)
+
+type importJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) domain.ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+func (r *importJobRepository) ByID(id uuid.UUID) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	if err := r.db.Preload("Recipe").Preload("Feed").First(&job, id).Error; err != nil {
+		return nil, fmt.Errorf("import job by id %s: %w", id, mapErr(err))
+	}
+	return &job, nil
+}
+
+func (r *importJobRepository) ListRunnable(staleBefore time.Time, limit int) ([]domain.ImportJob, error) {
+	var jobs []domain.ImportJob
+	err := r.db.Where("status = ?", domain.JobStatusPending).
+		Or("status = ? AND updated < ?", domain.JobStatusRunning, staleBefore).
+		Order("id").Limit(limit).Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("list runnable import jobs: %w", mapErr(err))
+	}
+	return jobs, nil
+}
+
+func (r *importJobRepository) ListByBulk(bulkID uuid.UUID) ([]domain.ImportJob, error) {
+	var jobs []domain.ImportJob
+	if err := r.db.Preload("Recipe").Where("bulk_id = ?", bulkID).Order("id").Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("list import jobs of bulk %s: %w", bulkID, mapErr(err))
+	}
+	return jobs, nil
+}
+
+func (r *importJobRepository) Create(job *domain.ImportJob) error {
+	if err := r.db.Create(job).Error; err != nil {
+		return fmt.Errorf("create import job: %w", mapErr(err))
+	}
+	return nil
+}
+
+func (r *importJobRepository) CreateBatch(jobs []*domain.ImportJob) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(jobs, 100).Error
+	})
+	if err != nil {
+		return fmt.Errorf("create import jobs: %w", mapErr(err))
+	}
+	return nil
+}
+
+func (r *importJobRepository) Update(job *domain.ImportJob) error {
+	if err := r.db.Model(job).Select("status", "error_message", "existing", "recipe_id", "feed_id", "html", "file_path", "report").Updates(job).Error; err != nil {
+		return fmt.Errorf("update import job %s: %w", job.ID, mapErr(err))
+	}
+	return nil
+}
+
+func (r *importJobRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status IN ? AND updated < ?", []string{domain.JobStatusSuccess, domain.JobStatusError}, before).
+		Delete(&domain.ImportJob{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("delete finished import jobs: %w", mapErr(result.Error))
+	}
+	return result.RowsAffected, nil
+}
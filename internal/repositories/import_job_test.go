@@ -0,0 +1,151 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/repositories"
+	"borscht.app/smetana/internal/storage"
+)
+
+func seedImportJob(t *testing.T, db *gorm.DB, hid uuid.UUID, status string, updated time.Time) *domain.ImportJob {
+	t.Helper()
+	job := &domain.ImportJob{HouseholdID: hid, UserID: uuid.New(), Url: "https://example.com/" + uuid.NewString(), Status: status}
+	require.NoError(t, db.Create(job).Error)
+	require.NoError(t, db.Model(job).UpdateColumn("updated", updated).Error)
+	return job
+}
+
+func TestImportJobRepository_ListRunnable_PendingAndStaleRunning(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewImportJobRepository(db)
+	hid := seedHousehold(t, db)
+	now := time.Now()
+
+	pending := seedImportJob(t, db, hid, domain.JobStatusPending, now)
+	interrupted := seedImportJob(t, db, hid, domain.JobStatusRunning, now.Add(-time.Hour))
+	seedImportJob(t, db, hid, domain.JobStatusRunning, now)
+	seedImportJob(t, db, hid, domain.JobStatusSuccess, now.Add(-time.Hour))
+	seedImportJob(t, db, hid, domain.JobStatusError, now.Add(-time.Hour))
+
+	jobs, err := repo.ListRunnable(now.Add(-10*time.Minute), 10)
+	require.NoError(t, err)
+
+	ids := make([]uuid.UUID, 0, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	assert.Equal(t, []uuid.UUID{pending.ID, interrupted.ID}, ids)
+}
+
+func TestImportJobRepository_CreateBatch_ListByBulkInOrder(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewImportJobRepository(db)
+	hid := seedHousehold(t, db)
+	bulkID := uuid.New()
+
+	var jobs []*domain.ImportJob
+	for _, url := range []string{"https://example.com/c", "https://example.com/a", "https://example.com/b"} {
+		id, err := uuid.NewV7()
+		require.NoError(t, err)
+		jobs = append(jobs, &domain.ImportJob{ID: id, BulkID: &bulkID, HouseholdID: hid, UserID: uuid.New(), Url: url, Status: domain.JobStatusPending})
+	}
+	require.NoError(t, repo.CreateBatch(jobs))
+	seedImportJob(t, db, hid, domain.JobStatusPending, time.Now())
+
+	got, err := repo.ListByBulk(bulkID)
+	require.NoError(t, err)
+	urls := make([]string, len(got))
+	for i, job := range got {
+		urls[i] = job.Url
+	}
+	assert.Equal(t, []string{"https://example.com/c", "https://example.com/a", "https://example.com/b"}, urls)
+}
+
+func TestImportJobRepository_CreateBatch_StoresNoneOnError(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewImportJobRepository(db)
+	hid := seedHousehold(t, db)
+	bulkID := uuid.New()
+	taken := seedImportJob(t, db, hid, domain.JobStatusPending, time.Now())
+
+	err := repo.CreateBatch([]*domain.ImportJob{
+		{BulkID: &bulkID, HouseholdID: hid, UserID: uuid.New(), Url: "https://example.com/a", Status: domain.JobStatusPending},
+		{ID: taken.ID, BulkID: &bulkID, HouseholdID: hid, UserID: uuid.New(), Url: "https://example.com/b", Status: domain.JobStatusPending},
+	})
+	require.Error(t, err)
+
+	got, err := repo.ListByBulk(bulkID)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestImportJobRepository_Update_RecordsResultAndDropsHTML(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewImportJobRepository(db)
+	hid := seedHousehold(t, db)
+	recipe := &domain.Recipe{Name: new("Borscht")}
+	seedRecipe(t, db, recipe)
+
+	job := &domain.ImportJob{HouseholdID: hid, UserID: uuid.New(), Url: "https://example.com/borscht", HTML: new("<html></html>"), Status: domain.JobStatusPending}
+	require.NoError(t, repo.Create(job))
+
+	job.Status, job.RecipeID, job.HTML = domain.JobStatusSuccess, &recipe.ID, nil
+	require.NoError(t, repo.Update(job))
+
+	stored, err := repo.ByID(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobStatusSuccess, stored.Status)
+	assert.Nil(t, stored.HTML)
+	require.NotNil(t, stored.Recipe)
+	assert.Equal(t, "Borscht", *stored.Recipe.Name)
+}
+
+func TestImportJobRepository_Update_RecordsFileReportAndDropsPath(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewImportJobRepository(db)
+	hid := seedHousehold(t, db)
+
+	job := &domain.ImportJob{HouseholdID: hid, UserID: uuid.New(), RequestedType: domain.ImportTypeFile, FilePath: new(storage.Path("imports/export.zip")), Status: domain.JobStatusPending}
+	require.NoError(t, repo.Create(job))
+
+	job.Status, job.FilePath = domain.JobStatusSuccess, nil
+	job.Report = &domain.FileImportReport{Source: domain.ImportSourcePaprika, Imported: 1, Failed: 1, Items: []domain.FileImportItem{
+		{Name: "Borscht", Recipe: &domain.Recipe{ID: uuid.New(), Name: new("Borscht")}},
+		{Name: "Broken", Error: "recipe has no name"},
+	}}
+	require.NoError(t, repo.Update(job))
+
+	stored, err := repo.ByID(job.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.FilePath)
+	require.NotNil(t, stored.Report)
+	assert.Equal(t, job.Report.Items[0].Recipe.ID, stored.Report.Items[0].Recipe.ID)
+	assert.Equal(t, "recipe has no name", stored.Report.Items[1].Error)
+}
+
+func TestImportJobRepository_DeleteFinishedBefore(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewImportJobRepository(db)
+	hid := seedHousehold(t, db)
+	now := time.Now()
+
+	seedImportJob(t, db, hid, domain.JobStatusSuccess, now.Add(-8*24*time.Hour))
+	seedImportJob(t, db, hid, domain.JobStatusError, now.Add(-8*24*time.Hour))
+	recent := seedImportJob(t, db, hid, domain.JobStatusSuccess, now)
+	old := seedImportJob(t, db, hid, domain.JobStatusPending, now.Add(-8*24*time.Hour))
+
+	deleted, err := repo.DeleteFinishedBefore(now.Add(-7 * 24 * time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, deleted)
+
+	var remaining []uuid.UUID
+	require.NoError(t, db.Model(&domain.ImportJob{}).Order("id").Pluck("id", &remaining).Error)
+	assert.ElementsMatch(t, []uuid.UUID{recent.ID, old.ID}, remaining, "unfinished jobs are kept however old")
+}
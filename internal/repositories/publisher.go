@@ -49,7 +49,7 @@ func (r *publisherRepository) Search(householdID uuid.UUID, opts types.SearchOpt
 		if householdID == uuid.Nil { // overall total, all households
 			selectCols = append(selectCols, `(
 				SELECT COUNT(*) FROM recipes
-				WHERE recipes.publisher_id = publishers.id
+				WHERE recipes.publisher_id = publishers.id AND recipes.deleted IS NULL
 			) AS total_recipes`)
 		} else {
 			scopeWhere, scopeArgs := scopeWhereArgs(opts.Scope, householdID)
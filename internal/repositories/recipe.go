@@ -3,6 +3,7 @@ package repositories
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -23,6 +24,16 @@ func (collectionRecipe) TableName() string {
 	return "collection_recipes"
 }
 
+// junction table for recipe instructions and the ingredients they use
+type instructionIngredient struct {
+	RecipeInstructionID uuid.UUID `gorm:"type:char(36);primaryKey"`
+	RecipeIngredientID  uuid.UUID `gorm:"type:char(36);primaryKey"`
+}
+
+func (instructionIngredient) TableName() string {
+	return "recipe_instruction_ingredients"
+}
+
 func NewRecipeRepository(db *gorm.DB) domain.RecipeRepository {
 	return &recipeRepository{db: db}
 }
@@ -33,7 +44,7 @@ func applyPreloads(q *gorm.DB, preload types.PreloadOptions, householdID uuid.UU
 		return q
 	}
 
-	if preload.HasAny("ingredients", "all") {
+	if preload.HasAny("ingredients", "subrecipes", "all") {
 		q = q.Scopes(WithPreloadIngredients)
 	}
 
@@ -42,7 +53,7 @@ func applyPreloads(q *gorm.DB, preload types.PreloadOptions, householdID uuid.UU
 	}
 
 	if preload.Has("all") {
-		q = q.Preload(clause.Associations)
+		q = q.Preload(clause.Associations).Preload("Instructions.Ingredients")
 		return q
 	}
 
@@ -81,6 +92,46 @@ func applyPreloads(q *gorm.DB, preload types.PreloadOptions, householdID uuid.UU
 	return q
 }
 
+// loadSubRecipes populates RecipeIngredient.SubRecipe level by level, each nested recipe with its
+// own ingredients, down to domain.MaxSubRecipeDepth. Only recipes visible to the household are loaded.
+func loadSubRecipes(db *gorm.DB, recipes []*domain.Recipe, householdID uuid.UUID) error {
+	level := recipes
+	for depth := 0; depth < domain.MaxSubRecipeDepth && len(level) > 0; depth++ {
+		pending := make(map[uuid.UUID][]*domain.RecipeIngredient)
+		for _, recipe := range level {
+			for _, ing := range recipe.Ingredients {
+				if ing.SubRecipeID != nil {
+					pending[*ing.SubRecipeID] = append(pending[*ing.SubRecipeID], ing)
+				}
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		var subRecipes []*domain.Recipe
+		err := db.Scopes(WithPreloadIngredients).
+			Where("id IN ?", ids).
+			Where("household_id IS NULL OR household_id = ?", householdID).
+			Find(&subRecipes).Error
+		if err != nil {
+			return fmt.Errorf("load sub-recipes: %w", mapErr(err))
+		}
+
+		for _, sub := range subRecipes {
+			for _, ing := range pending[sub.ID] {
+				ing.SubRecipe = sub
+			}
+		}
+		level = subRecipes
+	}
+	return nil
+}
+
 // loadSavedBy populates Recipe.SavedBy for each recipe in the slice using a
 // single JOIN query scoped to the given household.
 func loadSavedBy(db *gorm.DB, recipes []*domain.Recipe, householdID uuid.UUID) error {
@@ -129,6 +180,11 @@ func (r *recipeRepository) ByIDPreload(id uuid.UUID, userID, householdID uuid.UU
 			return nil, err
 		}
 	}
+	if preload.HasAny("subrecipes", "all") {
+		if err := loadSubRecipes(r.db, []*domain.Recipe{&recipe}, householdID); err != nil {
+			return nil, err
+		}
+	}
 	return &recipe, nil
 }
 
@@ -154,6 +210,26 @@ func (r *recipeRepository) Search(userID uuid.UUID, householdID uuid.UUID, opts
 		q = q.Where(scopeWhere, scopeArgs...)
 	}
 
+	if len(opts.HideStream) != 0 {
+		flags := make([]string, len(opts.HideStream))
+		args := []any{userID}
+		for i, flag := range opts.HideStream {
+			flags[i] = "stream_states." + string(flag) + " = ?"
+			args = append(args, true)
+		}
+		q = q.Where("NOT EXISTS (SELECT 1 FROM stream_states WHERE stream_states.recipe_id = recipes.id AND stream_states.user_id = ? AND ("+strings.Join(flags, " OR ")+"))", args...)
+	}
+
+	if opts.FeedFilters {
+		filterWhere, filterArgs, err := feedFilterWhereArgs(r.db, householdID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if filterWhere != "" {
+			q = q.Where(filterWhere, filterArgs...)
+		}
+	}
+
 	// apply filters/search options
 	if opts.SearchQuery != "" {
 		q = q.Where("recipes.name LIKE ? OR recipes.description LIKE ?", "%"+opts.SearchQuery+"%", "%"+opts.SearchQuery+"%")
@@ -201,25 +277,33 @@ func (r *recipeRepository) Search(userID uuid.UUID, householdID uuid.UUID, opts
 	// pagination
 	q = q.Offset(opts.Offset).Limit(opts.Limit)
 
-	// sorting
+	// sorting, with the id breaking ties so that pages neither repeat nor skip recipes
 	q = q.Order(clause.OrderByColumn{
 		Column: clause.Column{Table: "recipes", Name: opts.Sort},
 		Desc:   strings.EqualFold(opts.Order, "DESC"),
 	})
+	if opts.Sort != "id" {
+		q = q.Order("recipes.id")
+	}
 
 	if err := q.Find(&recipes).Error; err != nil {
 		return nil, 0, fmt.Errorf("search find: %w", mapErr(err))
 	}
 
+	ptrs := make([]*domain.Recipe, len(recipes))
+	for i := range recipes {
+		ptrs[i] = &recipes[i]
+	}
 	if opts.PreloadOptions.HasAny("saved", "all") {
-		ptrs := make([]*domain.Recipe, len(recipes))
-		for i := range recipes {
-			ptrs[i] = &recipes[i]
-		}
 		if err := loadSavedBy(r.db, ptrs, householdID); err != nil {
 			return nil, 0, err
 		}
 	}
+	if opts.PreloadOptions.HasAny("subrecipes", "all") {
+		if err := loadSubRecipes(r.db, ptrs, householdID); err != nil {
+			return nil, 0, err
+		}
+	}
 
 	return recipes, total, nil
 }
@@ -252,6 +336,10 @@ func (r *recipeRepository) Import(recipe *domain.Recipe) error {
 			if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&domain.RecipeIngredient{}).Error; err != nil {
 				return fmt.Errorf("import cleanup ingredients: %w", mapErr(err))
 			}
+			if err := tx.Where("recipe_instruction_id IN (?)", tx.Model(&domain.RecipeInstruction{}).Select("id").Where("recipe_id = ?", recipe.ID)).
+				Delete(&instructionIngredient{}).Error; err != nil {
+				return fmt.Errorf("import cleanup instruction ingredients: %w", mapErr(err))
+			}
 			if err := tx.Where("recipe_id = ?", recipe.ID).Delete(&domain.RecipeInstruction{}).Error; err != nil {
 				return fmt.Errorf("import cleanup instructions: %w", mapErr(err))
 			}
@@ -295,6 +383,18 @@ func (r *recipeRepository) Import(recipe *domain.Recipe) error {
 			if err := tx.Omit(clause.Associations).Create(&recipe.Instructions).Error; err != nil {
 				return fmt.Errorf("import create instructions: %w", mapErr(err))
 			}
+
+			var links []instructionIngredient
+			for _, inst := range recipe.Instructions {
+				for _, ing := range inst.Ingredients {
+					links = append(links, instructionIngredient{RecipeInstructionID: inst.ID, RecipeIngredientID: ing.ID})
+				}
+			}
+			if len(links) > 0 {
+				if err := tx.Create(&links).Error; err != nil {
+					return fmt.Errorf("import create instruction ingredients: %w", mapErr(err))
+				}
+			}
 		}
 
 		return nil
@@ -308,6 +408,66 @@ func (r *recipeRepository) Update(recipe *domain.Recipe) error {
 	return nil
 }
 
+func (r *recipeRepository) ListStale(updatedBefore time.Time, exclude []uuid.UUID, limit int) ([]domain.Recipe, error) {
+	q := r.db.Where("household_id IS NULL AND source_url IS NOT NULL AND updated < ?", updatedBefore)
+	if len(exclude) > 0 {
+		q = q.Where("id NOT IN ?", exclude)
+	}
+
+	var recipes []domain.Recipe
+	err := q.Preload("Ingredients", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Scopes(WithPreloadInstructions).
+		Preload("Images").
+		Order("updated").Limit(limit).Find(&recipes).Error
+	if err != nil {
+		return nil, fmt.Errorf("list stale recipes: %w", mapErr(err))
+	}
+	return recipes, nil
+}
+
+func (r *recipeRepository) MarkRefreshed(id uuid.UUID) error {
+	if err := r.db.Model(&domain.Recipe{ID: id}).UpdateColumn("updated", time.Now()).Error; err != nil {
+		return fmt.Errorf("mark recipe %s refreshed: %w", id, mapErr(err))
+	}
+	return nil
+}
+
+func (r *recipeRepository) DuplicateCandidates(householdID uuid.UUID, offset, limit int) ([]domain.Recipe, int64, error) {
+	scopeWhere, scopeArgs := scopeWhereArgs("", householdID)
+	sharedTitles := func() *gorm.DB {
+		return r.db.Model(&domain.Recipe{}).
+			Where(scopeWhere, scopeArgs...).
+			Where("recipes.title_key IS NOT NULL AND recipes.title_key <> ''").
+			Group("recipes.title_key").
+			Having("COUNT(*) > 1")
+	}
+
+	var total int64
+	if err := r.db.Table("(?) AS shared_titles", sharedTitles().Select("recipes.title_key")).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("duplicate candidates count: %w", mapErr(err))
+	} else if total == 0 {
+		return nil, 0, nil
+	}
+
+	var titles []string
+	if err := sharedTitles().Order("recipes.title_key").Offset(offset).Limit(limit).Pluck("recipes.title_key", &titles).Error; err != nil {
+		return nil, 0, fmt.Errorf("duplicate candidates titles: %w", mapErr(err))
+	}
+	if len(titles) == 0 {
+		return nil, total, nil
+	}
+
+	var recipes []domain.Recipe
+	err := r.db.Where(scopeWhere, scopeArgs...).
+		Where("recipes.title_key IN ?", titles).
+		Preload("Ingredients", func(db *gorm.DB) *gorm.DB { return db.Select("id", "recipe_id", "food_id", "name") }).
+		Order("created").Find(&recipes).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("duplicate candidates: %w", mapErr(err))
+	}
+	return recipes, total, nil
+}
+
 func (r *recipeRepository) Delete(id uuid.UUID) error {
 	if err := r.db.Delete(&domain.Recipe{}, id).Error; err != nil {
 		return fmt.Errorf("delete recipe %s: %w", id, mapErr(err))
@@ -315,6 +475,14 @@ func (r *recipeRepository) Delete(id uuid.UUID) error {
 	return nil
 }
 
+func (r *recipeRepository) TrashedByID(id uuid.UUID) (*domain.Recipe, error) {
+	var recipe domain.Recipe
+	if err := r.db.Unscoped().Preload("Images").Where("deleted IS NOT NULL").First(&recipe, id).Error; err != nil {
+		return nil, fmt.Errorf("trashed by id %s: %w", id, mapErr(err))
+	}
+	return &recipe, nil
+}
+
 func (r *recipeRepository) UserSave(recipeID uuid.UUID, userID uuid.UUID, householdID uuid.UUID) error {
 	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&domain.RecipeSaved{
 		UserID:      userID,
@@ -368,6 +536,28 @@ func (r *recipeRepository) DeleteIngredient(id uuid.UUID, recipeID uuid.UUID) er
 	return nil
 }
 
+func (r *recipeRepository) SubRecipeIDs(recipeIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	if len(recipeIDs) == 0 {
+		return nil, nil
+	}
+	var rows []struct {
+		RecipeID    uuid.UUID
+		SubRecipeID uuid.UUID
+	}
+	err := r.db.Model(&domain.RecipeIngredient{}).
+		Distinct("recipe_id", "sub_recipe_id").
+		Where("recipe_id IN ? AND sub_recipe_id IS NOT NULL", recipeIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("sub-recipe ids: %w", mapErr(err))
+	}
+	result := make(map[uuid.UUID][]uuid.UUID, len(rows))
+	for _, row := range rows {
+		result[row.RecipeID] = append(result[row.RecipeID], row.SubRecipeID)
+	}
+	return result, nil
+}
+
 func (r *recipeRepository) AddEquipment(recipeID uuid.UUID, equipmentID uuid.UUID) error {
 	if err := r.db.Model(&domain.Recipe{ID: recipeID}).Association("Equipment").Append(&domain.Equipment{ID: equipmentID}); err != nil {
 		return fmt.Errorf("add equipment %s to recipe %s: %w", equipmentID, recipeID, mapErr(err))
@@ -383,16 +573,23 @@ func (r *recipeRepository) RemoveEquipment(recipeID uuid.UUID, equipmentID uuid.
 }
 
 func (r *recipeRepository) CreateInstruction(instruction *domain.RecipeInstruction) error {
-	if err := r.db.Create(instruction).Error; err != nil {
+	// links are managed through LinkInstructionIngredient
+	if err := r.db.Omit("Ingredients").Create(instruction).Error; err != nil {
 		return fmt.Errorf("create instruction: %w", mapErr(err))
 	}
 	return nil
 }
 
 func (r *recipeRepository) UpdateInstruction(instruction *domain.RecipeInstruction) error {
-	if err := r.db.Model(instruction).Where("recipe_id = ?", instruction.RecipeID).Updates(instruction).Error; err != nil {
+	if err := r.db.Model(instruction).Omit("Ingredients").Where("recipe_id = ?", instruction.RecipeID).Updates(instruction).Error; err != nil {
 		return fmt.Errorf("update instruction %s: %w", instruction.ID, mapErr(err))
 	}
+	// timers and temperatures are derived from the text, so they must be cleared along with it
+	if instruction.Text != "" {
+		if err := r.db.Model(instruction).Select("Timers", "Temperatures").Where("recipe_id = ?", instruction.RecipeID).Updates(instruction).Error; err != nil {
+			return fmt.Errorf("update instruction %s metadata: %w", instruction.ID, mapErr(err))
+		}
+	}
 	return nil
 }
 
@@ -403,6 +600,32 @@ func (r *recipeRepository) DeleteInstruction(id uuid.UUID, recipeID uuid.UUID) e
 	return nil
 }
 
+func (r *recipeRepository) LinkInstructionIngredient(recipeID, instructionID, ingredientID uuid.UUID) error {
+	if err := r.db.Select("id").Where("id = ? AND recipe_id = ?", instructionID, recipeID).First(&domain.RecipeInstruction{}).Error; err != nil {
+		return fmt.Errorf("link instruction %s: %w", instructionID, mapErr(err))
+	}
+	if err := r.db.Select("id").Where("id = ? AND recipe_id = ?", ingredientID, recipeID).First(&domain.RecipeIngredient{}).Error; err != nil {
+		return fmt.Errorf("link instruction %s to ingredient %s: %w", instructionID, ingredientID, mapErr(err))
+	}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&instructionIngredient{
+		RecipeInstructionID: instructionID,
+		RecipeIngredientID:  ingredientID,
+	}).Error; err != nil {
+		return fmt.Errorf("link instruction %s to ingredient %s: %w", instructionID, ingredientID, mapErr(err))
+	}
+	return nil
+}
+
+func (r *recipeRepository) UnlinkInstructionIngredient(recipeID, instructionID, ingredientID uuid.UUID) error {
+	if err := r.db.
+		Where("recipe_instruction_id = ? AND recipe_ingredient_id = ?", instructionID, ingredientID).
+		Where("recipe_instruction_id IN (?)", r.db.Model(&domain.RecipeInstruction{}).Select("id").Where("recipe_id = ?", recipeID)).
+		Delete(&instructionIngredient{}).Error; err != nil {
+		return fmt.Errorf("unlink instruction %s from ingredient %s: %w", instructionID, ingredientID, mapErr(err))
+	}
+	return nil
+}
+
 func (r *recipeRepository) Transaction(fn func(txRepo domain.RecipeRepository) error) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		txRepo := NewRecipeRepository(tx)
@@ -411,7 +634,11 @@ func (r *recipeRepository) Transaction(fn func(txRepo domain.RecipeRepository) e
 }
 
 func (r *recipeRepository) ReplaceRecipePointers(oldRecipeID, newRecipeID, householdID uuid.UUID) error {
-	// 1. RecipeSaved
+	// 1. RecipeSaved, dropping saves of users who already saved the new recipe
+	if err := r.db.Where("recipe_id = ? AND household_id = ? AND user_id IN (SELECT user_id FROM recipes_saved WHERE recipe_id = ?)", oldRecipeID, householdID, newRecipeID).
+		Delete(&domain.RecipeSaved{}).Error; err != nil {
+		return fmt.Errorf("replace recipe pointers (RecipeSaved duplicates): %w", mapErr(err))
+	}
 	if err := r.db.Model(&domain.RecipeSaved{}).Where("recipe_id = ? AND household_id = ?", oldRecipeID, householdID).Update("recipe_id", newRecipeID).Error; err != nil {
 		return fmt.Errorf("replace recipe pointers (RecipeSaved): %w", mapErr(err))
 	}
@@ -419,11 +646,28 @@ func (r *recipeRepository) ReplaceRecipePointers(oldRecipeID, newRecipeID, house
 	if err := r.db.Model(&domain.MealPlan{}).Where("recipe_id = ? AND household_id = ?", oldRecipeID, householdID).Update("recipe_id", newRecipeID).Error; err != nil {
 		return fmt.Errorf("replace recipe pointers (MealPlan): %w", mapErr(err))
 	}
-	// 3. CollectionRecipes
+	// 3. CollectionRecipes, dropping entries of collections that already have the new recipe
+	if err := r.db.Where("recipe_id = ? AND collection_id IN (SELECT collection_id FROM collection_recipes WHERE recipe_id = ?)", oldRecipeID, newRecipeID).
+		Where("collection_id IN (SELECT id FROM collections WHERE household_id = ?)", householdID).
+		Delete(&collectionRecipe{}).Error; err != nil {
+		return fmt.Errorf("replace recipe pointers (CollectionRecipes duplicates): %w", mapErr(err))
+	}
 	if err := r.db.Model(&collectionRecipe{}).
 		Where("recipe_id = ? AND collection_id IN (SELECT id FROM collections WHERE household_id = ?)", oldRecipeID, householdID).
 		Update("recipe_id", newRecipeID).Error; err != nil {
 		return fmt.Errorf("replace recipe pointers (CollectionRecipes): %w", mapErr(err))
 	}
+	// 4. sub-recipes of the household's recipes, except the new recipe's own, which would then point at itself
+	ownRecipes := r.db.Model(&domain.Recipe{}).Select("id").Where("household_id = ? AND id <> ?", householdID, newRecipeID)
+	if err := r.db.Model(&domain.RecipeIngredient{}).
+		Where("sub_recipe_id = ? AND recipe_id IN (?)", oldRecipeID, ownRecipes).
+		Update("sub_recipe_id", newRecipeID).Error; err != nil {
+		return fmt.Errorf("replace recipe pointers (RecipeIngredients): %w", mapErr(err))
+	}
+	if err := r.db.Model(&domain.RecipeInstruction{}).
+		Where("sub_recipe_id = ? AND recipe_id IN (?)", oldRecipeID, ownRecipes).
+		Update("sub_recipe_id", newRecipeID).Error; err != nil {
+		return fmt.Errorf("replace recipe pointers (RecipeInstructions): %w", mapErr(err))
+	}
 	return nil
 }
@@ -83,7 +83,7 @@ func (r *authorRepository) Search(householdID uuid.UUID, opts types.SearchOption
 		if householdID == uuid.Nil {
 			selectCols = append(selectCols, `(
 				SELECT COUNT(*) FROM recipes
-				WHERE recipes.author_id = authors.id
+				WHERE recipes.author_id = authors.id AND recipes.deleted IS NULL
 			) AS total_recipes`)
 		} else {
 			scopeWhere, scopeArgs := scopeWhereArgs(opts.Scope, householdID)
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+)
+
+// Feature weights of the similarity score: sharing a cuisine says more about two recipes than sharing a food,
+// and both say more than sharing a keyword or a pan.
+const (
+	similarFoodWeight      = 3
+	similarCuisineWeight   = 4
+	similarTaxonomyWeight  = 2
+	similarEquipmentWeight = 1
+)
+
+// recipeFeatures selects foods, taxonomies and equipment as (recipe_id, feature, weight) rows: of the recipes in
+// ids, or with byFeature of every recipe having one of the features in ids. Features are IDs of different tables,
+// so they never collide, and every table is filtered on its own so its index is used.
+func (r *recipeRepository) recipeFeatures(byFeature bool, ids any) *gorm.DB {
+	food, taxonomy, equipment := "recipe_id", "recipe_taxonomies.recipe_id", "recipe_id"
+	if byFeature {
+		food, taxonomy, equipment = "food_id", "recipe_taxonomies.taxonomy_id", "equipment_id"
+	}
+	// weights are inlined, untyped placeholders in a select list can't be summed on Postgres
+	return r.db.Raw(fmt.Sprintf(`SELECT recipe_id, food_id AS feature, %d AS weight FROM recipe_ingredients WHERE food_id IS NOT NULL AND %s IN (?)
+		UNION SELECT recipe_taxonomies.recipe_id, recipe_taxonomies.taxonomy_id, CASE WHEN taxonomies.type = ? THEN %d ELSE %d END
+			FROM recipe_taxonomies JOIN taxonomies ON taxonomies.id = recipe_taxonomies.taxonomy_id WHERE %s IN (?)
+		UNION SELECT recipe_id, equipment_id, %d FROM recipe_equipment WHERE %s IN (?)`,
+		similarFoodWeight, food, similarCuisineWeight, similarTaxonomyWeight, taxonomy, similarEquipmentWeight, equipment),
+		ids, domain.TaxonomyTypeCuisine, ids, ids)
+}
+
+// Similar scores candidates by the weighted Jaccard index of their features: the weight of the shared features
+// divided by the weight of the features of either recipe. Only recipes sharing a feature with the source, and
+// visible to the household, are scored. The source's parent and its clones are copies of it, so they are left out.
+func (r *recipeRepository) Similar(recipeID uuid.UUID, householdID uuid.UUID, limit int) ([]domain.SimilarRecipe, error) {
+	var source domain.Recipe
+	if err := r.db.Select("id", "parent_id").First(&source, "id = ?", recipeID).Error; err != nil {
+		return nil, fmt.Errorf("similar to recipe %s (source): %w", recipeID, mapErr(err))
+	}
+	var features []struct {
+		Feature uuid.UUID
+		Weight  int
+	}
+	if err := r.recipeFeatures(false, []uuid.UUID{recipeID}).Scan(&features).Error; err != nil {
+		return nil, fmt.Errorf("similar to recipe %s (features): %w", recipeID, mapErr(err))
+	}
+	if len(features) == 0 {
+		return nil, nil
+	}
+	featureIDs := make([]uuid.UUID, len(features))
+	sourceWeight := 0
+	for i, f := range features {
+		featureIDs[i] = f.Feature
+		sourceWeight += f.Weight
+	}
+	excluded := []uuid.UUID{recipeID}
+	if source.ParentID != nil {
+		excluded = append(excluded, *source.ParentID)
+	}
+
+	scopeWhere, scopeArgs := scopeWhereArgs("", householdID)
+	candidates := r.db.Raw("SELECT recipe_id FROM (?) cand", r.recipeFeatures(true, featureIDs))
+	args := []any{r.recipeFeatures(true, featureIDs), r.recipeFeatures(false, candidates), excluded, recipeID}
+
+	var rows []struct {
+		RecipeID uuid.UUID
+		Score    float64
+	}
+	err := r.db.Raw(fmt.Sprintf(`SELECT shared.recipe_id, 1.0 * shared.weight / (%d + SUM(f.weight) - shared.weight) AS score
+		FROM (SELECT recipe_id, SUM(weight) AS weight FROM (?) cand GROUP BY recipe_id) shared
+		JOIN recipes ON recipes.id = shared.recipe_id
+		JOIN (?) f ON f.recipe_id = shared.recipe_id
+		WHERE recipes.id NOT IN ? AND (recipes.parent_id IS NULL OR recipes.parent_id <> ?) AND `+scopeWhere+`
+		GROUP BY shared.recipe_id, shared.weight
+		ORDER BY score DESC, shared.recipe_id
+		LIMIT ?`, sourceWeight), append(append(args, scopeArgs...), limit)...).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("similar to recipe %s: %w", recipeID, mapErr(err))
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.RecipeID
+	}
+	var recipes []domain.Recipe
+	if err := r.db.Preload("Images").Where("id IN ?", ids).Find(&recipes).Error; err != nil {
+		return nil, fmt.Errorf("similar to recipe %s (load): %w", recipeID, mapErr(err))
+	}
+	byID := make(map[uuid.UUID]domain.Recipe, len(recipes))
+	for _, recipe := range recipes {
+		byID[recipe.ID] = recipe
+	}
+
+	similar := make([]domain.SimilarRecipe, 0, len(rows))
+	for _, row := range rows {
+		if recipe, ok := byID[row.RecipeID]; ok {
+			similar = append(similar, domain.SimilarRecipe{Recipe: recipe, Score: row.Score})
+		}
+	}
+	return similar, nil
+}
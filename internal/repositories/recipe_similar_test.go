@@ -0,0 +1,104 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/repositories"
+)
+
+func TestRecipeRepository_Similar_RanksBySharedFeatures(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	otherHid := seedHousehold(t, db)
+	repo := repositories.NewRecipeRepository(db)
+	foodRepo := repositories.NewFoodRepository(db)
+
+	beet, cabbage, pasta := &domain.Food{Name: "Beet"}, &domain.Food{Name: "Cabbage"}, &domain.Food{Name: "Pasta"}
+	for _, f := range []*domain.Food{beet, cabbage, pasta} {
+		require.NoError(t, foodRepo.FindOrCreate(f))
+	}
+	ukrainian := seedTaxonomy(t, db, "Ukrainian", domain.TaxonomyTypeCuisine)
+	pot := seedEquipment(t, db, "Pot")
+
+	recipe := func(name string, household *domain.Recipe, foods ...*domain.Food) *domain.Recipe {
+		r := &domain.Recipe{Name: new(name)}
+		if household != nil {
+			r.HouseholdID = household.HouseholdID
+		}
+		seedRecipe(t, db, r)
+		for _, f := range foods {
+			require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: r.ID, FoodID: &f.ID, RawText: f.Name}).Error)
+		}
+		return r
+	}
+	own := &domain.Recipe{HouseholdID: &hid}
+	foreign := &domain.Recipe{HouseholdID: &otherHid}
+
+	borscht := recipe("Borscht", own, beet, cabbage)
+	linkRecipeTaxonomy(t, db, borscht.ID, ukrainian.ID)
+	require.NoError(t, repo.AddEquipment(borscht.ID, pot.ID))
+
+	greenBorscht := recipe("Green borscht", own, cabbage)
+	linkRecipeTaxonomy(t, db, greenBorscht.ID, ukrainian.ID)
+	require.NoError(t, repo.AddEquipment(greenBorscht.ID, pot.ID))
+	salad := recipe("Beet salad", own, beet, pasta)
+	recipe("Pasta", own, pasta)
+	recipe("Foreign borscht", foreign, beet, cabbage)
+
+	got, err := repo.Similar(borscht.ID, hid, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 2, "recipes sharing nothing and recipes of other households are left out")
+
+	assert.Equal(t, greenBorscht.ID, got[0].ID)
+	assert.InDelta(t, 8.0/11.0, got[0].Score, 0.001, "cabbage, cuisine and pot shared of 11 weighted features")
+	assert.Equal(t, salad.ID, got[1].ID)
+	assert.InDelta(t, 3.0/14.0, got[1].Score, 0.001)
+
+	got, err = repo.Similar(borscht.ID, hid, 1)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestRecipeRepository_Similar_LeavesOutParentAndClones(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	repo := repositories.NewRecipeRepository(db)
+	foodRepo := repositories.NewFoodRepository(db)
+
+	beet := &domain.Food{Name: "Beet"}
+	require.NoError(t, foodRepo.FindOrCreate(beet))
+
+	recipe := func(name string, parent *domain.Recipe) *domain.Recipe {
+		r := &domain.Recipe{Name: new(name), HouseholdID: &hid}
+		if parent != nil {
+			r.ParentID = &parent.ID
+		}
+		seedRecipe(t, db, r)
+		require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: r.ID, FoodID: &beet.ID, RawText: beet.Name}).Error)
+		return r
+	}
+	original := recipe("Borscht", nil)
+	clone := recipe("Borscht", original)
+	cloneOfClone := recipe("Borscht", clone)
+	salad := recipe("Beet salad", nil)
+	seedRecipe(t, db, &domain.Recipe{Name: new("Pasta"), HouseholdID: &hid})
+
+	got, err := repo.Similar(clone.ID, hid, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1, "the parent and the clones of the source are copies of it")
+	assert.Equal(t, salad.ID, got[0].ID)
+	assert.InDelta(t, 1.0, got[0].Score, 0.001)
+
+	got, err = repo.Similar(cloneOfClone.ID, hid, 10)
+	require.NoError(t, err)
+	ids := make([]any, len(got))
+	for i, s := range got {
+		ids[i] = s.ID
+	}
+	assert.NotContains(t, ids, clone.ID)
+	assert.Contains(t, ids, salad.ID)
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+)
+
+// tasteSignals selects the recipes a household showed interest in as (recipe_id, weight) rows:
+// cooking a recipe says more than saving it, and saving more than adding it to a collection.
+func (r *recipeRepository) tasteSignals(householdID uuid.UUID) *gorm.DB {
+	return r.db.Raw(`SELECT recipe_id, 3 AS weight FROM meal_plans
+			WHERE household_id = ? AND recipe_id IS NOT NULL AND date <= ? AND deleted IS NULL
+		UNION ALL SELECT recipe_id, 2 FROM recipes_saved WHERE household_id = ?
+		UNION ALL SELECT collection_recipes.recipe_id, 1 FROM collection_recipes
+			JOIN collections ON collections.id = collection_recipes.collection_id
+			WHERE collections.household_id = ? AND collections.deleted IS NULL`,
+		householdID, time.Now(), householdID, householdID)
+}
+
+func (r *recipeRepository) TasteProfile(householdID uuid.UUID) (*domain.TasteProfile, error) {
+	profile := &domain.TasteProfile{}
+	for _, q := range []struct {
+		into  *map[uuid.UUID]float64
+		query string
+	}{
+		{&profile.Taxonomies, `SELECT recipe_taxonomies.taxonomy_id AS id, SUM(signals.weight) AS weight
+			FROM (?) signals JOIN recipe_taxonomies ON recipe_taxonomies.recipe_id = signals.recipe_id
+			GROUP BY recipe_taxonomies.taxonomy_id`},
+		{&profile.Foods, `SELECT recipe_ingredients.food_id AS id, SUM(signals.weight) AS weight
+			FROM (?) signals JOIN recipe_ingredients ON recipe_ingredients.recipe_id = signals.recipe_id
+			WHERE recipe_ingredients.food_id IS NOT NULL
+			GROUP BY recipe_ingredients.food_id`},
+		{&profile.Publishers, `SELECT recipes.publisher_id AS id, SUM(signals.weight) AS weight
+			FROM (?) signals JOIN recipes ON recipes.id = signals.recipe_id
+			WHERE recipes.publisher_id IS NOT NULL
+			GROUP BY recipes.publisher_id`},
+	} {
+		var rows []struct {
+			ID     uuid.UUID
+			Weight float64
+		}
+		if err := r.db.Raw(q.query, r.tasteSignals(householdID)).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("taste profile of household %s: %w", householdID, mapErr(err))
+		}
+		*q.into = make(map[uuid.UUID]float64, len(rows))
+		for _, row := range rows {
+			(*q.into)[row.ID] = row.Weight
+		}
+	}
+	return profile, nil
+}
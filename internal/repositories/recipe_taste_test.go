@@ -0,0 +1,49 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/repositories"
+)
+
+func TestRecipeRepository_TasteProfile_WeighsCookedSavedAndCollected(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewRecipeRepository(db)
+	hid := seedHousehold(t, db)
+	publisher := seedPublisher(t, db)
+	soup := seedTaxonomy(t, db, "Soup", domain.TaxonomyTypeCategory)
+
+	cooked := &domain.Recipe{PublisherID: &publisher.ID}
+	seedRecipe(t, db, cooked)
+	linkRecipeTaxonomy(t, db, cooked.ID, soup.ID)
+	require.NoError(t, db.Create(&domain.MealPlan{HouseholdID: hid, RecipeID: &cooked.ID, Date: time.Now().Add(-24 * time.Hour)}).Error)
+
+	saved := &domain.Recipe{}
+	seedRecipe(t, db, saved)
+	linkRecipeTaxonomy(t, db, saved.ID, soup.ID)
+	seedSavedRecipe(t, db, saved.ID, hid)
+
+	// planned, not cooked yet
+	planned := &domain.Recipe{PublisherID: &publisher.ID}
+	seedRecipe(t, db, planned)
+	require.NoError(t, db.Create(&domain.MealPlan{HouseholdID: hid, RecipeID: &planned.ID, Date: time.Now().Add(48 * time.Hour)}).Error)
+
+	// another household's taste doesn't count
+	otherSaved := &domain.Recipe{}
+	seedRecipe(t, db, otherSaved)
+	linkRecipeTaxonomy(t, db, otherSaved.ID, soup.ID)
+	seedSavedRecipe(t, db, otherSaved.ID, seedHousehold(t, db))
+
+	profile, err := repo.TasteProfile(hid)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]float64{soup.ID: 5}, profile.Taxonomies)
+	assert.Equal(t, map[uuid.UUID]float64{publisher.ID: 3}, profile.Publishers)
+	assert.Empty(t, profile.Foods)
+}
@@ -324,6 +324,103 @@ func TestRecipeRepository_ReplaceRecipePointers_OtherHouseholdNotAffected(t *tes
 	assert.EqualValues(t, 1, count, "hid2 must remain untouched — ReplaceRecipePointers is scoped to one household")
 }
 
+func TestRecipeRepository_ReplaceRecipePointers_BothSaved_DropsDuplicates(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	u := seedUser(t, db, hid)
+
+	oldRecipe := &domain.Recipe{}
+	newRecipe := &domain.Recipe{}
+	seedRecipe(t, db, oldRecipe)
+	seedRecipe(t, db, newRecipe)
+
+	collection := &domain.Collection{Name: "Soups", HouseholdID: hid}
+	require.NoError(t, db.Create(collection).Error)
+	for _, r := range []*domain.Recipe{oldRecipe, newRecipe} {
+		require.NoError(t, db.Create(&domain.RecipeSaved{RecipeID: r.ID, UserID: u.ID, HouseholdID: hid}).Error)
+		require.NoError(t, db.Model(collection).Association("Recipes").Append(r))
+	}
+
+	repo := repositories.NewRecipeRepository(db)
+	require.NoError(t, repo.ReplaceRecipePointers(oldRecipe.ID, newRecipe.ID, hid))
+
+	var count int64
+	db.Model(&domain.RecipeSaved{}).Where("user_id = ?", u.ID).Count(&count)
+	assert.EqualValues(t, 1, count, "the user keeps a single save of the new recipe")
+	db.Table("collection_recipes").Where("collection_id = ?", collection.ID).Count(&count)
+	assert.EqualValues(t, 1, count, "the collection keeps a single entry of the new recipe")
+}
+
+func TestRecipeRepository_ReplaceRecipePointers_RepointsHouseholdSubRecipes(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid, otherHid := seedHousehold(t, db), seedHousehold(t, db)
+
+	oldSauce := &domain.Recipe{Name: new("Tomato Sauce"), HouseholdID: &hid}
+	newSauce := &domain.Recipe{Name: new("Tomato Sauce"), HouseholdID: &hid}
+	pizza := &domain.Recipe{Name: new("Pizza"), HouseholdID: &hid}
+	foreign := &domain.Recipe{Name: new("Pizza"), HouseholdID: &otherHid}
+	for _, r := range []*domain.Recipe{oldSauce, newSauce, pizza, foreign} {
+		seedRecipe(t, db, r)
+	}
+	ingredient := &domain.RecipeIngredient{RecipeID: pizza.ID, SubRecipeID: &oldSauce.ID, RawText: "1 batch tomato sauce"}
+	foreignIngredient := &domain.RecipeIngredient{RecipeID: foreign.ID, SubRecipeID: &oldSauce.ID, RawText: "1 batch tomato sauce"}
+	require.NoError(t, db.Create(ingredient).Error)
+	require.NoError(t, db.Create(foreignIngredient).Error)
+	step := &domain.RecipeInstruction{RecipeID: pizza.ID, SubRecipeID: &oldSauce.ID, Text: "Make the sauce."}
+	require.NoError(t, db.Create(step).Error)
+
+	repo := repositories.NewRecipeRepository(db)
+	require.NoError(t, repo.ReplaceRecipePointers(oldSauce.ID, newSauce.ID, hid))
+
+	var got domain.RecipeIngredient
+	require.NoError(t, db.First(&got, "id = ?", ingredient.ID).Error)
+	assert.Equal(t, newSauce.ID, *got.SubRecipeID)
+	var gotForeign domain.RecipeIngredient
+	require.NoError(t, db.First(&gotForeign, "id = ?", foreignIngredient.ID).Error)
+	assert.Equal(t, oldSauce.ID, *gotForeign.SubRecipeID, "recipes of other households are left alone")
+	var gotStep domain.RecipeInstruction
+	require.NoError(t, db.First(&gotStep, "id = ?", step.ID).Error)
+	assert.Equal(t, newSauce.ID, *gotStep.SubRecipeID)
+}
+
+func TestRecipeRepository_DuplicateCandidates_PagesSharedTitles(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	otherHid := seedHousehold(t, db)
+	u := seedUser(t, db, hid)
+
+	own := &domain.Recipe{Name: new("Borscht"), HouseholdID: &hid}
+	saved := &domain.Recipe{Name: new("borscht!")}
+	foreign := &domain.Recipe{Name: new("Borscht"), HouseholdID: &otherHid}
+	single := &domain.Recipe{Name: new("Vareniki"), HouseholdID: &hid}
+	pie := &domain.Recipe{Name: new("Apple Pie"), HouseholdID: &hid}
+	piePlain := &domain.Recipe{Name: new("Pie"), HouseholdID: &hid}
+	for _, r := range []*domain.Recipe{own, saved, foreign, single, pie, piePlain} {
+		seedRecipe(t, db, r)
+	}
+	require.NoError(t, db.Create(&domain.RecipeSaved{RecipeID: saved.ID, UserID: u.ID, HouseholdID: hid}).Error)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: own.ID, RawText: "2 beets", Name: new("beets")}).Error)
+	// the title key follows renames
+	piePlain.Name = new("apple pie")
+	require.NoError(t, db.Model(piePlain).Updates(piePlain).Error)
+
+	repo := repositories.NewRecipeRepository(db)
+	got, total, err := repo.DuplicateCandidates(hid, 0, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	require.Len(t, got, 2)
+	assert.Equal(t, pie.ID, got[0].ID)
+	assert.Equal(t, piePlain.ID, got[1].ID)
+
+	got, _, err = repo.DuplicateCandidates(hid, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, got, 2, "the recipe of another household is not visible")
+	assert.Equal(t, own.ID, got[0].ID)
+	assert.Equal(t, saved.ID, got[1].ID)
+	require.Len(t, got[0].Ingredients, 1)
+	assert.Equal(t, "beets", *got[0].Ingredients[0].Name)
+}
+
 func TestRecipeRepository_Transaction_RollsBackOnError(t *testing.T) {
 	db := openTestDB(t)
 	repo := repositories.NewRecipeRepository(db)
@@ -383,6 +480,33 @@ func TestRecipeRepository_Search_WithSavedPreload_PopulatesSaved(t *testing.T) {
 	assert.Equal(t, u.ID, results[0].SavedBy[0].ID)
 }
 
+func TestRecipeRepository_Search_SameNames_PagesByID(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	u := seedUser(t, db, hid)
+	var ids []uuid.UUID
+	for range 3 {
+		r := &domain.Recipe{Name: new("Borscht"), HouseholdID: &hid}
+		seedRecipe(t, db, r)
+		ids = append(ids, r.ID)
+	}
+
+	opts := defaultSearchOpts()
+	opts.Sort = "name"
+	opts.Limit = 1
+
+	repo := repositories.NewRecipeRepository(db)
+	var got []uuid.UUID
+	for offset := range 3 {
+		opts.Offset = offset
+		page, _, err := repo.Search(u.ID, hid, domain.RecipeSearchOptions{SearchOptions: opts})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		got = append(got, page[0].ID)
+	}
+	assert.Equal(t, ids, got, "recipes of the same name are paged in id order")
+}
+
 func TestRecipeRepository_Search_FromFeeds_NoComputedColumnError(t *testing.T) {
 	db := openTestDB(t)
 	hid := seedHousehold(t, db)
@@ -715,3 +839,240 @@ func TestRecipeRepository_Import_UpsertsData(t *testing.T) {
 	require.Len(t, got.Ingredients, 1)
 	assert.Equal(t, "new ingredient", got.Ingredients[0].RawText)
 }
+
+func TestRecipeRepository_ByIDPreload_WithSubRecipesPreload_LoadsNestedIngredients(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+
+	sauce := &domain.Recipe{Name: new("Tomato Sauce"), HouseholdID: &hid}
+	seedRecipe(t, db, sauce)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: sauce.ID, RawText: "400 g tomatoes"}).Error)
+
+	pizza := &domain.Recipe{Name: new("Pizza"), HouseholdID: &hid}
+	seedRecipe(t, db, pizza)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: pizza.ID, SubRecipeID: &sauce.ID, RawText: "1 batch tomato sauce"}).Error)
+
+	repo := repositories.NewRecipeRepository(db)
+	result, err := repo.ByIDPreload(pizza.ID, uuid.Nil, hid, types.Preload("subrecipes"))
+
+	require.NoError(t, err)
+	require.Len(t, result.Ingredients, 1)
+	require.NotNil(t, result.Ingredients[0].SubRecipe)
+	assert.Equal(t, sauce.ID, result.Ingredients[0].SubRecipe.ID)
+	require.Len(t, result.Ingredients[0].SubRecipe.Ingredients, 1)
+	assert.Equal(t, "400 g tomatoes", result.Ingredients[0].SubRecipe.Ingredients[0].RawText)
+}
+
+func TestRecipeRepository_Search_WithSubRecipesPreload_LoadsNestedIngredients(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	u := seedUser(t, db, hid)
+
+	sauce := &domain.Recipe{Name: new("Tomato Sauce"), HouseholdID: &hid}
+	seedRecipe(t, db, sauce)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: sauce.ID, RawText: "400 g tomatoes"}).Error)
+
+	pizza := &domain.Recipe{Name: new("Pizza"), HouseholdID: &hid}
+	seedRecipe(t, db, pizza)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: pizza.ID, SubRecipeID: &sauce.ID, RawText: "1 batch tomato sauce"}).Error)
+
+	opts := defaultSearchOpts()
+	opts.Preload = []string{"subrecipes"}
+	opts.SearchQuery = "Pizza"
+
+	repo := repositories.NewRecipeRepository(db)
+	results, _, err := repo.Search(u.ID, hid, domain.RecipeSearchOptions{SearchOptions: opts})
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Ingredients, 1)
+	require.NotNil(t, results[0].Ingredients[0].SubRecipe)
+	assert.Equal(t, sauce.ID, results[0].Ingredients[0].SubRecipe.ID)
+	require.Len(t, results[0].Ingredients[0].SubRecipe.Ingredients, 1)
+}
+
+func TestRecipeRepository_ByIDPreload_WithSubRecipesPreload_SkipsOtherHouseholdRecipes(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	otherHid := seedHousehold(t, db)
+
+	foreign := &domain.Recipe{Name: new("Secret Sauce"), HouseholdID: &otherHid}
+	seedRecipe(t, db, foreign)
+	r := &domain.Recipe{Name: new("Pizza"), HouseholdID: &hid}
+	seedRecipe(t, db, r)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: r.ID, SubRecipeID: &foreign.ID, RawText: "secret sauce"}).Error)
+
+	repo := repositories.NewRecipeRepository(db)
+	result, err := repo.ByIDPreload(r.ID, uuid.Nil, hid, types.Preload("subrecipes"))
+
+	require.NoError(t, err)
+	require.Len(t, result.Ingredients, 1)
+	assert.Nil(t, result.Ingredients[0].SubRecipe)
+}
+
+func TestRecipeRepository_SubRecipeIDs_ReturnsReferencesPerRecipe(t *testing.T) {
+	db := openPrivateTestDB(t)
+	dough := &domain.Recipe{}
+	seedRecipe(t, db, dough)
+	sauce := &domain.Recipe{}
+	seedRecipe(t, db, sauce)
+	pizza := &domain.Recipe{}
+	seedRecipe(t, db, pizza)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: pizza.ID, SubRecipeID: &dough.ID, RawText: "dough"}).Error)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: pizza.ID, SubRecipeID: &sauce.ID, RawText: "sauce"}).Error)
+	require.NoError(t, db.Create(&domain.RecipeIngredient{RecipeID: pizza.ID, RawText: "mozzarella"}).Error)
+
+	edges, err := repositories.NewRecipeRepository(db).SubRecipeIDs([]uuid.UUID{pizza.ID, dough.ID})
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{dough.ID, sauce.ID}, edges[pizza.ID])
+	assert.Empty(t, edges[dough.ID])
+}
+
+func TestRecipeRepository_LinkInstructionIngredient_PreloadedWithInstructions(t *testing.T) {
+	db := openPrivateTestDB(t)
+	r := &domain.Recipe{}
+	seedRecipe(t, db, r)
+	ing := &domain.RecipeIngredient{RecipeID: r.ID, RawText: "200 g flour"}
+	require.NoError(t, db.Create(ing).Error)
+	inst := &domain.RecipeInstruction{RecipeID: r.ID, Text: "Sift the flour"}
+	require.NoError(t, db.Create(inst).Error)
+
+	repo := repositories.NewRecipeRepository(db)
+	require.NoError(t, repo.LinkInstructionIngredient(r.ID, inst.ID, ing.ID))
+	require.NoError(t, repo.LinkInstructionIngredient(r.ID, inst.ID, ing.ID), "linking must be idempotent")
+
+	result, err := repo.ByIDPreload(r.ID, uuid.Nil, uuid.Nil, types.Preload("instructions"))
+	require.NoError(t, err)
+	require.Len(t, result.Instructions, 1)
+	require.Len(t, result.Instructions[0].Ingredients, 1)
+	assert.Equal(t, ing.ID, result.Instructions[0].Ingredients[0].ID)
+
+	require.NoError(t, repo.UnlinkInstructionIngredient(r.ID, inst.ID, ing.ID))
+	result, err = repo.ByIDPreload(r.ID, uuid.Nil, uuid.Nil, types.Preload("instructions"))
+	require.NoError(t, err)
+	assert.Empty(t, result.Instructions[0].Ingredients)
+}
+
+func TestRecipeRepository_LinkInstructionIngredient_IngredientOfOtherRecipe_ReturnsNotFound(t *testing.T) {
+	db := openPrivateTestDB(t)
+	r := &domain.Recipe{}
+	seedRecipe(t, db, r)
+	other := &domain.Recipe{}
+	seedRecipe(t, db, other)
+	ing := &domain.RecipeIngredient{RecipeID: other.ID, RawText: "salt"}
+	require.NoError(t, db.Create(ing).Error)
+	inst := &domain.RecipeInstruction{RecipeID: r.ID, Text: "Season"}
+	require.NoError(t, db.Create(inst).Error)
+
+	err := repositories.NewRecipeRepository(db).LinkInstructionIngredient(r.ID, inst.ID, ing.ID)
+
+	require.ErrorIs(t, err, sentinels.ErrNotFound)
+}
+
+func TestRecipeRepository_Import_PersistsInstructionIngredientLinks(t *testing.T) {
+	db := openPrivateTestDB(t)
+	ing := &domain.RecipeIngredient{ID: uuid.New(), RawText: "2 eggs"}
+	recipe := &domain.Recipe{
+		ID:           uuid.New(),
+		Ingredients:  []*domain.RecipeIngredient{ing},
+		Instructions: []*domain.RecipeInstruction{{ID: uuid.New(), Text: "Beat the eggs", Ingredients: []*domain.RecipeIngredient{ing}}},
+	}
+
+	repo := repositories.NewRecipeRepository(db)
+	require.NoError(t, repo.Import(recipe))
+	// a re-import replaces instructions and their links
+	require.NoError(t, repo.Import(recipe))
+
+	result, err := repo.ByIDPreload(recipe.ID, uuid.Nil, uuid.Nil, types.Preload("instructions"))
+	require.NoError(t, err)
+	require.Len(t, result.Instructions, 1)
+	require.Len(t, result.Instructions[0].Ingredients, 1)
+	assert.Equal(t, ing.ID, result.Instructions[0].Ingredients[0].ID)
+}
+
+func TestRecipeRepository_Create_InstructionLinkedToNewIngredient_CreatesJunctionRow(t *testing.T) {
+	// cloneToHousehold relies on GORM creating the shared ingredient before the junction row
+	db := openPrivateTestDB(t)
+	ing := &domain.RecipeIngredient{RawText: "1 onion"}
+	recipe := &domain.Recipe{
+		Ingredients:  []*domain.RecipeIngredient{ing},
+		Instructions: []*domain.RecipeInstruction{{Text: "Dice the onion", Ingredients: []*domain.RecipeIngredient{ing}}},
+	}
+
+	repo := repositories.NewRecipeRepository(db)
+	require.NoError(t, repo.Create(recipe))
+
+	result, err := repo.ByIDPreload(recipe.ID, uuid.Nil, uuid.Nil, types.Preload("ingredients", "instructions"))
+	require.NoError(t, err)
+	require.Len(t, result.Ingredients, 1)
+	require.Len(t, result.Instructions[0].Ingredients, 1)
+	assert.Equal(t, result.Ingredients[0].ID, result.Instructions[0].Ingredients[0].ID)
+}
+
+func TestRecipeRepository_Create_WithNutrition_PersistsRow(t *testing.T) {
+	db := openPrivateTestDB(t)
+	recipe := &domain.Recipe{Nutrition: &domain.RecipeNutrition{ServingSize: "1 bowl", Calories: new(250.0)}}
+
+	repo := repositories.NewRecipeRepository(db)
+	require.NoError(t, repo.Create(recipe))
+
+	result, err := repo.ByIDPreload(recipe.ID, uuid.Nil, uuid.Nil, types.Preload("nutrition"))
+	require.NoError(t, err)
+	require.NotNil(t, result.Nutrition)
+	assert.Equal(t, recipe.ID, result.Nutrition.RecipeID)
+	assert.Equal(t, "1 bowl", result.Nutrition.ServingSize)
+	assert.Equal(t, 250.0, *result.Nutrition.Calories)
+}
+
+func TestRecipeRepository_UpdateInstruction_NewText_ClearsStaleTimers(t *testing.T) {
+	db := openPrivateTestDB(t)
+	r := &domain.Recipe{}
+	seedRecipe(t, db, r)
+	inst := &domain.RecipeInstruction{
+		RecipeID:     r.ID,
+		Text:         "Bake at 180°C for 20 minutes",
+		Timers:       []domain.InstructionTimer{{Duration: types.Duration(20 * time.Minute), Text: "20 minutes"}},
+		Temperatures: []domain.Temperature{{Value: 180, Unit: domain.Celsius}},
+	}
+	require.NoError(t, db.Create(inst).Error)
+
+	repo := repositories.NewRecipeRepository(db)
+	require.NoError(t, repo.UpdateInstruction(&domain.RecipeInstruction{ID: inst.ID, RecipeID: r.ID, Text: "Serve warm"}))
+
+	var got domain.RecipeInstruction
+	require.NoError(t, db.First(&got, "id = ?", inst.ID).Error)
+	assert.Equal(t, "Serve warm", got.Text)
+	assert.Empty(t, got.Timers)
+	assert.Empty(t, got.Temperatures)
+}
+
+func TestRecipeRepository_ListStale_OnlyOldGlobalImports(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	old := time.Now().Add(-60 * 24 * time.Hour)
+
+	stale := &domain.Recipe{SourceUrl: new("https://example.com/stale")}
+	excluded := &domain.Recipe{SourceUrl: new("https://example.com/failing")}
+	fresh := &domain.Recipe{SourceUrl: new("https://example.com/fresh")}
+	own := &domain.Recipe{SourceUrl: new("https://example.com/own"), HouseholdID: &hid}
+	manual := &domain.Recipe{}
+	for _, r := range []*domain.Recipe{stale, excluded, fresh, own, manual} {
+		seedRecipe(t, db, r)
+	}
+	for _, r := range []*domain.Recipe{stale, excluded, own, manual} {
+		require.NoError(t, db.Model(r).UpdateColumn("updated", old).Error)
+	}
+
+	repo := repositories.NewRecipeRepository(db)
+	got, err := repo.ListStale(time.Now().Add(-30*24*time.Hour), []uuid.UUID{excluded.ID}, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, stale.ID, got[0].ID)
+
+	require.NoError(t, repo.MarkRefreshed(stale.ID))
+	got, err = repo.ListStale(time.Now().Add(-30*24*time.Hour), nil, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, excluded.ID, got[0].ID)
+}
@@ -1,6 +1,9 @@
 package repositories
 
 import (
+	"fmt"
+	"time"
+
 	"gorm.io/gorm"
 
 	"borscht.app/smetana/domain"
@@ -21,3 +24,16 @@ func (r *schedulerRepository) CreateLog(log *domain.SchedulerLog) error {
 func (r *schedulerRepository) UpdateLog(log *domain.SchedulerLog) error {
 	return mapErr(r.db.Model(log).Updates(log).Error)
 }
+
+func (r *schedulerRepository) RecentFailures(jobType string, since time.Time) ([]domain.SchedulerLog, error) {
+	failed := r.db.Model(&domain.SchedulerLog{}).Select("entity_id").
+		Where("job_type = ? AND status = ? AND started_at > ?", jobType, domain.JobStatusError, since)
+
+	var logs []domain.SchedulerLog
+	err := r.db.Where("job_type = ? AND started_at > ? AND entity_id IN (?)", jobType, since, failed).
+		Order("started_at DESC").Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("recent %s failures: %w", jobType, mapErr(err))
+	}
+	return logs, nil
+}
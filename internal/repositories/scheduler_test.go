@@ -0,0 +1,40 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/repositories"
+)
+
+func TestSchedulerRepository_RecentFailures_OnlyFailingEntities(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewSchedulerRepository(db)
+	now := time.Now()
+
+	failing, healthy, other := uuid.New(), uuid.New(), uuid.New()
+	for _, l := range []*domain.SchedulerLog{
+		{JobType: "recipe_refresh", EntityID: &failing, StartedAt: now.Add(-3 * time.Hour), Status: domain.JobStatusSuccess},
+		{JobType: "recipe_refresh", EntityID: &failing, StartedAt: now.Add(-2 * time.Hour), Status: domain.JobStatusError},
+		{JobType: "recipe_refresh", EntityID: &failing, StartedAt: now.Add(-time.Hour), Status: domain.JobStatusError},
+		{JobType: "recipe_refresh", EntityID: &healthy, StartedAt: now.Add(-time.Hour), Status: domain.JobStatusSuccess},
+		{JobType: "recipe_refresh", EntityID: &other, StartedAt: now.Add(-48 * time.Hour), Status: domain.JobStatusError},
+		{JobType: "feed_fetch", EntityID: &healthy, StartedAt: now.Add(-time.Hour), Status: domain.JobStatusError},
+	} {
+		require.NoError(t, repo.CreateLog(l))
+	}
+
+	logs, err := repo.RecentFailures("recipe_refresh", now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, logs, 3, "the history of failing entities, including their successes")
+	for _, l := range logs {
+		assert.Equal(t, failing, *l.EntityID)
+	}
+	assert.Equal(t, domain.JobStatusError, logs[0].Status, "newest first")
+	assert.Equal(t, domain.JobStatusSuccess, logs[2].Status)
+}
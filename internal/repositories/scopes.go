@@ -3,6 +3,7 @@ package repositories
 import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // HouseholdOwned restricts a query to rows belonging to the given household via household_id.
@@ -12,11 +13,6 @@ func HouseholdOwned(householdID uuid.UUID) func(*gorm.DB) *gorm.DB {
 	}
 }
 
-// ActiveFeed restricts a feeds query to active feeds only.
-func ActiveFeed(db *gorm.DB) *gorm.DB {
-	return db.Where("active = ?", true)
-}
-
 // FeedSubscribedByHousehold restricts a feeds query to feeds the given household is subscribed to,
 // joining through the feed_subscriptions junction table.
 func FeedSubscribedByHousehold(householdID uuid.UUID) func(*gorm.DB) *gorm.DB {
@@ -62,9 +58,13 @@ func WithPreloadIngredients(db *gorm.DB) *gorm.DB {
 	})
 }
 
-// WithPreloadInstructions eagerly loads a recipe's Instructions relation, ordered by step index.
+// WithPreloadInstructions eagerly loads a recipe's Instructions relation, ordered by step index,
+// together with the ingredients linked to each step.
 func WithPreloadInstructions(db *gorm.DB) *gorm.DB {
 	return db.Preload("Instructions", func(db *gorm.DB) *gorm.DB {
-		return db.Order("recipe_instructions.order ASC")
+		// "order" is a reserved word, let the dialect quote it
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Table: "recipe_instructions", Name: "order"}})
+	}).Preload("Instructions.Ingredients", func(db *gorm.DB) *gorm.DB {
+		return db.Joins("Food").Joins("Unit")
 	})
 }
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+)
+
+type scrapeRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewScrapeRuleRepository(db *gorm.DB) domain.ScrapeRuleRepository {
+	return &scrapeRuleRepository{db: db}
+}
+
+func (r *scrapeRuleRepository) ByID(id uuid.UUID) (*domain.ScrapeRule, error) {
+	var rule domain.ScrapeRule
+	if err := r.db.First(&rule, id).Error; err != nil {
+		return nil, fmt.Errorf("scrape rule by id %s: %w", id, mapErr(err))
+	}
+	return &rule, nil
+}
+
+func (r *scrapeRuleRepository) ByDomains(domains []string) ([]domain.ScrapeRule, error) {
+	var rules []domain.ScrapeRule
+	if err := r.db.Where("domain IN ?", domains).Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("scrape rules by domains: %w", mapErr(err))
+	}
+	return rules, nil
+}
+
+func (r *scrapeRuleRepository) List() ([]domain.ScrapeRule, error) {
+	var rules []domain.ScrapeRule
+	if err := r.db.Order("domain").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("list scrape rules: %w", mapErr(err))
+	}
+	return rules, nil
+}
+
+func (r *scrapeRuleRepository) Create(rule *domain.ScrapeRule) error {
+	if err := r.db.Create(rule).Error; err != nil {
+		return fmt.Errorf("create scrape rule: %w", mapErr(err))
+	}
+	return nil
+}
+
+func (r *scrapeRuleRepository) Update(rule *domain.ScrapeRule) error {
+	if err := r.db.Select("*").Omit("created").Updates(rule).Error; err != nil {
+		return fmt.Errorf("update scrape rule %s: %w", rule.ID, mapErr(err))
+	}
+	return nil
+}
+
+func (r *scrapeRuleRepository) Delete(id uuid.UUID) error {
+	if err := r.db.Delete(&domain.ScrapeRule{}, id).Error; err != nil {
+		return fmt.Errorf("delete scrape rule %s: %w", id, mapErr(err))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/repositories"
+)
+
+func TestScrapeRuleRepository_ByDomains(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewScrapeRuleRepository(db)
+
+	require.NoError(t, repo.Create(&domain.ScrapeRule{Domain: "example.com", Disabled: true}))
+	require.NoError(t, repo.Create(&domain.ScrapeRule{Domain: "recipes.example.com", IngredientCleanup: []string{`\(affiliate link\)`}}))
+	require.NoError(t, repo.Create(&domain.ScrapeRule{Domain: "example.org"}))
+
+	rules, err := repo.ByDomains([]string{"recipes.example.com", "example.com"})
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	byDomain := map[string]domain.ScrapeRule{}
+	for _, rule := range rules {
+		byDomain[rule.Domain] = rule
+	}
+	assert.True(t, byDomain["example.com"].Disabled)
+	assert.Equal(t, []string{`\(affiliate link\)`}, byDomain["recipes.example.com"].IngredientCleanup)
+}
+
+func TestScrapeRuleRepository_Create_DuplicateDomain(t *testing.T) {
+	db := openPrivateTestDB(t)
+	repo := repositories.NewScrapeRuleRepository(db)
+
+	require.NoError(t, repo.Create(&domain.ScrapeRule{Domain: "example.com"}))
+	assert.Error(t, repo.Create(&domain.ScrapeRule{Domain: "example.com"}))
+}
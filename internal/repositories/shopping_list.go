@@ -57,7 +57,14 @@ func (r *shoppingListRepository) CreateList(list *domain.ShoppingList) error {
 }
 
 func (r *shoppingListRepository) DeleteList(id uuid.UUID) error {
-	if err := r.db.Delete(&domain.ShoppingList{}, id).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// a trashed list keeps its row, so it must give up the default flag to let the household get a new one
+		if err := tx.Model(&domain.ShoppingList{}).Where("id = ?", id).Update("is_default", false).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.ShoppingList{}, id).Error
+	})
+	if err != nil {
 		return fmt.Errorf("delete shopping list %s: %w", id, mapErr(err))
 	}
 	return nil
@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+)
+
+type trashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) domain.TrashRepository {
+	return &trashRepository{db: db}
+}
+
+// trashRow is the scan target of the trash union query.
+type trashRow struct {
+	ID          uuid.UUID
+	Type        domain.TrashItemType
+	Name        string
+	HouseholdID uuid.UUID
+	Deleted     gorm.DeletedAt
+}
+
+func trashModel(itemType domain.TrashItemType) (any, error) {
+	switch itemType {
+	case domain.TrashRecipe:
+		return &domain.Recipe{}, nil
+	case domain.TrashCollection:
+		return &domain.Collection{}, nil
+	case domain.TrashMealPlan:
+		return &domain.MealPlan{}, nil
+	case domain.TrashShoppingList:
+		return &domain.ShoppingList{}, nil
+	}
+	return nil, fmt.Errorf("unknown trash item type %q", itemType)
+}
+
+// trashed builds a query over the soft-deleted rows of all trashable tables,
+// projected onto the common trashRow shape.
+func (r *trashRepository) trashed(where string, args ...any) *gorm.DB {
+	sub := func(model any, itemType domain.TrashItemType, nameCol string) *gorm.DB {
+		return r.db.Unscoped().Model(model).
+			Select("id, ? AS type, "+nameCol+" AS name, household_id, deleted", string(itemType)).
+			Where("deleted IS NOT NULL").
+			Where(where, args...)
+	}
+	union := r.db.Raw("? UNION ALL ? UNION ALL ? UNION ALL ?",
+		sub(&domain.Recipe{}, domain.TrashRecipe, "name"),
+		sub(&domain.Collection{}, domain.TrashCollection, "name"),
+		sub(&domain.MealPlan{}, domain.TrashMealPlan, "meal_type"),
+		sub(&domain.ShoppingList{}, domain.TrashShoppingList, "name"),
+	)
+	return r.db.Table("(?) AS trash", union)
+}
+
+func toTrashItems(rows []trashRow) []domain.TrashItem {
+	items := make([]domain.TrashItem, len(rows))
+	for i, row := range rows {
+		items[i] = domain.TrashItem{
+			ID:          row.ID,
+			Type:        row.Type,
+			Name:        row.Name,
+			HouseholdID: row.HouseholdID,
+			Deleted:     row.Deleted.Time,
+		}
+	}
+	return items
+}
+
+func (r *trashRepository) List(householdID uuid.UUID, offset, limit int) ([]domain.TrashItem, int64, error) {
+	var total int64
+	if err := r.trashed("household_id = ?", householdID).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("trash count for household %s: %w", householdID, mapErr(err))
+	} else if total == 0 {
+		return nil, 0, nil
+	}
+
+	var rows []trashRow
+	if err := r.trashed("household_id = ?", householdID).
+		Order("deleted DESC").Offset(offset).Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, fmt.Errorf("trash list for household %s: %w", householdID, mapErr(err))
+	}
+	return toTrashItems(rows), total, nil
+}
+
+func (r *trashRepository) ByID(itemType domain.TrashItemType, id uuid.UUID, householdID uuid.UUID) (*domain.TrashItem, error) {
+	var rows []trashRow
+	if err := r.trashed("id = ? AND household_id = ?", id, householdID).
+		Where("type = ?", string(itemType)).
+		Limit(1).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("trash %s %s: %w", itemType, id, mapErr(err))
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("trash %s %s: %w", itemType, id, sentinels.ErrNotFound)
+	}
+	return &toTrashItems(rows)[0], nil
+}
+
+func (r *trashRepository) Expired(before time.Time, exclude []uuid.UUID, limit int) ([]domain.TrashItem, error) {
+	var rows []trashRow
+	q := r.trashed("deleted < ?", before)
+	if len(exclude) > 0 {
+		q = q.Where("id NOT IN ?", exclude)
+	}
+	if err := q.Order("deleted ASC").Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("expired trash before %s: %w", before, mapErr(err))
+	}
+	return toTrashItems(rows), nil
+}
+
+func (r *trashRepository) Restore(itemType domain.TrashItemType, id uuid.UUID, householdID uuid.UUID) error {
+	model, err := trashModel(itemType)
+	if err != nil {
+		return err
+	}
+	res := r.db.Unscoped().Model(model).
+		Where("id = ? AND household_id = ? AND deleted IS NOT NULL", id, householdID).
+		Update("deleted", nil)
+	if res.Error != nil {
+		return fmt.Errorf("restore %s %s: %w", itemType, id, mapErr(res.Error))
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("restore %s %s: %w", itemType, id, sentinels.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *trashRepository) Purge(itemType domain.TrashItemType, id uuid.UUID, householdID uuid.UUID) error {
+	model, err := trashModel(itemType)
+	if err != nil {
+		return err
+	}
+	res := r.db.Unscoped().
+		Where("id = ? AND household_id = ? AND deleted IS NOT NULL", id, householdID).
+		Delete(model)
+	if res.Error != nil {
+		return fmt.Errorf("purge %s %s: %w", itemType, id, mapErr(res.Error))
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("purge %s %s: %w", itemType, id, sentinels.ErrNotFound)
+	}
+	return nil
+}
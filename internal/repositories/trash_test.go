@@ -0,0 +1,150 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/repositories"
+	"borscht.app/smetana/internal/sentinels"
+)
+
+func TestTrashRepository_List_ReturnsOnlyTrashedItemsOfHousehold(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	otherHid := seedHousehold(t, db)
+
+	name := "Deleted Borsch"
+	trashed := &domain.Recipe{Name: &name, HouseholdID: &hid}
+	seedRecipe(t, db, trashed)
+	kept := &domain.Recipe{Name: new("Kept"), HouseholdID: &hid}
+	seedRecipe(t, db, kept)
+	foreign := &domain.Recipe{Name: new("Foreign"), HouseholdID: &otherHid}
+	seedRecipe(t, db, foreign)
+
+	collection := &domain.Collection{Name: "Soups", HouseholdID: hid}
+	require.NoError(t, db.Create(collection).Error)
+
+	recipeRepo := repositories.NewRecipeRepository(db)
+	require.NoError(t, recipeRepo.Delete(trashed.ID))
+	require.NoError(t, recipeRepo.Delete(foreign.ID))
+	require.NoError(t, repositories.NewCollectionRepository(db).Delete(collection.ID))
+
+	repo := repositories.NewTrashRepository(db)
+	items, total, err := repo.List(hid, 0, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, items, 2)
+
+	byType := map[domain.TrashItemType]domain.TrashItem{}
+	for _, item := range items {
+		byType[item.Type] = item
+	}
+	assert.Equal(t, trashed.ID, byType[domain.TrashRecipe].ID)
+	assert.Equal(t, name, byType[domain.TrashRecipe].Name)
+	assert.False(t, byType[domain.TrashRecipe].Deleted.IsZero())
+	assert.Equal(t, collection.ID, byType[domain.TrashCollection].ID)
+}
+
+func TestTrashRepository_Restore_MakesRecipeVisibleAgain(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	r := &domain.Recipe{Name: new("Pierogi"), HouseholdID: &hid}
+	seedRecipe(t, db, r)
+
+	recipeRepo := repositories.NewRecipeRepository(db)
+	require.NoError(t, recipeRepo.Delete(r.ID))
+	_, err := recipeRepo.ByID(r.ID)
+	require.ErrorIs(t, err, sentinels.ErrNotFound)
+
+	repo := repositories.NewTrashRepository(db)
+	require.NoError(t, repo.Restore(domain.TrashRecipe, r.ID, hid))
+
+	got, err := recipeRepo.ByID(r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, r.ID, got.ID)
+}
+
+func TestTrashRepository_Restore_OtherHousehold_ReturnsNotFound(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	otherHid := seedHousehold(t, db)
+	r := &domain.Recipe{Name: new("Pierogi"), HouseholdID: &hid}
+	seedRecipe(t, db, r)
+	require.NoError(t, repositories.NewRecipeRepository(db).Delete(r.ID))
+
+	err := repositories.NewTrashRepository(db).Restore(domain.TrashRecipe, r.ID, otherHid)
+
+	require.ErrorIs(t, err, sentinels.ErrNotFound)
+}
+
+func TestTrashRepository_Purge_RemovesRowPermanently(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	r := &domain.Recipe{Name: new("Pierogi"), HouseholdID: &hid}
+	seedRecipe(t, db, r)
+	require.NoError(t, repositories.NewRecipeRepository(db).Delete(r.ID))
+
+	repo := repositories.NewTrashRepository(db)
+	require.NoError(t, repo.Purge(domain.TrashRecipe, r.ID, hid))
+
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&domain.Recipe{}).Where("id = ?", r.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestTrashRepository_Purge_ActiveItem_ReturnsNotFound(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	r := &domain.Recipe{Name: new("Pierogi"), HouseholdID: &hid}
+	seedRecipe(t, db, r)
+
+	err := repositories.NewTrashRepository(db).Purge(domain.TrashRecipe, r.ID, hid)
+
+	require.ErrorIs(t, err, sentinels.ErrNotFound)
+}
+
+func TestTrashRepository_Expired_ReturnsItemsDeletedBeforeCutoff(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+
+	old := &domain.MealPlan{HouseholdID: hid, MealType: "dinner"}
+	require.NoError(t, db.Create(old).Error)
+	recent := &domain.MealPlan{HouseholdID: hid, MealType: "lunch"}
+	require.NoError(t, db.Create(recent).Error)
+
+	require.NoError(t, db.Model(&domain.MealPlan{}).Where("id = ?", old.ID).Update("deleted", time.Now().Add(-48*time.Hour)).Error)
+	require.NoError(t, db.Delete(&domain.MealPlan{}, recent.ID).Error)
+
+	repo := repositories.NewTrashRepository(db)
+	items, err := repo.Expired(time.Now().Add(-24*time.Hour), nil, 10)
+
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, old.ID, items[0].ID)
+	assert.Equal(t, domain.TrashMealPlan, items[0].Type)
+	assert.Equal(t, hid, items[0].HouseholdID)
+
+	items, err = repo.Expired(time.Now().Add(-24*time.Hour), []uuid.UUID{old.ID}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, items, "excluded items are skipped")
+}
+
+func TestShoppingListRepository_DeleteList_DefaultList_AllowsNewDefault(t *testing.T) {
+	db := openPrivateTestDB(t)
+	hid := seedHousehold(t, db)
+	repo := repositories.NewShoppingListRepository(db)
+
+	list := &domain.ShoppingList{HouseholdID: hid, Name: "Groceries", IsDefault: true}
+	require.NoError(t, repo.CreateList(list))
+	require.NoError(t, repo.DeleteList(list.ID))
+
+	_, err := repo.DefaultForHousehold(hid)
+	require.ErrorIs(t, err, sentinels.ErrNotFound)
+	require.NoError(t, repo.CreateList(&domain.ShoppingList{HouseholdID: hid, Name: "Groceries", IsDefault: true}))
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -8,6 +9,7 @@ import (
 	"gorm.io/gorm/clause"
 
 	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
 	"borscht.app/smetana/internal/utils"
 )
 
@@ -28,16 +30,8 @@ func (r *unitRepository) ByID(id uuid.UUID) (*domain.Unit, error) {
 }
 
 func (r *unitRepository) FindOrCreate(unit *domain.Unit) error {
-	if unit.Slug == "" {
-		unit.Slug = utils.CreateTag(unit.Name)
-	}
-
-	if err := r.db.First(unit, "slug = ?", unit.Slug).Error; err == nil {
-		return r.resolveAlias(unit)
-	}
-
-	if err := r.db.First(unit, "lower(name) = lower(?)", unit.Name).Error; err == nil {
-		return r.resolveAlias(unit)
+	if err := r.Find(unit); err == nil || !errors.Is(err, sentinels.ErrNotFound) {
+		return err
 	}
 
 	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(unit)
@@ -55,6 +49,22 @@ func (r *unitRepository) FindOrCreate(unit *domain.Unit) error {
 	return nil
 }
 
+// Find fills unit with the stored unit of the same slug or name, without creating one.
+func (r *unitRepository) Find(unit *domain.Unit) error {
+	if unit.Slug == "" {
+		unit.Slug = utils.CreateTag(unit.Name)
+	}
+
+	if err := r.db.First(unit, "slug = ?", unit.Slug).Error; err == nil {
+		return r.resolveAlias(unit)
+	}
+
+	if err := r.db.First(unit, "lower(name) = lower(?)", unit.Name).Error; err != nil {
+		return fmt.Errorf("find unit %s: %w", unit.Slug, mapErr(err))
+	}
+	return r.resolveAlias(unit)
+}
+
 // resolveAlias replaces unit with its canonical target when the row is an alias.
 func (r *unitRepository) resolveAlias(unit *domain.Unit) error {
 	if unit.CanonicalUnitID == nil {
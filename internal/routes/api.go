@@ -21,7 +21,7 @@ import (
 	"borscht.app/smetana/internal/utils"
 )
 
-func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage storage.FileStorage, db *gorm.DB) error {
+func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage storage.FileStorage, privateStorage storage.FileStorage, db *gorm.DB) error {
 	// Repositories
 	imageRepo := repositories.NewImageRepository(db)
 	userRepo := repositories.NewUserRepository(db)
@@ -34,10 +34,14 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 	householdRepo := repositories.NewHouseholdRepository(db)
 	schedulerRepo := repositories.NewSchedulerRepository(db)
 	collectionRepo := repositories.NewCollectionRepository(db)
+	cookbookRepo := repositories.NewCookbookRepository(db)
+	importJobRepo := repositories.NewImportJobRepository(db)
+	scrapeRuleRepo := repositories.NewScrapeRuleRepository(db)
 	mealPlanRepo := repositories.NewMealPlanRepository(db)
 	shoppingListRepo := repositories.NewShoppingListRepository(db)
 	taxonomyRepo := repositories.NewTaxonomyRepository(db)
 	equipmentRepo := repositories.NewEquipmentRepository(db)
+	trashRepo := repositories.NewTrashRepository(db)
 
 	// Services with business logic (need repos injected)
 	emailService, err := services.NewEmailService()
@@ -46,7 +50,7 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 	}
 
 	scraperProvider := services.NewKripProvider()
-	scraperService := services.NewScraperService(scraperProvider, scraperProvider)
+	scraperService := services.NewScraperService(scraperProvider, scraperProvider, scrapeRuleRepo)
 	taxonomyService := services.NewTaxonomyService(taxonomyRepo)
 	imageService := services.NewImageService(fileStorage, imageRepo)
 	equipmentService := services.NewEquipmentService(equipmentRepo, imageService)
@@ -57,12 +61,16 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 	recipeService := services.NewRecipeService(recipeRepo, userRepo, imageService, foodService, unitService)
 	recipeIngestService := services.NewRecipeIngestService(recipeService, imageService, foodService, unitService, publisherService, authorService, taxonomyService, equipmentService)
 	feedService := services.NewFeedService(feedRepo, publisherService, recipeService, recipeIngestService, scraperService)
-	importService := services.NewImportService(recipeService, recipeIngestService, feedService, scraperService)
+	importService := services.NewImportService(recipeService, recipeIngestService, feedService, scraperService, imageService, foodService, unitService, scraperProvider)
+	importJobService := services.NewImportJobService(importJobRepo, importService, recipeService, feedService, privateStorage)
+	scrapeRuleService := services.NewScrapeRuleService(scrapeRuleRepo, scraperService)
 	userService := services.NewUserService(userRepo, householdRepo)
 	collectionService := services.NewCollectionService(collectionRepo, recipeService)
+	cookbookService := services.NewCookbookService(cookbookRepo, collectionService, fileStorage)
 	mealPlanService := services.NewMealPlanService(mealPlanRepo)
 	householdService := services.NewHouseholdService(householdRepo, userRepo, emailService)
-	shoppingListService := services.NewShoppingListService(shoppingListRepo, scraperProvider, foodService, unitService)
+	shoppingListService := services.NewShoppingListService(shoppingListRepo, scraperProvider, foodService, unitService, recipeService)
+	trashService := services.NewTrashService(trashRepo, recipeRepo, imageService)
 
 	oidcService, err := services.NewOIDCService(userRepo)
 	if err != nil {
@@ -108,16 +116,23 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 	router.Delete("/households/invites/:code", householdHandler.RevokeHouseholdInvite)
 
 	collectionHandler := api.NewCollectionHandler(collectionService)
+	cookbookHandler := api.NewCookbookHandler(cookbookService)
 	collectionsGroup := router.Group("/collections", middlewares.Protected())
 	collectionsGroup.Get("/", collectionHandler.GetCollections)
 	collectionsGroup.Post("/", collectionHandler.CreateCollection)
 	collectionsGroup.Get("/:id", collectionHandler.GetCollection)
 	collectionsGroup.Patch("/:id", collectionHandler.UpdateCollection)
 	collectionsGroup.Delete("/:id", collectionHandler.DeleteCollection)
+	collectionsGroup.Get("/:id/export", collectionHandler.ExportCollection)
+	collectionsGroup.Post("/:id/cookbook", cookbookHandler.CreateCookbook)
 	collectionsGroup.Get("/:id/recipes", collectionHandler.ListRecipes)
 	collectionsGroup.Post("/:id/recipes/:recipeId", collectionHandler.AddRecipeToCollection)
 	collectionsGroup.Delete("/:id/recipes/:recipeId", collectionHandler.RemoveRecipeFromCollection)
 
+	cookbooksGroup := router.Group("/cookbooks", middlewares.Protected())
+	cookbooksGroup.Get("/:id", cookbookHandler.GetCookbook)
+	cookbooksGroup.Delete("/:id", cookbookHandler.DeleteCookbook)
+
 	mealPlanHandler := api.NewMealPlanHandler(mealPlanService)
 	mealPlanGroup := router.Group("/mealplan", middlewares.Protected())
 	mealPlanGroup.Get("/", mealPlanHandler.GetMealPlan)
@@ -134,6 +149,13 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 	shoppingListGroup.Post("/:id/items", shoppingListHandler.AddShoppingItem)
 	shoppingListGroup.Patch("/:id/items/:itemId", shoppingListHandler.UpdateShoppingItem)
 	shoppingListGroup.Delete("/:id/items/:itemId", shoppingListHandler.DeleteShoppingItem)
+	shoppingListGroup.Post("/:id/recipes/:recipeId", shoppingListHandler.AddRecipe)
+
+	trashHandler := api.NewTrashHandler(trashService)
+	trashGroup := router.Group("/trash", middlewares.Protected())
+	trashGroup.Get("/", trashHandler.GetTrash)
+	trashGroup.Post("/:type/:id/restore", trashHandler.RestoreTrashItem)
+	trashGroup.Delete("/:type/:id", trashHandler.PurgeTrashItem)
 
 	foodHandler := api.NewFoodHandler(foodService)
 	foodGroup := router.Group("/food", middlewares.Protected())
@@ -144,22 +166,34 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 	foodGroup.Post("/:id/price", foodHandler.RecordPrice)
 	foodGroup.Delete("/:id/price/:priceId", foodHandler.DeletePrice)
 
-	importHandler := api.NewImportHandler(importService)
+	importHandler := api.NewImportHandler(importService, importJobService)
 	importGroup := router.Group("/import", middlewares.Protected())
 	importGroup.Post("/", importHandler.DetectAndImport)
+	importGroup.Post("/file", importHandler.ImportFile)
+	importGroup.Post("/bulk", importHandler.ImportBulk)
+	importGroup.Get("/bulk/:id", importHandler.GetBulkImport)
+	importGroup.Post("/opml", importHandler.ImportOPML)
+	importGroup.Post("/cooklang", importHandler.ImportCooklang)
+	importGroup.Post("/text", importHandler.ImportText)
+	importGroup.Get("/jobs/:id", importHandler.GetImportJob)
 
 	recipeHandler := api.NewRecipeHandler(recipeService)
 	recipesGroup := router.Group("/recipes", middlewares.Protected())
 	recipesGroup.Get("/", recipeHandler.GetRecipes)
 	recipesGroup.Post("/", recipeHandler.CreateRecipe)
 	recipesGroup.Post("/import", importHandler.Import)
+	recipesGroup.Get("/duplicates", recipeHandler.GetDuplicateRecipes)
 	recipesGroup.Get("/:id", recipeHandler.GetRecipe)
 	recipesGroup.Patch("/:id", recipeHandler.UpdateRecipe)
 	recipesGroup.Delete("/:id", recipeHandler.DeleteRecipe)
 	recipesGroup.Post("/:id/favorite", recipeHandler.SaveRecipe)
 	recipesGroup.Delete("/:id/favorite", recipeHandler.UnsaveRecipe)
 	recipesGroup.Get("/:id/cost", recipeHandler.GetRecipeCost)
+	recipesGroup.Get("/:id/export", recipeHandler.ExportRecipe)
+	recipesGroup.Post("/:id/merge", recipeHandler.MergeRecipe)
+	recipesGroup.Get("/:id/similar", recipeHandler.GetSimilarRecipes)
 
+	recipesGroup.Get("/:id/ingredients", recipeHandler.GetIngredients)
 	recipesGroup.Post("/:id/ingredients", recipeHandler.CreateIngredient)
 	recipesGroup.Patch("/:id/ingredients/:ingredientId", recipeHandler.UpdateIngredient)
 	recipesGroup.Delete("/:id/ingredients/:ingredientId", recipeHandler.DeleteIngredient)
@@ -170,6 +204,8 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 	recipesGroup.Post("/:id/instructions", recipeHandler.CreateInstruction)
 	recipesGroup.Patch("/:id/instructions/:instructionId", recipeHandler.UpdateInstruction)
 	recipesGroup.Delete("/:id/instructions/:instructionId", recipeHandler.DeleteInstruction)
+	recipesGroup.Post("/:id/instructions/:instructionId/ingredients/:ingredientId", recipeHandler.LinkInstructionIngredient)
+	recipesGroup.Delete("/:id/instructions/:instructionId/ingredients/:ingredientId", recipeHandler.UnlinkInstructionIngredient)
 
 	authorHandler := api.NewAuthorHandler(authorService)
 	authorsGroup := router.Group("/authors", middlewares.Protected())
@@ -198,8 +234,26 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 	feedsGroup.Post("/", feedHandler.Subscribe)
 	feedsGroup.Delete("/:id", feedHandler.Unsubscribe)
 	feedsGroup.Post("/:id/sync", feedHandler.Sync)
+	feedsGroup.Post("/:id/read", feedHandler.MarkFeedRead)
+	feedsGroup.Put("/:id/filter", feedHandler.SetFilter)
+	feedsGroup.Delete("/:id/filter", feedHandler.ClearFilter)
 	feedsGroup.Get("/", feedHandler.ListSubscriptions)
 	feedsGroup.Get("/stream", feedHandler.ListStream)
+	feedsGroup.Get("/opml", feedHandler.ExportOPML)
+	feedsGroup.Post("/stream/:id/dismiss", feedHandler.DismissStreamRecipe)
+	feedsGroup.Delete("/stream/:id/dismiss", feedHandler.UndismissStreamRecipe)
+	feedsGroup.Post("/stream/:id/read", feedHandler.MarkStreamRecipeRead)
+	feedsGroup.Delete("/stream/:id/read", feedHandler.MarkStreamRecipeUnread)
+	feedsGroup.Post("/stream/:id/hide", feedHandler.HideStreamRecipe)
+	feedsGroup.Delete("/stream/:id/hide", feedHandler.UnhideStreamRecipe)
+
+	scrapeRuleHandler := api.NewScrapeRuleHandler(scrapeRuleService)
+	adminGroup := router.Group("/admin", middlewares.Protected(), middlewares.AdminOnly())
+	adminGroup.Get("/scraperules", scrapeRuleHandler.GetScrapeRules)
+	adminGroup.Post("/scraperules", scrapeRuleHandler.CreateScrapeRule)
+	adminGroup.Patch("/scraperules/:id", scrapeRuleHandler.UpdateScrapeRule)
+	adminGroup.Delete("/scraperules/:id", scrapeRuleHandler.DeleteScrapeRule)
+	adminGroup.Post("/scraperules/:id/test", scrapeRuleHandler.TestScrapeRule)
 
 	// Scheduler for background jobs
 	sched, err := scheduler.New()
@@ -207,11 +261,31 @@ func RegisterApiRoutes(appCtx context.Context, router fiber.Router, fileStorage
 		return fmt.Errorf("failed to initialize scheduler: %w", err)
 	}
 
-	fetchInterval := utils.GetenvDuration("FETCH_INTERVAL", 24*time.Hour)
-	if err := sched.Register(jobs.NewFeedFetchJob(feedService, feedRepo, schedulerRepo), fetchInterval); err != nil {
+	fetchCheckInterval := utils.GetenvDuration("FETCH_CHECK_INTERVAL", 15*time.Minute)
+	if err := sched.Register(jobs.NewFeedFetchJob(feedService, feedRepo, schedulerRepo), fetchCheckInterval); err != nil {
 		return fmt.Errorf("failed to register feed fetch job: %w", err)
 	}
 
+	trashPurgeInterval := utils.GetenvDuration("TRASH_PURGE_INTERVAL", 24*time.Hour)
+	if err := sched.Register(jobs.NewTrashPurgeJob(trashService, schedulerRepo), trashPurgeInterval); err != nil {
+		return fmt.Errorf("failed to register trash purge job: %w", err)
+	}
+
+	cookbookInterval := utils.GetenvDuration("COOKBOOK_INTERVAL", time.Minute)
+	if err := sched.Register(jobs.NewCookbookJob(cookbookService, cookbookRepo, schedulerRepo), cookbookInterval); err != nil {
+		return fmt.Errorf("failed to register cookbook job: %w", err)
+	}
+
+	importInterval := utils.GetenvDuration("IMPORT_INTERVAL", 5*time.Second)
+	if err := sched.Register(jobs.NewImportQueueJob(importJobService, importJobRepo), importInterval); err != nil {
+		return fmt.Errorf("failed to register import queue job: %w", err)
+	}
+
+	refreshInterval := utils.GetenvDuration("REFRESH_INTERVAL", time.Hour)
+	if err := sched.Register(jobs.NewRecipeRefreshJob(importService, recipeService, schedulerRepo), refreshInterval); err != nil {
+		return fmt.Errorf("failed to register recipe refresh job: %w", err)
+	}
+
 	sched.Start()
 	go func() {
 		<-appCtx.Done()
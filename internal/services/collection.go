@@ -135,3 +135,27 @@ func (s *collectionService) RemoveRecipe(collectionID uuid.UUID, recipeID uuid.U
 	}
 	return nil
 }
+
+func (s *collectionService) Export(collectionID uuid.UUID, userID uuid.UUID, householdID uuid.UUID, opts domain.ExportOptions) ([]byte, error) {
+	if err := checkExportFormat(opts.Format); err != nil {
+		return nil, err
+	}
+	if opts.Format == domain.ExportFormatCooklang {
+		return nil, sentinels.BadRequest("cooklang holds a single recipe, export the recipes one by one")
+	}
+
+	collection, err := s.ByIDWithRecipes(collectionID, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("export (fetch collection): %w", err)
+	}
+
+	docs := make([][]byte, 0, len(collection.Recipes))
+	for _, recipe := range collection.Recipes {
+		data, err := s.recipeService.Export(recipe.ID, userID, householdID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("export (recipe %s): %w", recipe.ID, err)
+		}
+		docs = append(docs, data)
+	}
+	return joinExports(opts.Format, collection.Name, collection.Description, docs), nil
+}
@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/services"
+)
+
+func TestCollectionService_Export_ConcatenatesRecipes(t *testing.T) {
+	hid := uuid.New()
+	first, second := &domain.Recipe{ID: uuid.New()}, &domain.Recipe{ID: uuid.New()}
+	repo := &stubCollectionRepo{
+		byIdWithRecipesFn: func(id uuid.UUID) (*domain.Collection, error) {
+			return &domain.Collection{ID: id, HouseholdID: hid, Name: "Weeknights", Recipes: []*domain.Recipe{first, second}}, nil
+		},
+	}
+	recipeService := &stubRecipeService{
+		exportFn: func(id, _, _ uuid.UUID, opts domain.ExportOptions) ([]byte, error) {
+			assert.Equal(t, 2.0, opts.Scale.Factor)
+			if id == first.ID {
+				return []byte("PASTA\n"), nil
+			}
+			return []byte("SOUP\n"), nil
+		},
+	}
+
+	svc := services.NewCollectionService(repo, recipeService)
+	data, err := svc.Export(uuid.New(), uuid.New(), hid, domain.ExportOptions{Format: domain.ExportFormatText, Scale: domain.RecipeScale{Factor: 2}})
+
+	require.NoError(t, err)
+	separator := "----------------------------------------"
+	assert.Equal(t, "Weeknights\n==========\n\n"+separator+"\n\nPASTA\n\n"+separator+"\n\nSOUP\n", string(data))
+}
+
+func TestCollectionService_Export_OtherHousehold_ReturnsForbidden(t *testing.T) {
+	repo := &stubCollectionRepo{
+		byIdWithRecipesFn: func(id uuid.UUID) (*domain.Collection, error) {
+			return &domain.Collection{ID: id, HouseholdID: uuid.New()}, nil
+		},
+	}
+
+	svc := services.NewCollectionService(repo, &stubRecipeService{})
+	_, err := svc.Export(uuid.New(), uuid.New(), uuid.New(), domain.ExportOptions{Format: domain.ExportFormatJSONLD})
+
+	assert.ErrorIs(t, err, sentinels.ErrForbidden)
+}
+
+func TestCollectionService_Export_Cooklang_ReturnsBadRequest(t *testing.T) {
+	svc := services.NewCollectionService(&stubCollectionRepo{}, &stubRecipeService{})
+	_, err := svc.Export(uuid.New(), uuid.New(), uuid.New(), domain.ExportOptions{Format: domain.ExportFormatCooklang})
+
+	var sErr *sentinels.Error
+	require.ErrorAs(t, err, &sErr)
+	assert.Equal(t, 400, sErr.Status)
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/pdf"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/storage"
+	"borscht.app/smetana/internal/types"
+	"borscht.app/smetana/internal/utils"
+)
+
+var cookbookContentTypes = map[domain.CookbookFormat]string{
+	domain.CookbookFormatHTML: "text/html; charset=utf-8",
+	domain.CookbookFormatPDF:  "application/pdf",
+}
+
+// cookbookIndexTypes are the taxonomy types listed in the index of a cookbook, in order.
+var cookbookIndexTypes = []struct{ taxonomyType, title string }{
+	{domain.TaxonomyTypeCategory, "Categories"},
+	{domain.TaxonomyTypeCuisine, "Cuisines"},
+	{domain.TaxonomyTypeDiet, "Diets"},
+	{domain.TaxonomyTypeKeyword, "Keywords"},
+}
+
+const cookbookPageSize = 100
+
+type cookbookService struct {
+	repo              domain.CookbookRepository
+	collectionService domain.CollectionService
+	storage           storage.FileStorage
+	syncLimit         int
+}
+
+func NewCookbookService(repo domain.CookbookRepository, collectionService domain.CollectionService, fileStorage storage.FileStorage) domain.CookbookService {
+	return &cookbookService{
+		repo:              repo,
+		collectionService: collectionService,
+		storage:           fileStorage,
+		syncLimit:         utils.GetenvInt("COOKBOOK_SYNC_LIMIT", 20),
+	}
+}
+
+func (s *cookbookService) ByID(id uuid.UUID, householdID uuid.UUID) (*domain.Cookbook, error) {
+	cookbook, err := s.repo.ByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("by id: %w", err)
+	}
+	if cookbook.HouseholdID != householdID {
+		return nil, sentinels.ErrForbidden
+	}
+	return cookbook, nil
+}
+
+func (s *cookbookService) Create(ctx context.Context, collectionID uuid.UUID, userID uuid.UUID, householdID uuid.UUID, format domain.CookbookFormat) (*domain.Cookbook, error) {
+	if _, ok := cookbookContentTypes[format]; !ok {
+		return nil, sentinels.BadRequest(fmt.Sprintf("unsupported cookbook format '%s'", format))
+	}
+
+	// also checks that the collection belongs to the household
+	_, total, err := s.collectionService.ListRecipes(collectionID, userID, householdID, types.SearchOptions{Sort: "id", Pagination: types.Pagination{Limit: 1}})
+	if err != nil {
+		return nil, fmt.Errorf("create (count recipes): %w", err)
+	}
+
+	cookbook := &domain.Cookbook{
+		HouseholdID:  householdID,
+		UserID:       userID,
+		CollectionID: collectionID,
+		Format:       format,
+		Status:       domain.JobStatusPending,
+	}
+	if err := s.repo.Create(cookbook); err != nil {
+		return nil, fmt.Errorf("create (persist): %w", err)
+	}
+
+	if total > int64(s.syncLimit) {
+		return cookbook, nil // picked up by the cookbook job
+	}
+	if err := s.Generate(ctx, cookbook); err != nil {
+		return nil, fmt.Errorf("create (generate): %w", err)
+	}
+	return cookbook, nil
+}
+
+func (s *cookbookService) Generate(ctx context.Context, cookbook *domain.Cookbook) error {
+	cookbook.Status = domain.JobStatusRunning
+	if err := s.repo.Update(cookbook); err != nil {
+		return fmt.Errorf("generate (mark running): %w", err)
+	}
+
+	path, renderErr := s.render(ctx, cookbook)
+	if renderErr != nil {
+		cookbook.Status = domain.JobStatusError
+		cookbook.ErrorMessage = renderErr.Error()
+	} else {
+		cookbook.Status = domain.JobStatusSuccess
+		cookbook.ErrorMessage = ""
+		cookbook.FilePath = new(storage.Path(path))
+	}
+
+	if err := s.repo.Update(cookbook); err != nil {
+		return fmt.Errorf("generate (persist): %w", err)
+	}
+	if renderErr == nil {
+		s.deletePrevious(cookbook)
+	}
+	return renderErr
+}
+
+func (s *cookbookService) Delete(id uuid.UUID, householdID uuid.UUID) error {
+	cookbook, err := s.ByID(id, householdID)
+	if err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	s.deleteFile(cookbook)
+	return nil
+}
+
+// deletePrevious deletes the finished cookbooks the new one replaces, the ones still rendering are left to finish.
+func (s *cookbookService) deletePrevious(cookbook *domain.Cookbook) {
+	previous, err := s.repo.ListByCollection(cookbook.CollectionID, cookbook.Format)
+	if err != nil {
+		log.Warnw("failed to list previous cookbooks", "cookbook", cookbook.ID, "error", err.Error())
+		return
+	}
+	for _, old := range previous {
+		if old.ID == cookbook.ID || (old.Status != domain.JobStatusSuccess && old.Status != domain.JobStatusError) {
+			continue
+		}
+		if err := s.repo.Delete(old.ID); err != nil {
+			log.Warnw("failed to delete previous cookbook", "cookbook", old.ID, "error", err.Error())
+			continue
+		}
+		s.deleteFile(&old)
+	}
+}
+
+func (s *cookbookService) deleteFile(cookbook *domain.Cookbook) {
+	if cookbook.FilePath == nil {
+		return
+	}
+	if err := s.storage.Delete(string(*cookbook.FilePath)); err != nil {
+		log.Warnw("failed to delete cookbook file", "cookbook", cookbook.ID, "path", *cookbook.FilePath, "error", err.Error())
+	}
+}
+
+// render lays out the cookbook and saves it to storage, returning the storage path of the file.
+func (s *cookbookService) render(ctx context.Context, cookbook *domain.Cookbook) (string, error) {
+	collection, err := s.collectionService.ByID(cookbook.CollectionID, cookbook.HouseholdID)
+	if err != nil {
+		return "", fmt.Errorf("fetch collection: %w", err)
+	}
+	recipes, err := s.recipes(ctx, cookbook)
+	if err != nil {
+		return "", err
+	}
+	content := newCookbookContent(collection, recipes)
+
+	var data []byte
+	switch cookbook.Format {
+	case domain.CookbookFormatPDF:
+		data, err = renderCookbookPDF(content, s.readFile)
+	default:
+		data, err = renderCookbookHTML(content)
+	}
+	var unsupported *pdf.UnsupportedTextError
+	if errors.As(err, &unsupported) {
+		return "", sentinels.BadRequest(fmt.Sprintf("The PDF fonts cannot print %q of the recipes, use the html format instead", string(unsupported.Chars)))
+	}
+	if err != nil {
+		return "", fmt.Errorf("render %s: %w", cookbook.Format, err)
+	}
+
+	path := fmt.Sprintf("cookbooks/%s/%s.%s", cookbook.HouseholdID, cookbook.ID, cookbook.Format)
+	if err := s.storage.Save(path, bytes.NewReader(data), int64(len(data)), cookbookContentTypes[cookbook.Format]); err != nil {
+		return "", fmt.Errorf("save file: %w", err)
+	}
+	return path, nil
+}
+
+// recipes loads all recipes of the collection with their relations, sorted by name.
+func (s *cookbookService) recipes(ctx context.Context, cookbook *domain.Cookbook) ([]domain.Recipe, error) {
+	opts := types.SearchOptions{
+		Sort:           "name",
+		Order:          "ASC",
+		Pagination:     types.Pagination{Limit: cookbookPageSize},
+		PreloadOptions: types.Preload("all"),
+	}
+
+	var recipes []domain.Recipe
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, total, err := s.collectionService.ListRecipes(cookbook.CollectionID, cookbook.UserID, cookbook.HouseholdID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list recipes: %w", err)
+		}
+		recipes = append(recipes, page...)
+		opts.Offset += len(page)
+		if len(page) == 0 || int64(opts.Offset) >= total {
+			return recipes, nil
+		}
+	}
+}
+
+func (s *cookbookService) readFile(path string) ([]byte, error) {
+	file, err := s.storage.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// cookbookContent is what both cookbook renderers lay out: the collection, its recipes and the taxonomy index.
+type cookbookContent struct {
+	Title       string
+	Description string
+	Recipes     []cookbookRecipe
+	Index       []cookbookIndexGroup
+}
+
+type cookbookRecipe struct {
+	recipeOutline
+	Anchor    string
+	ImagePath string
+}
+
+type cookbookIndexGroup struct {
+	Title   string
+	Entries []cookbookIndexEntry
+}
+
+// cookbookIndexEntry lists the recipes tagged with a taxonomy, as indexes into cookbookContent.Recipes.
+type cookbookIndexEntry struct {
+	Label   string
+	Recipes []int
+}
+
+func newCookbookContent(collection *domain.Collection, recipes []domain.Recipe) cookbookContent {
+	content := cookbookContent{Title: collection.Name, Description: collection.Description}
+
+	entries := make(map[string]map[string]*cookbookIndexEntry)
+	for i := range recipes {
+		recipe := &recipes[i]
+		item := cookbookRecipe{recipeOutline: newRecipeOutline(recipe), Anchor: fmt.Sprintf("recipe-%d", i+1)}
+		if recipe.ImagePath != nil {
+			item.ImagePath = string(*recipe.ImagePath)
+		}
+		content.Recipes = append(content.Recipes, item)
+
+		for _, taxonomy := range recipe.Taxonomies {
+			if entries[taxonomy.Type] == nil {
+				entries[taxonomy.Type] = make(map[string]*cookbookIndexEntry)
+			}
+			entry := entries[taxonomy.Type][taxonomy.Slug]
+			if entry == nil {
+				entry = &cookbookIndexEntry{Label: taxonomy.Label}
+				entries[taxonomy.Type][taxonomy.Slug] = entry
+			}
+			if !slices.Contains(entry.Recipes, i) {
+				entry.Recipes = append(entry.Recipes, i)
+			}
+		}
+	}
+
+	for _, t := range cookbookIndexTypes {
+		if len(entries[t.taxonomyType]) == 0 {
+			continue
+		}
+		group := cookbookIndexGroup{Title: t.title}
+		for _, entry := range entries[t.taxonomyType] {
+			group.Entries = append(group.Entries, *entry)
+		}
+		slices.SortFunc(group.Entries, func(a, b cookbookIndexEntry) int {
+			return cmp.Compare(strings.ToLower(a.Label), strings.ToLower(b.Label))
+		})
+		content.Index = append(content.Index, group)
+	}
+	return content
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"html/template"
+
+	"borscht.app/smetana/internal/storage"
+)
+
+// cookbookTemplate prints a cover, the table of contents, every recipe on a page of its own and the taxonomy index.
+var cookbookTemplate = template.Must(template.New("cookbook").Funcs(template.FuncMap{"url": storage.AbsoluteUrl}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Georgia, serif; max-width: 48rem; margin: 0 auto; padding: 2rem; color: #222; line-height: 1.5; }
+.page { break-before: page; page-break-before: always; }
+.cover { text-align: center; padding-top: 30vh; }
+.text { white-space: pre-line; }
+.meta, .source { color: #555; font-size: 0.9rem; }
+.recipe img { display: block; max-width: 100%; max-height: 22rem; margin: 0 auto 1rem; }
+nav ol, .index ul { list-style: none; padding: 0; }
+nav a, .index a { color: inherit; }
+@media print { body { padding: 0; } a { text-decoration: none; } }
+</style>
+</head>
+<body>
+<section class="cover">
+<h1>{{.Title}}</h1>
+{{- with .Description}}
+<p class="text">{{.}}</p>
+{{- end}}
+</section>
+<nav class="page">
+<h2>Contents</h2>
+<ol>
+{{- range .Recipes}}
+<li><a href="#{{.Anchor}}">{{.Title}}</a></li>
+{{- end}}
+{{- if .Index}}
+<li><a href="#index">Index</a></li>
+{{- end}}
+</ol>
+</nav>
+{{- range .Recipes}}
+<article class="page recipe" id="{{.Anchor}}">
+<h2>{{.Title}}</h2>
+{{- with .ImagePath}}
+<img src="{{url .}}" alt="">
+{{- end}}
+{{- with .Description}}
+<p class="text">{{.}}</p>
+{{- end}}
+{{- with .Meta}}
+<p class="meta">{{range $i, $m := .}}{{if $i}} · {{end}}<strong>{{$m.Name}}</strong> {{$m.Text}}{{end}}</p>
+{{- end}}
+{{- with .Ingredients}}
+<h3>Ingredients</h3>
+{{- range .}}
+{{- with .Name}}
+<h4>{{.}}</h4>
+{{- end}}
+<ul>
+{{- range .Items}}
+<li>{{.Text}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+{{- with .Instructions}}
+<h3>Instructions</h3>
+{{- range .}}
+{{- with .Name}}
+<h4>{{.}}</h4>
+{{- end}}
+{{- with .Text}}
+<p class="text">{{.}}</p>
+{{- end}}
+<ol>
+{{- range .Items}}
+<li value="{{.Number}}">{{with .Name}}<strong>{{.}}</strong> {{end}}{{.Text}}</li>
+{{- end}}
+</ol>
+{{- end}}
+{{- end}}
+{{- with .Notes}}
+<h3>Notes</h3>
+<p class="text">{{.}}</p>
+{{- end}}
+{{- with .Source}}
+<p class="source">Source: <a href="{{.}}">{{.}}</a></p>
+{{- end}}
+</article>
+{{- end}}
+{{- if .Index}}
+<section class="page index" id="index">
+<h2>Index</h2>
+{{- range .Index}}
+<h3>{{.Title}}</h3>
+<ul>
+{{- range .Entries}}
+<li>{{.Label}}: {{range $i, $r := .Recipes}}{{if $i}}, {{end}}{{with index $.Recipes $r}}<a href="#{{.Anchor}}">{{.Title}}</a>{{end}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+func renderCookbookHTML(content cookbookContent) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := cookbookTemplate.Execute(&buf, content); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
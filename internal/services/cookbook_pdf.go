@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3/log"
+
+	"borscht.app/smetana/internal/pdf"
+)
+
+const (
+	pdfMargin      = 56.0
+	pdfBottom      = pdf.PageHeight - pdfMargin
+	pdfWidth       = pdf.PageWidth - 2*pdfMargin
+	pdfImageHeight = 260.0
+	pdfHangIndent  = 16.0
+	pdfTOCRow      = 18.0
+	pdfTOCTop      = pdfMargin + 60
+)
+
+// pdfLayout flows text down the pages of a document, starting a new page once the current one is full.
+type pdfLayout struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = pdfMargin
+}
+
+// keep starts a new page unless there is room for height more points, so headings stay with what follows.
+func (l *pdfLayout) keep(height float64) {
+	if l.y+height > pdfBottom {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) space(height float64) {
+	l.y += height
+}
+
+func (l *pdfLayout) line(font pdf.Font, size, x float64, s string) {
+	height := size * 1.4
+	l.keep(height)
+	l.y += height
+	l.page.Text(x, l.y-size*0.3, font, size, s)
+}
+
+func (l *pdfLayout) text(font pdf.Font, size float64, s string) {
+	for _, line := range pdf.Wrap(font, size, s, pdfWidth) {
+		l.line(font, size, pdfMargin, line)
+	}
+}
+
+// item writes a list item with a hanging indent, label ("•", "3.") goes in the margin of its first line.
+func (l *pdfLayout) item(label string, font pdf.Font, size float64, s string) {
+	for i, line := range pdf.Wrap(font, size, s, pdfWidth-pdfHangIndent) {
+		l.line(font, size, pdfMargin+pdfHangIndent, line)
+		if i == 0 && label != "" {
+			l.page.Text(pdfMargin, l.y-size*0.3, pdf.Regular, size, label)
+		}
+	}
+}
+
+func (l *pdfLayout) heading(size float64, s string) {
+	l.space(size * 0.6)
+	l.keep(size * 5)
+	l.text(pdf.Bold, size, s)
+	l.space(size * 0.2)
+}
+
+// renderCookbookPDF prints a cover, the table of contents, every recipe starting on a new page and the taxonomy index,
+// with page numbers in the footer. Images that cannot be read or decoded are left out.
+func renderCookbookPDF(content cookbookContent, readFile func(path string) ([]byte, error)) ([]byte, error) {
+	l := &pdfLayout{doc: pdf.New(content.Title)}
+
+	l.newPage()
+	l.y = pdf.PageHeight / 3
+	for _, line := range pdf.Wrap(pdf.Bold, 28, content.Title, pdfWidth) {
+		l.line(pdf.Bold, 28, (pdf.PageWidth-pdf.TextWidth(pdf.Bold, 28, line))/2, line)
+	}
+	l.space(12)
+	for _, line := range pdf.Wrap(pdf.Italic, 12, content.Description, pdfWidth) {
+		l.line(pdf.Italic, 12, (pdf.PageWidth-pdf.TextWidth(pdf.Italic, 12, line))/2, line)
+	}
+
+	// the contents pages are reserved up front and filled in once the page of every recipe is known
+	entries := len(content.Recipes)
+	if len(content.Index) > 0 {
+		entries++
+	}
+	firstRows := int(math.Floor((pdfBottom-pdfTOCTop)/pdfTOCRow)) + 1
+	rows := int(math.Floor((pdfBottom-pdfMargin)/pdfTOCRow)) + 1
+	tocPages := make([]*pdf.Page, 1+int(math.Ceil(float64(max(entries-firstRows, 0))/float64(rows))))
+	for i := range tocPages {
+		tocPages[i] = l.doc.AddPage()
+	}
+
+	startPages := make([]int, len(content.Recipes))
+	for i, recipe := range content.Recipes {
+		l.newPage()
+		startPages[i] = l.doc.PageCount()
+		layoutRecipePDF(l, recipe, readFile)
+	}
+
+	indexPage := 0
+	if len(content.Index) > 0 {
+		l.newPage()
+		indexPage = l.doc.PageCount()
+		l.text(pdf.Bold, 20, "Index")
+		for _, group := range content.Index {
+			l.heading(13, group.Title)
+			for _, entry := range group.Entries {
+				pages := make([]string, len(entry.Recipes))
+				for i, r := range entry.Recipes {
+					pages[i] = strconv.Itoa(startPages[r])
+				}
+				l.item("", pdf.Regular, 10, entry.Label+"  "+strings.Join(pages, ", "))
+			}
+		}
+	}
+
+	toc := &pdfLayout{doc: l.doc, page: tocPages[0], y: pdfMargin}
+	toc.text(pdf.Bold, 20, "Contents")
+	toc.y = pdfTOCTop - pdfTOCRow
+	next := 1
+	tocEntry := func(title string, page int) {
+		if toc.y+pdfTOCRow > pdfBottom {
+			toc.page, toc.y = tocPages[next], pdfMargin-pdfTOCRow
+			next++
+		}
+		toc.y += pdfTOCRow
+		number := strconv.Itoa(page)
+		right := pdf.PageWidth - pdfMargin
+		toc.page.Text(pdfMargin, toc.y, pdf.Regular, 11, truncateText(pdf.Regular, 11, title, pdfWidth-40))
+		toc.page.Text(right-pdf.TextWidth(pdf.Regular, 11, number), toc.y, pdf.Regular, 11, number)
+	}
+	for i, recipe := range content.Recipes {
+		tocEntry(recipe.Title, startPages[i])
+	}
+	if indexPage > 0 {
+		tocEntry("Index", indexPage)
+	}
+
+	for i := 2; i <= l.doc.PageCount(); i++ {
+		number := strconv.Itoa(i)
+		l.doc.Page(i).Text((pdf.PageWidth-pdf.TextWidth(pdf.Regular, 9, number))/2, pdf.PageHeight-pdfMargin/2, pdf.Regular, 9, number)
+	}
+
+	var buf bytes.Buffer
+	if _, err := l.doc.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func layoutRecipePDF(l *pdfLayout, recipe cookbookRecipe, readFile func(path string) ([]byte, error)) {
+	l.text(pdf.Bold, 20, recipe.Title)
+	l.space(8)
+
+	if recipe.ImagePath != "" {
+		if img, err := loadPDFImage(l.doc, recipe.ImagePath, readFile); err != nil {
+			log.Warnw("failed to add recipe image to cookbook", "path", recipe.ImagePath, "error", err.Error())
+		} else {
+			w, h := pdfWidth, pdfWidth*float64(img.Height)/float64(img.Width)
+			if h > pdfImageHeight {
+				w, h = pdfImageHeight*float64(img.Width)/float64(img.Height), pdfImageHeight
+			}
+			l.page.Image(img, pdfMargin+(pdfWidth-w)/2, l.y, w, h)
+			l.space(h + 12)
+		}
+	}
+
+	if recipe.Description != "" {
+		l.text(pdf.Italic, 10, recipe.Description)
+		l.space(6)
+	}
+	if len(recipe.Meta) > 0 {
+		meta := make([]string, len(recipe.Meta))
+		for i, item := range recipe.Meta {
+			meta[i] = item.Name + " " + item.Text
+		}
+		l.text(pdf.Regular, 9.5, strings.Join(meta, " · "))
+	}
+
+	if len(recipe.Ingredients) > 0 {
+		l.heading(13, "Ingredients")
+		for _, group := range recipe.Ingredients {
+			if group.Name != "" {
+				l.space(4)
+				l.text(pdf.Bold, 10.5, group.Name)
+			}
+			for _, item := range group.Items {
+				l.item("•", pdf.Regular, 10, item.Text)
+			}
+		}
+	}
+
+	if len(recipe.Instructions) > 0 {
+		l.heading(13, "Instructions")
+		for _, group := range recipe.Instructions {
+			if group.Name != "" {
+				l.space(4)
+				l.text(pdf.Bold, 10.5, group.Name)
+			}
+			if group.Text != "" {
+				l.text(pdf.Italic, 10, group.Text)
+			}
+			for _, item := range group.Items {
+				label := strconv.Itoa(item.Number) + "."
+				if item.Name != "" {
+					l.item(label, pdf.Bold, 10, item.Name)
+					label = ""
+				}
+				l.item(label, pdf.Regular, 10, item.Text)
+				l.space(3)
+			}
+		}
+	}
+
+	if recipe.Notes != "" {
+		l.heading(13, "Notes")
+		l.text(pdf.Regular, 10, recipe.Notes)
+	}
+	if recipe.Source != "" {
+		l.space(10)
+		l.text(pdf.Italic, 8.5, "Source: "+recipe.Source)
+	}
+}
+
+func loadPDFImage(doc *pdf.Document, path string, readFile func(path string) ([]byte, error)) (*pdf.Image, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return doc.AddImage(data)
+}
+
+// truncateText shortens s with an ellipsis to fit into width.
+func truncateText(font pdf.Font, size float64, s string, width float64) string {
+	if pdf.TextWidth(font, size, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.TextWidth(font, size, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
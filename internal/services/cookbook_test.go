@@ -0,0 +1,203 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/services"
+	"borscht.app/smetana/internal/storage"
+	"borscht.app/smetana/internal/types"
+)
+
+func cookbookCollection(hid uuid.UUID, recipes ...domain.Recipe) *stubCollectionService {
+	return &stubCollectionService{
+		byIDFn: func(id, _ uuid.UUID) (*domain.Collection, error) {
+			return &domain.Collection{ID: id, HouseholdID: hid, Name: "Sunday Dinners", Description: "Family favourites"}, nil
+		},
+		listRecipesFn: func(_, _, householdID uuid.UUID, opts types.SearchOptions) ([]domain.Recipe, int64, error) {
+			if householdID != hid {
+				return nil, 0, sentinels.ErrForbidden
+			}
+			total := int64(len(recipes))
+			end := min(opts.Offset+opts.Limit, len(recipes))
+			return recipes[opts.Offset:end], total, nil
+		},
+	}
+}
+
+func TestCookbookService_Create_RendersHTML(t *testing.T) {
+	fs := newFakeStorage()
+	storage.SetDefault(fs)
+	hid := uuid.New()
+	italian := &domain.Taxonomy{Type: domain.TaxonomyTypeCuisine, Slug: "italian", Label: "Italian"}
+	collection := cookbookCollection(hid,
+		domain.Recipe{
+			Name:        ptr("Carbonara"),
+			ImagePath:   new(storage.Path("recipes/carbonara.jpg")),
+			Ingredients: []*domain.RecipeIngredient{{RawText: "200 g spaghetti"}},
+			Taxonomies:  []*domain.Taxonomy{italian},
+		},
+		domain.Recipe{Name: ptr("Tiramisu"), Taxonomies: []*domain.Taxonomy{italian}},
+	)
+	repo := &stubCookbookRepo{}
+
+	svc := services.NewCookbookService(repo, collection, fs)
+	cookbook, err := svc.Create(context.Background(), uuid.New(), uuid.New(), hid, domain.CookbookFormatHTML)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobStatusSuccess, cookbook.Status)
+	path := "cookbooks/" + hid.String() + "/" + cookbook.ID.String() + ".html"
+	assert.Equal(t, storage.Path(path), *cookbook.FilePath)
+
+	html := string(fs.saved[path])
+	assert.Contains(t, html, `<li><a href="#recipe-1">Carbonara</a></li>`)
+	assert.Contains(t, html, `<article class="page recipe" id="recipe-2">`)
+	assert.Contains(t, html, `<img src="http://cdn.test/recipes/carbonara.jpg" alt="">`)
+	assert.Contains(t, html, `<li>200 g spaghetti</li>`)
+	assert.Contains(t, html, `<li>Italian: <a href="#recipe-1">Carbonara</a>, <a href="#recipe-2">Tiramisu</a></li>`)
+}
+
+func TestCookbookService_Create_RendersPDFWithDefaultImage(t *testing.T) {
+	fs := newFakeStorage()
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 6))))
+	fs.saved["recipes/soup.png"] = img.Bytes()
+	hid := uuid.New()
+	collection := cookbookCollection(hid, domain.Recipe{Name: ptr("Borscht"), ImagePath: new(storage.Path("recipes/soup.png"))})
+
+	svc := services.NewCookbookService(&stubCookbookRepo{}, collection, fs)
+	cookbook, err := svc.Create(context.Background(), uuid.New(), uuid.New(), hid, domain.CookbookFormatPDF)
+
+	require.NoError(t, err)
+	data := fs.saved[string(*cookbook.FilePath)]
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-")))
+	assert.Contains(t, string(data), "/Count 3") // cover, contents and the recipe
+	assert.Contains(t, string(data), "/Subtype /Image /Width 8 /Height 6 /ColorSpace /DeviceGray")
+}
+
+func TestCookbookService_Create_PDFWithCyrillicText(t *testing.T) {
+	fs := newFakeStorage()
+	hid := uuid.New()
+	collection := cookbookCollection(hid, domain.Recipe{Name: ptr("Борщ"), Ingredients: []*domain.RecipeIngredient{{RawText: "2 буряки"}}})
+
+	svc := services.NewCookbookService(&stubCookbookRepo{}, collection, fs)
+	cookbook, err := svc.Create(context.Background(), uuid.New(), uuid.New(), hid, domain.CookbookFormatPDF)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobStatusSuccess, cookbook.Status)
+	assert.True(t, bytes.HasPrefix(fs.saved[string(*cookbook.FilePath)], []byte("%PDF-")))
+}
+
+func TestCookbookService_Create_PDFWithUnsupportedText_ReturnsBadRequest(t *testing.T) {
+	fs := newFakeStorage()
+	hid := uuid.New()
+	repo := &stubCookbookRepo{}
+	collection := cookbookCollection(hid, domain.Recipe{Name: ptr("寿司")})
+
+	svc := services.NewCookbookService(repo, collection, fs)
+	_, err := svc.Create(context.Background(), uuid.New(), uuid.New(), hid, domain.CookbookFormatPDF)
+
+	var sentinelErr *sentinels.Error
+	require.ErrorAs(t, err, &sentinelErr)
+	assert.Equal(t, 400, sentinelErr.Status)
+	require.Len(t, repo.updated, 2)
+	assert.Equal(t, domain.JobStatusError, repo.updated[1].Status)
+	assert.Contains(t, repo.updated[1].ErrorMessage, "html format")
+	assert.Empty(t, fs.saved)
+}
+
+func TestCookbookService_Create_DeletesPreviousCookbooks(t *testing.T) {
+	fs := newFakeStorage()
+	hid := uuid.New()
+	old := domain.Cookbook{ID: uuid.New(), Status: domain.JobStatusSuccess, FilePath: new(storage.Path("cookbooks/old.pdf"))}
+	failed := domain.Cookbook{ID: uuid.New(), Status: domain.JobStatusError}
+	rendering := domain.Cookbook{ID: uuid.New(), Status: domain.JobStatusRunning}
+	repo := &stubCookbookRepo{previous: []domain.Cookbook{old, failed, rendering}}
+	collection := cookbookCollection(hid, domain.Recipe{Name: ptr("Borscht")})
+
+	svc := services.NewCookbookService(repo, collection, fs)
+	_, err := svc.Create(context.Background(), uuid.New(), uuid.New(), hid, domain.CookbookFormatPDF)
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{old.ID, failed.ID}, repo.deleted)
+	assert.Equal(t, []string{"cookbooks/old.pdf"}, fs.deleted)
+}
+
+func TestCookbookService_Delete_RemovesFile(t *testing.T) {
+	fs := newFakeStorage()
+	hid := uuid.New()
+	cookbook := &domain.Cookbook{ID: uuid.New(), HouseholdID: hid, FilePath: new(storage.Path("cookbooks/sunday.pdf"))}
+	repo := &stubCookbookRepo{byIDFn: func(uuid.UUID) (*domain.Cookbook, error) { return cookbook, nil }}
+
+	svc := services.NewCookbookService(repo, &stubCollectionService{}, fs)
+	assert.ErrorIs(t, svc.Delete(cookbook.ID, uuid.New()), sentinels.ErrForbidden)
+	assert.Empty(t, repo.deleted)
+
+	require.NoError(t, svc.Delete(cookbook.ID, hid))
+	assert.Equal(t, []uuid.UUID{cookbook.ID}, repo.deleted)
+	assert.Equal(t, []string{"cookbooks/sunday.pdf"}, fs.deleted)
+}
+
+func TestCookbookService_Create_LargeCollection_LeftPending(t *testing.T) {
+	t.Setenv("COOKBOOK_SYNC_LIMIT", "1")
+	fs := newFakeStorage()
+	hid := uuid.New()
+	repo := &stubCookbookRepo{}
+	collection := cookbookCollection(hid, domain.Recipe{Name: ptr("Borscht")}, domain.Recipe{Name: ptr("Varenyky")})
+
+	svc := services.NewCookbookService(repo, collection, fs)
+	cookbook, err := svc.Create(context.Background(), uuid.New(), uuid.New(), hid, domain.CookbookFormatPDF)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.JobStatusPending, cookbook.Status)
+	assert.Empty(t, repo.updated)
+	assert.Empty(t, fs.saved)
+}
+
+func TestCookbookService_Create_UnknownFormat_ReturnsBadRequest(t *testing.T) {
+	svc := services.NewCookbookService(&stubCookbookRepo{}, &stubCollectionService{}, newFakeStorage())
+	_, err := svc.Create(context.Background(), uuid.New(), uuid.New(), uuid.New(), "docx")
+
+	var sentinelErr *sentinels.Error
+	require.ErrorAs(t, err, &sentinelErr)
+	assert.Equal(t, 400, sentinelErr.Status)
+}
+
+func TestCookbookService_Generate_RecordsError(t *testing.T) {
+	repo := &stubCookbookRepo{}
+	collection := &stubCollectionService{
+		byIDFn: func(_, _ uuid.UUID) (*domain.Collection, error) { return nil, errors.New("db down") },
+	}
+
+	svc := services.NewCookbookService(repo, collection, newFakeStorage())
+	err := svc.Generate(context.Background(), &domain.Cookbook{ID: uuid.New(), Format: domain.CookbookFormatPDF})
+
+	require.Error(t, err)
+	require.Len(t, repo.updated, 2)
+	assert.Equal(t, domain.JobStatusRunning, repo.updated[0].Status)
+	assert.Equal(t, domain.JobStatusError, repo.updated[1].Status)
+	assert.Contains(t, repo.updated[1].ErrorMessage, "db down")
+}
+
+func TestCookbookService_ByID_OtherHousehold_ReturnsForbidden(t *testing.T) {
+	repo := &stubCookbookRepo{
+		byIDFn: func(id uuid.UUID) (*domain.Cookbook, error) {
+			return &domain.Cookbook{ID: id, HouseholdID: uuid.New()}, nil
+		},
+	}
+
+	svc := services.NewCookbookService(repo, &stubCollectionService{}, newFakeStorage())
+	_, err := svc.ByID(uuid.New(), uuid.New())
+
+	assert.ErrorIs(t, err, sentinels.ErrForbidden)
+}
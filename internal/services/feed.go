@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/borschtapp/krip"
@@ -23,6 +25,8 @@ type feedService struct {
 	recipeIngest     domain.RecipeIngestService
 	scraperService   domain.ScraperService
 	syncLimit        chan struct{}
+	rankWindow       int
+	schedule         feedSchedule
 }
 
 func NewFeedService(repo domain.FeedRepository, publisherService domain.PublisherService, recipeService domain.RecipeService, recipeIngest domain.RecipeIngestService, scraperService domain.ScraperService) domain.FeedService {
@@ -33,20 +37,38 @@ func NewFeedService(repo domain.FeedRepository, publisherService domain.Publishe
 		recipeIngest:     recipeIngest,
 		scraperService:   scraperService,
 		syncLimit:        make(chan struct{}, utils.GetenvInt("FEED_SYNC_CONCURRENCY", 2)),
+		rankWindow:       max(utils.GetenvInt("STREAM_RANK_WINDOW", 500), 1),
+		schedule:         newFeedSchedule(),
 	}
 }
 
-func (s *feedService) Search(householdID uuid.UUID, opts types.SearchOptions) ([]domain.Feed, int64, error) {
-	feeds, total, err := s.repo.Search(householdID, opts)
+func (s *feedService) Search(userID uuid.UUID, householdID uuid.UUID, opts types.SearchOptions) ([]domain.Feed, int64, error) {
+	feeds, total, err := s.repo.Search(userID, householdID, opts)
 	if err != nil {
 		return nil, 0, fmt.Errorf("search: %w", err)
 	}
 	return feeds, total, nil
 }
 
-func (s *feedService) Stream(userID uuid.UUID, householdID uuid.UUID, opts types.SearchOptions) ([]domain.Recipe, int64, error) {
-	opts.Scope = "feeds"
-	recipes, total, err := s.recipeService.Search(userID, householdID, domain.RecipeSearchOptions{SearchOptions: opts})
+func (s *feedService) Stream(userID uuid.UUID, householdID uuid.UUID, opts domain.StreamOptions) ([]domain.Recipe, int64, error) {
+	search := domain.RecipeSearchOptions{SearchOptions: opts.SearchOptions, HideStream: []domain.StreamFlag{domain.StreamFlagHidden}}
+	search.Scope = "feeds"
+	search.FeedFilters = true
+	if opts.HideDismissed {
+		search.HideStream = append(search.HideStream, domain.StreamFlagDismissed)
+	}
+	if opts.Unread {
+		search.HideStream = append(search.HideStream, domain.StreamFlagSeen)
+	}
+
+	var recipes []domain.Recipe
+	var total int64
+	var err error
+	if opts.Ranked {
+		recipes, total, err = s.rankedStream(userID, householdID, search)
+	} else {
+		recipes, total, err = s.recipeService.Search(userID, householdID, search)
+	}
 	if err != nil {
 		return nil, 0, fmt.Errorf("stream (recipe search): %w", err)
 	}
@@ -84,6 +106,66 @@ func (s *feedService) Stream(userID uuid.UUID, householdID uuid.UUID, opts types
 	return recipes, total, nil
 }
 
+// rankedStream ranks the newest recipes of the stream, up to the rank window, and returns the requested page of them.
+func (s *feedService) rankedStream(userID uuid.UUID, householdID uuid.UUID, search domain.RecipeSearchOptions) ([]domain.Recipe, int64, error) {
+	page := search.Pagination
+	requested := search.PreloadOptions
+	search.Sort, search.Order = "id", "DESC"
+	search.Pagination = types.Pagination{Offset: 0, Limit: s.rankWindow}
+	search.PreloadOptions = types.Preload(append(slices.Clone(requested.Preload), "taxonomies", "ingredients")...)
+
+	candidates, _, err := s.recipeService.Search(userID, householdID, search)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(candidates) == 0 {
+		return candidates, 0, nil
+	}
+
+	profile, err := s.recipeService.TasteProfile(householdID)
+	if err != nil {
+		return nil, 0, err
+	}
+	ranked := rankStream(candidates, profile, time.Now())
+
+	total := int64(len(ranked))
+	ranked = ranked[min(page.Offset, len(ranked)):min(page.Offset+page.Limit, len(ranked))]
+	for i := range ranked { // only loaded for ranking
+		if !requested.Has("taxonomies") {
+			ranked[i].Taxonomies = nil
+		}
+		if !requested.Has("ingredients") {
+			ranked[i].Ingredients = nil
+		}
+	}
+	return ranked, total, nil
+}
+
+func (s *feedService) SetStreamFlag(userID uuid.UUID, householdID uuid.UUID, recipeID uuid.UUID, flag domain.StreamFlag, value bool) error {
+	recipe, err := s.recipeService.ByID(recipeID, householdID)
+	if err != nil {
+		return fmt.Errorf("set stream flag (fetch recipe): %w", err)
+	}
+	// the stream shows household copies in place of the feed recipe they were cloned from
+	if recipe.HouseholdID != nil && recipe.ParentID != nil {
+		recipeID = *recipe.ParentID
+	}
+	if err := s.repo.SetStreamFlag(userID, householdID, recipeID, flag, value); err != nil {
+		return fmt.Errorf("set stream flag: %w", err)
+	}
+	return nil
+}
+
+func (s *feedService) MarkFeedRead(userID uuid.UUID, householdID uuid.UUID, feedID uuid.UUID) error {
+	if _, err := s.repo.ByIDForHousehold(feedID, householdID); err != nil {
+		return fmt.Errorf("mark feed read (fetch feed): %w", err)
+	}
+	if err := s.repo.MarkFeedSeen(userID, householdID, feedID); err != nil {
+		return fmt.Errorf("mark feed read: %w", err)
+	}
+	return nil
+}
+
 func (s *feedService) Subscribe(ctx context.Context, householdID uuid.UUID, url string, scraped *domain.Feed) (*domain.Feed, error) {
 	feed, err := s.findOrCreate(ctx, url, scraped)
 	if err != nil {
@@ -115,6 +197,40 @@ func (s *feedService) Unsubscribe(householdID uuid.UUID, feedID uuid.UUID) error
 	return nil
 }
 
+func (s *feedService) SetFilter(householdID uuid.UUID, feedID uuid.UUID, filter *domain.FeedFilter) (*domain.Feed, error) {
+	feed, err := s.repo.ByIDForHousehold(feedID, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("set filter (fetch feed): %w", err)
+	}
+
+	if filter != nil {
+		filter.IncludeKeywords = normalizeKeywords(filter.IncludeKeywords)
+		filter.ExcludeKeywords = normalizeKeywords(filter.ExcludeKeywords)
+		filter.Languages = normalizeKeywords(filter.Languages)
+	}
+	if filter.IsEmpty() {
+		filter = nil
+	}
+
+	if err := s.repo.SetFilter(householdID, feedID, filter); err != nil {
+		return nil, fmt.Errorf("set filter: %w", err)
+	}
+	feed.Filter = filter
+	return feed, nil
+}
+
+// normalizeKeywords lowercases and trims the keywords of a feed filter, dropping blank and repeated ones.
+func normalizeKeywords(keywords []string) []string {
+	var normalized []string
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" && !slices.Contains(normalized, keyword) {
+			normalized = append(normalized, keyword)
+		}
+	}
+	return normalized
+}
+
 func (s *feedService) findOrCreate(ctx context.Context, url string, scraped *domain.Feed) (*domain.Feed, error) {
 	url = utils.NormalizeURL(url)
 	feed, err := s.repo.ByUrl(url)
@@ -195,46 +311,59 @@ func (s *feedService) FetchFeed(ctx context.Context, feed *domain.Feed) (int, in
 	opts := krip.FeedOptions{}
 	opts.MinIngredients = 3
 	opts.Discovered = feed.Discovered
-	recipes, err := s.scraperService.ScrapeFeed(scrapeCtx, feed, opts)
+	// validators of the last fetch, put back when a new entry fails to import so the next fetch retries it
+	etag, lastModified, contentHash := feed.ETag, feed.LastModified, feed.ContentHash
+	known := 0
+	recipes, unchanged, err := s.scraperService.ScrapeFeedChanges(scrapeCtx, feed, opts, func(url string) bool {
+		existing, lookupErr := s.recipeService.ByUrl(url, uuid.Nil)
+		if lookupErr != nil {
+			return false
+		}
+		known++
+		// Public recipe exists — backfill FeedID if it was imported without one
+		if existing.FeedID == nil {
+			if updateErr := s.recipeService.SetFeedID(existing.ID, feed.ID); updateErr != nil {
+				log.Warnw("failed to link existing recipe to feed", "url", url, "error", updateErr.Error())
+			}
+		}
+		return true
+	})
 
 	if err != nil {
 		log.Warnw("failed to scrape feed", "feed", feed.ID, "error", err.Error())
 		feed.ErrorCount++
 		feed.LastSyncSuccess = false
-		if feed.ErrorCount > 3 {
+		if feed.ErrorCount > 3 && feed.Active {
+			// still fetched with the backoff, a success activates it again
 			feed.Active = false
 			log.Errorw("deactivating feed due to repeated errors", "feed", feed.ID)
 		}
+		s.schedule.failed(feed, feed.LastSyncAt)
 		if updateErr := s.repo.Update(feed); updateErr != nil {
 			log.Warnw("failed to persist error state for feed", "feed", feed.ID, "error", updateErr.Error())
 		}
 		return 0, 0, err
 	}
 
+	if !feed.Active {
+		log.Infow("reactivating feed after a successful fetch", "feed", feed.ID)
+	}
+	feed.Active = true
 	feed.ErrorCount = 0
 	feed.LastSyncSuccess = true
+	if unchanged {
+		log.Debugw("feed not modified since the last fetch", "feed", feed.ID)
+	}
 
+	// Known recipes were skipped by the scraper, every recipe left is new.
+	newRecipes := len(recipes)
 	for _, recipe := range recipes {
-		url := ""
-		if recipe.SourceUrl != nil {
-			url = utils.NormalizeURL(*recipe.SourceUrl)
-		}
-		if url == "" {
-			continue
-		}
-		existing, lookupErr := s.recipeService.ByUrl(url, uuid.Nil)
-		if lookupErr == nil {
-			// Public recipe exists — backfill FeedID if it was imported without one
-			if existing.FeedID == nil {
-				if updateErr := s.recipeService.SetFeedID(existing.ID, feed.ID); updateErr != nil {
-					log.Warnw("failed to link existing recipe to feed", "url", url, "error", updateErr.Error())
-				}
-			}
-			continue
-		}
-
 		recipe.FeedID = &feed.ID
 		if _, err := s.recipeIngest.ImportRecipe(ctx, recipe); err != nil {
+			url := ""
+			if recipe.SourceUrl != nil {
+				url = *recipe.SourceUrl
+			}
 			log.Warnw("failed to import recipe", "url", url, "error", err.Error())
 		} else {
 			imported++
@@ -246,9 +375,14 @@ func (s *feedService) FetchFeed(ctx context.Context, feed *domain.Feed) (int, in
 		}
 	}
 
+	if imported < newRecipes {
+		feed.ETag, feed.LastModified, feed.ContentHash = etag, lastModified, contentHash
+	}
+
+	s.schedule.succeeded(feed, newRecipes, feed.LastSyncAt)
 	if err := s.repo.Update(feed); err != nil {
 		log.Warnw("failed to persist feed metadata", "feed", feed.ID, "error", err.Error())
 	}
 
-	return len(recipes), imported, nil
+	return known + newRecipes, imported, nil
 }
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/types"
+	"borscht.app/smetana/internal/utils"
+)
+
+// opmlDocument is an OPML 2.0 subscription list, only the attributes RSS readers exchange are kept.
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// opmlOutline is a feed when it has an xmlUrl, otherwise a folder of more outlines.
+type opmlOutline struct {
+	Type        string        `xml:"type,attr,omitempty"`
+	Text        string        `xml:"text,attr"`
+	Title       string        `xml:"title,attr,omitempty"`
+	XmlUrl      string        `xml:"xmlUrl,attr,omitempty"`
+	HtmlUrl     string        `xml:"htmlUrl,attr,omitempty"`
+	Description string        `xml:"description,attr,omitempty"`
+	Outlines    []opmlOutline `xml:"outline"`
+}
+
+func (o *opmlOutline) name() string {
+	if o.Title != "" {
+		return o.Title
+	}
+	return o.Text
+}
+
+// subscriptionsPageSize is how many feeds subscribedFeeds loads at once.
+const subscriptionsPageSize = 100
+
+func (s *feedService) ExportOPML(userID uuid.UUID, householdID uuid.UUID) ([]byte, error) {
+	feeds, err := subscribedFeeds(s, userID, householdID, "publisher")
+	if err != nil {
+		return nil, fmt.Errorf("export opml: %w", err)
+	}
+
+	doc := opmlDocument{
+		Version: "2.0",
+		Head:    opmlHead{Title: "Recipe feeds", DateCreated: time.Now().UTC().Format(time.RFC1123Z)},
+	}
+	for _, feed := range feeds {
+		outline := opmlOutline{Type: "rss", Text: feed.Name, Title: feed.Name, XmlUrl: feed.Url}
+		if feed.Description != nil {
+			outline.Description = *feed.Description
+		}
+		if feed.Publisher != nil && feed.Publisher.Url != nil {
+			outline.HtmlUrl = *feed.Publisher.Url
+		}
+		doc.Body.Outlines = append(doc.Body.Outlines, outline)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("export opml (marshal): %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func (s *importJobService) EnqueueOPML(data []byte, userID uuid.UUID, householdID uuid.UUID) (*domain.FeedImportReport, error) {
+	outlines, err := parseOPML(data)
+	if err != nil {
+		return nil, sentinels.BadRequest("invalid OPML file: " + err.Error())
+	}
+	if len(outlines) == 0 {
+		return nil, sentinels.BadRequest("OPML file has no feeds")
+	}
+	if len(outlines) > maxBulkImportURLs {
+		return nil, sentinels.BadRequest(fmt.Sprintf("too many feeds, at most %d can be imported at once", maxBulkImportURLs))
+	}
+
+	subscribed, err := subscribedFeeds(s.feedService, userID, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("enqueue opml (subscriptions): %w", err)
+	}
+	byUrl := make(map[string]*domain.Feed, len(subscribed))
+	for i := range subscribed {
+		byUrl[utils.NormalizeURL(subscribed[i].Url)] = &subscribed[i]
+	}
+
+	report := &domain.FeedImportReport{Items: make([]domain.FeedImportItem, 0, len(outlines))}
+	seen := make(map[string]bool, len(outlines))
+	for _, outline := range outlines {
+		item := domain.FeedImportItem{Title: outline.name(), Url: strings.TrimSpace(outline.XmlUrl)}
+		switch {
+		case item.Url == "":
+			item.Status, item.Error = domain.FeedImportInvalid, "outline has no feed URL"
+		case !isWebURL(item.Url):
+			item.Status, item.Error = domain.FeedImportInvalid, "not a web URL"
+		default:
+			item.Url = utils.NormalizeURL(item.Url)
+			if seen[item.Url] {
+				continue
+			}
+			seen[item.Url] = true
+
+			if feed, ok := byUrl[item.Url]; ok {
+				item.Status, item.Feed = domain.FeedImportExisting, feed
+				break
+			}
+			job, err := s.Enqueue(item.Url, domain.ImportTypeFeed, false, "", userID, householdID)
+			if err != nil {
+				return nil, fmt.Errorf("enqueue opml: %w", err)
+			}
+			item.Status, item.Job = domain.FeedImportQueued, job
+		}
+
+		switch item.Status {
+		case domain.FeedImportQueued:
+			report.Queued++
+		case domain.FeedImportExisting:
+			report.Existing++
+		default:
+			report.Invalid++
+		}
+		report.Items = append(report.Items, item)
+	}
+	return report, nil
+}
+
+// parseOPML returns the feed outlines of an OPML document in document order, folders are flattened.
+// An outline without children or xmlUrl is returned too, so it can be reported as invalid.
+func parseOPML(data []byte) ([]opmlOutline, error) {
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var feeds []opmlOutline
+	var walk func(outlines []opmlOutline)
+	walk = func(outlines []opmlOutline) {
+		for _, outline := range outlines {
+			if outline.XmlUrl == "" && len(outline.Outlines) != 0 {
+				walk(outline.Outlines)
+				continue
+			}
+			feeds = append(feeds, outline)
+		}
+	}
+	walk(doc.Body.Outlines)
+	return feeds, nil
+}
+
+// subscribedFeeds loads every feed the household is subscribed to, page by page.
+func subscribedFeeds(feeds domain.FeedService, userID uuid.UUID, householdID uuid.UUID, preload ...string) ([]domain.Feed, error) {
+	opts := types.SearchOptions{
+		Sort:           "id",
+		Pagination:     types.Pagination{Limit: subscriptionsPageSize},
+		PreloadOptions: types.Preload(preload...),
+	}
+
+	var all []domain.Feed
+	for {
+		page, total, err := feeds.Search(userID, householdID, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		opts.Offset += len(page)
+		if len(page) == 0 || int64(opts.Offset) >= total {
+			return all, nil
+		}
+	}
+}
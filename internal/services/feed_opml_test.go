@@ -0,0 +1,115 @@
+package services_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/services"
+	"borscht.app/smetana/internal/types"
+)
+
+const testOPML = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="1.0">
+  <head><title>My feeds</title></head>
+  <body>
+    <outline text="Food">
+      <outline type="rss" text="Smitten Kitchen" xmlUrl="https://smittenkitchen.com/feed/"/>
+      <outline type="rss" text="Budget Bytes" title="Budget Bytes" xmlUrl="https://www.budgetbytes.com/feed/"/>
+    </outline>
+    <outline type="rss" text="Smitten Kitchen again" xmlUrl="https://SmittenKitchen.com/feed"/>
+    <outline type="rss" text="Broken" xmlUrl="javascript:alert(1)"/>
+    <outline text="Just a note"/>
+  </body>
+</opml>`
+
+func TestImportJobService_EnqueueOPML_QueuesNewFeedsOnly(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	existing := domain.Feed{ID: uuid.New(), Url: "https://www.budgetbytes.com/feed"}
+	feeds := &stubFeedService{
+		searchFn: func(_, h uuid.UUID, _ types.SearchOptions) ([]domain.Feed, int64, error) {
+			assert.Equal(t, hid, h)
+			return []domain.Feed{existing}, 1, nil
+		},
+	}
+	repo := &stubImportJobRepo{}
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, feeds, newFakeStorage())
+
+	report, err := svc.EnqueueOPML([]byte(testOPML), uid, hid)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Queued)
+	assert.Equal(t, 1, report.Existing)
+	assert.Equal(t, 2, report.Invalid)
+	require.Len(t, report.Items, 4, "the duplicate outline must be dropped")
+
+	assert.Equal(t, domain.FeedImportQueued, report.Items[0].Status)
+	assert.Equal(t, "Smitten Kitchen", report.Items[0].Title)
+	require.NotNil(t, report.Items[0].Job)
+	assert.Equal(t, domain.FeedImportExisting, report.Items[1].Status)
+	assert.Equal(t, existing.ID, report.Items[1].Feed.ID)
+	assert.Equal(t, domain.FeedImportInvalid, report.Items[2].Status)
+	assert.Equal(t, domain.FeedImportInvalid, report.Items[3].Status)
+
+	require.Len(t, repo.created, 1)
+	assert.Equal(t, "https://smittenkitchen.com/feed", repo.created[0].Url)
+	assert.Equal(t, domain.ImportTypeFeed, repo.created[0].RequestedType)
+	assert.Equal(t, uid, repo.created[0].UserID)
+}
+
+func TestImportJobService_EnqueueOPML_NotOPML_ReturnsBadRequest(t *testing.T) {
+	svc := services.NewImportJobService(&stubImportJobRepo{}, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	_, err := svc.EnqueueOPML([]byte("<html><body>not a list</body></html>"), uuid.New(), uuid.New())
+
+	var se *sentinels.Error
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, 400, se.Status)
+}
+
+func TestFeedService_ExportOPML_ListsEverySubscription(t *testing.T) {
+	publisherURL := "https://example.com"
+	var pages int
+	feedRepo := &stubFeedRepo{
+		searchFn: func(_, _ uuid.UUID, opts types.SearchOptions) ([]domain.Feed, int64, error) {
+			pages++
+			assert.True(t, opts.Has("publisher"))
+			const total = 120
+			var feeds []domain.Feed
+			for i := opts.Offset; i < min(opts.Offset+opts.Limit, total); i++ {
+				feeds = append(feeds, domain.Feed{
+					Name:      fmt.Sprintf("Feed %d", i),
+					Url:       fmt.Sprintf("https://example.com/%d/feed", i),
+					Publisher: &domain.Publisher{Url: &publisherURL},
+				})
+			}
+			return feeds, total, nil
+		},
+	}
+	svc := newTestFeedService(feedRepo, &stubPublisherService{}, &stubRecipeService{}, &stubRecipeIngestService{}, &stubScraperService{})
+
+	data, err := svc.ExportOPML(uuid.New(), uuid.New())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	var doc struct {
+		Version  string `xml:"version,attr"`
+		Outlines []struct {
+			Text    string `xml:"text,attr"`
+			XmlUrl  string `xml:"xmlUrl,attr"`
+			HtmlUrl string `xml:"htmlUrl,attr"`
+		} `xml:"body>outline"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, "2.0", doc.Version)
+	require.Len(t, doc.Outlines, 120)
+	assert.Equal(t, "Feed 0", doc.Outlines[0].Text)
+	assert.Equal(t, "https://example.com/0/feed", doc.Outlines[0].XmlUrl)
+	assert.Equal(t, publisherURL, doc.Outlines[0].HtmlUrl)
+}
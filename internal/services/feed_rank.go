@@ -0,0 +1,121 @@
+package services
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+)
+
+// Weights of the ranked stream score, freshness keeps a good new recipe above a good old one.
+const (
+	rankTaxonomyWeight  = 0.35
+	rankFoodWeight      = 0.3
+	rankPublisherWeight = 0.15
+	rankFreshnessWeight = 0.2
+
+	// rankFreshnessHalfLife is the age at which freshness drops to a half.
+	rankFreshnessHalfLife = 7 * 24 * time.Hour
+	// rankPublisherPenalty multiplies the score of a recipe for every recipe of its publisher ranked above it.
+	rankPublisherPenalty = 0.7
+)
+
+// rankStream orders recipes by how well they match the taste of the household, then spreads publishers
+// so that the newest recipes of a prolific one don't fill the stream.
+func rankStream(recipes []domain.Recipe, profile *domain.TasteProfile, now time.Time) []domain.Recipe {
+	maxTaxonomy, maxFood, maxPublisher := maxWeight(profile.Taxonomies), maxWeight(profile.Foods), maxWeight(profile.Publishers)
+
+	scores := make([]float64, len(recipes))
+	for i := range recipes {
+		recipe := &recipes[i]
+
+		taxonomies := make([]uuid.UUID, 0, len(recipe.Taxonomies))
+		for _, tax := range recipe.Taxonomies {
+			taxonomies = append(taxonomies, tax.ID)
+		}
+		foods := make([]uuid.UUID, 0, len(recipe.Ingredients))
+		for _, ing := range recipe.Ingredients {
+			if ing.FoodID != nil {
+				foods = append(foods, *ing.FoodID)
+			}
+		}
+		var publisher float64
+		if recipe.PublisherID != nil && maxPublisher > 0 {
+			publisher = profile.Publishers[*recipe.PublisherID] / maxPublisher
+		}
+
+		published := recipe.Created
+		if recipe.Published != nil {
+			published = *recipe.Published
+		}
+		freshness := math.Exp2(-max(now.Sub(published), 0).Hours() / rankFreshnessHalfLife.Hours())
+
+		scores[i] = rankTaxonomyWeight*affinity(taxonomies, profile.Taxonomies, maxTaxonomy) +
+			rankFoodWeight*affinity(foods, profile.Foods, maxFood) +
+			rankPublisherWeight*publisher +
+			rankFreshnessWeight*freshness
+	}
+
+	// greedily take the best recipe, its publisher counting against the next ones
+	remaining := make([]int, len(recipes))
+	for i := range remaining {
+		remaining[i] = i
+	}
+	picked := map[uuid.UUID]int{}
+	ranked := make([]domain.Recipe, 0, len(recipes))
+	for len(remaining) > 0 {
+		best, bestScore := 0, math.Inf(-1)
+		for j, i := range remaining {
+			score := scores[i]
+			if source, ok := recipeSource(&recipes[i]); ok {
+				score *= math.Pow(rankPublisherPenalty, float64(picked[source]))
+			}
+			if score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+		i := remaining[best]
+		if source, ok := recipeSource(&recipes[i]); ok {
+			picked[source]++
+		}
+		ranked = append(ranked, recipes[i])
+		remaining = slices.Delete(remaining, best, best+1)
+	}
+	return ranked
+}
+
+// affinity is the mean preference of the household for the features of a recipe, from 0 to 1.
+func affinity(features []uuid.UUID, weights map[uuid.UUID]float64, maxWeight float64) float64 {
+	slices.SortFunc(features, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	features = slices.Compact(features)
+	if len(features) == 0 || maxWeight == 0 {
+		return 0
+	}
+	var sum float64
+	for _, f := range features {
+		sum += weights[f]
+	}
+	return sum / maxWeight / float64(len(features))
+}
+
+func maxWeight(weights map[uuid.UUID]float64) float64 {
+	var m float64
+	for _, w := range weights {
+		m = max(m, w)
+	}
+	return m
+}
+
+// recipeSource is the publisher of a recipe, or its feed when the publisher is unknown.
+func recipeSource(recipe *domain.Recipe) (uuid.UUID, bool) {
+	switch {
+	case recipe.PublisherID != nil:
+		return *recipe.PublisherID, true
+	case recipe.FeedID != nil:
+		return *recipe.FeedID, true
+	}
+	return uuid.Nil, false
+}
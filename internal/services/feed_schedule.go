@@ -0,0 +1,59 @@
+package services
+
+import (
+	"time"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/utils"
+)
+
+// feedSchedule spaces the fetches of every feed by how often it publishes: each fetch that finds new recipes
+// halves the interval of the feed and each one that finds none stretches it, within the min and max bounds.
+// Failing feeds are retried with an exponential backoff from their interval, up to maxBackoff.
+type feedSchedule struct {
+	initial    time.Duration
+	min        time.Duration
+	max        time.Duration
+	maxBackoff time.Duration
+}
+
+func newFeedSchedule() feedSchedule {
+	s := feedSchedule{
+		initial:    utils.GetenvDuration("FETCH_INTERVAL", 24*time.Hour),
+		min:        utils.GetenvDuration("FETCH_MIN_INTERVAL", time.Hour),
+		max:        utils.GetenvDuration("FETCH_MAX_INTERVAL", 7*24*time.Hour),
+		maxBackoff: utils.GetenvDuration("FETCH_MAX_BACKOFF", 30*24*time.Hour),
+	}
+	s.max = max(s.max, s.min)
+	s.initial = min(max(s.initial, s.min), s.max)
+	return s
+}
+
+// interval is the current fetch interval of a feed, the initial one for a feed never fetched on schedule.
+func (s feedSchedule) interval(feed *domain.Feed) time.Duration {
+	if feed.FetchInterval <= 0 {
+		return s.initial
+	}
+	return min(max(feed.FetchInterval, s.min), s.max)
+}
+
+// succeeded adapts the interval of a feed to whether the fetch found new recipes and schedules the next one.
+func (s feedSchedule) succeeded(feed *domain.Feed, newRecipes int, now time.Time) {
+	interval := s.interval(feed)
+	if newRecipes > 0 {
+		interval /= 2
+	} else {
+		interval += interval / 2
+	}
+	feed.FetchInterval = min(max(interval, s.min), s.max)
+	feed.NextFetchAt = now.Add(feed.FetchInterval)
+}
+
+// failed schedules a retry of a feed that failed ErrorCount times in a row, doubling the delay with every failure.
+func (s feedSchedule) failed(feed *domain.Feed, now time.Time) {
+	delay := s.interval(feed)
+	for i := 1; i < feed.ErrorCount && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+	feed.NextFetchAt = now.Add(min(delay, s.maxBackoff))
+}
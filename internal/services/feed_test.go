@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/borschtapp/krip"
 	"github.com/google/uuid"
@@ -50,7 +51,7 @@ func TestFeedService_Stream_SwapsGlobalWithHouseholdOverrides(t *testing.T) {
 	}
 
 	svc := newTestFeedService(&stubFeedRepo{}, &stubPublisherService{}, recipeSvc, &stubRecipeIngestService{}, &stubScraperService{})
-	got, total, err := svc.Stream(uuid.New(), hid, types.SearchOptions{})
+	got, total, err := svc.Stream(uuid.New(), hid, domain.StreamOptions{})
 
 	require.NoError(t, err)
 	assert.EqualValues(t, 2, total)
@@ -75,7 +76,7 @@ func TestFeedService_Stream_OverrideLookupFailure_ReturnsOriginalResults(t *test
 	}
 
 	svc := newTestFeedService(&stubFeedRepo{}, &stubPublisherService{}, recipeSvc, &stubRecipeIngestService{}, &stubScraperService{})
-	got, total, err := svc.Stream(uuid.New(), uuid.New(), types.SearchOptions{})
+	got, total, err := svc.Stream(uuid.New(), uuid.New(), domain.StreamOptions{})
 
 	require.NoError(t, err, "override lookup failure must not propagate as an error")
 	assert.EqualValues(t, 1, total)
@@ -83,6 +84,155 @@ func TestFeedService_Stream_OverrideLookupFailure_ReturnsOriginalResults(t *test
 	assert.Equal(t, globalID, got[0].ID, "original recipe must be returned when override lookup fails")
 }
 
+func TestFeedService_Stream_Ranked_PrefersTasteAndSpreadsPublishers(t *testing.T) {
+	// Four recipes of one publisher matching the taste of the household and one unrelated recipe
+	// of another: the taste matches rank first, but the other publisher is not pushed to the end.
+	liked, publisherA, publisherB := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	b := domain.Recipe{ID: uuid.New(), PublisherID: &publisherB, Created: now}
+	candidates := []domain.Recipe{b}
+	for range 4 {
+		candidates = append(candidates, domain.Recipe{ID: uuid.New(), PublisherID: &publisherA, Created: now, Taxonomies: []*domain.Taxonomy{{ID: liked}}})
+	}
+
+	recipeSvc := &stubRecipeService{
+		searchFn: func(_, _ uuid.UUID, opts domain.RecipeSearchOptions) ([]domain.Recipe, int64, error) {
+			assert.Equal(t, "feeds", opts.Scope)
+			assert.True(t, opts.Has("taxonomies"), "ranking needs the taxonomies of the candidates")
+			return candidates, int64(len(candidates)), nil
+		},
+		tasteProfileFn: func(uuid.UUID) (*domain.TasteProfile, error) {
+			return &domain.TasteProfile{Taxonomies: map[uuid.UUID]float64{liked: 5}}, nil
+		},
+	}
+
+	svc := newTestFeedService(&stubFeedRepo{}, &stubPublisherService{}, recipeSvc, &stubRecipeIngestService{}, &stubScraperService{})
+	opts := domain.StreamOptions{Ranked: true}
+	opts.Pagination = types.Pagination{Limit: 10}
+	got, total, err := svc.Stream(uuid.New(), uuid.New(), opts)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, total)
+	require.Len(t, got, 5)
+	assert.Equal(t, publisherA, *got[0].PublisherID, "taste match must rank first")
+	assert.Equal(t, b.ID, got[3].ID, "publisher penalty must interleave the other publisher")
+	assert.Nil(t, got[0].Taxonomies, "preloads not requested must be stripped")
+}
+
+func TestFeedService_Stream_Filters_HideFlaggedRecipes(t *testing.T) {
+	var got []domain.StreamFlag
+	feedFilters := false
+	recipeSvc := &stubRecipeService{
+		searchFn: func(_, _ uuid.UUID, opts domain.RecipeSearchOptions) ([]domain.Recipe, int64, error) {
+			got, feedFilters = opts.HideStream, opts.FeedFilters
+			return nil, 0, nil
+		},
+	}
+	svc := newTestFeedService(&stubFeedRepo{}, &stubPublisherService{}, recipeSvc, &stubRecipeIngestService{}, &stubScraperService{})
+
+	_, _, err := svc.Stream(uuid.New(), uuid.New(), domain.StreamOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.StreamFlag{domain.StreamFlagHidden}, got, "hidden recipes are always left out")
+	assert.True(t, feedFilters, "the household's subscription filters always apply")
+
+	_, _, err = svc.Stream(uuid.New(), uuid.New(), domain.StreamOptions{HideDismissed: true, Unread: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []domain.StreamFlag{domain.StreamFlagHidden, domain.StreamFlagDismissed, domain.StreamFlagSeen}, got)
+}
+
+func TestFeedService_SetStreamFlag_HouseholdCopy_FlagsParent(t *testing.T) {
+	parentID, copyID, hid, uid := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	recipeSvc := &stubRecipeService{
+		byIDFn: func(id, _ uuid.UUID) (*domain.Recipe, error) {
+			return &domain.Recipe{ID: id, ParentID: &parentID, HouseholdID: &hid}, nil
+		},
+	}
+	var flaggedID uuid.UUID
+	feedRepo := &stubFeedRepo{
+		setStreamFlagFn: func(u, h, recipeID uuid.UUID, flag domain.StreamFlag, value bool) error {
+			assert.Equal(t, uid, u)
+			assert.Equal(t, hid, h)
+			assert.Equal(t, domain.StreamFlagDismissed, flag)
+			assert.True(t, value)
+			flaggedID = recipeID
+			return nil
+		},
+	}
+
+	svc := newTestFeedService(feedRepo, &stubPublisherService{}, recipeSvc, &stubRecipeIngestService{}, &stubScraperService{})
+	require.NoError(t, svc.SetStreamFlag(uid, hid, copyID, domain.StreamFlagDismissed, true))
+	assert.Equal(t, parentID, flaggedID, "the stream shows the copy in place of its parent")
+}
+
+func TestFeedService_SetStreamFlag_ForbiddenRecipe_Fails(t *testing.T) {
+	recipeSvc := &stubRecipeService{
+		byIDFn: func(uuid.UUID, uuid.UUID) (*domain.Recipe, error) { return nil, sentinels.ErrForbidden },
+	}
+	feedRepo := &stubFeedRepo{
+		setStreamFlagFn: func(uuid.UUID, uuid.UUID, uuid.UUID, domain.StreamFlag, bool) error {
+			t.Fatal("must not flag a recipe the household can't see")
+			return nil
+		},
+	}
+
+	svc := newTestFeedService(feedRepo, &stubPublisherService{}, recipeSvc, &stubRecipeIngestService{}, &stubScraperService{})
+	assert.ErrorIs(t, svc.SetStreamFlag(uuid.New(), uuid.New(), uuid.New(), domain.StreamFlagSeen, true), sentinels.ErrForbidden)
+}
+
+func TestFeedService_MarkFeedRead_NotSubscribed_ReturnsNotFound(t *testing.T) {
+	feedRepo := &stubFeedRepo{
+		byIDForHouseholdFn: func(uuid.UUID, uuid.UUID) (*domain.Feed, error) { return nil, sentinels.ErrNotFound },
+		markFeedSeenFn: func(uuid.UUID, uuid.UUID, uuid.UUID) error {
+			t.Fatal("must not mark a feed the household isn't subscribed to")
+			return nil
+		},
+	}
+
+	svc := newTestFeedService(feedRepo, &stubPublisherService{}, &stubRecipeService{}, &stubRecipeIngestService{}, &stubScraperService{})
+	assert.ErrorIs(t, svc.MarkFeedRead(uuid.New(), uuid.New(), uuid.New()), sentinels.ErrNotFound)
+}
+
+func TestFeedService_SetFilter_NormalizesKeywordsAndClearsEmptyFilter(t *testing.T) {
+	feedID, hid := uuid.New(), uuid.New()
+	var stored []*domain.FeedFilter
+	feedRepo := &stubFeedRepo{
+		byIDForHouseholdFn: func(id, _ uuid.UUID) (*domain.Feed, error) { return &domain.Feed{ID: id}, nil },
+		setFilterFn: func(h, f uuid.UUID, filter *domain.FeedFilter) error {
+			assert.Equal(t, hid, h)
+			assert.Equal(t, feedID, f)
+			stored = append(stored, filter)
+			return nil
+		},
+	}
+	svc := newTestFeedService(feedRepo, &stubPublisherService{}, &stubRecipeService{}, &stubRecipeIngestService{}, &stubScraperService{})
+
+	feed, err := svc.SetFilter(hid, feedID, &domain.FeedFilter{ExcludeKeywords: []string{" Cocktail", "cocktail ", "Review"}, Languages: []string{"EN"}})
+	require.NoError(t, err)
+	require.NotNil(t, feed.Filter)
+	assert.Equal(t, []string{"cocktail", "review"}, feed.Filter.ExcludeKeywords)
+	assert.Equal(t, []string{"en"}, feed.Filter.Languages)
+
+	feed, err = svc.SetFilter(hid, feedID, &domain.FeedFilter{IncludeKeywords: []string{"  "}})
+	require.NoError(t, err)
+	assert.Nil(t, feed.Filter)
+	require.Len(t, stored, 2)
+	assert.Nil(t, stored[1], "a filter without rules is stored as none")
+}
+
+func TestFeedService_SetFilter_NotSubscribed_ReturnsNotFound(t *testing.T) {
+	feedRepo := &stubFeedRepo{
+		byIDForHouseholdFn: func(uuid.UUID, uuid.UUID) (*domain.Feed, error) { return nil, sentinels.ErrNotFound },
+		setFilterFn: func(uuid.UUID, uuid.UUID, *domain.FeedFilter) error {
+			t.Fatal("must not filter a feed the household isn't subscribed to")
+			return nil
+		},
+	}
+
+	svc := newTestFeedService(feedRepo, &stubPublisherService{}, &stubRecipeService{}, &stubRecipeIngestService{}, &stubScraperService{})
+	_, err := svc.SetFilter(uuid.New(), uuid.New(), &domain.FeedFilter{MinIngredients: 3})
+	assert.ErrorIs(t, err, sentinels.ErrNotFound)
+}
+
 func TestFeedService_FetchFeed_ScrapeError_IncrementsErrorCount(t *testing.T) {
 	feed := domain.Feed{ID: uuid.New(), Active: true, ErrorCount: 2, Url: "https://bad.feed"}
 	var updatedFeed *domain.Feed
@@ -94,8 +244,8 @@ func TestFeedService_FetchFeed_ScrapeError_IncrementsErrorCount(t *testing.T) {
 		},
 	}
 	scraper := &stubScraperService{
-		scrapeFeedFn: func(_ context.Context, _ *domain.Feed, _ krip.FeedOptions) ([]*domain.Recipe, error) {
-			return nil, errors.New("feed unreachable")
+		scrapeFeedChangesFn: func(context.Context, *domain.Feed, krip.FeedOptions, func(string) bool) ([]*domain.Recipe, bool, error) {
+			return nil, false, errors.New("feed unreachable")
 		},
 	}
 
@@ -117,8 +267,8 @@ func TestFeedService_FetchFeed_ExceedErrorThreshold_DeactivatesFeed(t *testing.T
 		updateFn: func(f *domain.Feed) error { updatedFeed = f; return nil },
 	}
 	scraper := &stubScraperService{
-		scrapeFeedFn: func(_ context.Context, _ *domain.Feed, _ krip.FeedOptions) ([]*domain.Recipe, error) {
-			return nil, errors.New("still broken")
+		scrapeFeedChangesFn: func(context.Context, *domain.Feed, krip.FeedOptions, func(string) bool) ([]*domain.Recipe, bool, error) {
+			return nil, false, errors.New("still broken")
 		},
 	}
 
@@ -129,6 +279,76 @@ func TestFeedService_FetchFeed_ExceedErrorThreshold_DeactivatesFeed(t *testing.T
 	require.NotNil(t, updatedFeed)
 	assert.False(t, updatedFeed.Active, "feed must be deactivated after exceeding the error threshold")
 	assert.Equal(t, 4, updatedFeed.ErrorCount)
+	assert.False(t, updatedFeed.NextFetchAt.IsZero(), "a deactivated feed must still be probed later")
+}
+
+func TestFeedService_FetchFeed_ScrapeError_BacksOffExponentially(t *testing.T) {
+	feed := domain.Feed{ID: uuid.New(), Active: true, ErrorCount: 2, FetchInterval: 24 * time.Hour, Url: "https://bad.feed"}
+	scraper := &stubScraperService{
+		scrapeFeedChangesFn: func(context.Context, *domain.Feed, krip.FeedOptions, func(string) bool) ([]*domain.Recipe, bool, error) {
+			return nil, false, errors.New("feed unreachable")
+		},
+	}
+
+	svc := newTestFeedService(&stubFeedRepo{}, &stubPublisherService{}, &stubRecipeService{}, &stubRecipeIngestService{}, scraper)
+	_, _, err := svc.FetchFeed(context.Background(), &feed)
+
+	require.Error(t, err)
+	assert.Equal(t, 96*time.Hour, feed.NextFetchAt.Sub(feed.LastSyncAt), "the third failure in a row waits four intervals")
+	assert.Equal(t, 24*time.Hour, feed.FetchInterval, "failures must not change the publishing interval")
+}
+
+func TestFeedService_FetchFeed_DeactivatedFeedSucceeds_Reactivates(t *testing.T) {
+	feed := domain.Feed{ID: uuid.New(), Active: false, ErrorCount: 7, Url: "https://back.feed"}
+	var updatedFeed *domain.Feed
+	feedRepo := &stubFeedRepo{
+		updateFn: func(f *domain.Feed) error { updatedFeed = f; return nil },
+	}
+
+	svc := newTestFeedService(feedRepo, &stubPublisherService{}, &stubRecipeService{}, &stubRecipeIngestService{}, &stubScraperService{})
+	_, _, err := svc.FetchFeed(context.Background(), &feed)
+
+	require.NoError(t, err)
+	require.NotNil(t, updatedFeed)
+	assert.True(t, updatedFeed.Active)
+	assert.Zero(t, updatedFeed.ErrorCount)
+}
+
+func TestFeedService_FetchFeed_AdaptsIntervalToNewRecipes(t *testing.T) {
+	scraped := []*domain.Recipe{{SourceUrl: ptr("https://recipe.example.com/known")}, {SourceUrl: ptr("https://recipe.example.com/new")}}
+	scraper := &stubScraperService{
+		scrapeFeedChangesFn: feedChanges(scraped...),
+	}
+	known := true // whether the second recipe was imported before
+	recipeSvc := &stubRecipeService{
+		byUrlFn: func(url string, _ uuid.UUID) (*domain.Recipe, error) {
+			if url == "https://recipe.example.com/known" || known {
+				return &domain.Recipe{ID: uuid.New(), FeedID: new(uuid.New())}, nil
+			}
+			return nil, sentinels.ErrNotFound
+		},
+	}
+	ingest := &stubRecipeIngestService{
+		importRecipeFn: func(_ context.Context, r *domain.Recipe) (*domain.Recipe, error) { return r, nil },
+	}
+	svc := newTestFeedService(&stubFeedRepo{}, &stubPublisherService{}, recipeSvc, ingest, scraper)
+
+	feed := domain.Feed{ID: uuid.New(), Active: true, FetchInterval: 24 * time.Hour, Url: "https://good.feed"}
+	_, _, err := svc.FetchFeed(context.Background(), &feed)
+	require.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, feed.FetchInterval, "a fetch without new recipes stretches the interval")
+	assert.Equal(t, feed.FetchInterval, feed.NextFetchAt.Sub(feed.LastSyncAt))
+
+	known = false
+	_, _, err = svc.FetchFeed(context.Background(), &feed)
+	require.NoError(t, err)
+	assert.Equal(t, 18*time.Hour, feed.FetchInterval, "a fetch with new recipes halves the interval")
+
+	feed.FetchInterval = 0
+	known = true
+	_, _, err = svc.FetchFeed(context.Background(), &feed)
+	require.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, feed.FetchInterval, "a feed without an interval starts from FETCH_INTERVAL")
 }
 
 func TestFeedService_FetchFeed_SkipsAlreadyImportedRecipes(t *testing.T) {
@@ -140,9 +360,7 @@ func TestFeedService_FetchFeed_SkipsAlreadyImportedRecipes(t *testing.T) {
 		updateFn: func(_ *domain.Feed) error { return nil },
 	}
 	scraper := &stubScraperService{
-		scrapeFeedFn: func(_ context.Context, _ *domain.Feed, _ krip.FeedOptions) ([]*domain.Recipe, error) {
-			return []*domain.Recipe{scraped}, nil
-		},
+		scrapeFeedChangesFn: feedChanges(scraped),
 	}
 	recipeSvc := &stubRecipeService{
 		byUrlFn: func(_ string, _ uuid.UUID) (*domain.Recipe, error) {
@@ -172,9 +390,7 @@ func TestFeedService_FetchFeed_NewRecipe_ImportsAndAssignsFeedID(t *testing.T) {
 		updateFn: func(_ *domain.Feed) error { return nil },
 	}
 	scraper := &stubScraperService{
-		scrapeFeedFn: func(_ context.Context, _ *domain.Feed, _ krip.FeedOptions) ([]*domain.Recipe, error) {
-			return []*domain.Recipe{scraped}, nil
-		},
+		scrapeFeedChangesFn: feedChanges(scraped),
 	}
 	recipeSvc := &stubRecipeService{
 		byUrlFn: func(_ string, _ uuid.UUID) (*domain.Recipe, error) {
@@ -205,9 +421,7 @@ func TestFeedService_FetchFeed_ExistingPublicRecipeWithNoFeedID_BackfillsFeedID(
 
 	feedRepo := &stubFeedRepo{updateFn: func(_ *domain.Feed) error { return nil }}
 	scraper := &stubScraperService{
-		scrapeFeedFn: func(_ context.Context, _ *domain.Feed, _ krip.FeedOptions) ([]*domain.Recipe, error) {
-			return []*domain.Recipe{scraped}, nil
-		},
+		scrapeFeedChangesFn: feedChanges(scraped),
 	}
 	var setFeedIDRecipeID, setFeedIDFeedID uuid.UUID
 	recipeSvc := &stubRecipeService{
@@ -244,9 +458,7 @@ func TestFeedService_FetchFeed_ExistingPrivateRecipe_ImportsNewPublicCopy(t *tes
 
 	feedRepo := &stubFeedRepo{updateFn: func(_ *domain.Feed) error { return nil }}
 	scraper := &stubScraperService{
-		scrapeFeedFn: func(_ context.Context, _ *domain.Feed, _ krip.FeedOptions) ([]*domain.Recipe, error) {
-			return []*domain.Recipe{scraped}, nil
-		},
+		scrapeFeedChangesFn: feedChanges(scraped),
 	}
 	recipeSvc := &stubRecipeService{
 		byUrlFn: func(_ string, _ uuid.UUID) (*domain.Recipe, error) {
@@ -270,33 +482,61 @@ func TestFeedService_FetchFeed_ExistingPrivateRecipe_ImportsNewPublicCopy(t *tes
 	assert.Equal(t, 1, imported)
 }
 
-func TestFeedService_FetchFeed_RecipeWithNoURL_IsSkipped(t *testing.T) {
-	// Recipes without SourceUrl (no URL) cannot be deduplicated and must be
-	// skipped to avoid importing the same recipe repeatedly.
-	feed := domain.Feed{ID: uuid.New(), Active: true, Url: "https://good.feed"}
-	noURLRecipe := &domain.Recipe{SourceUrl: nil}
-
-	feedRepo := &stubFeedRepo{
-		updateFn: func(_ *domain.Feed) error { return nil },
-	}
+func TestFeedService_FetchFeed_Unchanged_SucceedsWithoutImport(t *testing.T) {
+	feed := domain.Feed{ID: uuid.New(), Active: true, ErrorCount: 1, FetchInterval: 24 * time.Hour, Url: "https://quiet.feed"}
 	scraper := &stubScraperService{
-		scrapeFeedFn: func(_ context.Context, _ *domain.Feed, _ krip.FeedOptions) ([]*domain.Recipe, error) {
-			return []*domain.Recipe{noURLRecipe}, nil
+		scrapeFeedChangesFn: func(context.Context, *domain.Feed, krip.FeedOptions, func(string) bool) ([]*domain.Recipe, bool, error) {
+			return nil, true, nil
 		},
 	}
 	importCalled := false
 	recipeIngest := &stubRecipeIngestService{
-		importRecipeFn: func(_ context.Context, _ *domain.Recipe) (*domain.Recipe, error) {
+		importRecipeFn: func(_ context.Context, r *domain.Recipe) (*domain.Recipe, error) {
 			importCalled = true
-			return nil, nil
+			return r, nil
 		},
 	}
 
-	svc := newTestFeedService(feedRepo, &stubPublisherService{}, &stubRecipeService{}, recipeIngest, scraper)
-	_, _, err := svc.FetchFeed(context.Background(), &feed)
+	svc := newTestFeedService(&stubFeedRepo{}, &stubPublisherService{}, &stubRecipeService{}, recipeIngest, scraper)
+	found, imported, err := svc.FetchFeed(context.Background(), &feed)
 
 	require.NoError(t, err)
-	assert.False(t, importCalled, "recipe without URL must be skipped")
+	assert.False(t, importCalled)
+	assert.Zero(t, found)
+	assert.Zero(t, imported)
+	assert.True(t, feed.LastSyncSuccess, "an unchanged feed is a successful fetch")
+	assert.Zero(t, feed.ErrorCount)
+	assert.Equal(t, 36*time.Hour, feed.FetchInterval, "an unchanged feed stretches the interval")
+}
+
+func TestFeedService_FetchFeed_ImportFails_KeepsPreviousValidators(t *testing.T) {
+	feed := domain.Feed{ID: uuid.New(), Active: true, Url: "https://flaky.feed", ETag: `"v1"`, ContentHash: "old"}
+	changes := feedChanges(&domain.Recipe{SourceUrl: ptr("https://recipe.example.com/ok")}, &domain.Recipe{SourceUrl: ptr("https://recipe.example.com/broken")})
+	scraper := &stubScraperService{
+		scrapeFeedChangesFn: func(ctx context.Context, f *domain.Feed, opts krip.FeedOptions, isKnown func(string) bool) ([]*domain.Recipe, bool, error) {
+			f.ETag, f.ContentHash = `"v2"`, "new"
+			return changes(ctx, f, opts, isKnown)
+		},
+	}
+	recipeSvc := &stubRecipeService{
+		byUrlFn: func(_ string, _ uuid.UUID) (*domain.Recipe, error) { return nil, sentinels.ErrNotFound },
+	}
+	recipeIngest := &stubRecipeIngestService{
+		importRecipeFn: func(_ context.Context, r *domain.Recipe) (*domain.Recipe, error) {
+			if *r.SourceUrl == "https://recipe.example.com/broken" {
+				return nil, errors.New("db down")
+			}
+			return r, nil
+		},
+	}
+
+	svc := newTestFeedService(&stubFeedRepo{}, &stubPublisherService{}, recipeSvc, recipeIngest, scraper)
+	_, imported, err := svc.FetchFeed(context.Background(), &feed)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	assert.Equal(t, `"v1"`, feed.ETag, "the next fetch must not be answered 304 before the failed entry is retried")
+	assert.Equal(t, "old", feed.ContentHash)
 }
 
 func TestFeedService_Subscribe_NoRecipesButHasName_Succeeds(t *testing.T) {
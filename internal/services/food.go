@@ -51,6 +51,13 @@ func (s *foodService) FindOrCreate(ctx context.Context, food *domain.Food) error
 	return nil
 }
 
+func (s *foodService) Find(food *domain.Food) error {
+	if err := s.repo.Find(food); err != nil {
+		return fmt.Errorf("find: %w", err)
+	}
+	return nil
+}
+
 func (s *foodService) Search(query string, offset, limit int) ([]domain.Food, int64, error) {
 	foods, total, err := s.repo.Search(query, offset, limit)
 	if err != nil {
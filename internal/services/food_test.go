@@ -21,6 +21,7 @@ func (r *fakeFoodRepo) ByIDs(_ []uuid.UUID) (map[uuid.UUID]*domain.Food, error)
 	return make(map[uuid.UUID]*domain.Food), nil
 }
 func (r *fakeFoodRepo) FindOrCreate(_ *domain.Food) error                       { return nil }
+func (r *fakeFoodRepo) Find(_ *domain.Food) error                               { return nil }
 func (r *fakeFoodRepo) Search(_ string, _, _ int) ([]domain.Food, int64, error) { return nil, 0, nil }
 func (r *fakeFoodRepo) Merge(_, _ uuid.UUID) error                              { return nil }
 func (r *fakeFoodRepo) AddTaxonomy(_ uuid.UUID, _ *domain.Taxonomy) error       { return nil }
@@ -1,8 +1,10 @@
 package services_test
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"io/fs"
 	"strings"
 	"testing"
 
@@ -29,12 +31,20 @@ func newFakeStorage() *fakeFileStorage {
 	return &fakeFileStorage{saved: make(map[string][]byte), baseURL: "http://cdn.test"}
 }
 
-func (f *fakeFileStorage) Save(path string, _ io.Reader, _ int64, _ string) error {
+func (f *fakeFileStorage) Save(path string, content io.Reader, _ int64, _ string) error {
 	if f.saveErr != nil {
 		return f.saveErr
 	}
-	f.saved[path] = nil
-	return nil
+	data, err := io.ReadAll(content)
+	f.saved[path] = data
+	return err
+}
+func (f *fakeFileStorage) Open(path string) (io.ReadCloser, error) {
+	data, ok := f.saved[path]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
 }
 func (f *fakeFileStorage) Delete(path string) error { f.deleted = append(f.deleted, path); return nil }
 func (f *fakeFileStorage) GetBaseURL() string       { return f.baseURL }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"cmp"
 	"context"
 	"errors"
 
@@ -17,14 +18,22 @@ type importService struct {
 	recipeIngest   domain.RecipeIngestService
 	feedService    domain.FeedService
 	scraperService domain.ScraperService
+	imageService   domain.ImageService
+	foodService    domain.FoodService
+	unitService    domain.UnitService
+	mapper         *scraperMapper
 }
 
-func NewImportService(recipeService domain.RecipeService, recipeIngest domain.RecipeIngestService, feedService domain.FeedService, scraperService domain.ScraperService) domain.ImportService {
+func NewImportService(recipeService domain.RecipeService, recipeIngest domain.RecipeIngestService, feedService domain.FeedService, scraperService domain.ScraperService, imageService domain.ImageService, foodService domain.FoodService, unitService domain.UnitService, parser IngredientParser) domain.ImportService {
 	return &importService{
 		recipeService:  recipeService,
 		recipeIngest:   recipeIngest,
 		feedService:    feedService,
 		scraperService: scraperService,
+		imageService:   imageService,
+		foodService:    foodService,
+		unitService:    unitService,
+		mapper:         newScraperMapper(parser),
 	}
 }
 
@@ -61,9 +70,28 @@ func (s *importService) ImportFromURL(ctx context.Context, url string, forceUpda
 }
 
 // DetectAndImport scrapes the given URL and imports it as either a recipe or a feed subscription.
-func (s *importService) DetectAndImport(ctx context.Context, url string, requestedType string, forceUpdate bool, userID uuid.UUID, householdID uuid.UUID) (*domain.ImportResult, error) {
+func (s *importService) DetectAndImport(ctx context.Context, url string, requestedType string, forceUpdate bool, dryRun bool, userID uuid.UUID, householdID uuid.UUID) (*domain.ImportResult, error) {
 	url = utils.NormalizeURL(url)
+	return s.detectAndImport(ctx, url, forceUpdate, dryRun, false, userID, householdID, func() (*domain.ScrapeResult, error) {
+		return s.scraperService.ScrapeUrl(ctx, url, requestedType)
+	})
+}
 
+// ImportHtml is DetectAndImport for a page the client fetched itself, for sites that block the server or need a login.
+// The page can't be trusted to match the URL, so the recipe is stored for the household only, next to the shared
+// recipes of scraped URLs, and feeds are fetched by the server.
+func (s *importService) ImportHtml(ctx context.Context, html []byte, url string, requestedType string, forceUpdate bool, dryRun bool, userID uuid.UUID, householdID uuid.UUID) (*domain.ImportResult, error) {
+	if forceUpdate {
+		return nil, sentinels.BadRequest("update is not supported for pages sent as html")
+	}
+	url = utils.NormalizeURL(url)
+	return s.detectAndImport(ctx, url, false, dryRun, true, userID, householdID, func() (*domain.ScrapeResult, error) {
+		return s.scraperService.ScrapeHtml(ctx, html, url, requestedType)
+	})
+}
+
+// detectAndImport imports the page scrape returns. A clientPage is kept in the household, see ImportHtml.
+func (s *importService) detectAndImport(ctx context.Context, url string, forceUpdate bool, dryRun bool, clientPage bool, userID uuid.UUID, householdID uuid.UUID, scrape func() (*domain.ScrapeResult, error)) (*domain.ImportResult, error) {
 	if !forceUpdate {
 		// Check recipe cache before paying the cost of a network scrape.
 		existing, err := s.recipeService.ByUrl(url, householdID)
@@ -71,6 +99,9 @@ func (s *importService) DetectAndImport(ctx context.Context, url string, request
 			return nil, fmt.Errorf("detect (lookup cache): %w", err)
 		}
 		if existing != nil {
+			if dryRun {
+				return &domain.ImportResult{Recipe: existing}, nil
+			}
 			if err := s.recipeService.UserSave(existing.ID, userID, householdID); err != nil {
 				return nil, fmt.Errorf("detect (save existing): %w", err)
 			}
@@ -79,19 +110,34 @@ func (s *importService) DetectAndImport(ctx context.Context, url string, request
 	}
 
 	// Unknown URL — must scrape to determine whether it's a recipe or a feed.
-	scraped, err := s.scraperService.ScrapeUrl(ctx, url, requestedType)
+	scraped, err := scrape()
 	if err != nil {
 		return nil, fmt.Errorf("detect (scrape): %w", err)
 	}
+	if dryRun {
+		preview, err := s.preview(scraped)
+		if err != nil {
+			return nil, fmt.Errorf("detect (preview): %w", err)
+		}
+		return &domain.ImportResult{Preview: preview}, nil
+	}
 
 	if scraped.Type == domain.PageTypeFeed {
-		feed, err := s.feedService.Subscribe(ctx, householdID, url, scraped.Feed)
+		scrapedFeed := scraped.Feed
+		if clientPage {
+			scrapedFeed = nil // feeds are shared, so the server fetches it rather than taking the client's word for it
+		}
+		feed, err := s.feedService.Subscribe(ctx, householdID, url, scrapedFeed)
 		if err != nil {
 			return nil, fmt.Errorf("detect (subscribe): %w", err)
 		}
 		return &domain.ImportResult{Created: true, Feed: feed}, nil
 	}
 
+	if clientPage {
+		scraped.Recipe.HouseholdID = &householdID
+		scraped.Recipe.UserID = &userID
+	}
 	recipe, err := s.recipeIngest.ImportRecipe(ctx, scraped.Recipe)
 	if err != nil {
 		return nil, fmt.Errorf("detect (ingest): %w", err)
@@ -101,3 +147,54 @@ func (s *importService) DetectAndImport(ctx context.Context, url string, request
 	}
 	return &domain.ImportResult{Created: true, Recipe: recipe}, nil
 }
+
+// preview looks up the foods and units of a scraped recipe without creating the missing ones.
+func (s *importService) preview(scraped *domain.ScrapeResult) (*domain.ImportPreview, error) {
+	preview := &domain.ImportPreview{ScrapeResult: *scraped}
+	if scraped.Recipe == nil {
+		return preview, nil
+	}
+
+	foods := make(map[string]bool)
+	units := make(map[string]bool)
+	for _, ing := range scraped.Recipe.Ingredients {
+		if ing.Food != nil && !foods[ing.Food.Slug] {
+			foods[ing.Food.Slug] = true
+			food := &domain.Food{Name: ing.Food.Name, Slug: cmp.Or(ing.Food.Slug, utils.CreateTag(ing.Food.Name))}
+			hint, err := importHint(food.Name, food.Slug, func() (uuid.UUID, error) {
+				err := s.foodService.Find(food)
+				return food.ID, err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("find food %s: %w", food.Slug, err)
+			}
+			preview.Foods = append(preview.Foods, hint)
+		}
+		if ing.Unit != nil && !units[ing.Unit.Slug] {
+			units[ing.Unit.Slug] = true
+			unit := &domain.Unit{Name: ing.Unit.Name, Slug: cmp.Or(ing.Unit.Slug, utils.CreateTag(ing.Unit.Name))}
+			hint, err := importHint(unit.Name, unit.Slug, func() (uuid.UUID, error) {
+				err := s.unitService.Find(unit)
+				return unit.ID, err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("find unit %s: %w", unit.Slug, err)
+			}
+			preview.Units = append(preview.Units, hint)
+		}
+	}
+	return preview, nil
+}
+
+func importHint(name, slug string, find func() (uuid.UUID, error)) (domain.ImportHint, error) {
+	hint := domain.ImportHint{Name: name, Slug: slug}
+	id, err := find()
+	if errors.Is(err, sentinels.ErrNotFound) {
+		return hint, nil
+	}
+	if err != nil {
+		return hint, err
+	}
+	hint.Exists, hint.ID = true, &id
+	return hint, nil
+}
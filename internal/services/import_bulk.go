@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/utils"
+)
+
+// maxBulkImportURLs caps how many URLs a single bulk import takes, larger lists have to be split by the client.
+const maxBulkImportURLs = 500
+
+// EnqueueURLs queues a recipe import job for each URL, so a long list doesn't hold the request while it is scraped.
+// Every URL gets a job of the bulk, the ones already imported and the invalid ones are finished right away.
+// Duplicates are queued once, the report keeps the order of the first occurrence.
+func (s *importJobService) EnqueueURLs(urls []string, userID uuid.UUID, householdID uuid.UUID) (*domain.BulkImportReport, error) {
+	unique := make([]string, 0, len(urls))
+	seen := make(map[string]bool, len(urls))
+	for _, raw := range urls {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		normalized := utils.NormalizeURL(raw)
+		if seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		unique = append(unique, normalized)
+	}
+	if len(unique) == 0 {
+		return nil, sentinels.BadRequest("no URLs to import")
+	}
+	if len(unique) > maxBulkImportURLs {
+		return nil, sentinels.BadRequest(fmt.Sprintf("too many URLs, at most %d can be imported at once", maxBulkImportURLs))
+	}
+
+	bulkID, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("enqueue urls (id): %w", err)
+	}
+	jobs := make([]*domain.ImportJob, len(unique))
+	for i, url := range unique {
+		// IDs are assigned in order, so the jobs of the bulk list in the order of the URLs
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, fmt.Errorf("enqueue urls (id): %w", err)
+		}
+		job := &domain.ImportJob{
+			ID:            id,
+			BulkID:        &bulkID,
+			HouseholdID:   householdID,
+			UserID:        userID,
+			Url:           url,
+			RequestedType: domain.ImportTypeRecipe,
+			Status:        domain.JobStatusPending,
+		}
+		if !isWebURL(url) {
+			job.Status, job.ErrorMessage = domain.JobStatusError, "not a web URL"
+		} else if existing, err := s.existingRecipe(url, userID, householdID); err != nil {
+			return nil, fmt.Errorf("enqueue urls: %w", err)
+		} else if existing != nil {
+			job.Status, job.Existing, job.RecipeID = domain.JobStatusSuccess, true, &existing.ID
+		}
+		jobs[i] = job
+	}
+
+	if err := s.repo.CreateBatch(jobs); err != nil {
+		return nil, fmt.Errorf("enqueue urls: %w", err)
+	}
+	return s.BulkByID(bulkID, householdID)
+}
+
+// existingRecipe saves the recipe already imported from url for the user, nil when there is none.
+func (s *importJobService) existingRecipe(url string, userID uuid.UUID, householdID uuid.UUID) (*domain.Recipe, error) {
+	existing, err := s.recipeService.ByUrl(url, householdID)
+	if err != nil && !errors.Is(err, sentinels.ErrNotFound) && !errors.Is(err, sentinels.ErrForbidden) {
+		return nil, fmt.Errorf("lookup %s: %w", url, err)
+	}
+	if existing == nil {
+		return nil, nil
+	}
+	if err := s.recipeService.UserSave(existing.ID, userID, householdID); err != nil {
+		return nil, fmt.Errorf("save existing %s: %w", url, err)
+	}
+	return existing, nil
+}
+
+func (s *importJobService) BulkByID(id uuid.UUID, householdID uuid.UUID) (*domain.BulkImportReport, error) {
+	jobs, err := s.repo.ListByBulk(id)
+	if err != nil {
+		return nil, fmt.Errorf("bulk by id: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, sentinels.ErrNotFound
+	}
+	if jobs[0].HouseholdID != householdID {
+		return nil, sentinels.ErrForbidden
+	}
+	return bulkReport(id, jobs), nil
+}
+
+// bulkReport rolls up the jobs of a bulk import, a finished job either created the recipe or found it imported.
+func bulkReport(id uuid.UUID, jobs []domain.ImportJob) *domain.BulkImportReport {
+	report := &domain.BulkImportReport{ID: id, Items: make([]domain.BulkImportItem, len(jobs))}
+	for i, job := range jobs {
+		item := domain.BulkImportItem{Url: job.Url, JobID: job.ID, Recipe: job.Recipe}
+		switch {
+		case job.Status == domain.JobStatusError:
+			item.Status, item.Error = domain.BulkImportFailed, job.ErrorMessage
+			report.Failed++
+		case job.Status != domain.JobStatusSuccess:
+			item.Status = domain.BulkImportQueued
+			report.Queued++
+		case job.Existing:
+			item.Status = domain.BulkImportExisting
+			report.Existing++
+		default:
+			item.Status = domain.BulkImportCreated
+			report.Created++
+		}
+		report.Items[i] = item
+	}
+	return report
+}
+
+// EnqueueBookmarks queues every web link of a Netscape bookmarks file, folders are ignored.
+func (s *importJobService) EnqueueBookmarks(data []byte, userID uuid.UUID, householdID uuid.UUID) (*domain.BulkImportReport, error) {
+	urls, err := parseBookmarks(data)
+	if err != nil {
+		return nil, sentinels.BadRequest("invalid bookmarks file: " + err.Error())
+	}
+	if len(urls) == 0 {
+		return nil, sentinels.BadRequest("bookmarks file has no links")
+	}
+	return s.EnqueueURLs(urls, userID, householdID)
+}
+
+// parseBookmarks returns the href of every link in a Netscape bookmarks file, which is loose HTML
+// (unclosed <DT> and <p> tags), so it is tokenized rather than parsed into a tree.
+func parseBookmarks(data []byte) ([]string, error) {
+	var urls []string
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return urls, nil
+		case html.StartTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "a" || !hasAttr {
+				continue
+			}
+			for {
+				key, val, more := tokenizer.TagAttr()
+				if string(key) == "href" && isWebURL(string(val)) {
+					urls = append(urls, string(val))
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+}
+
+// isWebURL reports whether rawURL is an absolute http or https URL, bookmarks also hold javascript: and place: links.
+func isWebURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
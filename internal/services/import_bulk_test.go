@@ -0,0 +1,136 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/services"
+)
+
+func TestImportJobService_EnqueueURLs_QueuesNewWebURLs(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	existing := &domain.Recipe{ID: uuid.New(), Name: new("Borscht")}
+	var saved []uuid.UUID
+	recipes := &stubRecipeService{
+		byUrlFn: func(url string, _ uuid.UUID) (*domain.Recipe, error) {
+			if url == "https://example.com/borscht" {
+				return existing, nil
+			}
+			return nil, sentinels.ErrNotFound
+		},
+		userSaveFn: func(recipeID, _, _ uuid.UUID) error {
+			saved = append(saved, recipeID)
+			return nil
+		},
+	}
+	repo := &stubImportJobRepo{}
+	svc := services.NewImportJobService(repo, &stubImportService{}, recipes, &stubFeedService{}, newFakeStorage())
+
+	report, err := svc.EnqueueURLs([]string{
+		"https://example.com/borscht",
+		"https://example.com/pelmeni",
+		" https://example.com/pelmeni ",
+		"ftp://example.com/file",
+		"",
+	}, uid, hid)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, uuid.Nil, report.ID)
+	assert.Equal(t, 1, report.Existing)
+	assert.Equal(t, 1, report.Queued)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Items, 3, "duplicates and blank lines are dropped")
+
+	assert.Equal(t, domain.BulkImportExisting, report.Items[0].Status)
+	assert.Equal(t, []uuid.UUID{existing.ID}, saved, "a known URL is saved for the user without a scrape")
+	assert.Equal(t, domain.BulkImportQueued, report.Items[1].Status)
+	assert.Equal(t, "https://example.com/pelmeni", report.Items[1].Url)
+	assert.Equal(t, domain.BulkImportFailed, report.Items[2].Status)
+	assert.Equal(t, "not a web URL", report.Items[2].Error)
+
+	require.Len(t, repo.created, 3, "every URL gets a job of the bulk")
+	for _, job := range repo.created {
+		assert.Equal(t, report.ID, *job.BulkID)
+		assert.Equal(t, domain.ImportTypeRecipe, job.RequestedType)
+		assert.Equal(t, []uuid.UUID{uid, hid}, []uuid.UUID{job.UserID, job.HouseholdID})
+	}
+	assert.Equal(t, domain.JobStatusPending, repo.created[1].Status, "only the new URL is left for the queue")
+}
+
+func TestImportJobService_EnqueueURLs_BatchFails_QueuesNothing(t *testing.T) {
+	repo := &stubImportJobRepo{createBatchErr: errors.New("db down")}
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	_, err := svc.EnqueueURLs([]string{"https://example.com/borscht", "https://example.com/pelmeni"}, uuid.New(), uuid.New())
+
+	require.Error(t, err)
+	assert.Empty(t, repo.created)
+}
+
+func TestImportJobService_BulkByID_RollsUpJobs(t *testing.T) {
+	hid := uuid.New()
+	bulkID := uuid.New()
+	recipe := &domain.Recipe{ID: uuid.New()}
+	repo := &stubImportJobRepo{created: []domain.ImportJob{
+		{ID: uuid.New(), BulkID: &bulkID, HouseholdID: hid, Url: "https://example.com/a", Status: domain.JobStatusSuccess, Recipe: recipe},
+		{ID: uuid.New(), BulkID: &bulkID, HouseholdID: hid, Url: "https://example.com/b", Status: domain.JobStatusSuccess, Existing: true},
+		{ID: uuid.New(), BulkID: &bulkID, HouseholdID: hid, Url: "https://example.com/c", Status: domain.JobStatusError, ErrorMessage: "no recipe found"},
+		{ID: uuid.New(), BulkID: &bulkID, HouseholdID: hid, Url: "https://example.com/d", Status: domain.JobStatusRunning},
+	}}
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	report, err := svc.BulkByID(bulkID, hid)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 1, 1, 1}, []int{report.Created, report.Existing, report.Failed, report.Queued})
+	assert.Equal(t, recipe, report.Items[0].Recipe)
+	assert.Equal(t, "no recipe found", report.Items[2].Error)
+
+	_, err = svc.BulkByID(bulkID, uuid.New())
+	assert.ErrorIs(t, err, sentinels.ErrForbidden)
+	_, err = svc.BulkByID(uuid.New(), hid)
+	assert.ErrorIs(t, err, sentinels.ErrNotFound)
+}
+
+func TestImportJobService_EnqueueURLs_NoURLs(t *testing.T) {
+	svc := services.NewImportJobService(&stubImportJobRepo{}, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+	_, err := svc.EnqueueURLs([]string{" ", ""}, uuid.New(), uuid.New())
+	assert.ErrorIs(t, err, sentinels.BadRequest(""))
+}
+
+func TestImportJobService_EnqueueBookmarks_QueuesWebLinks(t *testing.T) {
+	bookmarks := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000">Recipes</H3>
+    <DL><p>
+        <DT><A HREF="https://example.com/borscht" ADD_DATE="1700000000">Borscht</A>
+        <DT><A HREF="https://example.com/pelmeni">Pelmeni</A>
+        <DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+        <DT><A HREF="place:sort=8&maxResults=10">Recent</A>
+    </DL><p>
+</DL><p>
+`
+	repo := &stubImportJobRepo{}
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	report, err := svc.EnqueueBookmarks([]byte(bookmarks), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Queued)
+	require.Len(t, repo.created, 2)
+	assert.Equal(t, "https://example.com/borscht", repo.created[0].Url)
+	assert.Equal(t, "https://example.com/pelmeni", repo.created[1].Url)
+}
+
+func TestImportJobService_EnqueueBookmarks_NoLinks(t *testing.T) {
+	svc := services.NewImportJobService(&stubImportJobRepo{}, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+	_, err := svc.EnqueueBookmarks([]byte("<DL><p></DL>"), uuid.New(), uuid.New())
+	assert.ErrorIs(t, err, sentinels.BadRequest(""))
+}
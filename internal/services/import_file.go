@@ -0,0 +1,732 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/borschtapp/krip"
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/types"
+)
+
+// maxExportEntrySize caps how much is decompressed from a single archive entry, so a crafted archive cannot exhaust memory.
+const maxExportEntrySize = 64 << 20
+
+// exportedRecipe is a recipe read from an export file. Formats close to schema.org fill in schema
+// to be mapped like a scraped recipe, the others the recipe itself.
+type exportedRecipe struct {
+	name   string
+	schema *krip.Recipe
+	recipe *domain.Recipe
+	image  []byte
+	err    error
+}
+
+// ImportFile detects the format of an export archive and imports each of its recipes into the household.
+// Recipes are read from the archive in the same order every time, so the items of report match the first recipes.
+func (s *importService) ImportFile(ctx context.Context, data []byte, report *domain.FileImportReport, progress func(*domain.FileImportReport) error, userID uuid.UUID, householdID uuid.UUID) (*domain.FileImportReport, error) {
+	archive, source, err := openExport(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var exported []exportedRecipe
+	switch source {
+	case domain.ImportSourcePaprika:
+		exported = readPaprikaExport(archive)
+	case domain.ImportSourceCooklang:
+		exported = readCooklangExport(archive)
+	case domain.ImportSourceMealie:
+		if exported, err = readMealieExport(archive); err != nil {
+			return nil, sentinels.BadRequest("invalid Mealie backup: " + err.Error())
+		}
+	case domain.ImportSourceTandoor:
+		exported = readTandoorExport(archive)
+	}
+
+	if report == nil || report.Source != source || len(report.Items) > len(exported) {
+		report = &domain.FileImportReport{Source: source, Items: make([]domain.FileImportItem, 0, len(exported))}
+	}
+	for _, entry := range exported[len(report.Items):] {
+		item := domain.FileImportItem{Name: entry.name}
+		err := entry.err
+		if err == nil {
+			item.Recipe, err = s.importExported(ctx, entry, userID, householdID)
+		}
+		if err != nil {
+			log.Warnw("failed to import recipe from file", "source", source, "recipe", entry.name, "error", err.Error())
+			item.Error = err.Error()
+			report.Failed++
+		} else {
+			report.Imported++
+		}
+		report.Items = append(report.Items, item)
+		if progress != nil {
+			if err := progress(report); err != nil {
+				return report, fmt.Errorf("import file (progress): %w", err)
+			}
+		}
+	}
+	return report, nil
+}
+
+func (s *importService) importExported(ctx context.Context, entry exportedRecipe, userID uuid.UUID, householdID uuid.UUID) (*domain.Recipe, error) {
+	recipe := entry.recipe
+	if recipe == nil {
+		recipe = s.mapper.toRecipe(entry.schema)
+	}
+	if recipe.SourceUrl != nil && *recipe.SourceUrl == "" {
+		recipe.SourceUrl = nil
+	}
+	if recipe.Name == nil {
+		return nil, sentinels.Unprocessable("recipe has no name")
+	}
+	recipe.HouseholdID = &householdID
+	recipe.UserID = &userID
+
+	if len(entry.image) > 0 {
+		contentType := http.DetectContentType(entry.image)
+		if strings.HasPrefix(contentType, "image/") {
+			if uploaded, err := s.imageService.PersistUploaded(ctx, entry.image, contentType); err != nil {
+				log.Warnw("failed to store imported recipe image", "recipe", entry.name, "error", err.Error())
+			} else {
+				recipe.ImagePath = &uploaded.Path
+			}
+		}
+	}
+
+	recipe, err := s.recipeIngest.ImportRecipe(ctx, recipe)
+	if err != nil {
+		return nil, fmt.Errorf("import file (ingest): %w", err)
+	}
+	if err := s.recipeService.UserSave(recipe.ID, userID, householdID); err != nil {
+		return nil, fmt.Errorf("import file (save): %w", err)
+	}
+	return recipe, nil
+}
+
+// ImportCooklang parses a single Cooklang recipe and imports it into the household.
+func (s *importService) ImportCooklang(ctx context.Context, name string, text string, userID uuid.UUID, householdID uuid.UUID) (*domain.Recipe, error) {
+	recipe := parseCooklang(text)
+	if len(recipe.Instructions) == 0 {
+		return nil, sentinels.BadRequest("no Cooklang steps found")
+	}
+	if recipe.Name == nil && name != "" {
+		recipe.Name = &name
+	}
+	return s.importExported(ctx, exportedRecipe{name: name, recipe: recipe}, userID, householdID)
+}
+
+// openExport opens an export archive and detects the recipe manager it comes from.
+func openExport(data []byte) (*zip.Reader, string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, "", sentinels.BadRequest("file is not a Paprika, Mealie, Tandoor or Cooklang export")
+	}
+	source := detectExportSource(archive)
+	if source == "" {
+		return nil, "", sentinels.BadRequest("file is not a Paprika, Mealie, Tandoor or Cooklang export")
+	}
+	return archive, source, nil
+}
+
+func detectExportSource(archive *zip.Reader) string {
+	var mealie, tandoor bool
+	for _, f := range archive.File {
+		switch {
+		case strings.HasSuffix(f.Name, ".paprikarecipe"):
+			return domain.ImportSourcePaprika
+		case strings.HasSuffix(f.Name, ".cook"):
+			return domain.ImportSourceCooklang
+		case f.Name == "database.json":
+			mealie = true
+		case f.Name == "recipe.json" || strings.HasSuffix(f.Name, ".zip"):
+			tandoor = true
+		}
+	}
+	if mealie {
+		return domain.ImportSourceMealie
+	}
+	if tandoor {
+		return domain.ImportSourceTandoor
+	}
+	return ""
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxExportEntrySize {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rc.Close(); err != nil {
+			log.Warnw("failed to close archive entry", "name", f.Name, "error", err.Error())
+		}
+	}()
+	return readLimited(rc, f.Name)
+}
+
+func readLimited(r io.Reader, name string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxExportEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxExportEntrySize {
+		return nil, fmt.Errorf("%s is too large", name)
+	}
+	return data, nil
+}
+
+// Paprika: every .paprikarecipe entry is a gzipped JSON recipe with the photo embedded as base64.
+
+type paprikaRecipe struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Ingredients string   `json:"ingredients"`
+	Directions  string   `json:"directions"`
+	Notes       string   `json:"notes"`
+	Servings    string   `json:"servings"`
+	PrepTime    string   `json:"prep_time"`
+	CookTime    string   `json:"cook_time"`
+	TotalTime   string   `json:"total_time"`
+	Difficulty  string   `json:"difficulty"`
+	SourceURL   string   `json:"source_url"`
+	Categories  []string `json:"categories"`
+	PhotoData   string   `json:"photo_data"`
+}
+
+func readPaprikaExport(archive *zip.Reader) []exportedRecipe {
+	var exported []exportedRecipe
+	for _, f := range archive.File {
+		if !strings.HasSuffix(f.Name, ".paprikarecipe") {
+			continue
+		}
+		entry := exportedRecipe{name: strings.TrimSuffix(path.Base(f.Name), ".paprikarecipe")}
+		var src paprikaRecipe
+		if err := readPaprikaRecipe(f, &src); err != nil {
+			entry.err = fmt.Errorf("read %s: %w", f.Name, err)
+			exported = append(exported, entry)
+			continue
+		}
+		if src.Name != "" {
+			entry.name = src.Name
+		}
+
+		entry.schema = &krip.Recipe{
+			Name:         src.Name,
+			Description:  src.Description,
+			Url:          src.SourceURL,
+			Text:         strings.TrimSpace(src.Notes),
+			Yield:        src.Servings,
+			Difficulty:   src.Difficulty,
+			PrepTime:     parseExportDuration(src.PrepTime),
+			CookTime:     parseExportDuration(src.CookTime),
+			TotalTime:    parseExportDuration(src.TotalTime),
+			Categories:   src.Categories,
+			Ingredients:  textIngredients(src.Ingredients),
+			Instructions: textInstructions(src.Directions),
+		}
+		if src.PhotoData != "" {
+			if image, err := base64.StdEncoding.DecodeString(src.PhotoData); err == nil {
+				entry.image = image
+			}
+		}
+		exported = append(exported, entry)
+	}
+	return exported
+}
+
+func readPaprikaRecipe(f *zip.File, target *paprikaRecipe) error {
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	raw, err := readLimited(gz, f.Name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, target)
+}
+
+// textIngredients turns one ingredient per line into ingredients for the parser, skipping blank lines.
+func textIngredients(text string) []*krip.PropertyValue {
+	var ingredients []*krip.PropertyValue
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ingredients = append(ingredients, &krip.PropertyValue{Name: line})
+		}
+	}
+	return ingredients
+}
+
+// textInstructions turns every non-blank line into a step.
+func textInstructions(text string) []*krip.HowToSection {
+	section := &krip.HowToSection{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			section.Steps = append(section.Steps, &krip.HowToStep{Text: line})
+		}
+	}
+	if len(section.Steps) == 0 {
+		return nil
+	}
+	return []*krip.HowToSection{section}
+}
+
+// Cooklang: a folder of .cook files, named after their recipe, with an optional image of the same name next to them.
+
+var cooklangImageExtensions = []string{".jpg", ".jpeg", ".png", ".webp"}
+
+func readCooklangExport(archive *zip.Reader) []exportedRecipe {
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var exported []exportedRecipe
+	for _, f := range archive.File {
+		if !strings.HasSuffix(f.Name, ".cook") {
+			continue
+		}
+		base := strings.TrimSuffix(f.Name, ".cook")
+		entry := exportedRecipe{name: path.Base(base)}
+		data, err := readZipFile(f)
+		if err != nil {
+			entry.err = fmt.Errorf("read %s: %w", f.Name, err)
+			exported = append(exported, entry)
+			continue
+		}
+		entry.recipe = parseCooklang(string(data))
+		if entry.recipe.Name == nil {
+			entry.recipe.Name = new(entry.name)
+		}
+		for _, ext := range cooklangImageExtensions {
+			if img, ok := files[base+ext]; ok {
+				if image, err := readZipFile(img); err == nil {
+					entry.image = image
+				}
+				break
+			}
+		}
+		exported = append(exported, entry)
+	}
+	return exported
+}
+
+// Mealie: a backup holds every table of its database in database.json, images are under data/recipes/<id>/images.
+
+type mealieBackup struct {
+	Recipes      []mealieRecipe      `json:"recipes"`
+	Ingredients  []mealieIngredient  `json:"recipes_ingredients"`
+	Instructions []mealieInstruction `json:"recipe_instructions"`
+	Units        []mealieNamed       `json:"ingredient_units"`
+	Foods        []mealieNamed       `json:"ingredient_foods"`
+	Categories   []mealieNamed       `json:"categories"`
+	Tags         []mealieNamed       `json:"tags"`
+	RecipeCats   []mealieLink        `json:"recipes_to_categories"`
+	RecipeTags   []mealieLink        `json:"recipes_to_tags"`
+	Notes        []mealieNote        `json:"notes"`
+}
+
+type mealieRecipe struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	RecipeYield string `json:"recipe_yield"`
+	TotalTime   string `json:"total_time"`
+	PrepTime    string `json:"prep_time"`
+	PerformTime string `json:"perform_time"`
+	OrgURL      string `json:"org_url"`
+}
+
+type mealieIngredient struct {
+	RecipeID     string   `json:"recipe_id"`
+	Position     int      `json:"position"`
+	Title        string   `json:"title"`
+	Note         string   `json:"note"`
+	Quantity     *float64 `json:"quantity"`
+	UnitID       string   `json:"unit_id"`
+	FoodID       string   `json:"food_id"`
+	OriginalText string   `json:"original_text"`
+}
+
+type mealieInstruction struct {
+	RecipeID string `json:"recipe_id"`
+	Position int    `json:"position"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+}
+
+type mealieNamed struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type mealieLink struct {
+	RecipeID   string `json:"recipe_id"`
+	CategoryID string `json:"category_id"`
+	TagID      string `json:"tag_id"`
+}
+
+type mealieNote struct {
+	RecipeID string `json:"recipe_id"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+}
+
+// mealieID normalizes ids, which are stored without dashes in some databases and with dashes in image paths.
+func mealieID(id string) string {
+	return strings.ToLower(strings.ReplaceAll(id, "-", ""))
+}
+
+func readMealieExport(archive *zip.Reader) ([]exportedRecipe, error) {
+	var backup mealieBackup
+	images := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		if f.Name == "database.json" {
+			data, err := readZipFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(data, &backup); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		parts := strings.Split(f.Name, "/")
+		if len(parts) == 5 && parts[0] == "data" && parts[1] == "recipes" && parts[3] == "images" && strings.HasPrefix(parts[4], "original.") {
+			images[mealieID(parts[2])] = f
+		}
+	}
+
+	names := func(rows []mealieNamed) map[string]string {
+		m := make(map[string]string, len(rows))
+		for _, row := range rows {
+			m[mealieID(row.ID)] = row.Name
+		}
+		return m
+	}
+	units, foods, categories, tags := names(backup.Units), names(backup.Foods), names(backup.Categories), names(backup.Tags)
+
+	ingredients := make(map[string][]mealieIngredient)
+	for _, row := range backup.Ingredients {
+		ingredients[mealieID(row.RecipeID)] = append(ingredients[mealieID(row.RecipeID)], row)
+	}
+	instructions := make(map[string][]mealieInstruction)
+	for _, row := range backup.Instructions {
+		instructions[mealieID(row.RecipeID)] = append(instructions[mealieID(row.RecipeID)], row)
+	}
+	notes := make(map[string][]string)
+	for _, row := range backup.Notes {
+		text := strings.TrimSpace(strings.TrimSpace(row.Title) + "\n" + strings.TrimSpace(row.Text))
+		notes[mealieID(row.RecipeID)] = append(notes[mealieID(row.RecipeID)], text)
+	}
+	recipeCategories := make(map[string][]string)
+	for _, row := range backup.RecipeCats {
+		if name, ok := categories[mealieID(row.CategoryID)]; ok {
+			recipeCategories[mealieID(row.RecipeID)] = append(recipeCategories[mealieID(row.RecipeID)], name)
+		}
+	}
+	recipeTags := make(map[string][]string)
+	for _, row := range backup.RecipeTags {
+		if name, ok := tags[mealieID(row.TagID)]; ok {
+			recipeTags[mealieID(row.RecipeID)] = append(recipeTags[mealieID(row.RecipeID)], name)
+		}
+	}
+
+	exported := make([]exportedRecipe, 0, len(backup.Recipes))
+	for _, src := range backup.Recipes {
+		id := mealieID(src.ID)
+		recipe := &krip.Recipe{
+			Name:        src.Name,
+			Description: src.Description,
+			Url:         src.OrgURL,
+			Text:        strings.Join(notes[id], "\n\n"),
+			Yield:       src.RecipeYield,
+			PrepTime:    parseExportDuration(src.PrepTime),
+			CookTime:    parseExportDuration(src.PerformTime),
+			TotalTime:   parseExportDuration(src.TotalTime),
+			Categories:  recipeCategories[id],
+			Keywords:    recipeTags[id],
+		}
+
+		rows := ingredients[id]
+		slices.SortStableFunc(rows, func(a, b mealieIngredient) int { return a.Position - b.Position })
+		section := ""
+		for _, row := range rows {
+			if row.Title != "" {
+				section = row.Title
+			}
+			item := &krip.PropertyValue{Category: section}
+			if food := foods[mealieID(row.FoodID)]; food != "" {
+				item.Name = food
+				if row.Note != "" {
+					item.Name += ", " + row.Note
+				}
+				if row.Quantity != nil && *row.Quantity > 0 {
+					item.Value = strconv.FormatFloat(*row.Quantity, 'f', -1, 64)
+				}
+				item.UnitText = units[mealieID(row.UnitID)]
+			} else if row.OriginalText != "" {
+				item.Name = row.OriginalText
+			} else if row.Note != "" {
+				item.Name = row.Note
+			} else {
+				continue
+			}
+			recipe.Ingredients = append(recipe.Ingredients, item)
+		}
+
+		steps := instructions[id]
+		slices.SortStableFunc(steps, func(a, b mealieInstruction) int { return a.Position - b.Position })
+		var current *krip.HowToSection
+		for _, step := range steps {
+			if current == nil || step.Title != "" {
+				current = &krip.HowToSection{}
+				current.Name = step.Title
+				recipe.Instructions = append(recipe.Instructions, current)
+			}
+			if text := strings.TrimSpace(step.Text); text != "" {
+				current.Steps = append(current.Steps, &krip.HowToStep{Text: text})
+			}
+		}
+
+		entry := exportedRecipe{name: src.Name, schema: recipe}
+		if f, ok := images[id]; ok {
+			if image, err := readZipFile(f); err == nil {
+				entry.image = image
+			}
+		}
+		exported = append(exported, entry)
+	}
+	return exported, nil
+}
+
+// Tandoor: the export is a zip of per-recipe zips, each with a recipe.json and an optional image.
+
+type tandoorRecipe struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	SourceURL    string `json:"source_url"`
+	WorkingTime  int    `json:"working_time"`
+	WaitingTime  int    `json:"waiting_time"`
+	Servings     int    `json:"servings"`
+	ServingsText string `json:"servings_text"`
+	Keywords     []struct {
+		Name string `json:"name"`
+	} `json:"keywords"`
+	Steps []struct {
+		Name        string              `json:"name"`
+		Instruction string              `json:"instruction"`
+		Ingredients []tandoorIngredient `json:"ingredients"`
+	} `json:"steps"`
+}
+
+type tandoorIngredient struct {
+	Food *struct {
+		Name string `json:"name"`
+	} `json:"food"`
+	Unit *struct {
+		Name string `json:"name"`
+	} `json:"unit"`
+	Amount       looseNumber `json:"amount"`
+	Note         string      `json:"note"`
+	IsHeader     bool        `json:"is_header"`
+	NoAmount     bool        `json:"no_amount"`
+	OriginalText string      `json:"original_text"`
+}
+
+// looseNumber accepts both JSON numbers and numeric strings, Tandoor has used both for decimal fields.
+type looseNumber float64
+
+func (n *looseNumber) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*n = looseNumber(v)
+	return nil
+}
+
+func readTandoorExport(archive *zip.Reader) []exportedRecipe {
+	for _, f := range archive.File {
+		if f.Name == "recipe.json" {
+			return []exportedRecipe{readTandoorRecipe(archive, "recipe")}
+		}
+	}
+
+	var exported []exportedRecipe
+	for _, f := range archive.File {
+		if !strings.HasSuffix(f.Name, ".zip") {
+			continue
+		}
+		name := strings.TrimSuffix(path.Base(f.Name), ".zip")
+		data, err := readZipFile(f)
+		if err != nil {
+			exported = append(exported, exportedRecipe{name: name, err: fmt.Errorf("read %s: %w", f.Name, err)})
+			continue
+		}
+		inner, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			exported = append(exported, exportedRecipe{name: name, err: fmt.Errorf("read %s: %w", f.Name, err)})
+			continue
+		}
+		exported = append(exported, readTandoorRecipe(inner, name))
+	}
+	return exported
+}
+
+func readTandoorRecipe(archive *zip.Reader, name string) exportedRecipe {
+	entry := exportedRecipe{name: name}
+	var src tandoorRecipe
+	found := false
+	for _, f := range archive.File {
+		switch {
+		case f.Name == "recipe.json":
+			found = true
+			data, err := readZipFile(f)
+			if err == nil {
+				err = json.Unmarshal(data, &src)
+			}
+			if err != nil {
+				entry.err = fmt.Errorf("read recipe.json: %w", err)
+				return entry
+			}
+		case strings.HasPrefix(f.Name, "image."):
+			if image, err := readZipFile(f); err == nil {
+				entry.image = image
+			}
+		}
+	}
+	if !found {
+		entry.err = fmt.Errorf("recipe.json not found")
+		return entry
+	}
+	if src.Name != "" {
+		entry.name = src.Name
+	}
+
+	recipe := &krip.Recipe{
+		Name:        src.Name,
+		Description: src.Description,
+		Url:         src.SourceURL,
+	}
+	if src.WorkingTime > 0 {
+		recipe.PrepTime = types.Duration(time.Duration(src.WorkingTime) * time.Minute).ISO8601()
+	}
+	if src.WaitingTime > 0 {
+		recipe.CookTime = types.Duration(time.Duration(src.WaitingTime) * time.Minute).ISO8601()
+	}
+	if src.Servings > 0 {
+		recipe.Yield = strings.TrimSpace(strconv.Itoa(src.Servings) + " " + src.ServingsText)
+	}
+	for _, keyword := range src.Keywords {
+		recipe.Keywords = append(recipe.Keywords, keyword.Name)
+	}
+
+	section := &krip.HowToSection{}
+	for _, step := range src.Steps {
+		category := ""
+		for _, ing := range step.Ingredients {
+			if ing.IsHeader {
+				category = strings.TrimSpace(ing.Note)
+				continue
+			}
+			item := &krip.PropertyValue{Category: category}
+			if ing.Food != nil && ing.Food.Name != "" {
+				item.Name = ing.Food.Name
+				if ing.Note != "" {
+					item.Name += ", " + ing.Note
+				}
+				if !ing.NoAmount && ing.Amount > 0 {
+					item.Value = strconv.FormatFloat(float64(ing.Amount), 'f', -1, 64)
+				}
+				if ing.Unit != nil {
+					item.UnitText = ing.Unit.Name
+				}
+			} else if ing.OriginalText != "" {
+				item.Name = ing.OriginalText
+			} else {
+				continue
+			}
+			recipe.Ingredients = append(recipe.Ingredients, item)
+		}
+		if text := strings.TrimSpace(step.Instruction); text != "" || step.Name != "" {
+			section.Steps = append(section.Steps, &krip.HowToStep{Name: step.Name, Text: text})
+		}
+	}
+	if len(section.Steps) > 0 {
+		recipe.Instructions = []*krip.HowToSection{section}
+	}
+
+	entry.schema = recipe
+	return entry
+}
+
+// longer unit names come first, so that "1h30m" and "2 hours" are both read whole
+var exportDurationRe = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)`)
+
+// parseExportDuration converts the free-text times of other recipe managers ("1 hr 30 mins", "45") to ISO 8601.
+// A bare number is taken as minutes, text that cannot be read is dropped.
+func parseExportDuration(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	if _, err := types.DurationFromISO8601(text); err == nil {
+		return text
+	}
+	if minutes, err := strconv.ParseFloat(text, 64); err == nil && minutes > 0 {
+		return types.Duration(time.Duration(minutes * float64(time.Minute))).ISO8601()
+	}
+
+	var total time.Duration
+	for _, m := range exportDurationRe.FindAllStringSubmatch(text, -1) {
+		value, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+		if err != nil {
+			continue
+		}
+		unit := time.Minute
+		switch strings.ToLower(m[2])[0] {
+		case 'd':
+			unit = 24 * time.Hour
+		case 'h':
+			unit = time.Hour
+		case 's':
+			unit = time.Second
+		}
+		total += time.Duration(value * float64(unit))
+	}
+	if total <= 0 {
+		return ""
+	}
+	return types.Duration(total).ISO8601()
+}
@@ -0,0 +1,334 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/storage"
+)
+
+// jpegHeader is enough for content type detection.
+var jpegHeader = []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+
+func buildZip(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func paprikaEntry(t *testing.T, recipe map[string]any) []byte {
+	t.Helper()
+	raw, err := json.Marshal(recipe)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(raw)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// collectingIngest records the recipes handed to the ingest service.
+func collectingIngest(imported *[]*domain.Recipe) *stubRecipeIngestService {
+	return &stubRecipeIngestService{
+		importRecipeFn: func(_ context.Context, recipe *domain.Recipe) (*domain.Recipe, error) {
+			recipe.ID = uuid.New()
+			*imported = append(*imported, recipe)
+			return recipe, nil
+		},
+	}
+}
+
+func TestImportService_ImportFile_Paprika(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	data := buildZip(t, map[string][]byte{
+		"Borscht.paprikarecipe": paprikaEntry(t, map[string]any{
+			"name":        "Borscht",
+			"ingredients": "2 beets\n\n1 l broth\n",
+			"directions":  "Grate the beets.\nSimmer in broth.",
+			"notes":       "Serve with smetana.",
+			"servings":    "4",
+			"prep_time":   "1 hr 30 mins",
+			"cook_time":   "45",
+			"source_url":  "https://example.com/borscht",
+			"categories":  []string{"Soups"},
+			"photo_data":  base64.StdEncoding.EncodeToString(jpegHeader),
+		}),
+	})
+
+	var imported []*domain.Recipe
+	var savedFor []uuid.UUID
+	var uploadedType string
+	svc := newTestImportService(importServiceDeps{
+		recipeIngest: collectingIngest(&imported),
+		recipeService: &stubRecipeService{userSaveFn: func(_, u, h uuid.UUID) error {
+			savedFor = append(savedFor, u, h)
+			return nil
+		}},
+		imageService: &stubImageService{persistUploadedFn: func(_ context.Context, _ []byte, contentType string) (*domain.UploadedImage, error) {
+			uploadedType = contentType
+			return &domain.UploadedImage{Path: "uploads/borscht.jpg"}, nil
+		}},
+	})
+
+	report, err := svc.ImportFile(context.Background(), data, nil, nil, uid, hid)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSourcePaprika, report.Source)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 0, report.Failed)
+	require.Len(t, report.Items, 1)
+	assert.Equal(t, "Borscht", report.Items[0].Name)
+	assert.Equal(t, []uuid.UUID{uid, hid}, savedFor)
+	assert.Equal(t, "image/jpeg", uploadedType)
+
+	require.Len(t, imported, 1)
+	recipe := imported[0]
+	assert.Equal(t, "Borscht", *recipe.Name)
+	assert.Equal(t, hid, *recipe.HouseholdID)
+	assert.Equal(t, uid, *recipe.UserID)
+	assert.Equal(t, "https://example.com/borscht", *recipe.SourceUrl)
+	assert.Equal(t, "Serve with smetana.", *recipe.Text)
+	assert.Equal(t, storage.Path("uploads/borscht.jpg"), *recipe.ImagePath)
+	assert.Equal(t, 90*time.Minute, time.Duration(*recipe.PrepTime))
+	assert.Equal(t, 45*time.Minute, time.Duration(*recipe.CookTime))
+	assert.Equal(t, 4.0, *recipe.Yield)
+	require.Len(t, recipe.Ingredients, 2)
+	assert.Equal(t, "2 beets", recipe.Ingredients[0].RawText)
+	assert.Equal(t, "1 l broth", recipe.Ingredients[1].RawText)
+	require.Len(t, recipe.Instructions, 2)
+	assert.Equal(t, "Simmer in broth.", recipe.Instructions[1].Text)
+	require.Len(t, recipe.Taxonomies, 1)
+	assert.Equal(t, "Soups", recipe.Taxonomies[0].Label)
+}
+
+func TestImportService_ImportFile_Mealie(t *testing.T) {
+	database := map[string]any{
+		"recipes": []map[string]any{
+			{"id": "0a1b2c3d4e5f60718293a4b5c6d7e8f9", "name": "Pierogi", "recipe_yield": "24 pierogi", "total_time": "2 hours"},
+		},
+		"recipes_ingredients": []map[string]any{
+			{"recipe_id": "0a1b2c3d4e5f60718293a4b5c6d7e8f9", "position": 1, "quantity": 2, "unit_id": "u1", "food_id": "f2", "note": "boiled"},
+			{"recipe_id": "0a1b2c3d4e5f60718293a4b5c6d7e8f9", "position": 0, "title": "Dough", "original_text": "500 g flour"},
+		},
+		"recipe_instructions": []map[string]any{
+			{"recipe_id": "0a1b2c3d4e5f60718293a4b5c6d7e8f9", "position": 0, "text": "Knead the dough."},
+			{"recipe_id": "0a1b2c3d4e5f60718293a4b5c6d7e8f9", "position": 1, "title": "Filling", "text": "Mash the potatoes."},
+		},
+		"ingredient_units":      []map[string]any{{"id": "u1", "name": "cup"}},
+		"ingredient_foods":      []map[string]any{{"id": "f2", "name": "potatoes"}},
+		"tags":                  []map[string]any{{"id": "t1", "name": "Polish"}},
+		"recipes_to_tags":       []map[string]any{{"recipe_id": "0a1b2c3d4e5f60718293a4b5c6d7e8f9", "tag_id": "t1"}},
+		"notes":                 []map[string]any{{"recipe_id": "0a1b2c3d4e5f60718293a4b5c6d7e8f9", "title": "Tip", "text": "Freeze leftovers."}},
+		"categories":            []map[string]any{},
+		"recipes_to_categories": []map[string]any{},
+	}
+	raw, err := json.Marshal(database)
+	require.NoError(t, err)
+	data := buildZip(t, map[string][]byte{
+		"database.json": raw,
+		"data/recipes/0a1b2c3d-4e5f-6071-8293-a4b5c6d7e8f9/images/original.webp": jpegHeader,
+	})
+
+	var imported []*domain.Recipe
+	uploads := 0
+	svc := newTestImportService(importServiceDeps{
+		recipeIngest: collectingIngest(&imported),
+		imageService: &stubImageService{persistUploadedFn: func(_ context.Context, _ []byte, contentType string) (*domain.UploadedImage, error) {
+			uploads++
+			return &domain.UploadedImage{Path: "uploads/pierogi.jpg"}, nil
+		}},
+	})
+
+	report, err := svc.ImportFile(context.Background(), data, nil, nil, uuid.New(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSourceMealie, report.Source)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, uploads)
+
+	require.Len(t, imported, 1)
+	recipe := imported[0]
+	assert.Equal(t, "Pierogi", *recipe.Name)
+	assert.Nil(t, recipe.SourceUrl)
+	assert.Equal(t, 2*time.Hour, time.Duration(*recipe.TotalTime))
+	assert.Equal(t, "Tip\nFreeze leftovers.", *recipe.Text)
+
+	require.Len(t, recipe.Ingredients, 2)
+	assert.Equal(t, "500 g flour", recipe.Ingredients[0].RawText)
+	assert.Equal(t, "Dough", *recipe.Ingredients[0].Category)
+	assert.Equal(t, "2 cup potatoes, boiled", recipe.Ingredients[1].RawText)
+	assert.Equal(t, "Dough", *recipe.Ingredients[1].Category)
+	assert.Equal(t, 2.0, *recipe.Ingredients[1].Amount)
+
+	// an untitled step, then the "Filling" section with its step
+	require.Len(t, recipe.Instructions, 3)
+	assert.Equal(t, "Knead the dough.", recipe.Instructions[0].Text)
+	assert.Equal(t, "Filling", *recipe.Instructions[1].Title)
+	assert.Equal(t, "Mash the potatoes.", recipe.Instructions[2].Text)
+
+	require.Len(t, recipe.Taxonomies, 1)
+	assert.Equal(t, domain.TaxonomyTypeKeyword, recipe.Taxonomies[0].Type)
+}
+
+func TestImportService_ImportFile_Tandoor(t *testing.T) {
+	recipeJSON, err := json.Marshal(map[string]any{
+		"name":          "Varenyky",
+		"working_time":  40,
+		"waiting_time":  15,
+		"servings":      6,
+		"servings_text": "portions",
+		"keywords":      []map[string]any{{"name": "dumplings"}},
+		"steps": []map[string]any{{
+			"name":        "Dough",
+			"instruction": "Mix flour and water.",
+			"ingredients": []map[string]any{
+				{"is_header": true, "note": "For the dough"},
+				{"food": map[string]any{"name": "flour"}, "unit": map[string]any{"name": "g"}, "amount": "500.000"},
+				{"food": map[string]any{"name": "salt"}, "amount": 1, "no_amount": true},
+			},
+		}},
+	})
+	require.NoError(t, err)
+	data := buildZip(t, map[string][]byte{
+		"1.zip": buildZip(t, map[string][]byte{"recipe.json": recipeJSON, "image.jpg": jpegHeader}),
+		"2.zip": buildZip(t, map[string][]byte{"notes.txt": []byte("no recipe here")}),
+	})
+
+	var imported []*domain.Recipe
+	svc := newTestImportService(importServiceDeps{recipeIngest: collectingIngest(&imported)})
+
+	report, err := svc.ImportFile(context.Background(), data, nil, nil, uuid.New(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSourceTandoor, report.Source)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, report.Failed)
+
+	require.Len(t, imported, 1)
+	recipe := imported[0]
+	assert.Equal(t, "Varenyky", *recipe.Name)
+	assert.Equal(t, 40*time.Minute, time.Duration(*recipe.PrepTime))
+	assert.Equal(t, 15*time.Minute, time.Duration(*recipe.CookTime))
+	assert.Equal(t, "6 portions", *recipe.YieldText)
+	assert.NotNil(t, recipe.ImagePath)
+
+	require.Len(t, recipe.Ingredients, 2)
+	assert.Equal(t, "500 g flour", recipe.Ingredients[0].RawText)
+	assert.Equal(t, "For the dough", *recipe.Ingredients[0].Category)
+	assert.Equal(t, "salt", recipe.Ingredients[1].RawText)
+	require.Len(t, recipe.Instructions, 1)
+	assert.Equal(t, "Dough", *recipe.Instructions[0].Title)
+}
+
+func TestImportService_ImportFile_FailedRecipe_ReportedAndOthersImported(t *testing.T) {
+	data := buildZip(t, map[string][]byte{
+		"a.paprikarecipe": paprikaEntry(t, map[string]any{"name": "Good"}),
+		"b.paprikarecipe": paprikaEntry(t, map[string]any{"name": "Bad"}),
+		"c.paprikarecipe": []byte("not gzip"),
+	})
+
+	svc := newTestImportService(importServiceDeps{
+		recipeIngest: &stubRecipeIngestService{importRecipeFn: func(_ context.Context, recipe *domain.Recipe) (*domain.Recipe, error) {
+			if *recipe.Name == "Bad" {
+				return nil, errors.New("db down")
+			}
+			return recipe, nil
+		}},
+	})
+
+	report, err := svc.ImportFile(context.Background(), data, nil, nil, uuid.New(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 2, report.Failed)
+	for _, item := range report.Items {
+		if item.Name == "Good" {
+			assert.NotNil(t, item.Recipe)
+			assert.Empty(t, item.Error)
+		} else {
+			assert.Nil(t, item.Recipe)
+			assert.NotEmpty(t, item.Error)
+		}
+	}
+}
+
+func TestImportService_ImportFile_Resume_SkipsReportedRecipes(t *testing.T) {
+	data := buildZip(t, map[string][]byte{
+		"Borscht.paprikarecipe":  paprikaEntry(t, map[string]any{"name": "Borscht"}),
+		"Vareniki.paprikarecipe": paprikaEntry(t, map[string]any{"name": "Vareniki"}),
+	})
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	done := strings.TrimSuffix(archive.File[0].Name, ".paprikarecipe")
+
+	var imported []*domain.Recipe
+	svc := newTestImportService(importServiceDeps{recipeIngest: collectingIngest(&imported)})
+
+	var calls int
+	report := &domain.FileImportReport{Source: domain.ImportSourcePaprika, Imported: 1, Items: []domain.FileImportItem{{Name: done}}}
+	report, err = svc.ImportFile(context.Background(), data, report, func(*domain.FileImportReport) error {
+		calls++
+		return nil
+	}, uuid.New(), uuid.New())
+
+	require.NoError(t, err)
+	require.Len(t, imported, 1)
+	assert.NotEqual(t, done, *imported[0].Name)
+	assert.Equal(t, 2, report.Imported)
+	assert.Len(t, report.Items, 2)
+	assert.Equal(t, 1, calls)
+}
+
+func TestImportService_ImportFile_ProgressFails_StopsImport(t *testing.T) {
+	data := buildZip(t, map[string][]byte{
+		"Borscht.paprikarecipe":  paprikaEntry(t, map[string]any{"name": "Borscht"}),
+		"Vareniki.paprikarecipe": paprikaEntry(t, map[string]any{"name": "Vareniki"}),
+	})
+
+	var imported []*domain.Recipe
+	svc := newTestImportService(importServiceDeps{recipeIngest: collectingIngest(&imported)})
+
+	report, err := svc.ImportFile(context.Background(), data, nil, func(*domain.FileImportReport) error {
+		return errors.New("db down")
+	}, uuid.New(), uuid.New())
+
+	require.Error(t, err)
+	assert.Len(t, imported, 1)
+	assert.Len(t, report.Items, 1)
+}
+
+func TestImportService_ImportFile_UnknownFile_ReturnsBadRequest(t *testing.T) {
+	svc := newTestImportService(importServiceDeps{})
+
+	for name, data := range map[string][]byte{
+		"not a zip":   []byte("hello"),
+		"unknown zip": buildZip(t, map[string][]byte{"readme.txt": []byte("hi")}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.ImportFile(context.Background(), data, nil, nil, uuid.New(), uuid.New())
+			var sErr *sentinels.Error
+			require.ErrorAs(t, err, &sErr)
+			assert.Equal(t, http.StatusBadRequest, sErr.Status)
+		})
+	}
+}
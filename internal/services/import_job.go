@@ -0,0 +1,202 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/storage"
+	"borscht.app/smetana/internal/utils"
+)
+
+type importJobService struct {
+	repo          domain.ImportJobRepository
+	importService domain.ImportService
+	recipeService domain.RecipeService
+	feedService   domain.FeedService
+	storage       storage.FileStorage // keeps the uploaded export files, so it must be one that isn't served
+}
+
+func NewImportJobService(repo domain.ImportJobRepository, importService domain.ImportService, recipeService domain.RecipeService, feedService domain.FeedService, exportStorage storage.FileStorage) domain.ImportJobService {
+	return &importJobService{
+		repo:          repo,
+		importService: importService,
+		recipeService: recipeService,
+		feedService:   feedService,
+		storage:       exportStorage,
+	}
+}
+
+func (s *importJobService) ByID(id uuid.UUID, householdID uuid.UUID) (*domain.ImportJob, error) {
+	job, err := s.repo.ByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("by id: %w", err)
+	}
+	if job.HouseholdID != householdID {
+		return nil, sentinels.ErrForbidden
+	}
+	return job, nil
+}
+
+func (s *importJobService) Enqueue(url string, requestedType string, forceUpdate bool, html string, userID uuid.UUID, householdID uuid.UUID) (*domain.ImportJob, error) {
+	if html != "" && forceUpdate {
+		return nil, sentinels.BadRequest("update is not supported for pages sent as html")
+	}
+	job := &domain.ImportJob{
+		HouseholdID:   householdID,
+		UserID:        userID,
+		Url:           utils.NormalizeURL(url),
+		RequestedType: requestedType,
+		ForceUpdate:   forceUpdate,
+		Status:        domain.JobStatusPending,
+	}
+	if html != "" {
+		job.HTML = &html
+	}
+	if err := s.repo.Create(job); err != nil {
+		return nil, fmt.Errorf("enqueue: %w", err)
+	}
+	return job, nil
+}
+
+func (s *importJobService) EnqueueFile(data []byte, userID uuid.UUID, householdID uuid.UUID) (*domain.ImportJob, error) {
+	_, source, err := openExport(data)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("enqueue file (id): %w", err)
+	}
+	path := fmt.Sprintf("imports/%s/%s.zip", householdID, id)
+	if err := s.storage.Save(path, bytes.NewReader(data), int64(len(data)), "application/zip"); err != nil {
+		return nil, fmt.Errorf("enqueue file (save): %w", err)
+	}
+
+	job := &domain.ImportJob{
+		ID:            id,
+		HouseholdID:   householdID,
+		UserID:        userID,
+		RequestedType: domain.ImportTypeFile,
+		FilePath:      new(storage.Path(path)),
+		Report:        &domain.FileImportReport{Source: source},
+		Status:        domain.JobStatusPending,
+	}
+	if err := s.repo.Create(job); err != nil {
+		if err := s.storage.Delete(path); err != nil {
+			log.Warnw("failed to delete export file of unsaved import job", "path", path, "error", err.Error())
+		}
+		return nil, fmt.Errorf("enqueue file: %w", err)
+	}
+	return job, nil
+}
+
+func (s *importJobService) Run(ctx context.Context, job *domain.ImportJob) error {
+	job.Status = domain.JobStatusRunning
+	if err := s.repo.Update(job); err != nil {
+		return fmt.Errorf("run (mark running): %w", err)
+	}
+	if job.FilePath != nil {
+		return s.runFile(ctx, job)
+	}
+
+	var result *domain.ImportResult
+	var importErr error
+	if job.HTML != nil {
+		result, importErr = s.importService.ImportHtml(ctx, []byte(*job.HTML), job.Url, job.RequestedType, job.ForceUpdate, false, job.UserID, job.HouseholdID)
+	} else {
+		result, importErr = s.importService.DetectAndImport(ctx, job.Url, job.RequestedType, job.ForceUpdate, false, job.UserID, job.HouseholdID)
+	}
+
+	job.HTML = nil
+	if importErr != nil {
+		job.Status = domain.JobStatusError
+		job.ErrorMessage = importErr.Error()
+	} else {
+		job.Status = domain.JobStatusSuccess
+		job.ErrorMessage = ""
+		job.Existing = !result.Created
+		if result.Recipe != nil {
+			job.RecipeID = &result.Recipe.ID
+		}
+		if result.Feed != nil {
+			job.FeedID = &result.Feed.ID
+		}
+	}
+
+	if err := s.repo.Update(job); err != nil {
+		return fmt.Errorf("run (persist): %w", err)
+	}
+	return importErr
+}
+
+// runFile imports the export file of a job and deletes it. The report is stored after each recipe, which also keeps
+// the job from going stale, and a job interrupted midway resumes after the recipes it already imported.
+func (s *importJobService) runFile(ctx context.Context, job *domain.ImportJob) error {
+	path := string(*job.FilePath)
+	var persistErr error
+	report, importErr := s.importFile(ctx, path, job.Report, func(report *domain.FileImportReport) error {
+		job.Report = slimFileReport(report)
+		persistErr = s.repo.Update(job)
+		return persistErr
+	}, job.UserID, job.HouseholdID)
+	if persistErr != nil {
+		// left running with its file, so it resumes once stale
+		return fmt.Errorf("run file (progress): %w", persistErr)
+	}
+
+	job.FilePath = nil
+	switch {
+	case importErr != nil:
+		job.Status = domain.JobStatusError
+		job.ErrorMessage = importErr.Error()
+	case report.Imported == 0 && report.Failed > 0:
+		job.Status = domain.JobStatusError
+		job.ErrorMessage = "no recipe could be imported"
+	default:
+		job.Status = domain.JobStatusSuccess
+		job.ErrorMessage = ""
+	}
+	if report != nil {
+		job.Report = slimFileReport(report)
+	}
+
+	if err := s.repo.Update(job); err != nil {
+		return fmt.Errorf("run file (persist): %w", err)
+	}
+	if err := s.storage.Delete(path); err != nil {
+		log.Warnw("failed to delete export file of import job", "job", job.ID, "path", path, "error", err.Error())
+	}
+	return importErr
+}
+
+// slimFileReport keeps the id and name of each imported recipe, not all of it with its ingredients and instructions.
+func slimFileReport(report *domain.FileImportReport) *domain.FileImportReport {
+	for i, item := range report.Items {
+		if item.Recipe != nil {
+			report.Items[i].Recipe = &domain.Recipe{ID: item.Recipe.ID, Name: item.Recipe.Name}
+		}
+	}
+	return report
+}
+
+func (s *importJobService) importFile(ctx context.Context, path string, report *domain.FileImportReport, progress func(*domain.FileImportReport) error, userID, householdID uuid.UUID) (*domain.FileImportReport, error) {
+	file, err := s.storage.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open export file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("read export file: %w", err)
+	}
+	return s.importService.ImportFile(ctx, data, report, progress, userID, householdID)
+}
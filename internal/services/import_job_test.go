@@ -0,0 +1,238 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/services"
+	"borscht.app/smetana/internal/storage"
+)
+
+func TestImportJobService_Enqueue_StoresPendingJob(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	repo := &stubImportJobRepo{}
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	job, err := svc.Enqueue("https://Example.com/borscht/", domain.ImportTypeAuto, true, "", uid, hid)
+
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, job.ID)
+	require.Len(t, repo.created, 1)
+	assert.Equal(t, domain.JobStatusPending, repo.created[0].Status)
+	assert.Equal(t, hid, repo.created[0].HouseholdID)
+	assert.Equal(t, "https://example.com/borscht", repo.created[0].Url)
+	assert.True(t, repo.created[0].ForceUpdate)
+	assert.Nil(t, repo.created[0].HTML)
+}
+
+func TestImportJobService_Enqueue_HTMLWithUpdate_ReturnsBadRequest(t *testing.T) {
+	repo := &stubImportJobRepo{}
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	_, err := svc.Enqueue("https://example.com/borscht", domain.ImportTypeAuto, true, "<html>...</html>", uuid.New(), uuid.New())
+
+	var sErr *sentinels.Error
+	require.ErrorAs(t, err, &sErr)
+	assert.Equal(t, http.StatusBadRequest, sErr.Status)
+	assert.Empty(t, repo.created)
+}
+
+func TestImportJobService_Run_RecordsRecipe(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	recipeID := uuid.New()
+	repo := &stubImportJobRepo{}
+	imports := &stubImportService{
+		detectAndImportFn: func(_ context.Context, url, requestedType string, forceUpdate, dryRun bool, userID, householdID uuid.UUID) (*domain.ImportResult, error) {
+			assert.Equal(t, "https://example.com/borscht", url)
+			assert.False(t, dryRun)
+			assert.Equal(t, []uuid.UUID{uid, hid}, []uuid.UUID{userID, householdID})
+			return &domain.ImportResult{Created: true, Recipe: &domain.Recipe{ID: recipeID}}, nil
+		},
+	}
+	svc := services.NewImportJobService(repo, imports, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	job := &domain.ImportJob{ID: uuid.New(), HouseholdID: hid, UserID: uid, Url: "https://example.com/borscht", Status: domain.JobStatusPending}
+	require.NoError(t, svc.Run(context.Background(), job))
+
+	require.Len(t, repo.updated, 2)
+	assert.Equal(t, domain.JobStatusRunning, repo.updated[0].Status)
+	assert.Equal(t, domain.JobStatusSuccess, repo.updated[1].Status)
+	assert.Equal(t, recipeID, *repo.updated[1].RecipeID)
+	assert.False(t, repo.updated[1].Existing)
+	assert.Nil(t, repo.updated[1].FeedID)
+}
+
+func TestImportJobService_Run_WithHTML_ImportsPageAndDropsIt(t *testing.T) {
+	repo := &stubImportJobRepo{}
+	feedID := uuid.New()
+	imports := &stubImportService{
+		importHtmlFn: func(_ context.Context, html []byte, _, _ string, _, _ bool, _, _ uuid.UUID) (*domain.ImportResult, error) {
+			assert.Equal(t, "<html>feed</html>", string(html))
+			return &domain.ImportResult{Created: true, Feed: &domain.Feed{ID: feedID}}, nil
+		},
+	}
+	svc := services.NewImportJobService(repo, imports, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	job := &domain.ImportJob{ID: uuid.New(), Url: "https://example.com/feed", HTML: ptr("<html>feed</html>"), Status: domain.JobStatusPending}
+	require.NoError(t, svc.Run(context.Background(), job))
+
+	final := repo.updated[len(repo.updated)-1]
+	assert.Equal(t, feedID, *final.FeedID)
+	assert.Nil(t, final.HTML)
+}
+
+func TestImportJobService_Run_Failure_RecordsError(t *testing.T) {
+	repo := &stubImportJobRepo{}
+	imports := &stubImportService{
+		detectAndImportFn: func(context.Context, string, string, bool, bool, uuid.UUID, uuid.UUID) (*domain.ImportResult, error) {
+			return nil, errors.New("site blocked the request")
+		},
+	}
+	svc := services.NewImportJobService(repo, imports, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	err := svc.Run(context.Background(), &domain.ImportJob{ID: uuid.New(), Url: "https://example.com/blocked"})
+
+	require.Error(t, err)
+	final := repo.updated[len(repo.updated)-1]
+	assert.Equal(t, domain.JobStatusError, final.Status)
+	assert.Equal(t, "site blocked the request", final.ErrorMessage)
+}
+
+func TestImportJobService_EnqueueFile_StoresFileAndPendingJob(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	repo := &stubImportJobRepo{}
+	fs := newFakeStorage()
+	data := buildZip(t, map[string][]byte{"Borscht.paprikarecipe": {}})
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, fs)
+
+	job, err := svc.EnqueueFile(data, uid, hid)
+
+	require.NoError(t, err)
+	require.Len(t, repo.created, 1)
+	assert.Equal(t, domain.JobStatusPending, job.Status)
+	assert.Equal(t, domain.ImportTypeFile, job.RequestedType)
+	assert.Equal(t, domain.ImportSourcePaprika, job.Report.Source)
+	require.NotNil(t, job.FilePath)
+	assert.Equal(t, data, fs.saved[string(*job.FilePath)])
+}
+
+func TestImportJobService_EnqueueFile_UnknownFile_ReturnsBadRequest(t *testing.T) {
+	repo := &stubImportJobRepo{}
+	fs := newFakeStorage()
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, fs)
+
+	_, err := svc.EnqueueFile([]byte("not a zip"), uuid.New(), uuid.New())
+
+	var sentinelErr *sentinels.Error
+	require.ErrorAs(t, err, &sentinelErr)
+	assert.Equal(t, 400, sentinelErr.Status)
+	assert.Empty(t, repo.created)
+	assert.Empty(t, fs.saved)
+}
+
+func TestImportJobService_Run_File_RecordsReportAndDeletesFile(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	recipeID := uuid.New()
+	repo := &stubImportJobRepo{}
+	fs := newFakeStorage()
+	fs.saved["imports/export.zip"] = []byte("zip")
+	imports := &stubImportService{
+		importFileFn: func(_ context.Context, data []byte, _ *domain.FileImportReport, _ func(*domain.FileImportReport) error, userID, householdID uuid.UUID) (*domain.FileImportReport, error) {
+			assert.Equal(t, "zip", string(data))
+			assert.Equal(t, []uuid.UUID{uid, hid}, []uuid.UUID{userID, householdID})
+			return &domain.FileImportReport{Source: domain.ImportSourcePaprika, Imported: 1, Failed: 1, Items: []domain.FileImportItem{
+				{Name: "Borscht", Recipe: &domain.Recipe{ID: recipeID, Name: ptr("Borscht"), Ingredients: []*domain.RecipeIngredient{{RawText: "2 beets"}}}},
+				{Name: "Broken", Error: "recipe has no name"},
+			}}, nil
+		},
+	}
+	svc := services.NewImportJobService(repo, imports, &stubRecipeService{}, &stubFeedService{}, fs)
+
+	job := &domain.ImportJob{ID: uuid.New(), HouseholdID: hid, UserID: uid, RequestedType: domain.ImportTypeFile, FilePath: new(storage.Path("imports/export.zip")), Status: domain.JobStatusPending}
+	require.NoError(t, svc.Run(context.Background(), job))
+
+	final := repo.updated[len(repo.updated)-1]
+	assert.Equal(t, domain.JobStatusSuccess, final.Status)
+	assert.Nil(t, final.FilePath)
+	require.NotNil(t, final.Report)
+	assert.Equal(t, 1, final.Report.Imported)
+	assert.Equal(t, recipeID, final.Report.Items[0].Recipe.ID)
+	assert.Empty(t, final.Report.Items[0].Recipe.Ingredients, "the job only keeps a summary of the recipe")
+	assert.Equal(t, []string{"imports/export.zip"}, fs.deleted)
+}
+
+func TestImportJobService_Run_File_ResumesAndStoresProgress(t *testing.T) {
+	recipeID := uuid.New()
+	repo := &stubImportJobRepo{}
+	fs := newFakeStorage()
+	fs.saved["imports/export.zip"] = []byte("zip")
+	done := &domain.FileImportReport{Source: domain.ImportSourcePaprika, Imported: 1, Items: []domain.FileImportItem{{Name: "Borscht"}}}
+	imports := &stubImportService{
+		importFileFn: func(_ context.Context, _ []byte, report *domain.FileImportReport, progress func(*domain.FileImportReport) error, _, _ uuid.UUID) (*domain.FileImportReport, error) {
+			assert.Same(t, done, report, "the import resumes from the stored report")
+			report.Imported++
+			report.Items = append(report.Items, domain.FileImportItem{Name: "Vareniki", Recipe: &domain.Recipe{ID: recipeID, Ingredients: []*domain.RecipeIngredient{{RawText: "flour"}}}})
+			require.NoError(t, progress(report))
+			return report, nil
+		},
+	}
+	svc := services.NewImportJobService(repo, imports, &stubRecipeService{}, &stubFeedService{}, fs)
+
+	job := &domain.ImportJob{ID: uuid.New(), RequestedType: domain.ImportTypeFile, FilePath: new(storage.Path("imports/export.zip")), Report: done, Status: domain.JobStatusRunning}
+	require.NoError(t, svc.Run(context.Background(), job))
+
+	require.Len(t, repo.updated, 3)
+	progress := repo.updated[1]
+	assert.Equal(t, domain.JobStatusRunning, progress.Status)
+	assert.NotNil(t, progress.FilePath)
+	require.Len(t, progress.Report.Items, 2)
+	assert.Empty(t, progress.Report.Items[1].Recipe.Ingredients, "the job only keeps a summary of the recipe")
+	assert.Equal(t, domain.JobStatusSuccess, repo.updated[2].Status)
+}
+
+func TestImportJobService_Run_File_ProgressNotStored_LeavesJobToResume(t *testing.T) {
+	repo := &stubImportJobRepo{}
+	fs := newFakeStorage()
+	fs.saved["imports/export.zip"] = []byte("zip")
+	repo.updateFn = func(*domain.ImportJob) error {
+		if len(repo.updated) > 1 {
+			return errors.New("db down")
+		}
+		return nil
+	}
+	imports := &stubImportService{
+		importFileFn: func(_ context.Context, _ []byte, report *domain.FileImportReport, progress func(*domain.FileImportReport) error, _, _ uuid.UUID) (*domain.FileImportReport, error) {
+			report = &domain.FileImportReport{Source: domain.ImportSourcePaprika, Imported: 1, Items: []domain.FileImportItem{{Name: "Borscht"}}}
+			return report, progress(report)
+		},
+	}
+	svc := services.NewImportJobService(repo, imports, &stubRecipeService{}, &stubFeedService{}, fs)
+
+	job := &domain.ImportJob{ID: uuid.New(), RequestedType: domain.ImportTypeFile, FilePath: new(storage.Path("imports/export.zip")), Status: domain.JobStatusPending}
+	require.Error(t, svc.Run(context.Background(), job))
+
+	assert.Len(t, repo.updated, 2)
+	assert.Equal(t, domain.JobStatusRunning, job.Status)
+	assert.NotNil(t, job.FilePath)
+	assert.Empty(t, fs.deleted)
+	assert.Contains(t, fs.saved, "imports/export.zip")
+}
+
+func TestImportJobService_ByID_OtherHousehold_ReturnsForbidden(t *testing.T) {
+	repo := &stubImportJobRepo{byIDFn: func(id uuid.UUID) (*domain.ImportJob, error) {
+		return &domain.ImportJob{ID: id, HouseholdID: uuid.New()}, nil
+	}}
+	svc := services.NewImportJobService(repo, &stubImportService{}, &stubRecipeService{}, &stubFeedService{}, newFakeStorage())
+
+	_, err := svc.ByID(uuid.New(), uuid.New())
+
+	assert.ErrorIs(t, err, sentinels.ErrForbidden)
+}
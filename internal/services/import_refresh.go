@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/types"
+)
+
+// RefreshRecipe scrapes the source of a global recipe again. A recipe that still matches is only marked refreshed,
+// otherwise the scraped recipe replaces it under the same ID, keeping the stored images when the source kept them.
+func (s *importService) RefreshRecipe(ctx context.Context, recipe *domain.Recipe) ([]domain.RecipeChange, error) {
+	if recipe.SourceUrl == nil {
+		return nil, sentinels.BadRequest("recipe has no source url")
+	}
+
+	scraped, err := s.scraperService.ScrapeRecipe(ctx, *recipe.SourceUrl)
+	if err != nil {
+		return nil, fmt.Errorf("refresh (scrape): %w", err)
+	}
+	if scraped == nil || scraped.Name == nil || len(scraped.Ingredients) == 0 {
+		return nil, sentinels.BadRequest("refresh: source no longer has a recipe")
+	}
+
+	changes := diffRecipe(recipe, scraped)
+	if len(changes) == 0 {
+		if err := s.recipeService.MarkRefreshed(recipe.ID); err != nil {
+			return nil, fmt.Errorf("refresh (mark refreshed): %w", err)
+		}
+		return nil, nil
+	}
+
+	scraped.ID = recipe.ID
+	scraped.SourceUrl = recipe.SourceUrl
+	if !slices.ContainsFunc(changes, func(c domain.RecipeChange) bool { return c.Field == "images" }) {
+		scraped.Images = nil // already stored, don't download them again
+	}
+	if _, err := s.recipeIngest.ImportRecipe(ctx, scraped); err != nil {
+		return nil, fmt.Errorf("refresh (ingest): %w", err)
+	}
+	return changes, nil
+}
+
+// diffRecipe lists the fields the source changed. Fields the source no longer has are not changes,
+// an import never clears them either.
+func diffRecipe(stored *domain.Recipe, scraped *domain.Recipe) []domain.RecipeChange {
+	var changes []domain.RecipeChange
+	changes = diffString(changes, "name", stored.Name, scraped.Name)
+	changes = diffString(changes, "description", stored.Description, scraped.Description)
+	changes = diffDuration(changes, "prep_time", stored.PrepTime, scraped.PrepTime)
+	changes = diffDuration(changes, "cook_time", stored.CookTime, scraped.CookTime)
+	changes = diffDuration(changes, "total_time", stored.TotalTime, scraped.TotalTime)
+	if scraped.Yield != nil && (stored.Yield == nil || *stored.Yield != *scraped.Yield) {
+		changes = append(changes, domain.RecipeChange{Field: "yield", Old: stored.Yield, New: scraped.Yield})
+	}
+
+	storedImages, scrapedImages := imageSources(stored.Images), imageSources(scraped.Images)
+	if len(scrapedImages) > 0 && slices.ContainsFunc(scrapedImages, func(src string) bool { return !slices.Contains(storedImages, src) }) {
+		changes = append(changes, domain.RecipeChange{Field: "images", Old: storedImages, New: scrapedImages})
+	}
+
+	storedLines, scrapedLines := ingredientLines(stored.Ingredients), ingredientLines(scraped.Ingredients)
+	if len(scrapedLines) > 0 && !slices.Equal(storedLines, scrapedLines) {
+		changes = append(changes, domain.RecipeChange{Field: "ingredients", Old: storedLines, New: scrapedLines})
+	}
+	storedSteps, scrapedSteps := instructionLines(stored.Instructions), instructionLines(scraped.Instructions)
+	if len(scrapedSteps) > 0 && !slices.Equal(storedSteps, scrapedSteps) {
+		changes = append(changes, domain.RecipeChange{Field: "instructions", Old: storedSteps, New: scrapedSteps})
+	}
+	return changes
+}
+
+func diffString(changes []domain.RecipeChange, field string, stored *string, scraped *string) []domain.RecipeChange {
+	if scraped == nil || strings.TrimSpace(*scraped) == "" {
+		return changes
+	}
+	if stored == nil || strings.TrimSpace(*stored) != strings.TrimSpace(*scraped) {
+		return append(changes, domain.RecipeChange{Field: field, Old: stored, New: scraped})
+	}
+	return changes
+}
+
+func diffDuration(changes []domain.RecipeChange, field string, stored *types.Duration, scraped *types.Duration) []domain.RecipeChange {
+	if scraped == nil || *scraped == 0 {
+		return changes
+	}
+	if stored == nil || *stored != *scraped {
+		return append(changes, domain.RecipeChange{Field: field, Old: stored, New: scraped})
+	}
+	return changes
+}
+
+func imageSources(images []*domain.Image) []string {
+	var sources []string
+	for _, img := range images {
+		if img.SourceURL != "" {
+			sources = append(sources, img.SourceURL)
+		}
+	}
+	return sources
+}
+
+func ingredientLines(ingredients []*domain.RecipeIngredient) []string {
+	var lines []string
+	for _, ing := range ingredients {
+		if line := strings.Join(strings.Fields(ing.RawText), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func instructionLines(instructions []*domain.RecipeInstruction) []string {
+	var lines []string
+	for _, inst := range instructions {
+		if line := strings.Join(strings.Fields(inst.Text), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
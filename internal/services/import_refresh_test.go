@@ -0,0 +1,103 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+)
+
+func refreshFixture() *domain.Recipe {
+	return &domain.Recipe{
+		ID:          uuid.New(),
+		Name:        new("Borscht"),
+		SourceUrl:   new("https://example.com/borscht"),
+		Images:      []*domain.Image{{SourceURL: "https://example.com/borscht.jpg"}},
+		Ingredients: []*domain.RecipeIngredient{{RawText: "2 beets"}, {RawText: "1 onion"}},
+		Instructions: []*domain.RecipeInstruction{
+			{Text: "Boil the beets."},
+		},
+	}
+}
+
+func TestImportService_RefreshRecipe_UnchangedMarksRefreshed(t *testing.T) {
+	stored := refreshFixture()
+
+	var marked uuid.UUID
+	svc := newTestImportService(importServiceDeps{
+		recipeService: &stubRecipeService{
+			markRefreshedFn: func(id uuid.UUID) error { marked = id; return nil },
+		},
+		scraper: &stubScraperService{
+			scrapeRecipeFn: func(_ context.Context, _ string) (*domain.Recipe, error) {
+				scraped := refreshFixture()
+				scraped.Ingredients[0].RawText = " 2  beets "
+				scraped.Description = nil
+				return scraped, nil
+			},
+		},
+		recipeIngest: &stubRecipeIngestService{
+			importRecipeFn: func(_ context.Context, _ *domain.Recipe) (*domain.Recipe, error) {
+				t.Fatal("an unchanged recipe must not be imported again")
+				return nil, nil
+			},
+		},
+	})
+
+	changes, err := svc.RefreshRecipe(context.Background(), stored)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, stored.ID, marked)
+}
+
+func TestImportService_RefreshRecipe_ChangedReplacesRecipe(t *testing.T) {
+	stored := refreshFixture()
+
+	var imported *domain.Recipe
+	svc := newTestImportService(importServiceDeps{
+		scraper: &stubScraperService{
+			scrapeRecipeFn: func(_ context.Context, _ string) (*domain.Recipe, error) {
+				scraped := refreshFixture()
+				scraped.Name = new("Ukrainian Borscht")
+				scraped.Ingredients = append(scraped.Ingredients, &domain.RecipeIngredient{RawText: "1 cabbage"})
+				return scraped, nil
+			},
+		},
+		recipeIngest: &stubRecipeIngestService{
+			importRecipeFn: func(_ context.Context, r *domain.Recipe) (*domain.Recipe, error) {
+				imported = r
+				return r, nil
+			},
+		},
+	})
+
+	changes, err := svc.RefreshRecipe(context.Background(), stored)
+	require.NoError(t, err)
+
+	fields := make([]string, 0, len(changes))
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	assert.Equal(t, []string{"name", "ingredients"}, fields)
+
+	require.NotNil(t, imported)
+	assert.Equal(t, stored.ID, imported.ID, "the stored recipe is updated in place")
+	assert.Nil(t, imported.Images, "unchanged images are not downloaded again")
+}
+
+func TestImportService_RefreshRecipe_SourceWithoutRecipeFails(t *testing.T) {
+	svc := newTestImportService(importServiceDeps{
+		scraper: &stubScraperService{
+			scrapeRecipeFn: func(_ context.Context, _ string) (*domain.Recipe, error) {
+				return &domain.Recipe{}, nil
+			},
+		},
+	})
+
+	_, err := svc.RefreshRecipe(context.Background(), refreshFixture())
+	assert.Error(t, err)
+}
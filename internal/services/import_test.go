@@ -2,6 +2,7 @@ package services_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/google/uuid"
@@ -18,6 +19,10 @@ type importServiceDeps struct {
 	recipeIngest  *stubRecipeIngestService
 	feedService   *stubFeedService
 	scraper       *stubScraperService
+	imageService  *stubImageService
+	foodService   *stubFoodService
+	unitService   *stubUnitService
+	parser        stubIngredientParser
 }
 
 func newTestImportService(deps importServiceDeps) domain.ImportService {
@@ -33,7 +38,16 @@ func newTestImportService(deps importServiceDeps) domain.ImportService {
 	if deps.scraper == nil {
 		deps.scraper = &stubScraperService{}
 	}
-	return services.NewImportService(deps.recipeService, deps.recipeIngest, deps.feedService, deps.scraper)
+	if deps.imageService == nil {
+		deps.imageService = &stubImageService{}
+	}
+	if deps.foodService == nil {
+		deps.foodService = &stubFoodService{}
+	}
+	if deps.unitService == nil {
+		deps.unitService = &stubUnitService{}
+	}
+	return services.NewImportService(deps.recipeService, deps.recipeIngest, deps.feedService, deps.scraper, deps.imageService, deps.foodService, deps.unitService, deps.parser)
 }
 
 func TestImportService_ImportFromURL_ExistingRecipe_SavesForUser(t *testing.T) {
@@ -202,12 +216,12 @@ func TestImportService_DetectAndImport_SameURL_DifferentHouseholds_OneCopyStored
 
 	svc := newTestImportService(importServiceDeps{recipeService: recipeSvc, recipeIngest: recipeIngest, scraper: scraper})
 
-	res1, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, false, uid1, hid1)
+	res1, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, false, false, uid1, hid1)
 	require.NoError(t, err)
 	require.NotNil(t, res1.Recipe)
 	assert.Equal(t, importedID, res1.Recipe.ID)
 
-	res2, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, false, uid2, hid2)
+	res2, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, false, false, uid2, hid2)
 	require.NoError(t, err)
 	require.NotNil(t, res2.Recipe)
 	assert.Equal(t, importedID, res2.Recipe.ID)
@@ -246,7 +260,7 @@ func TestImportService_DetectAndImport_FeedURL_SubscribesAndReturnsFeed(t *testi
 	}
 
 	svc := newTestImportService(importServiceDeps{recipeService: recipeSvc, feedService: feedSvc, scraper: scraper})
-	result, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, false, uid, hid)
+	result, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, false, false, uid, hid)
 
 	require.NoError(t, err)
 	require.NotNil(t, result)
@@ -295,10 +309,161 @@ func TestImportService_DetectAndImport_ForceUpdate(t *testing.T) {
 	svc := newTestImportService(importServiceDeps{recipeService: recipeSvc, recipeIngest: recipeIngest, scraper: scraper})
 
 	// forceUpdate = true
-	res, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, true, uid, hid)
+	res, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, true, false, uid, hid)
 
 	require.NoError(t, err)
 	require.NotNil(t, res.Recipe)
 	assert.Equal(t, 1, scrapeCallCount, "URL must be scraped even if it exists when forceUpdate is true")
 	assert.Equal(t, 1, importCallCount, "recipe must be imported even if it exists when forceUpdate is true")
 }
+
+func TestImportService_ImportHtml_ScrapesClientPage(t *testing.T) {
+	testURL := "https://example.com/members/recipe"
+	page := []byte("<html>...</html>")
+	uid, hid := uuid.New(), uuid.New()
+
+	scraper := &stubScraperService{
+		scrapeUrlFn: func(_ context.Context, _ string, _ string) (*domain.ScrapeResult, error) {
+			t.Fatal("the server must not fetch the page itself")
+			return nil, nil
+		},
+		scrapeHtmlFn: func(_ context.Context, html []byte, url string, requestedType string) (*domain.ScrapeResult, error) {
+			assert.Equal(t, page, html)
+			assert.Equal(t, testURL, url)
+			assert.Equal(t, domain.ImportTypeRecipe, requestedType)
+			return &domain.ScrapeResult{Type: domain.PageTypeRecipe, Recipe: &domain.Recipe{SourceUrl: &testURL}}, nil
+		},
+	}
+	var ingested *domain.Recipe
+	recipeIngest := &stubRecipeIngestService{importRecipeFn: func(_ context.Context, recipe *domain.Recipe) (*domain.Recipe, error) {
+		ingested = recipe
+		return recipe, nil
+	}}
+	var savedFor []uuid.UUID
+	recipeSvc := &stubRecipeService{
+		byUrlFn: func(_ string, _ uuid.UUID) (*domain.Recipe, error) { return nil, sentinels.ErrNotFound },
+		userSaveFn: func(_, u, h uuid.UUID) error {
+			savedFor = append(savedFor, u, h)
+			return nil
+		},
+	}
+
+	svc := newTestImportService(importServiceDeps{recipeService: recipeSvc, recipeIngest: recipeIngest, scraper: scraper})
+	result, err := svc.ImportHtml(context.Background(), page, testURL, domain.ImportTypeRecipe, false, false, uid, hid)
+
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	require.NotNil(t, result.Recipe)
+	require.NotNil(t, ingested)
+	require.NotNil(t, ingested.HouseholdID, "a page sent by the client must not become a shared recipe")
+	assert.Equal(t, hid, *ingested.HouseholdID)
+	assert.Equal(t, &uid, ingested.UserID)
+	assert.Equal(t, []uuid.UUID{uid, hid}, savedFor)
+}
+
+func TestImportService_ImportHtml_Update_ReturnsBadRequest(t *testing.T) {
+	scraper := &stubScraperService{
+		scrapeHtmlFn: func(_ context.Context, _ []byte, _ string, _ string) (*domain.ScrapeResult, error) {
+			t.Fatal("the page must not be scraped")
+			return nil, nil
+		},
+	}
+
+	svc := newTestImportService(importServiceDeps{scraper: scraper})
+	_, err := svc.ImportHtml(context.Background(), []byte("<html>...</html>"), "https://example.com/recipe", domain.ImportTypeAuto, true, false, uuid.New(), uuid.New())
+
+	var sErr *sentinels.Error
+	require.ErrorAs(t, err, &sErr)
+	assert.Equal(t, http.StatusBadRequest, sErr.Status)
+}
+
+func TestImportService_ImportHtml_Feed_IsFetchedByServer(t *testing.T) {
+	testURL := "https://example.com/feed.xml"
+	hid := uuid.New()
+
+	scraper := &stubScraperService{
+		scrapeHtmlFn: func(_ context.Context, _ []byte, _ string, _ string) (*domain.ScrapeResult, error) {
+			return &domain.ScrapeResult{Type: domain.PageTypeFeed, Feed: &domain.Feed{Url: testURL, Name: "Not the real feed"}}, nil
+		},
+	}
+	recipeSvc := &stubRecipeService{
+		byUrlFn: func(_ string, _ uuid.UUID) (*domain.Recipe, error) { return nil, sentinels.ErrNotFound },
+	}
+	var subscribed bool
+	feedSvc := &stubFeedService{
+		subscribeFn: func(_ context.Context, householdID uuid.UUID, url string, scraped *domain.Feed) (*domain.Feed, error) {
+			subscribed = true
+			assert.Equal(t, hid, householdID)
+			assert.Equal(t, testURL, url)
+			assert.Nil(t, scraped, "feed metadata sent by the client must not be stored")
+			return &domain.Feed{Url: url}, nil
+		},
+	}
+
+	svc := newTestImportService(importServiceDeps{recipeService: recipeSvc, feedService: feedSvc, scraper: scraper})
+	result, err := svc.ImportHtml(context.Background(), []byte("<rss/>"), testURL, domain.ImportTypeAuto, false, false, uuid.New(), hid)
+
+	require.NoError(t, err)
+	assert.True(t, subscribed)
+	require.NotNil(t, result.Feed)
+}
+
+func TestImportService_DetectAndImport_DryRun_PreviewsWithoutWriting(t *testing.T) {
+	testURL := "https://example.com/recipe/borsch"
+	beetID := uuid.New()
+
+	scraper := &stubScraperService{
+		scrapeUrlFn: func(_ context.Context, _ string, _ string) (*domain.ScrapeResult, error) {
+			return &domain.ScrapeResult{Type: domain.PageTypeRecipe, Recipe: &domain.Recipe{
+				Name: ptr("Borsch"),
+				Ingredients: []*domain.RecipeIngredient{
+					{Food: &domain.Food{Name: "beetroot", Slug: "beetroot"}, Unit: &domain.Unit{Name: "g", Slug: "g"}},
+					{Food: &domain.Food{Name: "beetroot", Slug: "beetroot"}},
+					{Food: &domain.Food{Name: "lovage", Slug: "lovage"}},
+				},
+			}}, nil
+		},
+	}
+	recipeSvc := &stubRecipeService{
+		byUrlFn: func(_ string, _ uuid.UUID) (*domain.Recipe, error) { return nil, sentinels.ErrNotFound },
+		userSaveFn: func(_, _, _ uuid.UUID) error {
+			t.Fatal("a dry run must not save the recipe for the user")
+			return nil
+		},
+	}
+	recipeIngest := &stubRecipeIngestService{importRecipeFn: func(_ context.Context, _ *domain.Recipe) (*domain.Recipe, error) {
+		t.Fatal("a dry run must not ingest the recipe")
+		return nil, nil
+	}}
+	foodLookups := 0
+	foodSvc := &stubFoodService{
+		findFn: func(f *domain.Food) error {
+			foodLookups++
+			if f.Slug == "beetroot" {
+				f.ID = beetID
+				return nil
+			}
+			return sentinels.ErrNotFound
+		},
+		findOrCreateFn: func(_ context.Context, _ *domain.Food) error {
+			t.Fatal("a dry run must not create foods")
+			return nil
+		},
+	}
+	unitSvc := &stubUnitService{findFn: func(_ *domain.Unit) error { return sentinels.ErrNotFound }}
+
+	svc := newTestImportService(importServiceDeps{recipeService: recipeSvc, recipeIngest: recipeIngest, scraper: scraper, foodService: foodSvc, unitService: unitSvc})
+	result, err := svc.DetectAndImport(context.Background(), testURL, domain.ImportTypeAuto, false, true, uuid.New(), uuid.New())
+
+	require.NoError(t, err)
+	assert.False(t, result.Created)
+	assert.Nil(t, result.Recipe)
+	require.NotNil(t, result.Preview)
+	assert.Equal(t, "Borsch", *result.Preview.Recipe.Name)
+	assert.Equal(t, 2, foodLookups, "each food is looked up once")
+	assert.Equal(t, []domain.ImportHint{
+		{Name: "beetroot", Slug: "beetroot", Exists: true, ID: &beetID},
+		{Name: "lovage", Slug: "lovage"},
+	}, result.Preview.Foods)
+	assert.Equal(t, []domain.ImportHint{{Name: "g", Slug: "g"}}, result.Preview.Units)
+}
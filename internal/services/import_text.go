@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/types"
+)
+
+var (
+	// textIngredientsHeaderRe and textInstructionsHeaderRe match the headings that split a pasted recipe into parts.
+	textIngredientsHeaderRe  = regexp.MustCompile(`(?i)^(ingredients?|you(?:'ll| will)? need|what you(?:'ll| will)? need|shopping list)\s*:?$`)
+	textInstructionsHeaderRe = regexp.MustCompile(`(?i)^(instructions?|directions?|method|preparation|steps?|how to (?:make|cook|prepare)(?: it)?)\s*:?$`)
+	textNotesHeaderRe        = regexp.MustCompile(`(?i)^(notes?|tips?|variations?)\s*:?$`)
+	// textYieldRe and textTimeRe match metadata lines such as "Serves 4" or "Prep time: 20 minutes".
+	textYieldRe = regexp.MustCompile(`(?i)^(serves|servings|makes|yields?)\b`)
+	textTimeRe  = regexp.MustCompile(`(?i)^(prep(?:aration)?|cook(?:ing)?|total|active)\s+time\s*:?\s*(.+)$`)
+	// textBulletRe matches list markers, textStepNumberRe step numbers like "1.", "2)" or "Step 3:".
+	textBulletRe     = regexp.MustCompile(`^[-*•·–—▢□]\s*`)
+	textStepNumberRe = regexp.MustCompile(`(?i)^(?:step\s*\d{1,2}\s*[.):]?|\d{1,2}\s*[.)])(?:\s+|$)`)
+	// textQuantityRe matches lines that start like an ingredient: "200 g", "½ cup", "a pinch of".
+	textQuantityRe = regexp.MustCompile(`(?i)^(\d|[½⅓⅔¼¾⅕⅖⅗⅘⅙⅚⅛⅜⅝⅞]|(a|an|one|two|three|four|five|six|half|few|some|pinch|handful|dash|splash)\s)`)
+)
+
+const (
+	// maxTextHeadingLength bounds sub-headings like "For the dough:", longer lines ending in a colon are steps.
+	maxTextHeadingLength = 60
+	// maxTextIngredientLength bounds lines taken for ingredients when the text has no headings.
+	maxTextIngredientLength = 80
+)
+
+type textPart int
+
+const (
+	textPartDescription textPart = iota
+	textPartIngredients
+	textPartInstructions
+	textPartNotes
+)
+
+// ImportText turns a pasted recipe into a draft. The draft is only stored when save is set.
+func (s *importService) ImportText(ctx context.Context, text string, save bool, userID uuid.UUID, householdID uuid.UUID) (*domain.Recipe, error) {
+	recipe := s.parseRecipeText(text)
+	if recipe.Name == nil {
+		return nil, sentinels.BadRequest("text is empty")
+	}
+	if len(recipe.Ingredients) == 0 && len(recipe.Instructions) == 0 {
+		return nil, sentinels.BadRequest("no ingredients or instructions found in text")
+	}
+	if !save {
+		return recipe, nil
+	}
+
+	// like the recipes of export files, the ingest service resolves foods and units and links the steps to them
+	recipe.HouseholdID = &householdID
+	recipe.UserID = &userID
+	recipe, err := s.recipeIngest.ImportRecipe(ctx, recipe)
+	if err != nil {
+		return nil, fmt.Errorf("from text (ingest): %w", err)
+	}
+	if err := s.recipeService.UserSave(recipe.ID, userID, householdID); err != nil {
+		return nil, fmt.Errorf("from text (save): %w", err)
+	}
+	return recipe, nil
+}
+
+// parseRecipeText splits free text into title, description, ingredients, instructions and notes. Headings like
+// "Ingredients" and "Method" decide which part a line belongs to; without them a line is an ingredient when it is
+// short and starts with a quantity or bullet, and everything from the first other line on is an instruction.
+func (s *importService) parseRecipeText(text string) *domain.Recipe {
+	recipe := &domain.Recipe{}
+	var description, notes []string
+	var section *domain.RecipeInstruction
+	var category *string
+	var numbered bool
+	part := textPartDescription
+	headings := hasTextHeadings(text)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if recipe.Name == nil {
+			recipe.Name = new(strings.TrimPrefix(line, "# "))
+			continue
+		}
+
+		switch {
+		case textIngredientsHeaderRe.MatchString(line):
+			part, category = textPartIngredients, nil
+			continue
+		case textInstructionsHeaderRe.MatchString(line):
+			part, section = textPartInstructions, nil
+			continue
+		case textNotesHeaderRe.MatchString(line):
+			part = textPartNotes
+			continue
+		case part != textPartInstructions && part != textPartNotes && applyTextMetadata(recipe, line):
+			continue
+		}
+
+		if !headings && part != textPartInstructions {
+			if isTextIngredient(line) {
+				part = textPartIngredients
+			} else if _, heading := textHeading(line); (part == textPartIngredients && !heading) || textStepNumberRe.MatchString(line) {
+				part = textPartInstructions
+			}
+		}
+
+		switch part {
+		case textPartDescription:
+			description = append(description, line)
+		case textPartNotes:
+			notes = append(notes, textBulletRe.ReplaceAllString(line, ""))
+		case textPartIngredients:
+			if heading, ok := textHeading(line); ok {
+				category = new(heading)
+				continue
+			}
+			raw := textBulletRe.ReplaceAllString(line, "")
+			recipe.Ingredients = append(recipe.Ingredients, &domain.RecipeIngredient{
+				Name:     new(raw),
+				RawText:  raw,
+				Category: category,
+			})
+		case textPartInstructions:
+			if heading, ok := textHeading(line); ok {
+				section = &domain.RecipeInstruction{Title: new(heading)}
+				recipe.Instructions = append(recipe.Instructions, section)
+				continue
+			}
+			step := textBulletRe.ReplaceAllString(line, "")
+			if textStepNumberRe.MatchString(step) {
+				numbered = true
+				step = textStepNumberRe.ReplaceAllString(step, "")
+			} else if last := lastTextStep(recipe); numbered && last != nil {
+				// an unnumbered line in a numbered list continues the step above it
+				last.Text += " " + step
+				continue
+			}
+			recipe.Instructions = append(recipe.Instructions, &domain.RecipeInstruction{Text: step, Parent: section})
+		}
+	}
+
+	if !headings && len(recipe.Ingredients) == 0 && len(recipe.Instructions) == 0 {
+		// nothing looked like a list, so the paragraphs under the title are the method
+		for _, line := range description {
+			recipe.Instructions = append(recipe.Instructions, &domain.RecipeInstruction{Text: line})
+		}
+		description = nil
+	}
+	if len(description) > 0 {
+		recipe.Description = new(strings.Join(description, "\n"))
+	}
+	if len(notes) > 0 {
+		recipe.Text = new(strings.Join(notes, "\n"))
+	}
+
+	// the draft is returned as is for preview, so it gets its IDs and order here rather than on save
+	for _, ing := range recipe.Ingredients {
+		ing.ID, _ = uuid.NewV7()
+	}
+	for i, inst := range recipe.Instructions {
+		inst.ID, _ = uuid.NewV7()
+		inst.Order = uint8(i)
+	}
+	for _, inst := range recipe.Instructions {
+		if inst.Parent != nil {
+			inst.ParentID = &inst.Parent.ID
+			inst.Parent = nil
+		}
+	}
+
+	s.mapper.enrichIngredients(recipe.Ingredients, "")
+	linkInstructionIngredients(recipe)
+	for _, inst := range recipe.Instructions {
+		parseInstructionMetadata(inst)
+	}
+	return recipe
+}
+
+// hasTextHeadings reports whether the text marks its ingredients or instructions with a heading.
+func hasTextHeadings(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if textIngredientsHeaderRe.MatchString(line) || textInstructionsHeaderRe.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTextMetadata reads yield and time lines into the recipe, it reports whether the line was one of them.
+func applyTextMetadata(recipe *domain.Recipe, line string) bool {
+	if textYieldRe.MatchString(line) {
+		applyYield(recipe, line)
+		return true
+	}
+	m := textTimeRe.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	d, err := types.DurationFromISO8601(parseExportDuration(m[2]))
+	if err != nil {
+		return true
+	}
+	switch strings.ToLower(m[1])[0] {
+	case 'p', 'a':
+		recipe.PrepTime = &d
+	case 'c':
+		recipe.CookTime = &d
+	default:
+		recipe.TotalTime = &d
+	}
+	return true
+}
+
+// textHeading recognises sub-headings such as "For the dough:" and returns them without the colon.
+func textHeading(line string) (string, bool) {
+	line = strings.TrimSpace(textBulletRe.ReplaceAllString(line, ""))
+	if !strings.HasSuffix(line, ":") || utf8.RuneCountInString(line) > maxTextHeadingLength {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimSuffix(line, ":")), true
+}
+
+// isTextIngredient guesses whether a line of a text without headings is an ingredient.
+func isTextIngredient(line string) bool {
+	if textStepNumberRe.MatchString(line) || strings.HasSuffix(line, ".") || utf8.RuneCountInString(line) > maxTextIngredientLength {
+		return false
+	}
+	return textBulletRe.MatchString(line) || textQuantityRe.MatchString(line)
+}
+
+func lastTextStep(recipe *domain.Recipe) *domain.RecipeInstruction {
+	if n := len(recipe.Instructions); n > 0 && recipe.Instructions[n-1].Title == nil {
+		return recipe.Instructions[n-1]
+	}
+	return nil
+}
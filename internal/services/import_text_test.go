@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/borschtapp/kapusta"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+)
+
+// quantityParser reads "<amount> [g|ml] <name>", enough to tell parsed ingredients from raw ones.
+var quantityParser = stubIngredientParser{parseFn: func(text string) kapusta.Ingredient {
+	fields := strings.Fields(text)
+	amount, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return kapusta.Ingredient{Name: text}
+	}
+	ing := kapusta.Ingredient{Amount: amount, Name: strings.Join(fields[1:], " ")}
+	if len(fields) > 2 && (fields[1] == "g" || fields[1] == "ml") {
+		ing.Unit, ing.UnitCode, ing.Name = fields[1], fields[1], strings.Join(fields[2:], " ")
+	}
+	return ing
+}}
+
+const syrnikyText = `Syrniki
+
+Ukrainian cottage cheese pancakes.
+Serves 4
+Prep time: 15 minutes
+
+Ingredients
+- 500 g tvorog
+- 2 eggs
+For frying:
+- 30 ml oil
+
+Method
+1. Mash the tvorog and whisk in the eggs.
+2. Shape into patties,
+   about 1 cm thick.
+To serve:
+3. Fry in the oil for 3 minutes per side.
+
+Notes
+Serve with smetana.
+`
+
+func TestImportService_ImportText_Preview(t *testing.T) {
+	svc := newTestImportService(importServiceDeps{
+		parser: quantityParser,
+		recipeService: &stubRecipeService{createFn: func(*domain.Recipe, uuid.UUID, uuid.UUID) error {
+			t.Fatal("a preview must not be saved")
+			return nil
+		}},
+	})
+
+	recipe, err := svc.ImportText(context.Background(), syrnikyText, false, uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Syrniki", *recipe.Name)
+	assert.Equal(t, "Ukrainian cottage cheese pancakes.", *recipe.Description)
+	assert.Equal(t, 4.0, *recipe.Yield)
+	assert.Equal(t, 15*time.Minute, time.Duration(*recipe.PrepTime))
+	assert.Equal(t, "Serve with smetana.", *recipe.Text)
+
+	require.Len(t, recipe.Ingredients, 3)
+	tvorog, eggs, oil := recipe.Ingredients[0], recipe.Ingredients[1], recipe.Ingredients[2]
+	assert.Equal(t, "500 g tvorog", tvorog.RawText)
+	assert.Equal(t, "tvorog", *tvorog.Name)
+	assert.Equal(t, 500.0, *tvorog.Amount)
+	assert.Equal(t, "g", tvorog.Unit.Name)
+	assert.Equal(t, 2.0, *eggs.Amount)
+	assert.Nil(t, eggs.Category)
+	assert.Equal(t, "For frying", *oil.Category)
+	assert.NotEqual(t, uuid.Nil, oil.ID)
+
+	require.Len(t, recipe.Instructions, 4)
+	assert.Equal(t, "Mash the tvorog and whisk in the eggs.", recipe.Instructions[0].Text)
+	assert.Equal(t, []*domain.RecipeIngredient{tvorog, eggs}, recipe.Instructions[0].Ingredients)
+	assert.Equal(t, "Shape into patties, about 1 cm thick.", recipe.Instructions[1].Text)
+	section := recipe.Instructions[2]
+	assert.Equal(t, "To serve", *section.Title)
+	step := recipe.Instructions[3]
+	assert.Equal(t, uint8(3), step.Order)
+	assert.Equal(t, section.ID, *step.ParentID)
+	assert.Nil(t, step.Parent)
+	require.Len(t, step.Timers, 1)
+	assert.Equal(t, 3*time.Minute, time.Duration(step.Timers[0].Duration))
+}
+
+func TestImportService_ImportText_NoHeadings_GuessesFromLineShape(t *testing.T) {
+	svc := newTestImportService(importServiceDeps{})
+
+	recipe, err := svc.ImportText(context.Background(), `Garlic toast
+The quickest snack.
+2 slices of bread
+1 clove garlic
+• olive oil
+Toast the bread until golden.
+Rub with the garlic and drizzle with oil.`, false, uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Garlic toast", *recipe.Name)
+	assert.Equal(t, "The quickest snack.", *recipe.Description)
+	require.Len(t, recipe.Ingredients, 3)
+	assert.Equal(t, "olive oil", recipe.Ingredients[2].RawText)
+	require.Len(t, recipe.Instructions, 2)
+	assert.Equal(t, "Rub with the garlic and drizzle with oil.", recipe.Instructions[1].Text)
+}
+
+func TestImportService_ImportText_NoList_ParagraphsBecomeSteps(t *testing.T) {
+	svc := newTestImportService(importServiceDeps{})
+
+	recipe, err := svc.ImportText(context.Background(), "Tea\nBoil the water.\nPour it over the leaves.", false, uuid.New(), uuid.New())
+	require.NoError(t, err)
+
+	assert.Nil(t, recipe.Description)
+	assert.Empty(t, recipe.Ingredients)
+	require.Len(t, recipe.Instructions, 2)
+	assert.Equal(t, "Boil the water.", recipe.Instructions[0].Text)
+}
+
+func TestImportService_ImportText_Save_IngestsIntoHousehold(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	var imported []*domain.Recipe
+	var savedFor []uuid.UUID
+	svc := newTestImportService(importServiceDeps{
+		parser:       quantityParser,
+		recipeIngest: collectingIngest(&imported),
+		recipeService: &stubRecipeService{
+			createFn: func(*domain.Recipe, uuid.UUID, uuid.UUID) error {
+				t.Fatal("the recipe must go through the ingest service")
+				return nil
+			},
+			userSaveFn: func(recipeID, userID, householdID uuid.UUID) error {
+				savedFor = []uuid.UUID{recipeID, userID, householdID}
+				return nil
+			},
+		},
+	})
+
+	recipe, err := svc.ImportText(context.Background(), "Sugar syrup\nIngredients\n100 g sugar\n100 ml water\nSteps\nBoil the sugar in the water.", true, uid, hid)
+	require.NoError(t, err)
+
+	require.Len(t, imported, 1)
+	assert.Same(t, recipe, imported[0])
+	assert.Equal(t, hid, *recipe.HouseholdID)
+	assert.Equal(t, uid, *recipe.UserID)
+	assert.Equal(t, []uuid.UUID{recipe.ID, uid, hid}, savedFor)
+	require.Len(t, recipe.Ingredients, 2)
+	assert.Equal(t, "sugar", recipe.Ingredients[0].Food.Name, "foods are handed to the ingest service to resolve")
+	assert.Len(t, recipe.Instructions[0].Ingredients, 2)
+}
+
+func TestImportService_ImportText_Empty_ReturnsBadRequest(t *testing.T) {
+	svc := newTestImportService(importServiceDeps{})
+
+	for _, text := range []string{"", "  \n\n", "Just a title"} {
+		_, err := svc.ImportText(context.Background(), text, true, uuid.New(), uuid.New())
+
+		var sErr *sentinels.Error
+		require.ErrorAs(t, err, &sErr, text)
+		assert.Equal(t, http.StatusBadRequest, sErr.Status)
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"borscht.app/smetana/domain"
+)
+
+// minLinkTermLength skips names too short to be matched reliably (e.g. "egg" is fine, "o" is not).
+const minLinkTermLength = 3
+
+type linkTerm struct {
+	text       string
+	ingredient *domain.RecipeIngredient
+}
+
+// linkInstructionIngredients links every instruction to the ingredients mentioned in its text, matching the
+// ingredient name as written and the name of its food as whole words, optionally in plural form. Longer names
+// are matched first and consume the text they cover, so "olive oil" does not also link a separate "oil".
+// Instructions that already have links, e.g. from the source, are left untouched.
+func linkInstructionIngredients(recipe *domain.Recipe) {
+	var terms []linkTerm
+	for _, ing := range recipe.Ingredients {
+		names := make([]string, 0, 2)
+		if ing.Name != nil {
+			names = append(names, *ing.Name)
+		}
+		if ing.Food != nil {
+			names = append(names, ing.Food.Name)
+		}
+		for _, name := range names {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if utf8.RuneCountInString(name) >= minLinkTermLength {
+				terms = append(terms, linkTerm{text: name, ingredient: ing})
+			}
+		}
+	}
+	if len(terms) == 0 {
+		return
+	}
+	slices.SortStableFunc(terms, func(a, b linkTerm) int {
+		return cmp.Compare(len(b.text), len(a.text))
+	})
+
+	for _, inst := range recipe.Instructions {
+		if len(inst.Ingredients) > 0 {
+			continue
+		}
+		text := []byte(strings.ToLower(inst.Text))
+		linked := make(map[*domain.RecipeIngredient]bool)
+		for _, term := range terms {
+			start, end := findWord(string(text), term.text)
+			if start < 0 {
+				continue
+			}
+			for i := start; i < end; i++ {
+				text[i] = ' '
+			}
+			if !linked[term.ingredient] {
+				linked[term.ingredient] = true
+				inst.Ingredients = append(inst.Ingredients, term.ingredient)
+			}
+		}
+	}
+}
+
+// findWord returns the byte range of the first whole-word occurrence of term in text, allowing a plural
+// "s" or "es" suffix, or -1 when there is none.
+func findWord(text, term string) (int, int) {
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		end := start + len(term)
+		offset = start + 1
+
+		if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(r) {
+			continue
+		}
+		for _, suffix := range []string{"", "s", "es"} {
+			if !strings.HasPrefix(text[end:], suffix) {
+				continue
+			}
+			if r, _ := utf8.DecodeRuneInString(text[end+len(suffix):]); end+len(suffix) == len(text) || !isWordRune(r) {
+				return start, end + len(suffix)
+			}
+		}
+	}
+	return -1, -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
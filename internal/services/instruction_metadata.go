@@ -0,0 +1,131 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/types"
+)
+
+var (
+	// durationRe matches "20 minutes", "20-25 mins" and "1 hour 30 minutes". The amount must be a number, so phrases
+	// like "wait a second" are not taken as timers.
+	durationRe = regexp.MustCompile(`(?i)\b(\d+(?:[.,]\d+)?)(?:\s*(?:-|–|—|to)\s*(\d+(?:[.,]\d+)?))?\s*(hours?|hrs?|h|minutes?|mins?|seconds?|secs?)\b(?:,?\s*(?:and\s+)?(\d+)\s*(?:minutes?|mins?)\b)?`)
+	// temperatureRe matches "180°C", "350 °F", "200C", "180-200 degrees Celsius", "350°" and "350 degrees".
+	temperatureRe = regexp.MustCompile(`(\d{2,3})(?:\s*(?:-|–|—|(?i:to))\s*(\d{2,3}))?\s*(?:(°|º|˚|(?i:degrees?))(?:\s*((?i:celsius|fahrenheit|c|f))\b)?|([CF])\b)`)
+	// heatRe matches the words that make a bare "degrees" a cooking temperature rather than an angle.
+	heatRe = regexp.MustCompile(`(?i)\b(?:oven|pre-?heat|heat|bak(?:e|ing)|roast|broil|grill|fry|temperature|thermometer)`)
+	// temperatureSeparatorRe matches what separates two notations of one temperature, as in "180°C / 350°F".
+	temperatureSeparatorRe = regexp.MustCompile(`^[\s/|(),]*(?:or\s*)?$`)
+)
+
+// parseInstructionMetadata sets the timers and temperatures of an instruction from its text.
+func parseInstructionMetadata(inst *domain.RecipeInstruction) {
+	inst.Timers = parseTimers(inst.Text)
+	inst.Temperatures = parseTemperatures(inst.Text)
+}
+
+func parseTimers(text string) []domain.InstructionTimer {
+	var timers []domain.InstructionTimer
+	for _, m := range durationRe.FindAllStringSubmatch(text, -1) {
+		unit := durationUnit(m[3])
+		value, ok := parseDurationAmount(m[1])
+		if !ok {
+			continue
+		}
+		timer := domain.InstructionTimer{Duration: types.Duration(value * float64(unit)), Text: strings.TrimSpace(m[0])}
+		if m[2] != "" {
+			if maxValue, ok := parseDurationAmount(m[2]); ok && maxValue > value {
+				timer.MaxDuration = new(types.Duration(maxValue * float64(unit)))
+			}
+		}
+		if m[4] != "" && unit == time.Hour {
+			minutes, _ := strconv.Atoi(m[4])
+			timer.Duration += types.Duration(time.Duration(minutes) * time.Minute)
+		}
+		if timer.Duration > 0 {
+			timers = append(timers, timer)
+		}
+	}
+	return timers
+}
+
+func durationUnit(s string) time.Duration {
+	switch s = strings.ToLower(s); {
+	case strings.HasPrefix(s, "h"):
+		return time.Hour
+	case strings.HasPrefix(s, "m"):
+		return time.Minute
+	default:
+		return time.Second
+	}
+}
+
+func parseDurationAmount(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	return v, err == nil
+}
+
+// parseTemperatures returns the temperatures mentioned in text. A temperature directly followed by the same
+// temperature in the other unit ("180°C / 350°F") is kept once, in the unit written first. Without a unit,
+// values above 250 are taken as Fahrenheit, since no home oven goes much beyond 250°C. A bare "degrees" without
+// a unit only counts in a sentence about heat ("bake at 350 degrees"), not in "turn the dough 90 degrees".
+func parseTemperatures(text string) []domain.Temperature {
+	var temps []domain.Temperature
+	prevEnd := -1
+	for _, loc := range temperatureRe.FindAllStringSubmatchIndex(text, -1) {
+		group := func(i int) string {
+			if loc[2*i] < 0 {
+				return ""
+			}
+			return text[loc[2*i]:loc[2*i+1]]
+		}
+
+		unit := strings.ToLower(group(4) + group(5))
+		if unit == "" && strings.HasPrefix(strings.ToLower(group(3)), "d") && !heatRe.MatchString(sentenceAt(text, loc[0], loc[1])) {
+			continue
+		}
+
+		value, _ := strconv.ParseFloat(group(1), 64)
+		temp := domain.Temperature{Value: value, Unit: domain.Celsius}
+		switch {
+		case strings.HasPrefix(unit, "f"):
+			temp.Unit = domain.Fahrenheit
+		case unit == "" && value > 250:
+			temp.Unit = domain.Fahrenheit
+		}
+		if maxValue, _ := strconv.ParseFloat(group(2), 64); maxValue > value {
+			temp.MaxValue = new(maxValue)
+		}
+
+		if n := len(temps); n > 0 && temps[n-1].Unit != temp.Unit && temperatureSeparatorRe.MatchString(text[prevEnd:loc[0]]) {
+			prevEnd = loc[1]
+			continue
+		}
+		temps = append(temps, temp)
+		prevEnd = loc[1]
+	}
+	return temps
+}
+
+// sentenceAt returns the sentence of text that holds text[start:end].
+func sentenceAt(text string, start, end int) string {
+	from := strings.LastIndexAny(text[:start], ".!?\n") + 1
+	to := len(text)
+	if i := strings.IndexAny(text[end:], ".!?\n"); i >= 0 {
+		to = end + i
+	}
+	return text[from:to]
+}
+
+// localizeTemperatures converts the instruction temperatures to the given unit.
+func localizeTemperatures(instructions []*domain.RecipeInstruction, unit domain.TemperatureUnit) {
+	for _, inst := range instructions {
+		for i := range inst.Temperatures {
+			inst.Temperatures[i] = inst.Temperatures[i].In(unit)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"math"
 	"time"
 
 	"github.com/gofiber/fiber/v3/log"
@@ -47,6 +48,16 @@ func (s *mealPlanService) Create(mealPlan *domain.MealPlan, householdID uuid.UUI
 		}
 	}
 
+	// plan the servings the recipe yields unless told otherwise; yields like "12 cookies" are not servings
+	if mealPlan.Servings == nil && mealPlan.Recipe != nil && mealPlan.Recipe.YieldsServings() {
+		servings := max(1, int(math.Round(*mealPlan.Recipe.Yield)))
+		if err := s.repo.Update(&domain.MealPlan{ID: mealPlan.ID, Servings: &servings}); err != nil {
+			log.Warnw("failed to default meal plan servings", "meal_plan", mealPlan.ID, "error", err.Error())
+		} else {
+			mealPlan.Servings = &servings
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,54 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/services"
+)
+
+func newMealPlanRepoWithRecipe(recipe *domain.Recipe, updated *[]*domain.MealPlan) *stubMealPlanRepo {
+	return &stubMealPlanRepo{
+		createFn: func(mp *domain.MealPlan) error {
+			mp.ID = uuid.New()
+			return nil
+		},
+		byIDWithRecipesFn: func(id uuid.UUID) (*domain.MealPlan, error) {
+			return &domain.MealPlan{ID: id, RecipeID: &recipe.ID, Recipe: recipe}, nil
+		},
+		updateFn: func(mp *domain.MealPlan) error {
+			*updated = append(*updated, mp)
+			return nil
+		},
+	}
+}
+
+func TestMealPlanService_Create_WithoutServings_DefaultsToRecipeYield(t *testing.T) {
+	recipe := &domain.Recipe{ID: uuid.New(), Yield: ptr(4.0)}
+	var updated []*domain.MealPlan
+
+	svc := services.NewMealPlanService(newMealPlanRepoWithRecipe(recipe, &updated))
+	mealPlan := &domain.MealPlan{RecipeID: &recipe.ID, MealType: "dinner"}
+	require.NoError(t, svc.Create(mealPlan, uuid.New()))
+
+	require.NotNil(t, mealPlan.Servings)
+	assert.Equal(t, 4, *mealPlan.Servings)
+	require.Len(t, updated, 1)
+	assert.Equal(t, 4, *updated[0].Servings)
+}
+
+func TestMealPlanService_Create_YieldNotInServings_KeepsServingsEmpty(t *testing.T) {
+	recipe := &domain.Recipe{ID: uuid.New(), Yield: ptr(12.0), YieldUnit: ptr("cookies")}
+	var updated []*domain.MealPlan
+
+	svc := services.NewMealPlanService(newMealPlanRepoWithRecipe(recipe, &updated))
+	mealPlan := &domain.MealPlan{RecipeID: &recipe.ID, MealType: "dinner"}
+	require.NoError(t, svc.Create(mealPlan, uuid.New()))
+
+	assert.Nil(t, mealPlan.Servings)
+	assert.Empty(t, updated)
+}
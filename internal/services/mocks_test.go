@@ -2,8 +2,10 @@ package services_test
 
 import (
 	"context"
+	"time"
 
 	"borscht.app/smetana/internal/types"
+	"github.com/borschtapp/kapusta"
 	"github.com/borschtapp/krip"
 	"github.com/google/uuid"
 
@@ -19,8 +21,12 @@ type stubRecipeService struct {
 	byParentIDsAndHouseholdFn func([]uuid.UUID, uuid.UUID, types.PreloadOptions) ([]domain.Recipe, error)
 	searchFn                  func(uuid.UUID, uuid.UUID, domain.RecipeSearchOptions) ([]domain.Recipe, int64, error)
 	userSaveFn                func(uuid.UUID, uuid.UUID, uuid.UUID) error
+	createFn                  func(*domain.Recipe, uuid.UUID, uuid.UUID) error
 	importFn                  func(*domain.Recipe) error
 	setFeedIDFn               func(uuid.UUID, uuid.UUID) error
+	exportFn                  func(uuid.UUID, uuid.UUID, uuid.UUID, domain.ExportOptions) ([]byte, error)
+	markRefreshedFn           func(uuid.UUID) error
+	tasteProfileFn            func(uuid.UUID) (*domain.TasteProfile, error)
 }
 
 func (s *stubRecipeService) ByID(id, householdID uuid.UUID) (*domain.Recipe, error) {
@@ -30,6 +36,13 @@ func (s *stubRecipeService) ByID(id, householdID uuid.UUID) (*domain.Recipe, err
 	return nil, nil
 }
 
+func (s *stubRecipeService) Export(id, userID, householdID uuid.UUID, opts domain.ExportOptions) ([]byte, error) {
+	if s.exportFn != nil {
+		return s.exportFn(id, userID, householdID, opts)
+	}
+	return nil, nil
+}
+
 func (s *stubRecipeService) ByUrl(url string, householdID uuid.UUID) (*domain.Recipe, error) {
 	if s.byUrlFn != nil {
 		return s.byUrlFn(url, householdID)
@@ -58,6 +71,13 @@ func (s *stubRecipeService) UserSave(recipeID, userID, householdID uuid.UUID) er
 	return nil
 }
 
+func (s *stubRecipeService) Create(recipe *domain.Recipe, userID, householdID uuid.UUID) error {
+	if s.createFn != nil {
+		return s.createFn(recipe, userID, householdID)
+	}
+	return nil
+}
+
 func (s *stubRecipeService) Import(recipe *domain.Recipe) error {
 	if s.importFn != nil {
 		return s.importFn(recipe)
@@ -72,6 +92,20 @@ func (s *stubRecipeService) SetFeedID(recipeID, feedID uuid.UUID) error {
 	return nil
 }
 
+func (s *stubRecipeService) TasteProfile(householdID uuid.UUID) (*domain.TasteProfile, error) {
+	if s.tasteProfileFn != nil {
+		return s.tasteProfileFn(householdID)
+	}
+	return &domain.TasteProfile{}, nil
+}
+
+func (s *stubRecipeService) MarkRefreshed(id uuid.UUID) error {
+	if s.markRefreshedFn != nil {
+		return s.markRefreshedFn(id)
+	}
+	return nil
+}
+
 type stubRecipeIngestService struct {
 	domain.RecipeIngestService
 	importRecipeFn func(context.Context, *domain.Recipe) (*domain.Recipe, error)
@@ -96,6 +130,8 @@ type stubRecipeRepo struct {
 	importFn                  func(*domain.Recipe) error
 	updateFn                  func(*domain.Recipe) error
 	deleteFn                  func(uuid.UUID) error
+	trashedByIDFn             func(uuid.UUID) (*domain.Recipe, error)
+	subRecipeIDsFn            func([]uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
 	userSaveFn                func(uuid.UUID, uuid.UUID, uuid.UUID) error
 	userUnsaveFn              func(uuid.UUID, uuid.UUID) error
 	updateIngredientFn        func(*domain.RecipeIngredient) error
@@ -106,6 +142,7 @@ type stubRecipeRepo struct {
 	updateInstructionFn       func(*domain.RecipeInstruction) error
 	transactionFn             func(func(domain.RecipeRepository) error) error
 	replaceRecipePointersFn   func(uuid.UUID, uuid.UUID, uuid.UUID) error
+	duplicateCandidatesFn     func(uuid.UUID, int, int) ([]domain.Recipe, int64, error)
 }
 
 func (s *stubRecipeRepo) ByID(id uuid.UUID) (*domain.Recipe, error) { return s.byIDFn(id) }
@@ -125,6 +162,15 @@ func (s *stubRecipeRepo) Update(r *domain.Recipe) error          { return s.upda
 func (s *stubRecipeRepo) Delete(id uuid.UUID) error              { return s.deleteFn(id) }
 func (s *stubRecipeRepo) UserSave(rid, uid, hid uuid.UUID) error { return s.userSaveFn(rid, uid, hid) }
 func (s *stubRecipeRepo) UserUnsave(rid, uid uuid.UUID) error    { return s.userUnsaveFn(rid, uid) }
+func (s *stubRecipeRepo) TrashedByID(id uuid.UUID) (*domain.Recipe, error) {
+	return s.trashedByIDFn(id)
+}
+func (s *stubRecipeRepo) SubRecipeIDs(ids []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	if s.subRecipeIDsFn != nil {
+		return s.subRecipeIDsFn(ids)
+	}
+	return nil, nil
+}
 func (s *stubRecipeRepo) CreateIngredient(i *domain.RecipeIngredient) error {
 	if s.createIngredientFn != nil {
 		return s.createIngredientFn(i)
@@ -161,6 +207,9 @@ func (s *stubRecipeRepo) Transaction(fn func(domain.RecipeRepository) error) err
 func (s *stubRecipeRepo) ReplaceRecipePointers(old, newID, hid uuid.UUID) error {
 	return s.replaceRecipePointersFn(old, newID, hid)
 }
+func (s *stubRecipeRepo) DuplicateCandidates(hid uuid.UUID, offset, limit int) ([]domain.Recipe, int64, error) {
+	return s.duplicateCandidatesFn(hid, offset, limit)
+}
 
 type stubUserRepo struct {
 	domain.UserRepository
@@ -224,9 +273,10 @@ func (s *stubHouseholdRepo) Delete(id uuid.UUID) error {
 type stubImageService struct {
 	domain.ImageService
 
-	persistRemoteFn func(context.Context, *domain.Image, string) error
-	deleteFn        func(uuid.UUID) error
-	setDefaultFn    func(*domain.Image) error
+	persistRemoteFn   func(context.Context, *domain.Image, string) error
+	persistUploadedFn func(context.Context, []byte, string) (*domain.UploadedImage, error)
+	deleteFn          func(uuid.UUID) error
+	setDefaultFn      func(*domain.Image) error
 }
 
 func (s *stubImageService) PersistRemote(ctx context.Context, image *domain.Image, pathPrefix string) error {
@@ -235,6 +285,12 @@ func (s *stubImageService) PersistRemote(ctx context.Context, image *domain.Imag
 	}
 	return nil
 }
+func (s *stubImageService) PersistUploaded(ctx context.Context, data []byte, contentType string) (*domain.UploadedImage, error) {
+	if s.persistUploadedFn != nil {
+		return s.persistUploadedFn(ctx, data, contentType)
+	}
+	return &domain.UploadedImage{Path: "uploads/stub.jpg", ContentType: contentType, Size: int64(len(data))}, nil
+}
 func (s *stubImageService) Delete(id uuid.UUID) error {
 	if s.deleteFn != nil {
 		return s.deleteFn(id)
@@ -278,6 +334,7 @@ type stubFoodService struct {
 	domain.FoodService
 
 	findOrCreateFn func(context.Context, *domain.Food) error
+	findFn         func(*domain.Food) error
 	updateFn       func(*domain.Food) error
 	byIDsFn        func([]uuid.UUID) (map[uuid.UUID]*domain.Food, error)
 	latestPricesFn func(uuid.UUID, []uuid.UUID) (map[uuid.UUID]*domain.FoodPrice, error)
@@ -296,6 +353,12 @@ func (s *stubFoodService) FindOrCreate(ctx context.Context, f *domain.Food) erro
 	}
 	return nil
 }
+func (s *stubFoodService) Find(f *domain.Food) error {
+	if s.findFn != nil {
+		return s.findFn(f)
+	}
+	return nil
+}
 func (s *stubFoodService) AddTaxonomy(foodID uuid.UUID, taxonomy *domain.Taxonomy) error {
 	return nil
 }
@@ -316,7 +379,9 @@ type stubUnitService struct {
 	domain.UnitService
 
 	findOrCreateFn func(*domain.Unit) error
+	findFn         func(*domain.Unit) error
 	convertFn      func(float64, uuid.UUID, uuid.UUID) (float64, error)
+	bestUnitFn     func(float64, uuid.UUID, bool) (*domain.Unit, error)
 }
 
 func (s *stubUnitService) FindOrCreate(u *domain.Unit) error {
@@ -325,12 +390,24 @@ func (s *stubUnitService) FindOrCreate(u *domain.Unit) error {
 	}
 	return nil
 }
+func (s *stubUnitService) Find(u *domain.Unit) error {
+	if s.findFn != nil {
+		return s.findFn(u)
+	}
+	return nil
+}
 func (s *stubUnitService) Convert(amount float64, from, to uuid.UUID) (float64, error) {
 	if s.convertFn != nil {
 		return s.convertFn(amount, from, to)
 	}
 	return amount, nil
 }
+func (s *stubUnitService) BestUnit(amount float64, from uuid.UUID, imperial bool) (*domain.Unit, error) {
+	if s.bestUnitFn != nil {
+		return s.bestUnitFn(amount, from, imperial)
+	}
+	return nil, sentinels.Unprocessable("no units found with the same base unit")
+}
 
 type stubTaxonomyRepo struct {
 	domain.TaxonomyRepository
@@ -362,8 +439,11 @@ type stubScraperService struct {
 	domain.ScraperService
 
 	scrapeUrlFn    func(context.Context, string, string) (*domain.ScrapeResult, error)
+	scrapeHtmlFn   func(context.Context, []byte, string, string) (*domain.ScrapeResult, error)
 	scrapeRecipeFn func(context.Context, string) (*domain.Recipe, error)
 	scrapeFeedFn   func(context.Context, *domain.Feed, krip.FeedOptions) ([]*domain.Recipe, error)
+
+	scrapeFeedChangesFn func(context.Context, *domain.Feed, krip.FeedOptions, func(string) bool) ([]*domain.Recipe, bool, error)
 }
 
 func (s *stubScraperService) ScrapeUrl(ctx context.Context, url string, requestedType string) (*domain.ScrapeResult, error) {
@@ -372,6 +452,12 @@ func (s *stubScraperService) ScrapeUrl(ctx context.Context, url string, requeste
 	}
 	return nil, nil
 }
+func (s *stubScraperService) ScrapeHtml(ctx context.Context, html []byte, url string, requestedType string) (*domain.ScrapeResult, error) {
+	if s.scrapeHtmlFn != nil {
+		return s.scrapeHtmlFn(ctx, html, url, requestedType)
+	}
+	return nil, nil
+}
 func (s *stubScraperService) ScrapeRecipe(ctx context.Context, url string) (*domain.Recipe, error) {
 	if s.scrapeRecipeFn != nil {
 		return s.scrapeRecipeFn(ctx, url)
@@ -384,21 +470,72 @@ func (m *stubScraperService) ScrapeFeed(ctx context.Context, feed *domain.Feed,
 	}
 	return nil, nil
 }
+func (m *stubScraperService) ScrapeFeedChanges(ctx context.Context, feed *domain.Feed, opts krip.FeedOptions, isKnown func(string) bool) ([]*domain.Recipe, bool, error) {
+	if m.scrapeFeedChangesFn != nil {
+		return m.scrapeFeedChangesFn(ctx, feed, opts, isKnown)
+	}
+	return nil, false, nil
+}
+
+// feedChanges stubs ScrapeFeedChanges with a changed feed of the given entries, leaving out the ones isKnown reports.
+func feedChanges(entries ...*domain.Recipe) func(context.Context, *domain.Feed, krip.FeedOptions, func(string) bool) ([]*domain.Recipe, bool, error) {
+	return func(_ context.Context, _ *domain.Feed, _ krip.FeedOptions, isKnown func(string) bool) ([]*domain.Recipe, bool, error) {
+		var recipes []*domain.Recipe
+		for _, entry := range entries {
+			if entry.SourceUrl != nil && !isKnown(*entry.SourceUrl) {
+				recipes = append(recipes, entry)
+			}
+		}
+		return recipes, false, nil
+	}
+}
 
 type stubFeedRepo struct {
 	domain.FeedRepository
 
-	listActiveFn       func() ([]domain.Feed, error)
+	listDueFn          func(time.Time) ([]domain.Feed, error)
 	updateFn           func(*domain.Feed) error
 	byUrlFn            func(string) (*domain.Feed, error)
 	byIDForHouseholdFn func(uuid.UUID, uuid.UUID) (*domain.Feed, error)
 	addFeedFn          func(uuid.UUID, *domain.Feed) error
 	createFn           func(*domain.Feed) error
+	setStreamFlagFn    func(uuid.UUID, uuid.UUID, uuid.UUID, domain.StreamFlag, bool) error
+	markFeedSeenFn     func(uuid.UUID, uuid.UUID, uuid.UUID) error
+	setFilterFn        func(uuid.UUID, uuid.UUID, *domain.FeedFilter) error
+	searchFn           func(uuid.UUID, uuid.UUID, types.SearchOptions) ([]domain.Feed, int64, error)
+}
+
+func (s *stubFeedRepo) Search(userID, householdID uuid.UUID, opts types.SearchOptions) ([]domain.Feed, int64, error) {
+	if s.searchFn != nil {
+		return s.searchFn(userID, householdID, opts)
+	}
+	return nil, 0, nil
+}
+
+func (s *stubFeedRepo) SetStreamFlag(userID, householdID, recipeID uuid.UUID, flag domain.StreamFlag, value bool) error {
+	if s.setStreamFlagFn != nil {
+		return s.setStreamFlagFn(userID, householdID, recipeID, flag, value)
+	}
+	return nil
+}
+
+func (s *stubFeedRepo) MarkFeedSeen(userID, householdID, feedID uuid.UUID) error {
+	if s.markFeedSeenFn != nil {
+		return s.markFeedSeenFn(userID, householdID, feedID)
+	}
+	return nil
+}
+
+func (s *stubFeedRepo) SetFilter(householdID, feedID uuid.UUID, filter *domain.FeedFilter) error {
+	if s.setFilterFn != nil {
+		return s.setFilterFn(householdID, feedID, filter)
+	}
+	return nil
 }
 
-func (s *stubFeedRepo) ListActive() ([]domain.Feed, error) {
-	if s.listActiveFn != nil {
-		return s.listActiveFn()
+func (s *stubFeedRepo) ListDue(now time.Time) ([]domain.Feed, error) {
+	if s.listDueFn != nil {
+		return s.listDueFn(now)
 	}
 	return nil, nil
 }
@@ -437,6 +574,14 @@ type stubFeedService struct {
 	domain.FeedService
 
 	subscribeFn func(context.Context, uuid.UUID, string, *domain.Feed) (*domain.Feed, error)
+	searchFn    func(uuid.UUID, uuid.UUID, types.SearchOptions) ([]domain.Feed, int64, error)
+}
+
+func (s *stubFeedService) Search(userID, householdID uuid.UUID, opts types.SearchOptions) ([]domain.Feed, int64, error) {
+	if s.searchFn != nil {
+		return s.searchFn(userID, householdID, opts)
+	}
+	return nil, 0, nil
 }
 
 func (s *stubFeedService) Subscribe(ctx context.Context, householdID uuid.UUID, url string, scraped *domain.Feed) (*domain.Feed, error) {
@@ -459,3 +604,161 @@ func (s *stubTaxonomyService) FindOrCreate(t *domain.Taxonomy) error {
 }
 
 func ptr[T any](v T) *T { return &v }
+
+// stubIngredientParser leaves every ingredient unparsed unless parseFn is set.
+type stubIngredientParser struct {
+	parseFn func(string) kapusta.Ingredient
+}
+
+func (s stubIngredientParser) ParseIngredient(text string, _ kapusta.IngredientOptions) (kapusta.Ingredient, error) {
+	if s.parseFn != nil {
+		return s.parseFn(text), nil
+	}
+	return kapusta.Ingredient{}, nil
+}
+
+type stubTrashRepo struct {
+	domain.TrashRepository
+
+	byIDFn    func(domain.TrashItemType, uuid.UUID, uuid.UUID) (*domain.TrashItem, error)
+	expiredFn func(time.Time, []uuid.UUID, int) ([]domain.TrashItem, error)
+	restoreFn func(domain.TrashItemType, uuid.UUID, uuid.UUID) error
+	purgeFn   func(domain.TrashItemType, uuid.UUID, uuid.UUID) error
+}
+
+func (s *stubTrashRepo) ByID(t domain.TrashItemType, id, hid uuid.UUID) (*domain.TrashItem, error) {
+	return s.byIDFn(t, id, hid)
+}
+func (s *stubTrashRepo) Expired(before time.Time, exclude []uuid.UUID, limit int) ([]domain.TrashItem, error) {
+	return s.expiredFn(before, exclude, limit)
+}
+func (s *stubTrashRepo) Restore(t domain.TrashItemType, id, hid uuid.UUID) error {
+	return s.restoreFn(t, id, hid)
+}
+func (s *stubTrashRepo) Purge(t domain.TrashItemType, id, hid uuid.UUID) error {
+	return s.purgeFn(t, id, hid)
+}
+
+type stubMealPlanRepo struct {
+	domain.MealPlanRepository
+
+	byIDWithRecipesFn func(uuid.UUID) (*domain.MealPlan, error)
+	createFn          func(*domain.MealPlan) error
+	updateFn          func(*domain.MealPlan) error
+}
+
+func (s *stubMealPlanRepo) ByIdWithRecipes(id uuid.UUID) (*domain.MealPlan, error) {
+	return s.byIDWithRecipesFn(id)
+}
+func (s *stubMealPlanRepo) Create(mp *domain.MealPlan) error { return s.createFn(mp) }
+func (s *stubMealPlanRepo) Update(mp *domain.MealPlan) error { return s.updateFn(mp) }
+
+type stubCollectionRepo struct {
+	domain.CollectionRepository
+
+	byIdWithRecipesFn func(uuid.UUID) (*domain.Collection, error)
+}
+
+func (s *stubCollectionRepo) ByIdWithRecipes(id uuid.UUID) (*domain.Collection, error) {
+	return s.byIdWithRecipesFn(id)
+}
+
+type stubCollectionService struct {
+	domain.CollectionService
+
+	byIDFn        func(uuid.UUID, uuid.UUID) (*domain.Collection, error)
+	listRecipesFn func(uuid.UUID, uuid.UUID, uuid.UUID, types.SearchOptions) ([]domain.Recipe, int64, error)
+}
+
+func (s *stubCollectionService) ByID(id, hid uuid.UUID) (*domain.Collection, error) {
+	return s.byIDFn(id, hid)
+}
+func (s *stubCollectionService) ListRecipes(id, uid, hid uuid.UUID, opts types.SearchOptions) ([]domain.Recipe, int64, error) {
+	return s.listRecipesFn(id, uid, hid, opts)
+}
+
+type stubCookbookRepo struct {
+	domain.CookbookRepository
+
+	byIDFn   func(uuid.UUID) (*domain.Cookbook, error)
+	previous []domain.Cookbook
+	updated  []domain.Cookbook
+	deleted  []uuid.UUID
+}
+
+func (s *stubCookbookRepo) ByID(id uuid.UUID) (*domain.Cookbook, error) { return s.byIDFn(id) }
+func (s *stubCookbookRepo) Create(cookbook *domain.Cookbook) error {
+	cookbook.ID = uuid.New()
+	return nil
+}
+func (s *stubCookbookRepo) Update(cookbook *domain.Cookbook) error {
+	s.updated = append(s.updated, *cookbook)
+	return nil
+}
+func (s *stubCookbookRepo) ListByCollection(uuid.UUID, domain.CookbookFormat) ([]domain.Cookbook, error) {
+	return s.previous, nil
+}
+func (s *stubCookbookRepo) Delete(id uuid.UUID) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+type stubImportJobRepo struct {
+	domain.ImportJobRepository
+
+	byIDFn         func(uuid.UUID) (*domain.ImportJob, error)
+	updateFn       func(*domain.ImportJob) error
+	createBatchErr error
+	created        []domain.ImportJob
+	updated        []domain.ImportJob
+}
+
+func (s *stubImportJobRepo) ByID(id uuid.UUID) (*domain.ImportJob, error) { return s.byIDFn(id) }
+func (s *stubImportJobRepo) Create(job *domain.ImportJob) error {
+	job.ID = uuid.New()
+	s.created = append(s.created, *job)
+	return nil
+}
+func (s *stubImportJobRepo) CreateBatch(jobs []*domain.ImportJob) error {
+	if s.createBatchErr != nil {
+		return s.createBatchErr
+	}
+	for _, job := range jobs {
+		s.created = append(s.created, *job)
+	}
+	return nil
+}
+func (s *stubImportJobRepo) ListByBulk(bulkID uuid.UUID) ([]domain.ImportJob, error) {
+	var jobs []domain.ImportJob
+	for _, job := range s.created {
+		if job.BulkID != nil && *job.BulkID == bulkID {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+func (s *stubImportJobRepo) Update(job *domain.ImportJob) error {
+	s.updated = append(s.updated, *job)
+	if s.updateFn != nil {
+		return s.updateFn(job)
+	}
+	return nil
+}
+
+type stubImportService struct {
+	domain.ImportService
+
+	detectAndImportFn func(context.Context, string, string, bool, bool, uuid.UUID, uuid.UUID) (*domain.ImportResult, error)
+	importHtmlFn      func(context.Context, []byte, string, string, bool, bool, uuid.UUID, uuid.UUID) (*domain.ImportResult, error)
+	importFileFn      func(context.Context, []byte, *domain.FileImportReport, func(*domain.FileImportReport) error, uuid.UUID, uuid.UUID) (*domain.FileImportReport, error)
+}
+
+func (s *stubImportService) DetectAndImport(ctx context.Context, url string, requestedType string, forceUpdate bool, dryRun bool, userID uuid.UUID, householdID uuid.UUID) (*domain.ImportResult, error) {
+	return s.detectAndImportFn(ctx, url, requestedType, forceUpdate, dryRun, userID, householdID)
+}
+func (s *stubImportService) ImportFile(ctx context.Context, data []byte, report *domain.FileImportReport, progress func(*domain.FileImportReport) error, userID uuid.UUID, householdID uuid.UUID) (*domain.FileImportReport, error) {
+	return s.importFileFn(ctx, data, report, progress, userID, householdID)
+}
+func (s *stubImportService) ImportHtml(ctx context.Context, html []byte, url string, requestedType string, forceUpdate bool, dryRun bool, userID uuid.UUID, householdID uuid.UUID) (*domain.ImportResult, error) {
+	return s.importHtmlFn(ctx, html, url, requestedType, forceUpdate, dryRun, userID, householdID)
+}
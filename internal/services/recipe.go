@@ -2,6 +2,8 @@ package services
 
 import (
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/gofiber/fiber/v3/log"
 	"github.com/google/uuid"
@@ -51,9 +53,25 @@ func (s *recipeService) ByIDPreload(id, userID, householdID uuid.UUID, preload t
 	if recipe.HouseholdID != nil && *recipe.HouseholdID != householdID {
 		return recipe, sentinels.ErrForbidden
 	}
+	s.localizeForUser(userID, recipe)
 	return recipe, nil
 }
 
+// localizeForUser converts the temperatures of the loaded instructions to the unit system of the user.
+func (s *recipeService) localizeForUser(userID uuid.UUID, recipes ...*domain.Recipe) {
+	if userID == uuid.Nil || !slices.ContainsFunc(recipes, func(r *domain.Recipe) bool { return len(r.Instructions) > 0 }) {
+		return
+	}
+	user, err := s.userRepo.ByID(userID)
+	if err != nil {
+		log.Warnw("failed to load unit system, keeping temperatures as written", "user_id", userID, "error", err.Error())
+		return
+	}
+	for _, recipe := range recipes {
+		localizeTemperatures(recipe.Instructions, user.UnitSystem.TemperatureUnit())
+	}
+}
+
 func (s *recipeService) ByUrl(url string, householdID uuid.UUID) (*domain.Recipe, error) {
 	recipe, err := s.repo.ByUrl(utils.NormalizeURL(url))
 	if err != nil {
@@ -79,10 +97,27 @@ func (s *recipeService) Search(userID uuid.UUID, householdID uuid.UUID, opts dom
 	if err != nil {
 		return nil, 0, fmt.Errorf("search: %w", err)
 	}
+	ptrs := make([]*domain.Recipe, len(recipes))
+	for i := range recipes {
+		ptrs[i] = &recipes[i]
+	}
+	s.localizeForUser(userID, ptrs...)
 	return recipes, total, nil
 }
 
 func (s *recipeService) Create(recipe *domain.Recipe, userID uuid.UUID, householdID uuid.UUID) error {
+	for _, ing := range recipe.Ingredients {
+		if ing.SubRecipeID != nil {
+			if err := s.checkSubRecipe(recipe.ID, *ing.SubRecipeID, householdID); err != nil {
+				return fmt.Errorf("create (check sub-recipe): %w", err)
+			}
+		}
+	}
+
+	for _, inst := range recipe.Instructions {
+		parseInstructionMetadata(inst)
+	}
+
 	recipe.HouseholdID = &householdID
 	recipe.UserID = &userID
 	if err := s.repo.Create(recipe); err != nil {
@@ -108,6 +143,40 @@ func (s *recipeService) SetFeedID(recipeID, feedID uuid.UUID) error {
 	return nil
 }
 
+func (s *recipeService) ListStale(updatedBefore time.Time, exclude []uuid.UUID, limit int) ([]domain.Recipe, error) {
+	recipes, err := s.repo.ListStale(updatedBefore, exclude, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list stale: %w", err)
+	}
+	return recipes, nil
+}
+
+func (s *recipeService) MarkRefreshed(id uuid.UUID) error {
+	if err := s.repo.MarkRefreshed(id); err != nil {
+		return fmt.Errorf("mark refreshed: %w", err)
+	}
+	return nil
+}
+
+func (s *recipeService) Similar(recipeID uuid.UUID, householdID uuid.UUID, limit int) ([]domain.SimilarRecipe, error) {
+	if _, err := s.ByID(recipeID, householdID); err != nil {
+		return nil, fmt.Errorf("similar (fetch recipe): %w", err)
+	}
+	similar, err := s.repo.Similar(recipeID, householdID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("similar: %w", err)
+	}
+	return similar, nil
+}
+
+func (s *recipeService) TasteProfile(householdID uuid.UUID) (*domain.TasteProfile, error) {
+	profile, err := s.repo.TasteProfile(householdID)
+	if err != nil {
+		return nil, fmt.Errorf("taste profile: %w", err)
+	}
+	return profile, nil
+}
+
 func (s *recipeService) Update(recipe *domain.Recipe, userID uuid.UUID, householdID uuid.UUID) error {
 	existing, err := s.repo.ByID(recipe.ID)
 	if err != nil {
@@ -116,7 +185,11 @@ func (s *recipeService) Update(recipe *domain.Recipe, userID uuid.UUID, househol
 
 	// Copy-on-write: if it doesn't belong to household yet, clone it into the household first
 	if existing.HouseholdID == nil {
-		cloned, err := s.cloneToHousehold(existing, userID, householdID)
+		global, err := s.repo.ByIDPreload(recipe.ID, userID, householdID, cloneRecipePreload)
+		if err != nil {
+			return fmt.Errorf("update (fetch global): %w", err)
+		}
+		cloned, err := s.cloneToHousehold(global, userID, householdID)
 		if err != nil {
 			return fmt.Errorf("update (clone to household): %w", err)
 		}
@@ -133,7 +206,10 @@ func (s *recipeService) Update(recipe *domain.Recipe, userID uuid.UUID, househol
 	return nil
 }
 
-// cloneToHousehold clones a global recipe into the given household. Make sure to preload all relevant associations
+// cloneRecipePreload loads the associations cloneToHousehold copies, instructions come with their linked ingredients.
+var cloneRecipePreload = types.Preload("ingredients", "instructions", "equipment", "taxonomies", "nutrition", "publisher")
+
+// cloneToHousehold clones a global recipe into the given household. Load it with cloneRecipePreload first.
 func (s *recipeService) cloneToHousehold(global *domain.Recipe, userID, householdID uuid.UUID) (*domain.Recipe, error) {
 	clone := *global
 	clone.ID = uuid.Nil // BeforeCreate hook will assign a new UUID
@@ -146,16 +222,26 @@ func (s *recipeService) cloneToHousehold(global *domain.Recipe, userID, househol
 	clone.Taxonomies = global.Taxonomies // keep taxonomy associations (many2many OK to share)
 	clone.Publisher = global.Publisher
 	clone.Feed = nil
+	clone.Parent = nil
+	clone.SavedBy = nil
+	if global.Nutrition != nil {
+		nutrition := *global.Nutrition
+		nutrition.RecipeID = uuid.Nil
+		clone.Nutrition = &nutrition
+	}
 
 	clone.Ingredients = make([]*domain.RecipeIngredient, len(global.Ingredients))
+	clonedIngredients := make(map[uuid.UUID]*domain.RecipeIngredient, len(global.Ingredients))
 	for i, ing := range global.Ingredients {
 		copy_ := *ing
 		copy_.ID = uuid.Nil
 		copy_.RecipeID = uuid.Nil // will be set by GORM after Create
 		copy_.Recipe = nil
+		copy_.SubRecipe = nil
 		copy_.Food = ing.Food
 		copy_.Unit = ing.Unit
 		clone.Ingredients[i] = &copy_
+		clonedIngredients[ing.ID] = &copy_
 	}
 	clone.Instructions = make([]*domain.RecipeInstruction, len(global.Instructions))
 	for i, ins := range global.Instructions {
@@ -165,6 +251,14 @@ func (s *recipeService) cloneToHousehold(global *domain.Recipe, userID, househol
 		copy_.Recipe = nil
 		copy_.ParentID = nil // instruction parent is within same recipe
 		copy_.Parent = nil
+		copy_.SubRecipe = nil
+		// point links at the cloned ingredients, GORM creates the ingredients first and then the junction rows
+		copy_.Ingredients = nil
+		for _, ing := range ins.Ingredients {
+			if cloned, ok := clonedIngredients[ing.ID]; ok {
+				copy_.Ingredients = append(copy_.Ingredients, cloned)
+			}
+		}
 		clone.Instructions[i] = &copy_
 	}
 
@@ -198,27 +292,13 @@ func (s *recipeService) Delete(id uuid.UUID, householdID uuid.UUID) error {
 		return sentinels.ErrForbidden
 	}
 
-	if existing.ParentID == nil {
-		s.deleteImages(existing.Images)
-	}
-
+	// soft delete: the recipe moves to the household trash, images are removed when it is purged
 	if err := s.repo.Delete(id); err != nil {
 		return fmt.Errorf("delete (persist): %w", err)
 	}
 	return nil
 }
 
-func (s *recipeService) deleteImages(images []*domain.Image) {
-	for _, image := range images {
-		if image.ID == uuid.Nil {
-			continue
-		}
-		if err := s.imageService.Delete(image.ID); err != nil {
-			log.Warnw("failed to delete image", "image_id", image.ID, "error", err.Error())
-		}
-	}
-}
-
 func (s *recipeService) UserSave(recipeID uuid.UUID, userID uuid.UUID, householdID uuid.UUID) error {
 	if err := s.repo.UserSave(recipeID, userID, householdID); err != nil {
 		return fmt.Errorf("user save: %w", err)
@@ -237,6 +317,11 @@ func (s *recipeService) CreateIngredient(ingredient *domain.RecipeIngredient, ho
 	if _, err := s.ByID(ingredient.RecipeID, householdID); err != nil {
 		return fmt.Errorf("create ingredient (auth check): %w", err)
 	}
+	if ingredient.SubRecipeID != nil {
+		if err := s.checkSubRecipe(ingredient.RecipeID, *ingredient.SubRecipeID, householdID); err != nil {
+			return fmt.Errorf("create ingredient (check sub-recipe): %w", err)
+		}
+	}
 	if err := s.repo.CreateIngredient(ingredient); err != nil {
 		return fmt.Errorf("create ingredient (persist): %w", err)
 	}
@@ -247,6 +332,11 @@ func (s *recipeService) UpdateIngredient(ingredient *domain.RecipeIngredient, ho
 	if _, err := s.ByID(ingredient.RecipeID, householdID); err != nil {
 		return fmt.Errorf("update ingredient (auth check): %w", err)
 	}
+	if ingredient.SubRecipeID != nil {
+		if err := s.checkSubRecipe(ingredient.RecipeID, *ingredient.SubRecipeID, householdID); err != nil {
+			return fmt.Errorf("update ingredient (check sub-recipe): %w", err)
+		}
+	}
 	if err := s.repo.UpdateIngredient(ingredient); err != nil {
 		return fmt.Errorf("update ingredient (persist): %w", err)
 	}
@@ -263,6 +353,110 @@ func (s *recipeService) DeleteIngredient(id uuid.UUID, recipeID uuid.UUID, house
 	return nil
 }
 
+// checkSubRecipe verifies that recipeID may reference subRecipeID: the sub-recipe must be readable
+// by the household and must not, directly or through its own sub-recipes, include recipeID.
+func (s *recipeService) checkSubRecipe(recipeID, subRecipeID, householdID uuid.UUID) error {
+	if subRecipeID == recipeID {
+		return sentinels.BadRequest("a recipe cannot be its own sub-recipe")
+	}
+	if _, err := s.ByID(subRecipeID, householdID); err != nil {
+		return fmt.Errorf("fetch sub-recipe: %w", err)
+	}
+
+	visited := map[uuid.UUID]bool{subRecipeID: true}
+	frontier := []uuid.UUID{subRecipeID}
+	for depth := 1; len(frontier) > 0; depth++ {
+		if depth >= domain.MaxSubRecipeDepth {
+			return sentinels.BadRequest(fmt.Sprintf("sub-recipes cannot be nested more than %d levels deep", domain.MaxSubRecipeDepth))
+		}
+		edges, err := s.repo.SubRecipeIDs(frontier)
+		if err != nil {
+			return fmt.Errorf("fetch nested sub-recipes: %w", err)
+		}
+		var next []uuid.UUID
+		for _, children := range edges {
+			for _, child := range children {
+				if child == recipeID {
+					return sentinels.BadRequest("sub-recipe would create a cycle")
+				}
+				if !visited[child] {
+					visited[child] = true
+					next = append(next, child)
+				}
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
+
+func (s *recipeService) ExpandIngredients(recipeID uuid.UUID, householdID uuid.UUID, scale domain.RecipeScale) ([]*domain.RecipeIngredient, error) {
+	recipe, err := s.ByIDPreload(recipeID, uuid.Nil, householdID, types.Preload("subrecipes"))
+	if err != nil {
+		return nil, fmt.Errorf("expand ingredients (fetch recipe): %w", err)
+	}
+	return expandIngredients(recipe, scaleFactor(recipe, scale)), nil
+}
+
+// scaleFactor resolves the multiplier applied to the ingredient amounts of a recipe.
+func scaleFactor(recipe *domain.Recipe, scale domain.RecipeScale) float64 {
+	if scale.Servings > 0 && recipe.Yield != nil && *recipe.Yield > 0 {
+		return float64(scale.Servings) / *recipe.Yield
+	}
+	if scale.Factor > 0 {
+		return scale.Factor
+	}
+	return 1
+}
+
+// subRecipeBatches returns how many batches of its sub-recipe an ingredient stands for. Amounts in the unit of
+// the sub-recipe yield (e.g. "200 ml" of a sauce that yields "500 ml") are related to the yield, other amounts
+// with a unit cannot be, so count as one batch.
+func subRecipeBatches(ing *domain.RecipeIngredient) float64 {
+	if ing.Amount == nil {
+		return 1
+	}
+	if ing.UnitID == nil {
+		return *ing.Amount
+	}
+	if ing.SubRecipe != nil && ing.SubRecipe.Yield != nil && *ing.SubRecipe.Yield > 0 && yieldMatchesUnit(ing.SubRecipe, ing.Unit) {
+		return *ing.Amount / *ing.SubRecipe.Yield
+	}
+	return 1
+}
+
+// expandIngredients flattens the preloaded ingredient tree of a recipe into scaled copies, replacing
+// every sub-recipe ingredient by the ingredients of the sub-recipe. Nested ingredients without a
+// category are grouped under the sub-recipe name. Sub-recipes that were not loaded are kept as-is.
+func expandIngredients(recipe *domain.Recipe, factor float64) []*domain.RecipeIngredient {
+	var result []*domain.RecipeIngredient
+	path := make(map[uuid.UUID]bool)
+
+	var walk func(r *domain.Recipe, factor float64, category *string)
+	walk = func(r *domain.Recipe, factor float64, category *string) {
+		path[r.ID] = true
+		defer delete(path, r.ID)
+
+		for _, ing := range r.Ingredients {
+			if ing.SubRecipe != nil && !path[ing.SubRecipe.ID] {
+				subCategory := ing.Category
+				if subCategory == nil {
+					subCategory = ing.SubRecipe.Name
+				}
+				walk(ing.SubRecipe, factor*subRecipeBatches(ing), subCategory)
+				continue
+			}
+			scaled := ing.Scaled(factor)
+			if scaled.Category == nil {
+				scaled.Category = category
+			}
+			result = append(result, scaled)
+		}
+	}
+	walk(recipe, factor, nil)
+	return result
+}
+
 func (s *recipeService) AddEquipment(recipeID uuid.UUID, equipmentID uuid.UUID, householdID uuid.UUID) error {
 	if _, err := s.ByID(recipeID, householdID); err != nil {
 		return fmt.Errorf("add equipment (auth check): %w", err)
@@ -287,6 +481,12 @@ func (s *recipeService) CreateInstruction(instruction *domain.RecipeInstruction,
 	if _, err := s.ByID(instruction.RecipeID, householdID); err != nil {
 		return fmt.Errorf("create instruction (auth check): %w", err)
 	}
+	if instruction.SubRecipeID != nil {
+		if err := s.checkSubRecipe(instruction.RecipeID, *instruction.SubRecipeID, householdID); err != nil {
+			return fmt.Errorf("create instruction (check sub-recipe): %w", err)
+		}
+	}
+	parseInstructionMetadata(instruction)
 	if err := s.repo.CreateInstruction(instruction); err != nil {
 		return fmt.Errorf("create instruction (persist): %w", err)
 	}
@@ -297,6 +497,14 @@ func (s *recipeService) UpdateInstruction(instruction *domain.RecipeInstruction,
 	if _, err := s.ByID(instruction.RecipeID, householdID); err != nil {
 		return fmt.Errorf("update instruction (auth check): %w", err)
 	}
+	if instruction.SubRecipeID != nil {
+		if err := s.checkSubRecipe(instruction.RecipeID, *instruction.SubRecipeID, householdID); err != nil {
+			return fmt.Errorf("update instruction (check sub-recipe): %w", err)
+		}
+	}
+	if instruction.Text != "" {
+		parseInstructionMetadata(instruction)
+	}
 	if err := s.repo.UpdateInstruction(instruction); err != nil {
 		return fmt.Errorf("update instruction (persist): %w", err)
 	}
@@ -313,14 +521,35 @@ func (s *recipeService) DeleteInstruction(id uuid.UUID, recipeID uuid.UUID, hous
 	return nil
 }
 
+func (s *recipeService) LinkInstructionIngredient(recipeID, instructionID, ingredientID uuid.UUID, householdID uuid.UUID) error {
+	if _, err := s.ByID(recipeID, householdID); err != nil {
+		return fmt.Errorf("link instruction ingredient (auth check): %w", err)
+	}
+	if err := s.repo.LinkInstructionIngredient(recipeID, instructionID, ingredientID); err != nil {
+		return fmt.Errorf("link instruction ingredient (persist): %w", err)
+	}
+	return nil
+}
+
+func (s *recipeService) UnlinkInstructionIngredient(recipeID, instructionID, ingredientID uuid.UUID, householdID uuid.UUID) error {
+	if _, err := s.ByID(recipeID, householdID); err != nil {
+		return fmt.Errorf("unlink instruction ingredient (auth check): %w", err)
+	}
+	if err := s.repo.UnlinkInstructionIngredient(recipeID, instructionID, ingredientID); err != nil {
+		return fmt.Errorf("unlink instruction ingredient (persist): %w", err)
+	}
+	return nil
+}
+
 func (s *recipeService) EstimatePrice(recipeID uuid.UUID, householdID uuid.UUID) (*domain.RecipeCostEstimate, error) {
-	recipe, err := s.ByIDPreload(recipeID, uuid.Nil, householdID, types.Preload("ingredients"))
+	recipe, err := s.ByIDPreload(recipeID, uuid.Nil, householdID, types.Preload("ingredients", "subrecipes"))
 	if err != nil {
 		return nil, fmt.Errorf("estimate price (fetch recipe): %w", err)
 	}
+	ingredients := expandIngredients(recipe, 1)
 
-	foodIDs := make([]uuid.UUID, 0, len(recipe.Ingredients))
-	for _, ing := range recipe.Ingredients {
+	foodIDs := make([]uuid.UUID, 0, len(ingredients))
+	for _, ing := range ingredients {
 		if ing.FoodID != nil {
 			foodIDs = append(foodIDs, *ing.FoodID)
 		}
@@ -345,10 +574,10 @@ func (s *recipeService) EstimatePrice(recipeID uuid.UUID, householdID uuid.UUID)
 	}
 
 	estimate := &domain.RecipeCostEstimate{
-		Items: make([]*domain.RecipeIngredientCost, 0, len(recipe.Ingredients)),
+		Items: make([]*domain.RecipeIngredientCost, 0, len(ingredients)),
 	}
 
-	for _, ing := range recipe.Ingredients {
+	for _, ing := range ingredients {
 		ingCost := &domain.RecipeIngredientCost{
 			IngredientID: ing.ID,
 		}
@@ -390,8 +619,8 @@ func (s *recipeService) EstimatePrice(recipeID uuid.UUID, householdID uuid.UUID)
 		estimate.Items = append(estimate.Items, ingCost)
 	}
 
-	if recipe.Yield != nil && *recipe.Yield > 0 && estimate.Total > 0 {
-		estimate.PerServing = new(estimate.Total / float64(*recipe.Yield))
+	if recipe.YieldsServings() && estimate.Total > 0 {
+		estimate.PerServing = new(estimate.Total / *recipe.Yield)
 	}
 
 	return estimate, nil
@@ -0,0 +1,673 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/types"
+	"borscht.app/smetana/internal/utils"
+)
+
+// Cooklang (https://cooklang.org/docs/spec/) keeps a recipe as plain-text steps with inline markup:
+// @ingredients{quantity%unit}(note), #cookware{} and ~timers{quantity%unit}, "= Section" lines, "> notes",
+// and metadata as YAML front matter or ">> key: value" lines. A backslash before a marker keeps it as text.
+
+var (
+	cooklangBlockCommentRe = regexp.MustCompile(`(?s)\[-.*?-\]`)
+	cooklangWordRe         = regexp.MustCompile(`^[\p{L}\p{N}_]+(?:-[\p{L}\p{N}_]+)*`)
+	// cooklangEscaper escapes the markers in the plain text of a step, a backslash makes the next of them literal
+	cooklangEscaper = strings.NewReplacer(`\`, `\\`, "@", `\@`, "#", `\#`, "~", `\~`)
+)
+
+// cooklangEscaped are the characters a backslash escapes.
+const cooklangEscaped = `@#~\`
+
+// cooklangMention is an ingredient, cookware or timer written in a step.
+type cooklangMention struct {
+	name     string
+	quantity string
+	unit     string
+	note     string
+}
+
+// parseCooklang reads a Cooklang recipe. Ingredients are left for the ingest to resolve to canonical foods and units,
+// cookware becomes equipment and timers are kept on the instructions that set them.
+func parseCooklang(text string) *domain.Recipe {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = cooklangBlockCommentRe.ReplaceAllString(text, "")
+
+	recipe := &domain.Recipe{}
+	meta := map[string]any{}
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if front, body, ok := strings.Cut(rest, "\n---\n"); ok {
+			if err := yaml.Unmarshal([]byte(front), &meta); err == nil {
+				text = body
+			}
+		}
+	}
+
+	p := &cooklangParser{recipe: recipe, ingredients: map[string]*domain.RecipeIngredient{}, equipment: map[string]bool{}}
+	var notes, paragraph []string
+	for _, line := range strings.Split(text, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 && (i == 0 || line[i-1] == ' ') {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, ">>"):
+			if key, value, ok := strings.Cut(strings.TrimPrefix(line, ">>"), ":"); ok {
+				meta[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		case strings.HasPrefix(line, ">"):
+			notes = append(notes, strings.TrimSpace(strings.TrimPrefix(line, ">")))
+		case strings.HasPrefix(line, "="):
+			p.step(strings.Join(paragraph, " "))
+			paragraph = nil
+			p.startSection(strings.TrimSpace(strings.Trim(line, "=")))
+		case line == "":
+			p.step(strings.Join(paragraph, " "))
+			paragraph = nil
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	p.step(strings.Join(paragraph, " "))
+
+	if len(notes) > 0 {
+		recipe.Text = new(strings.Join(notes, "\n"))
+	}
+	applyCooklangMetadata(recipe, meta)
+	return recipe
+}
+
+type cooklangParser struct {
+	recipe      *domain.Recipe
+	section     *domain.RecipeInstruction
+	ingredients map[string]*domain.RecipeIngredient
+	equipment   map[string]bool
+}
+
+func (p *cooklangParser) startSection(name string) {
+	p.section = nil
+	if name != "" {
+		p.section = &domain.RecipeInstruction{Title: &name}
+		p.recipe.Instructions = append(p.recipe.Instructions, p.section)
+	}
+}
+
+// step resolves the markup of a paragraph into readable text, recording what it mentions.
+func (p *cooklangParser) step(paragraph string) {
+	if strings.TrimSpace(paragraph) == "" {
+		return
+	}
+	inst := &domain.RecipeInstruction{Parent: p.section}
+
+	var b strings.Builder
+	for i := 0; i < len(paragraph); {
+		marker := paragraph[i]
+		if marker == '\\' && i+1 < len(paragraph) && strings.IndexByte(cooklangEscaped, paragraph[i+1]) >= 0 {
+			b.WriteByte(paragraph[i+1])
+			i += 2
+			continue
+		}
+		if marker != '@' && marker != '#' && marker != '~' {
+			b.WriteByte(marker)
+			i++
+			continue
+		}
+		mention, n, ok := scanCooklangMention(paragraph[i+1:], marker)
+		if !ok {
+			b.WriteByte(marker)
+			i++
+			continue
+		}
+		i += 1 + n
+
+		switch marker {
+		case '@':
+			ing := p.ingredient(mention)
+			if !slices.Contains(inst.Ingredients, ing) {
+				inst.Ingredients = append(inst.Ingredients, ing)
+			}
+			b.WriteString(mention.name)
+		case '#':
+			if key := strings.ToLower(mention.name); !p.equipment[key] {
+				p.equipment[key] = true
+				p.recipe.Equipment = append(p.recipe.Equipment, &domain.Equipment{Name: mention.name, Slug: utils.CreateTag(mention.name)})
+			}
+			b.WriteString(mention.name)
+		case '~':
+			timerText := strings.TrimSpace(mention.quantity + " " + mention.unit)
+			if timerText == "" {
+				timerText = mention.name
+			}
+			if timer, ok := cooklangTimer(mention, timerText); ok {
+				inst.Timers = append(inst.Timers, timer)
+			}
+			b.WriteString(timerText)
+		}
+	}
+	inst.Text = strings.Join(strings.Fields(b.String()), " ")
+	p.recipe.Instructions = append(p.recipe.Instructions, inst)
+}
+
+// ingredient adds a mentioned ingredient to the recipe. Mentions of the same ingredient and unit are summed up,
+// like Cooklang tools list them, a mention without a quantity refers to the ingredient listed before.
+func (p *cooklangParser) ingredient(m cooklangMention) *domain.RecipeIngredient {
+	amount, maxAmount, hasAmount := parseCooklangQuantity(m.quantity)
+	key := strings.ToLower(m.name) + "%" + strings.ToLower(m.unit)
+	if existing, ok := p.ingredients[key]; ok {
+		switch {
+		case m.quantity == "":
+			return existing
+		case hasAmount && maxAmount == nil && existing.Amount != nil && existing.MaxAmount == nil:
+			existing.Amount = new(*existing.Amount + amount)
+			existing.RawText = ingredientText(existing)
+			return existing
+		}
+	}
+
+	ing := &domain.RecipeIngredient{
+		Name: new(m.name),
+		Food: &domain.Food{Name: m.name, Slug: utils.CreateTag(m.name)},
+	}
+	if hasAmount {
+		ing.Amount = &amount
+		ing.MaxAmount = maxAmount
+	}
+	if m.unit != "" {
+		ing.Unit = &domain.Unit{Name: m.unit}
+	}
+	if m.note != "" {
+		ing.Description = new(m.note)
+	}
+	if p.section != nil {
+		ing.Category = p.section.Title
+	}
+	ing.RawText = strings.Join(strings.Fields(m.quantity+" "+m.unit+" "+m.name), " ")
+	if m.note != "" {
+		ing.RawText += ", " + m.note
+	}
+
+	if _, ok := p.ingredients[key]; !ok {
+		p.ingredients[key] = ing
+	}
+	p.recipe.Ingredients = append(p.recipe.Ingredients, ing)
+	return ing
+}
+
+// scanCooklangMention reads what follows a marker: a single word, or any words up to {quantity%unit},
+// then an ingredient note in parentheses. It returns how many bytes were consumed.
+func scanCooklangMention(s string, marker byte) (cooklangMention, int, bool) {
+	var m cooklangMention
+	n := 0
+	if marker == '@' {
+		// modifiers: @@ reference, @& and @? optional, @- hidden, @+ new
+		for n < len(s) && strings.IndexByte("@&?-+", s[n]) >= 0 {
+			n++
+		}
+	}
+	rest := s[n:]
+
+	braced := false
+	if open := strings.IndexByte(rest, '{'); open >= 0 && !strings.ContainsAny(rest[:open], "@#~}\n,;:!?()") {
+		end := strings.IndexByte(rest[open:], '}')
+		name := strings.TrimSpace(rest[:open])
+		if end > 0 && (name != "" || marker == '~') {
+			braced = true
+			m.name = name
+			m.quantity, m.unit, _ = strings.Cut(rest[open+1:open+end], "%")
+			n += open + end + 1
+		}
+	}
+	if !braced {
+		word := cooklangWordRe.FindString(rest)
+		if word == "" {
+			return m, 0, false
+		}
+		m.name = word
+		n += len(word)
+	}
+
+	if marker == '@' && strings.HasPrefix(s[n:], "(") {
+		if end := strings.IndexByte(s[n:], ')'); end > 0 {
+			m.note = strings.TrimSpace(s[n+1 : n+end])
+			n += end + 1
+		}
+	}
+	if strings.Contains(m.name, "/") { // recipe reference such as @./sauces/Tomato sauce{}
+		m.name = path.Base(m.name)
+	}
+	m.quantity = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(m.quantity), "="))
+	m.unit = strings.TrimSpace(m.unit)
+	return m, n, true
+}
+
+// parseCooklangQuantity reads "2", "1.5", "1/2" and ranges such as "2-3".
+func parseCooklangQuantity(s string) (float64, *float64, bool) {
+	if s == "" {
+		return 0, nil, false
+	}
+	parse := func(v string) (float64, bool) {
+		v = strings.Replace(strings.TrimSpace(v), ",", ".", 1)
+		if num, den, ok := strings.Cut(v, "/"); ok {
+			n, err1 := strconv.ParseFloat(strings.TrimSpace(num), 64)
+			d, err2 := strconv.ParseFloat(strings.TrimSpace(den), 64)
+			return n / d, err1 == nil && err2 == nil && d != 0
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	if low, high, ok := strings.Cut(s, "-"); ok {
+		minAmount, ok1 := parse(low)
+		maxAmount, ok2 := parse(high)
+		if ok1 && ok2 && minAmount > 0 && maxAmount > minAmount {
+			return minAmount, &maxAmount, true
+		}
+		return 0, nil, false
+	}
+	amount, ok := parse(s)
+	return amount, nil, ok && amount > 0
+}
+
+func cooklangTimer(m cooklangMention, text string) (domain.InstructionTimer, bool) {
+	amount, maxAmount, ok := parseCooklangQuantity(m.quantity)
+	if !ok {
+		return domain.InstructionTimer{}, false
+	}
+	unit := durationUnit(m.unit)
+	timer := domain.InstructionTimer{Duration: types.Duration(amount * float64(unit)), Text: text}
+	if maxAmount != nil {
+		timer.MaxDuration = new(types.Duration(*maxAmount * float64(unit)))
+	}
+	return timer, true
+}
+
+// applyCooklangMetadata maps the canonical metadata keys and their common aliases onto the recipe.
+func applyCooklangMetadata(recipe *domain.Recipe, meta map[string]any) {
+	flat := map[string]any{}
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for key, value := range m {
+			key = strings.ToLower(strings.NewReplacer("_", " ", "-", " ").Replace(strings.TrimSpace(key)))
+			if nested, ok := value.(map[string]any); ok {
+				flatten(prefix+key+".", nested)
+			} else {
+				flat[prefix+key] = value
+			}
+		}
+	}
+	flatten("", meta)
+
+	duration := func(value any) *types.Duration {
+		if d, err := types.DurationFromISO8601(parseExportDuration(cooklangMetaString(value))); err == nil && d > 0 {
+			return &d
+		}
+		return nil
+	}
+	taxonomies := func(taxType string, value any) {
+		for _, label := range cooklangMetaList(value) {
+			recipe.Taxonomies = append(recipe.Taxonomies, &domain.Taxonomy{Type: taxType, Label: label, Slug: utils.CreateTag(label)})
+		}
+	}
+
+	for key, value := range flat {
+		s := cooklangMetaString(value)
+		if s == "" {
+			continue
+		}
+		switch key {
+		case "title":
+			recipe.Name = new(s)
+		case "description", "introduction":
+			recipe.Description = new(s)
+		case "servings", "serves", "yield":
+			applyYield(recipe, s)
+		case "source", "source.url", "url", "original":
+			if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+				recipe.SourceUrl = new(utils.NormalizeURL(s))
+			}
+		case "author", "source.author":
+			recipe.Author = &domain.Author{Name: s}
+		case "tags", "keywords":
+			taxonomies(domain.TaxonomyTypeKeyword, value)
+		case "course", "category":
+			taxonomies(domain.TaxonomyTypeCategory, value)
+		case "cuisine":
+			taxonomies(domain.TaxonomyTypeCuisine, value)
+		case "diet":
+			taxonomies(domain.TaxonomyTypeDiet, value)
+		case "difficulty":
+			recipe.Difficulty = new(s)
+		case "prep time", "time.prep":
+			recipe.PrepTime = duration(value)
+		case "cook time", "time.cook":
+			recipe.CookTime = duration(value)
+		case "time", "total time", "time required", "duration":
+			recipe.TotalTime = duration(value)
+		case "locale", "language", "lang":
+			if len(s) >= 2 {
+				recipe.Language = new(strings.ToLower(s[:2]))
+			}
+		}
+	}
+}
+
+func cooklangMetaString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		return strings.Join(cooklangMetaList(v), ", ")
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+// cooklangMetaList reads a YAML list or a comma separated string.
+func cooklangMetaList(value any) []string {
+	var items []string
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+				items = append(items, s)
+			}
+		}
+	default:
+		for _, item := range strings.Split(cooklangMetaString(v), ",") {
+			if s := strings.TrimSpace(item); s != "" {
+				items = append(items, s)
+			}
+		}
+	}
+	return items
+}
+
+// cooklangFrontMatter is the metadata written by renderCooklang, in the order of the fields.
+type cooklangFrontMatter struct {
+	Title       string   `yaml:"title,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+	Servings    string   `yaml:"servings,omitempty"`
+	Source      string   `yaml:"source,omitempty"`
+	Author      string   `yaml:"author,omitempty"`
+	Course      []string `yaml:"course,omitempty"`
+	Cuisine     []string `yaml:"cuisine,omitempty"`
+	Diet        []string `yaml:"diet,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+	Difficulty  string   `yaml:"difficulty,omitempty"`
+	PrepTime    string   `yaml:"prep time,omitempty"`
+	CookTime    string   `yaml:"cook time,omitempty"`
+	TotalTime   string   `yaml:"time,omitempty"`
+	Locale      string   `yaml:"locale,omitempty"`
+}
+
+// renderCooklang writes a recipe as Cooklang. Ingredients, equipment and timers are marked up where a step mentions them,
+// ingredients and equipment that no step mentions are listed in a first step so that none is lost.
+func renderCooklang(recipe *domain.Recipe) string {
+	front := cooklangFrontMatter{
+		Title:       deref(recipe.Name),
+		Description: deref(recipe.Description),
+		Servings:    schemaYield(recipe),
+		Source:      deref(recipe.SourceUrl),
+		Difficulty:  deref(recipe.Difficulty),
+		Locale:      deref(recipe.Language),
+	}
+	if recipe.Author != nil {
+		front.Author = recipe.Author.Name
+	}
+	for _, t := range recipe.Taxonomies {
+		switch t.Type {
+		case domain.TaxonomyTypeCategory:
+			front.Course = append(front.Course, t.Label)
+		case domain.TaxonomyTypeCuisine:
+			front.Cuisine = append(front.Cuisine, t.Label)
+		case domain.TaxonomyTypeDiet:
+			front.Diet = append(front.Diet, t.Label)
+		case domain.TaxonomyTypeKeyword:
+			front.Tags = append(front.Tags, t.Label)
+		}
+	}
+	for _, t := range []struct {
+		target *string
+		value  *types.Duration
+	}{{&front.PrepTime, recipe.PrepTime}, {&front.CookTime, recipe.CookTime}, {&front.TotalTime, recipe.TotalTime}} {
+		if t.value != nil && *t.value > 0 {
+			*t.target = formatDuration(*t.value)
+		}
+	}
+
+	var blocks []string
+	if data, err := yaml.Marshal(front); err == nil && string(data) != "{}\n" {
+		blocks = append(blocks, "---\n"+string(data)+"---")
+	}
+
+	w := &cooklangWriter{recipe: recipe, mentioned: map[uuid.UUID]bool{}, equipment: map[uuid.UUID]bool{}}
+	var steps []string
+	for _, section := range cooklangSections(recipe.Instructions) {
+		if deref(section.head.Title) != "" {
+			steps = append(steps, "= "+*section.head.Title)
+		}
+		for _, inst := range append([]*domain.RecipeInstruction{section.head}, section.steps...) {
+			if strings.TrimSpace(inst.Text) != "" {
+				steps = append(steps, w.step(inst))
+			}
+		}
+	}
+
+	var unmentioned []string
+	for _, ing := range recipe.Ingredients {
+		if !w.mentioned[ing.ID] {
+			unmentioned = append(unmentioned, cooklangIngredient(cooklangIngredientName(ing), ing, true))
+		}
+	}
+	for _, e := range recipe.Equipment {
+		if !w.equipment[e.ID] {
+			unmentioned = append(unmentioned, "#"+cooklangName(e.Name)+"{}")
+		}
+	}
+	if len(unmentioned) > 0 {
+		blocks = append(blocks, strings.Join(unmentioned, ", "))
+	}
+	blocks = append(blocks, steps...)
+
+	if notes := strings.TrimSpace(deref(recipe.Text)); notes != "" {
+		lines := strings.Split(notes, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace("> " + line)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+type cooklangSection struct {
+	head  *domain.RecipeInstruction
+	steps []*domain.RecipeInstruction
+}
+
+// cooklangSections orders the instructions and puts every step under the section it belongs to.
+func cooklangSections(instructions []*domain.RecipeInstruction) []*cooklangSection {
+	ordered := slices.Clone(instructions)
+	slices.SortStableFunc(ordered, func(a, b *domain.RecipeInstruction) int {
+		return int(a.Order) - int(b.Order)
+	})
+	index := make(map[uuid.UUID]*cooklangSection)
+	var sections []*cooklangSection
+	for _, inst := range ordered {
+		if inst.ParentID != nil {
+			if section, ok := index[*inst.ParentID]; ok {
+				section.steps = append(section.steps, inst)
+				continue
+			}
+		}
+		section := &cooklangSection{head: inst}
+		index[inst.ID] = section
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+type cooklangWriter struct {
+	recipe    *domain.Recipe
+	mentioned map[uuid.UUID]bool
+	equipment map[uuid.UUID]bool
+}
+
+type cooklangReplacement struct {
+	start, end int
+	text       string
+}
+
+// step marks up the first mention in the text of each ingredient linked to the instruction, of the equipment and of the timers.
+// The quantity of an ingredient goes with its first mention in the recipe.
+func (w *cooklangWriter) step(inst *domain.RecipeInstruction) string {
+	text := strings.Join(strings.Fields(inst.Text), " ")
+	var replacements []cooklangReplacement
+	place := func(term string, markup func(match string) string) bool {
+		if strings.TrimSpace(term) == "" {
+			return false
+		}
+		for start := 0; start+len(term) <= len(text); start++ {
+			end := start + len(term)
+			if !utf8.RuneStart(text[start]) || !strings.EqualFold(text[start:end], term) ||
+				!cooklangWordBoundary(text, start, end) || slices.ContainsFunc(replacements, func(r cooklangReplacement) bool {
+				return start < r.end && r.start < end
+			}) {
+				continue
+			}
+			replacements = append(replacements, cooklangReplacement{start, end, markup(text[start:end])})
+			return true
+		}
+		return false
+	}
+
+	linked := make(map[uuid.UUID]bool, len(inst.Ingredients))
+	for _, ing := range inst.Ingredients {
+		linked[ing.ID] = true
+	}
+	for _, ing := range w.recipe.Ingredients {
+		if len(linked) > 0 && !linked[ing.ID] {
+			continue
+		}
+		first := !w.mentioned[ing.ID]
+		for _, name := range cooklangIngredientNames(ing) {
+			if place(name, func(match string) string { return cooklangIngredient(match, ing, first) }) {
+				w.mentioned[ing.ID] = true
+				break
+			}
+		}
+	}
+	for _, e := range w.recipe.Equipment {
+		if place(e.Name, func(match string) string { return "#" + cooklangName(match) + "{}" }) {
+			w.equipment[e.ID] = true
+		}
+	}
+	for _, timer := range inst.Timers {
+		place(timer.Text, func(string) string { return cooklangTimerMarkup(timer) })
+	}
+
+	slices.SortFunc(replacements, func(a, b cooklangReplacement) int { return a.start - b.start })
+	var b strings.Builder
+	pos := 0
+	for _, r := range replacements {
+		b.WriteString(cooklangEscaper.Replace(text[pos:r.start]))
+		b.WriteString(r.text)
+		pos = r.end
+	}
+	b.WriteString(cooklangEscaper.Replace(text[pos:]))
+	return b.String()
+}
+
+func cooklangIngredientNames(ing *domain.RecipeIngredient) []string {
+	var names []string
+	if ing.Name != nil {
+		names = append(names, *ing.Name)
+	}
+	if ing.Food != nil && (ing.Name == nil || !strings.EqualFold(*ing.Name, ing.Food.Name)) {
+		names = append(names, ing.Food.Name)
+	}
+	return names
+}
+
+func cooklangIngredientName(ing *domain.RecipeIngredient) string {
+	if names := cooklangIngredientNames(ing); len(names) > 0 {
+		return names[0]
+	}
+	return ing.RawText
+}
+
+func cooklangIngredient(name string, ing *domain.RecipeIngredient, withQuantity bool) string {
+	name = cooklangName(name)
+	quantity := ""
+	if withQuantity && ing.Amount != nil {
+		quantity = formatAmount(*ing.Amount)
+		if ing.MaxAmount != nil {
+			quantity += "-" + formatAmount(*ing.MaxAmount)
+		}
+		if ing.Unit != nil {
+			quantity += "%" + ing.Unit.Name
+		}
+	}
+
+	markup := "@" + name
+	if quantity != "" || cooklangWordRe.FindString(name) != name {
+		markup += "{" + quantity + "}"
+	}
+	if withQuantity && ing.Description != nil {
+		markup += "(" + strings.NewReplacer("(", "", ")", "").Replace(*ing.Description) + ")"
+	}
+	return markup
+}
+
+func cooklangTimerMarkup(timer domain.InstructionTimer) string {
+	d := timer.Duration.ToDuration()
+	unit, size := "minutes", time.Minute
+	switch {
+	case d%time.Hour == 0 && (timer.MaxDuration == nil || timer.MaxDuration.ToDuration()%time.Hour == 0):
+		unit, size = "hours", time.Hour
+	case d%time.Minute != 0:
+		unit, size = "seconds", time.Second
+	}
+	quantity := formatAmount(float64(d) / float64(size))
+	if timer.MaxDuration != nil {
+		quantity += "-" + formatAmount(float64(timer.MaxDuration.ToDuration())/float64(size))
+	}
+	return "~{" + quantity + "%" + unit + "}"
+}
+
+// cooklangName drops the characters that would end a Cooklang name early.
+func cooklangName(name string) string {
+	return strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if strings.ContainsRune("@#~{}()%\n", r) {
+			return ' '
+		}
+		return r
+	}, name)), " ")
+}
+
+func cooklangWordBoundary(text string, start, end int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	if start > 0 {
+		if r := []rune(text[:start]); isWord(r[len(r)-1]) {
+			return false
+		}
+	}
+	if end < len(text) {
+		if r := []rune(text[end:]); isWord(r[0]) {
+			return false
+		}
+	}
+	return true
+}
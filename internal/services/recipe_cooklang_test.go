@@ -0,0 +1,256 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/types"
+)
+
+const borschtCooklang = `---
+title: Borscht
+servings: 6
+tags: [soup, ukrainian]
+prep time: 20 minutes
+time: 1h30m
+source: https://example.com/borscht
+---
+
+-- the beets are what matters
+Peel @beets{3} and @potatoes{2}(cubed). [- block comment -]
+
+= Broth
+Bring @water{2.5%l} to a boil in a #large pot{}, add the @beets and simmer for ~{20%minutes}.
+
+Season with @salt and @black pepper{}, then add @water{0.5%l}.
+
+> Serve with smetana.
+> Tastes better the next day.
+`
+
+func TestImportService_ImportCooklang_ParsesMarkup(t *testing.T) {
+	uid, hid := uuid.New(), uuid.New()
+	var imported *domain.Recipe
+	svc := newTestImportService(importServiceDeps{
+		recipeIngest: &stubRecipeIngestService{importRecipeFn: func(_ context.Context, recipe *domain.Recipe) (*domain.Recipe, error) {
+			imported = recipe
+			return recipe, nil
+		}},
+	})
+
+	_, err := svc.ImportCooklang(context.Background(), "borscht", borschtCooklang, uid, hid)
+	require.NoError(t, err)
+	require.NotNil(t, imported)
+
+	assert.Equal(t, "Borscht", *imported.Name)
+	assert.Equal(t, hid, *imported.HouseholdID)
+	assert.Equal(t, "https://example.com/borscht", *imported.SourceUrl)
+	assert.Equal(t, 6.0, *imported.Yield)
+	assert.Equal(t, 20*time.Minute, time.Duration(*imported.PrepTime))
+	assert.Equal(t, 90*time.Minute, time.Duration(*imported.TotalTime))
+	assert.Equal(t, "Serve with smetana.\nTastes better the next day.", *imported.Text)
+	require.Len(t, imported.Taxonomies, 2)
+	assert.Equal(t, domain.TaxonomyTypeKeyword, imported.Taxonomies[0].Type)
+
+	require.Len(t, imported.Ingredients, 5)
+	beets, potatoes, water := imported.Ingredients[0], imported.Ingredients[1], imported.Ingredients[2]
+	assert.Equal(t, "beets", *beets.Name)
+	assert.Equal(t, "beets", beets.Food.Slug)
+	assert.Equal(t, 3.0, *beets.Amount)
+	assert.Equal(t, "cubed", *potatoes.Description)
+	assert.Equal(t, "2 potatoes, cubed", potatoes.RawText)
+	assert.Equal(t, 3.0, *water.Amount, "mentions of the same ingredient and unit are summed up")
+	assert.Equal(t, "l", water.Unit.Name)
+	assert.Equal(t, "Broth", *water.Category)
+	assert.Equal(t, "3 l water", water.RawText)
+	assert.Nil(t, imported.Ingredients[3].Amount)
+	assert.Equal(t, "black pepper", *imported.Ingredients[4].Name)
+
+	require.Len(t, imported.Equipment, 1)
+	assert.Equal(t, "large pot", imported.Equipment[0].Name)
+
+	require.Len(t, imported.Instructions, 4)
+	assert.Equal(t, "Peel beets and potatoes.", imported.Instructions[0].Text)
+	assert.Equal(t, []*domain.RecipeIngredient{beets, potatoes}, imported.Instructions[0].Ingredients)
+	section := imported.Instructions[1]
+	assert.Equal(t, "Broth", *section.Title)
+	step := imported.Instructions[2]
+	assert.Same(t, section, step.Parent)
+	assert.Equal(t, "Bring water to a boil in a large pot, add the beets and simmer for 20 minutes.", step.Text)
+	assert.Equal(t, []*domain.RecipeIngredient{water, beets}, step.Ingredients)
+	require.Len(t, step.Timers, 1)
+	assert.Equal(t, types.Duration(20*time.Minute), step.Timers[0].Duration)
+	assert.Equal(t, "Season with salt and black pepper, then add water.", imported.Instructions[3].Text)
+}
+
+func TestImportService_ImportCooklang_NameFallsBackToFileName(t *testing.T) {
+	var imported *domain.Recipe
+	svc := newTestImportService(importServiceDeps{
+		recipeIngest: &stubRecipeIngestService{importRecipeFn: func(_ context.Context, recipe *domain.Recipe) (*domain.Recipe, error) {
+			imported = recipe
+			return recipe, nil
+		}},
+	})
+
+	_, err := svc.ImportCooklang(context.Background(), "Toast", ">> servings: 1\nToast the @bread{2%slices}.", uuid.New(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, "Toast", *imported.Name)
+	assert.Equal(t, 1.0, *imported.Yield)
+	assert.Equal(t, "slices", imported.Ingredients[0].Unit.Name)
+}
+
+func TestImportService_ImportCooklang_NoSteps_ReturnsBadRequest(t *testing.T) {
+	svc := newTestImportService(importServiceDeps{})
+
+	_, err := svc.ImportCooklang(context.Background(), "Empty", "---\ntitle: Empty\n---\n", uuid.New(), uuid.New())
+
+	var sErr *sentinels.Error
+	require.ErrorAs(t, err, &sErr)
+	assert.Equal(t, http.StatusBadRequest, sErr.Status)
+}
+
+func TestImportService_ImportFile_CooklangFolder(t *testing.T) {
+	data := buildZip(t, map[string][]byte{
+		"recipes/Pancakes.cook": []byte("Mix @flour{200%g} with @milk{300%ml}."),
+		"recipes/Pancakes.jpg":  jpegHeader,
+		"recipes/README.md":     []byte("my recipes"),
+	})
+
+	var imported []*domain.Recipe
+	svc := newTestImportService(importServiceDeps{recipeIngest: collectingIngest(&imported)})
+
+	report, err := svc.ImportFile(context.Background(), data, nil, nil, uuid.New(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, domain.ImportSourceCooklang, report.Source)
+	assert.Equal(t, 1, report.Imported)
+	require.Len(t, imported, 1)
+	assert.Equal(t, "Pancakes", *imported[0].Name)
+	assert.NotNil(t, imported[0].ImagePath)
+	assert.Len(t, imported[0].Ingredients, 2)
+}
+
+func TestRecipeService_Export_Cooklang(t *testing.T) {
+	litre := &domain.Unit{ID: uuid.New(), Name: "l"}
+	beets := &domain.RecipeIngredient{ID: uuid.New(), Amount: ptr(3.0), Name: ptr("beets"), Description: ptr("peeled"), RawText: "3 beets, peeled"}
+	water := &domain.RecipeIngredient{ID: uuid.New(), Amount: ptr(2.5), UnitID: &litre.ID, Unit: litre, Name: ptr("water"), RawText: "2.5 l water"}
+	dill := &domain.RecipeIngredient{ID: uuid.New(), Name: ptr("fresh dill"), RawText: "fresh dill"}
+	pot := &domain.Equipment{ID: uuid.New(), Name: "large pot"}
+	sectionID := uuid.New()
+	recipe := &domain.Recipe{
+		ID:         uuid.New(),
+		Name:       ptr("Borscht"),
+		Yield:      ptr(6.0),
+		PrepTime:   new(types.Duration(20 * time.Minute)),
+		Text:       ptr("Serve with smetana."),
+		Taxonomies: []*domain.Taxonomy{{Type: domain.TaxonomyTypeKeyword, Label: "soup"}},
+		Equipment:  []*domain.Equipment{pot},
+		Ingredients: []*domain.RecipeIngredient{
+			beets, water, dill,
+		},
+		Instructions: []*domain.RecipeInstruction{
+			{ID: uuid.New(), Order: 0, Text: "Grate the beets.", Ingredients: []*domain.RecipeIngredient{beets}},
+			{ID: sectionID, Order: 1, Title: ptr("Broth")},
+			{
+				ID: uuid.New(), Order: 2, ParentID: &sectionID,
+				Text:        "Boil water in a large pot, add the beets and simmer for 20–25 minutes.",
+				Ingredients: []*domain.RecipeIngredient{water, beets},
+				Timers:      []domain.InstructionTimer{{Duration: types.Duration(20 * time.Minute), MaxDuration: new(types.Duration(25 * time.Minute)), Text: "20–25 minutes"}},
+			},
+		},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return recipe, nil
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	data, err := svc.Export(recipe.ID, uuid.New(), uuid.New(), domain.ExportOptions{Format: domain.ExportFormatCooklang})
+
+	require.NoError(t, err)
+	assert.Equal(t, `---
+title: Borscht
+servings: "6"
+tags:
+    - soup
+prep time: 20 min
+---
+
+@fresh dill{}
+
+Grate the @beets{3}(peeled).
+
+= Broth
+
+Boil @water{2.5%l} in a #large pot{}, add the @beets and simmer for ~{20-25%minutes}.
+
+> Serve with smetana.
+`, string(data))
+
+	// and back again
+	var imported *domain.Recipe
+	importSvc := newTestImportService(importServiceDeps{
+		recipeIngest: &stubRecipeIngestService{importRecipeFn: func(_ context.Context, r *domain.Recipe) (*domain.Recipe, error) {
+			imported = r
+			return r, nil
+		}},
+	})
+	_, err = importSvc.ImportCooklang(context.Background(), "", string(data), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, "Borscht", *imported.Name)
+	assert.Equal(t, 20*time.Minute, time.Duration(*imported.PrepTime))
+	require.Len(t, imported.Ingredients, 3)
+	assert.Equal(t, "fresh dill", *imported.Ingredients[0].Name)
+	assert.Equal(t, "3 beets, peeled", imported.Ingredients[1].RawText)
+	assert.Equal(t, "2.5 l water", imported.Ingredients[2].RawText)
+	require.Len(t, imported.Equipment, 1)
+	timers := imported.Instructions[len(imported.Instructions)-1].Timers
+	require.Len(t, timers, 1)
+	assert.Equal(t, types.Duration(25*time.Minute), *timers[0].MaxDuration)
+}
+
+func TestRecipeService_Export_Cooklang_EscapesLiteralMarkers(t *testing.T) {
+	salt := &domain.RecipeIngredient{ID: uuid.New(), Name: ptr("Salt"), RawText: "salt"}
+	text := `Step #1: add ~5 g of salt, see notes@home or C:\@dir.`
+	recipe := &domain.Recipe{
+		ID:           uuid.New(),
+		Name:         ptr("Brine"),
+		Ingredients:  []*domain.RecipeIngredient{salt},
+		Instructions: []*domain.RecipeInstruction{{ID: uuid.New(), Text: text, Ingredients: []*domain.RecipeIngredient{salt}}},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return recipe, nil
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	data, err := svc.Export(recipe.ID, uuid.New(), uuid.New(), domain.ExportOptions{Format: domain.ExportFormatCooklang})
+
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `Step \#1: add \~5 g of @salt, see notes\@home or C:\\\@dir.`, "the ingredient is found whatever its case")
+
+	var imported *domain.Recipe
+	importSvc := newTestImportService(importServiceDeps{
+		recipeIngest: &stubRecipeIngestService{importRecipeFn: func(_ context.Context, r *domain.Recipe) (*domain.Recipe, error) {
+			imported = r
+			return r, nil
+		}},
+	})
+	_, err = importSvc.ImportCooklang(context.Background(), "", string(data), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	require.Len(t, imported.Instructions, 1)
+	assert.Equal(t, text, imported.Instructions[0].Text)
+	assert.Empty(t, imported.Instructions[0].Timers)
+	assert.Empty(t, imported.Equipment)
+	require.Len(t, imported.Ingredients, 1)
+	assert.Equal(t, "salt", *imported.Ingredients[0].Name)
+}
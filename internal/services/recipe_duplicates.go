@@ -0,0 +1,95 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+)
+
+func (s *recipeService) Duplicates(householdID uuid.UUID, offset, limit int) ([]domain.RecipeDuplicates, int64, error) {
+	recipes, total, err := s.repo.DuplicateCandidates(householdID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("duplicates: %w", err)
+	}
+
+	var groups []domain.RecipeDuplicates
+	index := map[string]int{}
+	for _, recipe := range recipes { // oldest first, so is every group
+		fingerprint := recipeFingerprint(&recipe)
+		if fingerprint == "" {
+			continue
+		}
+		recipe.Ingredients = nil // only loaded for the fingerprint
+		if i, ok := index[fingerprint]; ok {
+			groups[i].Recipes = append(groups[i].Recipes, recipe)
+			continue
+		}
+		index[fingerprint] = len(groups)
+		groups = append(groups, domain.RecipeDuplicates{Fingerprint: fingerprint, Recipes: []domain.Recipe{recipe}})
+	}
+	return slices.DeleteFunc(groups, func(g domain.RecipeDuplicates) bool { return len(g.Recipes) < 2 }), total, nil
+}
+
+func (s *recipeService) Merge(keepID, mergeID uuid.UUID, householdID uuid.UUID) error {
+	if keepID == mergeID {
+		return sentinels.BadRequest("a recipe cannot be merged into itself")
+	}
+	if _, err := s.ByID(keepID, householdID); err != nil {
+		return fmt.Errorf("merge (fetch kept): %w", err)
+	}
+	merged, err := s.ByID(mergeID, householdID)
+	if err != nil {
+		return fmt.Errorf("merge (fetch merged): %w", err)
+	}
+
+	err = s.repo.Transaction(func(txRepo domain.RecipeRepository) error {
+		if err := txRepo.ReplaceRecipePointers(mergeID, keepID, householdID); err != nil {
+			return err
+		}
+		// a global recipe stays for other households, this one just no longer points at it
+		if merged.HouseholdID != nil {
+			return txRepo.Delete(mergeID)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("merge transaction: %w", err)
+	}
+	return nil
+}
+
+// recipeFingerprint identifies a recipe by its normalized title and the set of its ingredient foods,
+// falling back to the ingredient name while the food is not resolved. Empty for a recipe without a title.
+func recipeFingerprint(recipe *domain.Recipe) string {
+	if recipe.Name == nil {
+		return ""
+	}
+	title := domain.NormalizeFingerprintText(*recipe.Name)
+	if title == "" {
+		return ""
+	}
+
+	foods := make([]string, 0, len(recipe.Ingredients))
+	for _, ing := range recipe.Ingredients {
+		switch {
+		case ing.FoodID != nil:
+			foods = append(foods, ing.FoodID.String())
+		case ing.Name != nil:
+			if name := domain.NormalizeFingerprintText(*ing.Name); name != "" {
+				foods = append(foods, name)
+			}
+		}
+	}
+	slices.Sort(foods)
+	foods = slices.Compact(foods)
+
+	sum := sha256.Sum256([]byte(title + "\n" + strings.Join(foods, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,130 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+)
+
+func TestRecipeService_Duplicates_GroupsByTitleAndFoods(t *testing.T) {
+	beet, onion, cabbage := uuid.New(), uuid.New(), uuid.New()
+	ingredients := func(foods ...uuid.UUID) []*domain.RecipeIngredient {
+		var list []*domain.RecipeIngredient
+		for _, f := range foods {
+			list = append(list, &domain.RecipeIngredient{FoodID: &f})
+		}
+		return list
+	}
+
+	original := domain.Recipe{ID: uuid.New(), Name: ptr("Borscht"), Ingredients: ingredients(beet, onion)}
+	amp := domain.Recipe{ID: uuid.New(), Name: ptr("  borscht! "), Ingredients: ingredients(onion, beet, beet)}
+	variant := domain.Recipe{ID: uuid.New(), Name: ptr("Borscht"), Ingredients: ingredients(beet, onion, cabbage)}
+	untitled := domain.Recipe{ID: uuid.New(), Ingredients: ingredients(beet, onion)}
+
+	hid := uuid.New()
+	svc := newTestRecipeService(recipeServiceDeps{repo: &stubRecipeRepo{
+		duplicateCandidatesFn: func(h uuid.UUID, offset, limit int) ([]domain.Recipe, int64, error) {
+			assert.Equal(t, hid, h)
+			assert.Equal(t, []int{10, 5}, []int{offset, limit})
+			return []domain.Recipe{original, variant, amp, untitled}, 11, nil
+		},
+	}})
+
+	groups, total, err := svc.Duplicates(hid, 10, 5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 11, total)
+	require.Len(t, groups, 1, "recipes with other foods are not duplicates")
+	require.Len(t, groups[0].Recipes, 2)
+	assert.Equal(t, original.ID, groups[0].Recipes[0].ID, "oldest first")
+	assert.Equal(t, amp.ID, groups[0].Recipes[1].ID)
+	assert.NotEmpty(t, groups[0].Fingerprint)
+	assert.Nil(t, groups[0].Recipes[0].Ingredients)
+}
+
+func TestRecipeService_Merge_OwnedDuplicate_MovesPointersAndTrashes(t *testing.T) {
+	hid := uuid.New()
+	keep := &domain.Recipe{ID: uuid.New()}
+	owned := &domain.Recipe{ID: uuid.New(), HouseholdID: &hid}
+
+	var replaced, deleted bool
+	repo := &stubRecipeRepo{
+		byIDFn: func(id uuid.UUID) (*domain.Recipe, error) {
+			if id == keep.ID {
+				return keep, nil
+			}
+			return owned, nil
+		},
+		transactionFn: func(fn func(domain.RecipeRepository) error) error {
+			return fn(&stubRecipeRepo{
+				replaceRecipePointersFn: func(oldID, newID, h uuid.UUID) error {
+					replaced = true
+					assert.Equal(t, owned.ID, oldID)
+					assert.Equal(t, keep.ID, newID)
+					assert.Equal(t, hid, h)
+					return nil
+				},
+				deleteFn: func(id uuid.UUID) error {
+					deleted = true
+					assert.Equal(t, owned.ID, id)
+					return nil
+				},
+			})
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	require.NoError(t, svc.Merge(keep.ID, owned.ID, hid))
+	assert.True(t, replaced)
+	assert.True(t, deleted)
+}
+
+func TestRecipeService_Merge_GlobalDuplicate_IsKept(t *testing.T) {
+	keep := &domain.Recipe{ID: uuid.New()}
+	global := &domain.Recipe{ID: uuid.New()}
+
+	repo := &stubRecipeRepo{
+		byIDFn: func(id uuid.UUID) (*domain.Recipe, error) {
+			if id == keep.ID {
+				return keep, nil
+			}
+			return global, nil
+		},
+		transactionFn: func(fn func(domain.RecipeRepository) error) error {
+			return fn(&stubRecipeRepo{
+				replaceRecipePointersFn: func(_, _, _ uuid.UUID) error { return nil },
+				deleteFn: func(uuid.UUID) error {
+					t.Fatal("global recipes are shared with other households and must not be deleted")
+					return nil
+				},
+			})
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	require.NoError(t, svc.Merge(keep.ID, global.ID, uuid.New()))
+}
+
+func TestRecipeService_Merge_OtherHousehold_ReturnsForbidden(t *testing.T) {
+	other := uuid.New()
+	foreign := &domain.Recipe{ID: uuid.New(), HouseholdID: &other}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: &stubRecipeRepo{
+		byIDFn: func(id uuid.UUID) (*domain.Recipe, error) {
+			if id == foreign.ID {
+				return foreign, nil
+			}
+			return &domain.Recipe{ID: id}, nil
+		},
+	}})
+
+	err := svc.Merge(uuid.New(), foreign.ID, uuid.New())
+	assert.ErrorIs(t, err, sentinels.ErrForbidden)
+
+	id := uuid.New()
+	assert.ErrorIs(t, svc.Merge(id, id, uuid.New()), sentinels.BadRequest(""))
+}
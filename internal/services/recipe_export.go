@@ -0,0 +1,314 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/borschtapp/krip"
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/types"
+)
+
+var exportFormats = []domain.ExportFormat{domain.ExportFormatJSONLD, domain.ExportFormatMarkdown, domain.ExportFormatText, domain.ExportFormatCooklang}
+
+func checkExportFormat(format domain.ExportFormat) error {
+	if !slices.Contains(exportFormats, format) {
+		return sentinels.BadRequest(fmt.Sprintf("unsupported export format '%s'", format))
+	}
+	return nil
+}
+
+func (s *recipeService) Export(id, userID, householdID uuid.UUID, opts domain.ExportOptions) ([]byte, error) {
+	if err := checkExportFormat(opts.Format); err != nil {
+		return nil, err
+	}
+
+	recipe, err := s.ByIDPreload(id, userID, householdID, types.Preload("all"))
+	if err != nil {
+		return nil, fmt.Errorf("export (fetch recipe): %w", err)
+	}
+	s.applyExportOptions(recipe, opts)
+
+	if opts.Format == domain.ExportFormatJSONLD {
+		data, err := marshalJSONLD(toSchemaRecipe(recipe))
+		if err != nil {
+			return nil, fmt.Errorf("export (marshal): %w", err)
+		}
+		return data, nil
+	}
+	if opts.Format == domain.ExportFormatCooklang {
+		return []byte(renderCooklang(recipe)), nil
+	}
+	return []byte(renderRecipeText(recipe, textStyles[opts.Format])), nil
+}
+
+// applyExportOptions scales the ingredients and yield of a loaded recipe and converts it to the requested unit system.
+// Ingredient lines that no longer match what was written are recomposed from the structured fields.
+func (s *recipeService) applyExportOptions(recipe *domain.Recipe, opts domain.ExportOptions) {
+	factor := scaleFactor(recipe, opts.Scale)
+	if factor != 1 {
+		if recipe.Yield != nil {
+			recipe.Yield = new(*recipe.Yield * factor)
+			recipe.YieldText = nil
+		}
+		if recipe.YieldMax != nil {
+			recipe.YieldMax = new(*recipe.YieldMax * factor)
+		}
+	}
+
+	for i, ing := range recipe.Ingredients {
+		changed := factor != 1
+		if changed {
+			ing = ing.Scaled(factor)
+			recipe.Ingredients[i] = ing
+		}
+		if opts.UnitSystem != nil && s.convertIngredient(ing, *opts.UnitSystem == domain.UnitSystemImperial) {
+			changed = true
+		}
+		if changed {
+			ing.RawText = ingredientText(ing)
+		}
+	}
+
+	if opts.UnitSystem != nil {
+		unit := opts.UnitSystem.TemperatureUnit()
+		localizeTemperatures(recipe.Instructions, unit)
+		for _, inst := range recipe.Instructions {
+			inst.Text = localizeTemperatureText(inst.Text, unit)
+		}
+	}
+}
+
+// convertIngredient converts the amount of an ingredient to the most readable unit of the other unit system.
+func (s *recipeService) convertIngredient(ing *domain.RecipeIngredient, imperial bool) bool {
+	if ing.Amount == nil || ing.UnitID == nil || ing.Unit == nil || !ing.Unit.Convertible() || ing.Unit.Imperial == imperial {
+		return false
+	}
+
+	best, err := s.unitService.BestUnit(*ing.Amount, *ing.UnitID, imperial)
+	if err != nil {
+		log.Warnw("no unit to convert to, keeping ingredient as written", "unit_id", *ing.UnitID, "error", err.Error())
+		return false
+	}
+	amount, err := s.unitService.Convert(*ing.Amount, *ing.UnitID, best.ID)
+	if err != nil {
+		log.Warnw("failed to convert ingredient amount", "unit_id", *ing.UnitID, "error", err.Error())
+		return false
+	}
+	if ing.MaxAmount != nil {
+		maxAmount, err := s.unitService.Convert(*ing.MaxAmount, *ing.UnitID, best.ID)
+		if err != nil {
+			return false
+		}
+		ing.MaxAmount = &maxAmount
+	}
+	ing.Amount = &amount
+	ing.UnitID = &best.ID
+	ing.Unit = best
+	return true
+}
+
+// ingredientText composes an ingredient line such as "1.5-2 cups flour, sifted" from its structured fields.
+// Ingredients without an amount or a name are kept as written.
+func ingredientText(ing *domain.RecipeIngredient) string {
+	name := deref(ing.Name)
+	if name == "" && ing.Food != nil {
+		name = ing.Food.Name
+	}
+	if name == "" && ing.SubRecipe != nil {
+		name = deref(ing.SubRecipe.Name)
+	}
+	if ing.Amount == nil || name == "" {
+		return ing.RawText
+	}
+
+	amount := formatAmount(*ing.Amount)
+	if ing.MaxAmount != nil {
+		amount += "-" + formatAmount(*ing.MaxAmount)
+	}
+	parts := []string{amount}
+	if ing.Unit != nil {
+		parts = append(parts, ing.Unit.Name)
+	}
+	parts = append(parts, name)
+
+	text := strings.Join(parts, " ")
+	if ing.Description != nil {
+		text += ", " + *ing.Description
+	}
+	return text
+}
+
+// formatAmount rounds large amounts to whole numbers and small ones to two decimals, without trailing zeros.
+func formatAmount(v float64) string {
+	if v >= 10 {
+		return strconv.FormatFloat(math.Round(v), 'f', -1, 64)
+	}
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// localizeTemperatureText follows every temperature in text with its conversion to unit,
+// unless the text already mentions temperatures in that unit (e.g. "180°C / 350°F").
+func localizeTemperatureText(text string, unit domain.TemperatureUnit) string {
+	for _, temp := range parseTemperatures(text) {
+		if temp.Unit == unit {
+			return text
+		}
+	}
+	return temperatureRe.ReplaceAllStringFunc(text, func(match string) string {
+		temps := parseTemperatures(match)
+		if len(temps) != 1 {
+			return match
+		}
+		return match + " (" + formatTemperature(temps[0].In(unit)) + ")"
+	})
+}
+
+func formatTemperature(t domain.Temperature) string {
+	value := strconv.FormatFloat(t.Value, 'f', -1, 64)
+	if t.MaxValue != nil {
+		value += "-" + strconv.FormatFloat(*t.MaxValue, 'f', -1, 64)
+	}
+	return value + "°" + string(t.Unit)
+}
+
+func formatDuration(d types.Duration) string {
+	t := d.ToDuration().Round(time.Minute)
+	hours, minutes := int(t.Hours()), int(t.Minutes())%60
+	switch {
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%d h %d min", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%d h", hours)
+	default:
+		return fmt.Sprintf("%d min", minutes)
+	}
+}
+
+// recipeOutline is the printable structure of a recipe shared by the text, HTML and PDF renderers.
+type recipeOutline struct {
+	Title        string
+	Description  string
+	Meta         []outlineItem // label and value, e.g. "Prep:" and "15 min"
+	Ingredients  []outlineGroup
+	Instructions []outlineGroup
+	Notes        string
+	Source       string
+}
+
+// outlineGroup is a titled list such as an ingredient category or an instruction section, Name is empty for ungrouped items.
+type outlineGroup struct {
+	Name  string
+	Text  string
+	Items []outlineItem
+}
+
+// outlineItem is an ingredient line, a numbered step or a meta entry.
+type outlineItem struct {
+	Number int
+	Name   string
+	Text   string
+}
+
+// newRecipeOutline groups ingredients by category, uncategorized first, and numbers the instructions within their sections.
+func newRecipeOutline(recipe *domain.Recipe) recipeOutline {
+	outline := recipeOutline{
+		Title:       deref(recipe.Name),
+		Description: deref(recipe.Description),
+		Notes:       deref(recipe.Text),
+		Source:      deref(recipe.SourceUrl),
+	}
+
+	if yield := schemaYield(recipe); yield != "" {
+		outline.Meta = append(outline.Meta, outlineItem{Name: "Yield:", Text: yield})
+	}
+	for _, t := range []struct {
+		label string
+		value *types.Duration
+	}{{"Prep:", recipe.PrepTime}, {"Cook:", recipe.CookTime}, {"Total:", recipe.TotalTime}} {
+		if t.value != nil && *t.value > 0 {
+			outline.Meta = append(outline.Meta, outlineItem{Name: t.label, Text: formatDuration(*t.value)})
+		}
+	}
+
+	// uncategorized ingredients come first, then each category in order of appearance
+	groups := []outlineGroup{{}}
+	index := map[string]int{"": 0}
+	for _, ing := range recipe.Ingredients {
+		category := deref(ing.Category)
+		i, ok := index[category]
+		if !ok {
+			i = len(groups)
+			index[category] = i
+			groups = append(groups, outlineGroup{Name: category})
+		}
+		groups[i].Items = append(groups[i].Items, outlineItem{Text: ing.RawText})
+	}
+	for _, group := range groups {
+		if len(group.Items) > 0 {
+			outline.Ingredients = append(outline.Ingredients, group)
+		}
+	}
+
+	numbered := 0 // steps outside of sections are numbered through
+	run := -1     // group collecting the consecutive steps outside of sections
+	for _, section := range schemaInstructions(recipe.Instructions) {
+		if len(section.Steps) == 0 {
+			if run < 0 {
+				run = len(outline.Instructions)
+				outline.Instructions = append(outline.Instructions, outlineGroup{})
+			}
+			numbered++
+			outline.Instructions[run].Items = append(outline.Instructions[run].Items, outlineStep(numbered, section.HowToStep))
+			continue
+		}
+		run = -1
+		group := outlineGroup{Name: section.Name, Text: section.Text}
+		for i, step := range section.Steps {
+			group.Items = append(group.Items, outlineStep(i+1, *step))
+		}
+		outline.Instructions = append(outline.Instructions, group)
+	}
+	return outline
+}
+
+func outlineStep(number int, step krip.HowToStep) outlineItem {
+	if step.Text == "" {
+		return outlineItem{Number: number, Text: step.Name}
+	}
+	return outlineItem{Number: number, Name: step.Name, Text: step.Text}
+}
+
+// joinExports concatenates exported recipes into a single document: a JSON array for JSON-LD,
+// otherwise the recipes one after another under the title and description.
+func joinExports(format domain.ExportFormat, title, description string, docs [][]byte) []byte {
+	if format == domain.ExportFormatJSONLD {
+		return append(append([]byte("["), bytes.Join(docs, []byte(","))...), ']')
+	}
+
+	style := textStyles[format]
+	header := style.title(title)
+	if description != "" {
+		header += "\n\n" + description
+	}
+	parts := append([][]byte{[]byte(header + "\n")}, docs...)
+	return bytes.Join(parts, []byte("\n"+style.separator+"\n\n"))
+}
+
+// deref returns the value of p, or the zero value when p is nil.
+func deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	"github.com/borschtapp/krip"
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/storage"
+)
+
+// toSchemaRecipe is the reverse of scraperMapper.toRecipe, so that an imported recipe exports to the schema it was scraped from.
+func toSchemaRecipe(recipe *domain.Recipe) *krip.Recipe {
+	r := &krip.Recipe{
+		Url:           deref(recipe.SourceUrl),
+		Name:          deref(recipe.Name),
+		Description:   deref(recipe.Description),
+		Language:      deref(recipe.Language),
+		Text:          deref(recipe.Text),
+		Difficulty:    deref(recipe.Difficulty),
+		CookingMethod: deref(recipe.Method),
+		Yield:         schemaYield(recipe),
+		DatePublished: recipe.Published,
+	}
+	if recipe.PrepTime != nil {
+		r.PrepTime = recipe.PrepTime.ISO8601()
+	}
+	if recipe.CookTime != nil {
+		r.CookTime = recipe.CookTime.ISO8601()
+	}
+	if recipe.TotalTime != nil {
+		r.TotalTime = recipe.TotalTime.ISO8601()
+	}
+
+	for _, image := range recipe.Images {
+		if image.Path != nil {
+			r.AddImage(&krip.ImageObject{
+				Url:     storage.AbsoluteUrl(string(*image.Path)),
+				Width:   deref(image.Width),
+				Height:  deref(image.Height),
+				Caption: deref(image.Caption),
+			})
+		}
+	}
+	if len(r.Images) == 0 && recipe.ImagePath != nil {
+		r.AddImageUrl(storage.AbsoluteUrl(string(*recipe.ImagePath)))
+	}
+
+	if recipe.Author != nil {
+		r.Author = &krip.Person{
+			Name:        recipe.Author.Name,
+			Description: deref(recipe.Author.Description),
+			Url:         deref(recipe.Author.Url),
+		}
+		if recipe.Author.ImagePath != nil {
+			r.Author.Image = storage.AbsoluteUrl(string(*recipe.Author.ImagePath))
+		}
+	}
+	if recipe.Publisher != nil {
+		r.Publisher = &krip.Organization{
+			Name:        recipe.Publisher.Name,
+			Description: deref(recipe.Publisher.Description),
+			Url:         deref(recipe.Publisher.Url),
+		}
+		if recipe.Publisher.ImagePath != nil {
+			r.Publisher.Logo = storage.AbsoluteUrl(string(*recipe.Publisher.ImagePath))
+		}
+	}
+
+	for _, tax := range recipe.Taxonomies {
+		switch tax.Type {
+		case domain.TaxonomyTypeDiet:
+			r.Diets = append(r.Diets, tax.Label)
+		case domain.TaxonomyTypeCategory:
+			r.Categories = append(r.Categories, tax.Label)
+		case domain.TaxonomyTypeCuisine:
+			r.Cuisines = append(r.Cuisines, tax.Label)
+		case domain.TaxonomyTypeKeyword:
+			r.Keywords = append(r.Keywords, tax.Label)
+		}
+	}
+
+	if n := recipe.Nutrition; n != nil {
+		r.Nutrition = &krip.NutritionInformation{
+			ServingSize:         n.ServingSize,
+			Calories:            n.Calories,
+			CarbohydrateContent: n.Carbs,
+			FiberContent:        n.CarbFiber,
+			SugarContent:        n.CarbSugar,
+			CholesterolContent:  n.Cholesterol,
+			SodiumContent:       n.Sodium,
+			FatContent:          n.Fats,
+			SaturatedFatContent: n.FatSat,
+			TransFatContent:     n.FatTrans,
+			ProteinContent:      n.Protein,
+			SaltContent:         n.Salt,
+			IronContent:         n.Iron,
+			PotassiumContent:    n.Potassium,
+			CalciumContent:      n.Calcium,
+		}
+	}
+
+	if recipe.Rating != nil {
+		r.Rating = &krip.AggregateRating{
+			ReviewCount: deref(recipe.Rating.Reviews),
+			RatingCount: deref(recipe.Rating.Count),
+			RatingValue: deref(recipe.Rating.Value),
+		}
+	}
+	if v := recipe.Video; v != nil {
+		r.Video = &krip.VideoObject{
+			Name:         deref(v.Name),
+			Description:  deref(v.Description),
+			EmbedUrl:     deref(v.EmbedUrl),
+			ContentUrl:   deref(v.ContentUrl),
+			ThumbnailUrl: deref(v.ThumbnailUrl),
+		}
+	}
+
+	// recipeIngredient is plain text in most schemas, the structured form stays within smetana
+	for _, ing := range recipe.Ingredients {
+		r.Ingredients = append(r.Ingredients, &krip.PropertyValue{Name: ing.RawText})
+	}
+	for _, eq := range recipe.Equipment {
+		tool := &krip.HowToTool{Name: eq.Name, Description: deref(eq.Description)}
+		if eq.ImagePath != nil {
+			tool.Image = storage.AbsoluteUrl(string(*eq.ImagePath))
+		}
+		r.Equipment = append(r.Equipment, tool)
+	}
+	r.Instructions = schemaInstructions(recipe.Instructions)
+	return r
+}
+
+// schemaInstructions groups steps under the instruction referenced by their ParentID as a HowToSection.
+func schemaInstructions(instructions []*domain.RecipeInstruction) []*krip.HowToSection {
+	ordered := slices.Clone(instructions)
+	slices.SortStableFunc(ordered, func(a, b *domain.RecipeInstruction) int {
+		return int(a.Order) - int(b.Order)
+	})
+
+	sections := make(map[uuid.UUID]*krip.HowToSection)
+	var result []*krip.HowToSection
+	for _, ins := range ordered {
+		if ins.ParentID != nil {
+			if section, ok := sections[*ins.ParentID]; ok {
+				section.Steps = append(section.Steps, new(schemaStep(ins)))
+				continue
+			}
+		}
+		section := &krip.HowToSection{HowToStep: schemaStep(ins)}
+		sections[ins.ID] = section
+		result = append(result, section)
+	}
+	return result
+}
+
+func schemaStep(ins *domain.RecipeInstruction) krip.HowToStep {
+	step := krip.HowToStep{
+		Name:  deref(ins.Title),
+		Text:  ins.Text,
+		Url:   deref(ins.Url),
+		Video: deref(ins.VideoUrl),
+	}
+	if ins.ImagePath != nil {
+		step.Image = storage.AbsoluteUrl(string(*ins.ImagePath))
+	}
+	return step
+}
+
+// schemaYield returns the yield as written in the source, or composes it from the structured yield.
+func schemaYield(recipe *domain.Recipe) string {
+	if recipe.YieldText != nil {
+		return *recipe.YieldText
+	}
+	if recipe.Yield == nil {
+		return ""
+	}
+	yield := strconv.FormatFloat(*recipe.Yield, 'f', -1, 64)
+	if recipe.YieldMax != nil {
+		yield += "-" + strconv.FormatFloat(*recipe.YieldMax, 'f', -1, 64)
+	}
+	if recipe.YieldUnit != nil {
+		yield += " " + *recipe.YieldUnit
+	}
+	return yield
+}
+
+// jsonldRecipe decorates krip.Recipe with the JSON-LD @context and @type of each nested schema.org node.
+// Fields declared here shadow the embedded ones with the same JSON name.
+type jsonldRecipe struct {
+	Context string `json:"@context"`
+	Type    string `json:"@type"`
+	*krip.Recipe
+	Images       []jsonldImage    `json:"image,omitempty"`
+	Author       *jsonldPerson    `json:"author,omitempty"`
+	Publisher    *jsonldOrg       `json:"publisher,omitempty"`
+	Ingredients  []string         `json:"recipeIngredient,omitempty"`
+	Equipment    []jsonldTool     `json:"tool,omitempty"`
+	Instructions []jsonldSection  `json:"recipeInstructions,omitempty"`
+	Nutrition    *jsonldNutrition `json:"nutrition,omitempty"`
+	Rating       *jsonldRating    `json:"aggregateRating,omitempty"`
+	Video        *jsonldVideo     `json:"video,omitempty"`
+}
+
+type jsonldImage struct {
+	Type string `json:"@type"`
+	*krip.ImageObject
+}
+
+type jsonldPerson struct {
+	Type string `json:"@type"`
+	*krip.Person
+}
+
+type jsonldOrg struct {
+	Type string `json:"@type"`
+	*krip.Organization
+}
+
+type jsonldTool struct {
+	Type string `json:"@type"`
+	*krip.HowToTool
+}
+
+type jsonldStep struct {
+	Type string `json:"@type"`
+	*krip.HowToStep
+}
+
+type jsonldSection struct {
+	Type string `json:"@type"`
+	*krip.HowToStep
+	Steps []jsonldStep `json:"itemListElement,omitempty"`
+}
+
+type jsonldNutrition struct {
+	Type string `json:"@type"`
+	*krip.NutritionInformation
+}
+
+type jsonldRating struct {
+	Type string `json:"@type"`
+	*krip.AggregateRating
+}
+
+type jsonldVideo struct {
+	Type string `json:"@type"`
+	*krip.VideoObject
+}
+
+func marshalJSONLD(r *krip.Recipe) ([]byte, error) {
+	doc := jsonldRecipe{Context: "https://schema.org", Type: "Recipe", Recipe: r}
+	for _, image := range r.Images {
+		doc.Images = append(doc.Images, jsonldImage{Type: "ImageObject", ImageObject: image})
+	}
+	if r.Author != nil {
+		doc.Author = &jsonldPerson{Type: "Person", Person: r.Author}
+	}
+	if r.Publisher != nil {
+		doc.Publisher = &jsonldOrg{Type: "Organization", Organization: r.Publisher}
+	}
+	for _, ing := range r.Ingredients {
+		doc.Ingredients = append(doc.Ingredients, ing.Name)
+	}
+	for _, tool := range r.Equipment {
+		doc.Equipment = append(doc.Equipment, jsonldTool{Type: "HowToTool", HowToTool: tool})
+	}
+	for _, section := range r.Instructions {
+		if len(section.Steps) == 0 {
+			doc.Instructions = append(doc.Instructions, jsonldSection{Type: "HowToStep", HowToStep: &section.HowToStep})
+			continue
+		}
+		s := jsonldSection{Type: "HowToSection", HowToStep: &section.HowToStep}
+		for _, step := range section.Steps {
+			s.Steps = append(s.Steps, jsonldStep{Type: "HowToStep", HowToStep: step})
+		}
+		doc.Instructions = append(doc.Instructions, s)
+	}
+	if r.Nutrition != nil {
+		doc.Nutrition = &jsonldNutrition{Type: "NutritionInformation", NutritionInformation: r.Nutrition}
+	}
+	if r.Rating != nil {
+		doc.Rating = &jsonldRating{Type: "AggregateRating", AggregateRating: r.Rating}
+	}
+	if r.Video != nil {
+		doc.Video = &jsonldVideo{Type: "VideoObject", VideoObject: r.Video}
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"borscht.app/smetana/domain"
+)
+
+// textStyle decorates the blocks of a markdown or plain-text export.
+type textStyle struct {
+	title     func(string) string
+	heading   func(string) string
+	group     func(string) string
+	strong    func(string) string
+	separator string
+}
+
+var textStyles = map[domain.ExportFormat]textStyle{
+	domain.ExportFormatMarkdown: {
+		title:     func(s string) string { return "# " + s },
+		heading:   func(s string) string { return "## " + s },
+		group:     func(s string) string { return "### " + s },
+		strong:    func(s string) string { return "**" + s + "**" },
+		separator: "---",
+	},
+	domain.ExportFormatText: {
+		title:     func(s string) string { return s + "\n" + strings.Repeat("=", utf8.RuneCountInString(s)) },
+		heading:   strings.ToUpper,
+		group:     func(s string) string { return s + ":" },
+		strong:    func(s string) string { return s },
+		separator: strings.Repeat("-", 40),
+	},
+}
+
+// renderRecipeText renders title, yield and times, ingredients grouped by category,
+// numbered instructions under their section titles, and notes.
+func renderRecipeText(recipe *domain.Recipe, style textStyle) string {
+	outline := newRecipeOutline(recipe)
+
+	var blocks []string
+	blocks = append(blocks, style.title(outline.Title))
+	if outline.Description != "" {
+		blocks = append(blocks, outline.Description)
+	}
+
+	var meta []string
+	for _, item := range outline.Meta {
+		meta = append(meta, style.strong(item.Name)+" "+item.Text)
+	}
+	if len(meta) > 0 {
+		blocks = append(blocks, strings.Join(meta, " · "))
+	}
+
+	if len(outline.Ingredients) > 0 {
+		blocks = append(blocks, style.heading("Ingredients"))
+		for _, group := range outline.Ingredients {
+			var lines []string
+			if group.Name != "" {
+				lines = append(lines, style.group(group.Name))
+			}
+			for _, item := range group.Items {
+				lines = append(lines, "- "+item.Text)
+			}
+			blocks = append(blocks, strings.Join(lines, "\n"))
+		}
+	}
+
+	if len(outline.Instructions) > 0 {
+		blocks = append(blocks, style.heading("Instructions"))
+		for _, group := range outline.Instructions {
+			if group.Name != "" {
+				blocks = append(blocks, style.group(group.Name))
+			}
+			if group.Text != "" {
+				blocks = append(blocks, group.Text)
+			}
+			steps := make([]string, len(group.Items))
+			for i, item := range group.Items {
+				steps[i] = fmt.Sprintf("%d. %s", item.Number, stepText(item, style))
+			}
+			blocks = append(blocks, strings.Join(steps, "\n"))
+		}
+	}
+
+	if outline.Notes != "" {
+		blocks = append(blocks, style.heading("Notes"), outline.Notes)
+	}
+	if outline.Source != "" {
+		blocks = append(blocks, style.strong("Source:")+" "+outline.Source)
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+func stepText(step outlineItem, style textStyle) string {
+	if step.Name != "" {
+		return style.strong(step.Name) + " " + step.Text
+	}
+	return step.Text
+}
@@ -65,6 +65,17 @@ func (s *recipeIngestService) ImportRecipe(ctx context.Context, recipe *domain.R
 			inst.ID, _ = uuid.NewV7()
 		}
 	}
+	for _, inst := range recipe.Instructions {
+		if inst.Parent != nil {
+			inst.ParentID = &inst.Parent.ID
+			inst.Parent = nil
+		}
+	}
+	for _, ing := range recipe.Ingredients {
+		if ing.ID == uuid.Nil {
+			ing.ID, _ = uuid.NewV7()
+		}
+	}
 
 	// 1. Persist main images
 	s.processRecipeImages(ctx, recipe)
@@ -188,7 +199,17 @@ func (s *recipeIngestService) ImportRecipe(ctx context.Context, recipe *domain.R
 	// 7. Persist instruction images
 	s.processInstructionImages(ctx, recipe)
 
-	// 8. Save global recipe
+	// 8. Link instructions to the ingredients they mention, extract timers and temperatures
+	linkInstructionIngredients(recipe)
+	for _, inst := range recipe.Instructions {
+		timers := inst.Timers
+		parseInstructionMetadata(inst)
+		if len(timers) > 0 { // set by the source, e.g. Cooklang ~timers
+			inst.Timers = timers
+		}
+	}
+
+	// 9. Save global recipe
 	if err := s.recipeService.Import(recipe); err != nil {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -11,6 +12,7 @@ import (
 
 	"borscht.app/smetana/domain"
 	"borscht.app/smetana/internal/services"
+	"borscht.app/smetana/internal/types"
 )
 
 type recipeIngestDeps struct {
@@ -219,3 +221,51 @@ func TestRecipeIngestService_ImportRecipe_UnitError_NilsUnit(t *testing.T) {
 	assert.Nil(t, result.Ingredients[0].Unit)
 	assert.Nil(t, result.Ingredients[0].UnitID)
 }
+
+func TestRecipeIngestService_ImportRecipe_LinksInstructionsToMentionedIngredients(t *testing.T) {
+	flour := &domain.RecipeIngredient{Name: ptr("all-purpose flour"), Food: &domain.Food{Name: "flour", Slug: "flour"}}
+	egg := &domain.RecipeIngredient{Name: ptr("egg")}
+	oliveOil := &domain.RecipeIngredient{Name: ptr("olive oil")}
+	oil := &domain.RecipeIngredient{Name: ptr("oil")}
+	recipe := &domain.Recipe{
+		Ingredients: []*domain.RecipeIngredient{flour, egg, oliveOil, oil},
+		Instructions: []*domain.RecipeInstruction{
+			{Text: "Whisk the Flour with 2 eggs."},
+			{Text: "Fry in olive oil until golden."},
+			{Text: "Slice the eggplant."},
+		},
+	}
+	foodService := &stubFoodService{
+		findOrCreateFn: func(_ context.Context, f *domain.Food) error {
+			f.ID = uuid.New()
+			return nil
+		},
+	}
+
+	svc := newTestRecipeIngestService(recipeIngestDeps{foodService: foodService})
+	result, err := svc.ImportRecipe(context.Background(), recipe)
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*domain.RecipeIngredient{flour, egg}, result.Instructions[0].Ingredients)
+	assert.Equal(t, []*domain.RecipeIngredient{oliveOil}, result.Instructions[1].Ingredients, "a longer name must consume the text it matched")
+	assert.Empty(t, result.Instructions[2].Ingredients, "names must only match whole words")
+	assert.NotEqual(t, uuid.Nil, flour.ID, "ingredients need an ID before the links are persisted")
+}
+
+func TestRecipeIngestService_ImportRecipe_KeepsTimersSetBySource(t *testing.T) {
+	recipe := &domain.Recipe{
+		Instructions: []*domain.RecipeInstruction{
+			{Text: "Rest the dough.", Timers: []domain.InstructionTimer{{Duration: types.Duration(30 * time.Minute), Text: "30 minutes"}}},
+			{Text: "Bake for 20 minutes at 200°C."},
+		},
+	}
+
+	svc := newTestRecipeIngestService(recipeIngestDeps{})
+	result, err := svc.ImportRecipe(context.Background(), recipe)
+
+	require.NoError(t, err)
+	require.Len(t, result.Instructions[0].Timers, 1)
+	assert.Equal(t, types.Duration(30*time.Minute), result.Instructions[0].Timers[0].Duration)
+	require.Len(t, result.Instructions[1].Timers, 1, "timers are still parsed from the text when the source has none")
+	assert.Len(t, result.Instructions[1].Temperatures, 1)
+}
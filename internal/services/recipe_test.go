@@ -2,6 +2,7 @@ package services_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -10,6 +11,7 @@ import (
 	"borscht.app/smetana/domain"
 	"borscht.app/smetana/internal/sentinels"
 	"borscht.app/smetana/internal/services"
+	"borscht.app/smetana/internal/storage"
 	"borscht.app/smetana/internal/types"
 )
 
@@ -32,6 +34,11 @@ func newTestRecipeService(deps recipeServiceDeps) domain.RecipeService {
 	if deps.unitService == nil {
 		deps.unitService = &stubUnitService{}
 	}
+	if deps.userRepo == nil {
+		deps.userRepo = &stubUserRepo{byIDFn: func(id uuid.UUID) (*domain.User, error) {
+			return &domain.User{ID: id, UnitSystem: domain.UnitSystemMetric}, nil
+		}}
+	}
 	return services.NewRecipeService(deps.repo, deps.userRepo, deps.imgService, deps.foodService, deps.unitService)
 }
 
@@ -93,6 +100,9 @@ func TestRecipeService_Update_GlobalRecipe_ClonesBeforeUpdate(t *testing.T) {
 
 	repo := &stubRecipeRepo{
 		byIDFn: func(_ uuid.UUID) (*domain.Recipe, error) { return global, nil },
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return global, nil
+		},
 		transactionFn: func(fn func(domain.RecipeRepository) error) error {
 			return fn(&stubRecipeRepo{
 				createFn: func(r *domain.Recipe) error {
@@ -149,6 +159,9 @@ func TestRecipeService_Update_GlobalRecipe_DeepClonesIngredientsAndInstructions(
 	var cloned *domain.Recipe
 	repo := &stubRecipeRepo{
 		byIDFn: func(_ uuid.UUID) (*domain.Recipe, error) { return global, nil },
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return global, nil
+		},
 		transactionFn: func(fn func(domain.RecipeRepository) error) error {
 			return fn(&stubRecipeRepo{
 				createFn: func(r *domain.Recipe) error {
@@ -181,6 +194,92 @@ func TestRecipeService_Update_GlobalRecipe_DeepClonesIngredientsAndInstructions(
 	assert.NotSame(t, global.Instructions[0], cloned.Instructions[0], "instruction must be a deep copy")
 }
 
+func TestRecipeService_Update_GlobalRecipe_RemapsInstructionIngredientLinks(t *testing.T) {
+	globalIngredient := &domain.RecipeIngredient{ID: uuid.New(), RawText: "200 g flour"}
+	global := &domain.Recipe{
+		ID:          uuid.New(),
+		Ingredients: []*domain.RecipeIngredient{globalIngredient},
+		Instructions: []*domain.RecipeInstruction{
+			{ID: uuid.New(), Text: "Sift the flour", Ingredients: []*domain.RecipeIngredient{globalIngredient}},
+		},
+	}
+
+	var cloned *domain.Recipe
+	repo := &stubRecipeRepo{
+		byIDFn: func(_ uuid.UUID) (*domain.Recipe, error) { return global, nil },
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return global, nil
+		},
+		transactionFn: func(fn func(domain.RecipeRepository) error) error {
+			return fn(&stubRecipeRepo{
+				createFn: func(r *domain.Recipe) error {
+					cloned = r
+					return nil
+				},
+				replaceRecipePointersFn: func(_, _, _ uuid.UUID) error { return nil },
+			})
+		},
+		updateFn: func(_ *domain.Recipe) error { return nil },
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	require.NoError(t, svc.Update(&domain.Recipe{ID: global.ID}, uuid.New(), uuid.New()))
+
+	require.NotNil(t, cloned)
+	require.Len(t, cloned.Instructions[0].Ingredients, 1)
+	assert.Same(t, cloned.Ingredients[0], cloned.Instructions[0].Ingredients[0], "links must point at the cloned ingredient")
+	assert.Equal(t, []*domain.RecipeIngredient{globalIngredient}, global.Instructions[0].Ingredients, "the global recipe must not be modified")
+}
+
+func TestRecipeService_Update_GlobalRecipe_CopiesNutrition(t *testing.T) {
+	nutrition := &domain.RecipeNutrition{RecipeID: uuid.New(), ServingSize: "1 bowl", Calories: ptr(250.0)}
+	global := &domain.Recipe{ID: nutrition.RecipeID, Name: ptr("Borscht"), Nutrition: nutrition}
+
+	var preload types.PreloadOptions
+	var cloned *domain.Recipe
+	repo := &stubRecipeRepo{
+		byIDFn: func(_ uuid.UUID) (*domain.Recipe, error) { return global, nil },
+		byIDPreloadFn: func(_, _, _ uuid.UUID, p types.PreloadOptions) (*domain.Recipe, error) {
+			preload = p
+			return global, nil
+		},
+		transactionFn: func(fn func(domain.RecipeRepository) error) error {
+			return fn(&stubRecipeRepo{
+				createFn: func(r *domain.Recipe) error {
+					cloned = r
+					return nil
+				},
+				replaceRecipePointersFn: func(_, _, _ uuid.UUID) error { return nil },
+			})
+		},
+		updateFn: func(_ *domain.Recipe) error { return nil },
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	require.NoError(t, svc.Update(&domain.Recipe{ID: global.ID, Name: ptr("My Borscht")}, uuid.New(), uuid.New()))
+
+	assert.True(t, preload.Has("nutrition") && preload.Has("ingredients") && preload.Has("instructions"), "the associations to copy are loaded")
+	require.NotNil(t, cloned)
+	require.NotNil(t, cloned.Nutrition)
+	assert.NotSame(t, nutrition, cloned.Nutrition, "nutrition must be a copy")
+	assert.Equal(t, uuid.Nil, cloned.Nutrition.RecipeID, "the copy is created with the clone")
+	assert.Equal(t, "1 bowl", cloned.Nutrition.ServingSize)
+	assert.Equal(t, global.ID, nutrition.RecipeID, "the global recipe must not be modified")
+	assert.Equal(t, "Borscht", *global.Name)
+}
+
+func TestRecipeService_LinkInstructionIngredient_OtherHousehold_ReturnsForbidden(t *testing.T) {
+	otherHID := uuid.New()
+	repo := &stubRecipeRepo{
+		byIDFn: func(id uuid.UUID) (*domain.Recipe, error) { return &domain.Recipe{ID: id, HouseholdID: &otherHID}, nil },
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	err := svc.LinkInstructionIngredient(uuid.New(), uuid.New(), uuid.New(), uuid.New())
+
+	require.ErrorIs(t, err, sentinels.ErrForbidden)
+}
+
 func TestRecipeService_Update_GlobalRecipe_SharesAssociations(t *testing.T) {
 	globalID := uuid.New()
 	myHID := uuid.New()
@@ -203,6 +302,9 @@ func TestRecipeService_Update_GlobalRecipe_SharesAssociations(t *testing.T) {
 	var cloned *domain.Recipe
 	repo := &stubRecipeRepo{
 		byIDFn: func(_ uuid.UUID) (*domain.Recipe, error) { return global, nil },
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return global, nil
+		},
 		transactionFn: func(fn func(domain.RecipeRepository) error) error {
 			return fn(&stubRecipeRepo{
 				createFn: func(r *domain.Recipe) error {
@@ -245,6 +347,9 @@ func TestRecipeService_Update_GlobalRecipe_ClearsSpecificFields(t *testing.T) {
 	var cloned *domain.Recipe
 	repo := &stubRecipeRepo{
 		byIDFn: func(_ uuid.UUID) (*domain.Recipe, error) { return global, nil },
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return global, nil
+		},
 		transactionFn: func(fn func(domain.RecipeRepository) error) error {
 			return fn(&stubRecipeRepo{
 				createFn: func(r *domain.Recipe) error {
@@ -280,6 +385,9 @@ func TestRecipeService_Update_GlobalRecipe_TransactionFailure(t *testing.T) {
 
 	repo := &stubRecipeRepo{
 		byIDFn: func(_ uuid.UUID) (*domain.Recipe, error) { return global, nil },
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return global, nil
+		},
 		transactionFn: func(fn func(domain.RecipeRepository) error) error {
 			return fn(&stubRecipeRepo{
 				createFn:                func(_ *domain.Recipe) error { return nil },
@@ -302,7 +410,7 @@ func TestRecipeService_EstimatePrice_CalculatesTotal(t *testing.T) {
 
 	recipe := &domain.Recipe{
 		ID:    uuid.New(),
-		Yield: ptr(4),
+		Yield: ptr(4.0),
 		Ingredients: []*domain.RecipeIngredient{
 			{FoodID: &foodID1, Amount: ptr(200.0), UnitID: &unitID},
 			{FoodID: &foodID2, Amount: ptr(500.0), UnitID: &unitID},
@@ -344,7 +452,7 @@ func TestRecipeService_EstimatePrice_CalculatesTotal(t *testing.T) {
 	assert.InDelta(t, 3.0, *estimate.PerServing, 0.01)
 }
 
-func TestRecipeService_Delete_OwnedByHousehold_DeletesImagesAndRepoRecord(t *testing.T) {
+func TestRecipeService_Delete_OwnedByHousehold_SoftDeletesAndKeepsImages(t *testing.T) {
 	hid := uuid.New()
 	recipeID := uuid.New()
 	imageID := uuid.New()
@@ -368,9 +476,8 @@ func TestRecipeService_Delete_OwnedByHousehold_DeletesImagesAndRepoRecord(t *tes
 	}
 
 	imgSvc := &stubImageService{
-		deleteFn: func(id uuid.UUID) error {
+		deleteFn: func(_ uuid.UUID) error {
 			imageDeleted = true
-			assert.Equal(t, imageID, id)
 			return nil
 		},
 	}
@@ -380,39 +487,494 @@ func TestRecipeService_Delete_OwnedByHousehold_DeletesImagesAndRepoRecord(t *tes
 
 	require.NoError(t, err)
 	assert.True(t, repoDeleted)
-	assert.True(t, imageDeleted, "images of a household recipe must be deleted from storage")
+	assert.False(t, imageDeleted, "images must survive until the recipe is purged from the trash")
 }
 
-func TestRecipeService_Delete_GlobalRecipeClone_DoesNotDeleteSharedImages(t *testing.T) {
-	// When deleting a household's clone of a global recipe, we must NOT delete
-	// the images because they are shared with the global recipe and potentially other clones.
+func TestRecipeService_Delete_OtherHousehold_ReturnsForbidden(t *testing.T) {
 	hid := uuid.New()
-	recipeID := uuid.New()
-	parentID := uuid.New()
-	imageID := uuid.New()
+	otherHid := uuid.New()
+	recipe := &domain.Recipe{ID: uuid.New(), HouseholdID: &otherHid}
+
+	repo := &stubRecipeRepo{
+		byIDFn: func(_ uuid.UUID) (*domain.Recipe, error) { return recipe, nil },
+		deleteFn: func(_ uuid.UUID) error {
+			t.Fatal("repo.Delete must not be called for a foreign recipe")
+			return nil
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	err := svc.Delete(recipe.ID, hid)
 
+	require.ErrorIs(t, err, sentinels.ErrForbidden)
+}
+
+func TestRecipeService_ExpandIngredients_ReplacesSubRecipeWithScaledIngredients(t *testing.T) {
+	hid := uuid.New()
+	sauce := &domain.Recipe{
+		ID:   uuid.New(),
+		Name: ptr("Tomato Sauce"),
+		Ingredients: []*domain.RecipeIngredient{
+			{RawText: "400 g tomatoes", Amount: ptr(400.0)},
+		},
+	}
 	recipe := &domain.Recipe{
-		ID:          recipeID,
-		ParentID:    &parentID, // it's a clone
-		HouseholdID: &hid,
-		Images:      []*domain.Image{{ID: imageID}},
+		ID:    uuid.New(),
+		Yield: ptr(2.0),
+		Ingredients: []*domain.RecipeIngredient{
+			{RawText: "300 g flour", Amount: ptr(300.0)},
+			{RawText: "2 batches tomato sauce", Amount: ptr(2.0), SubRecipeID: &sauce.ID, SubRecipe: sauce},
+		},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_ uuid.UUID, _, _ uuid.UUID, p types.PreloadOptions) (*domain.Recipe, error) {
+			assert.True(t, p.Has("subrecipes"))
+			return recipe, nil
+		},
 	}
 
-	imageDeleted := false
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	got, err := svc.ExpandIngredients(recipe.ID, hid, domain.RecipeScale{Servings: 4})
+
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.InDelta(t, 600.0, *got[0].Amount, 0.001)
+	assert.InDelta(t, 1600.0, *got[1].Amount, 0.001) // 400 g × 2 batches × 2
+	assert.Equal(t, "Tomato Sauce", *got[1].Category)
+	assert.InDelta(t, 400.0, *sauce.Ingredients[0].Amount, 0.001, "expansion must not mutate the loaded recipe")
+}
+
+func TestRecipeService_CreateIngredient_SelfAsSubRecipe_ReturnsBadRequest(t *testing.T) {
+	hid := uuid.New()
+	recipeID := uuid.New()
 	repo := &stubRecipeRepo{
-		byIDFn:   func(_ uuid.UUID) (*domain.Recipe, error) { return recipe, nil },
-		deleteFn: func(_ uuid.UUID) error { return nil },
+		byIDFn: func(id uuid.UUID) (*domain.Recipe, error) { return &domain.Recipe{ID: id, HouseholdID: &hid}, nil },
 	}
-	imgSvc := &stubImageService{
-		deleteFn: func(_ uuid.UUID) error {
-			imageDeleted = true
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	err := svc.CreateIngredient(&domain.RecipeIngredient{RecipeID: recipeID, SubRecipeID: &recipeID, RawText: "itself"}, hid)
+
+	var sentinel *sentinels.Error
+	require.ErrorAs(t, err, &sentinel)
+	assert.Equal(t, 400, sentinel.Status)
+}
+
+func TestRecipeService_CreateIngredient_SubRecipeCycle_ReturnsBadRequest(t *testing.T) {
+	hid := uuid.New()
+	recipeID := uuid.New()
+	subID := uuid.New()
+	repo := &stubRecipeRepo{
+		byIDFn: func(id uuid.UUID) (*domain.Recipe, error) { return &domain.Recipe{ID: id, HouseholdID: &hid}, nil },
+		subRecipeIDsFn: func(ids []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+			// the sub-recipe already uses the recipe itself
+			return map[uuid.UUID][]uuid.UUID{subID: {recipeID}}, nil
+		},
+		createIngredientFn: func(_ *domain.RecipeIngredient) error {
+			t.Fatal("a cyclic sub-recipe must not be persisted")
 			return nil
 		},
 	}
 
-	svc := newTestRecipeService(recipeServiceDeps{repo: repo, imgService: imgSvc})
-	err := svc.Delete(recipeID, hid)
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	err := svc.CreateIngredient(&domain.RecipeIngredient{RecipeID: recipeID, SubRecipeID: &subID, RawText: "cycle"}, hid)
+
+	var sentinel *sentinels.Error
+	require.ErrorAs(t, err, &sentinel)
+	assert.Equal(t, 400, sentinel.Status)
+}
+
+func TestRecipeService_CreateIngredient_SubRecipeOfOtherHousehold_ReturnsForbidden(t *testing.T) {
+	hid := uuid.New()
+	otherHid := uuid.New()
+	recipeID := uuid.New()
+	subID := uuid.New()
+	repo := &stubRecipeRepo{
+		byIDFn: func(id uuid.UUID) (*domain.Recipe, error) {
+			if id == subID {
+				return &domain.Recipe{ID: id, HouseholdID: &otherHid}, nil
+			}
+			return &domain.Recipe{ID: id, HouseholdID: &hid}, nil
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	err := svc.CreateIngredient(&domain.RecipeIngredient{RecipeID: recipeID, SubRecipeID: &subID, RawText: "foreign"}, hid)
+
+	require.ErrorIs(t, err, sentinels.ErrForbidden)
+}
+
+func TestRecipeService_EstimatePrice_IncludesSubRecipeIngredients(t *testing.T) {
+	hid := uuid.New()
+	foodID := uuid.New()
+	unitID := uuid.New()
+	sauce := &domain.Recipe{
+		ID:          uuid.New(),
+		Ingredients: []*domain.RecipeIngredient{{FoodID: &foodID, Amount: ptr(500.0), UnitID: &unitID}},
+	}
+	recipe := &domain.Recipe{
+		ID:          uuid.New(),
+		Ingredients: []*domain.RecipeIngredient{{SubRecipeID: &sauce.ID, SubRecipe: sauce, Amount: ptr(2.0)}},
+	}
+
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_ uuid.UUID, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return recipe, nil
+		},
+	}
+	foodSvc := &stubFoodService{
+		latestPricesFn: func(_ uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]*domain.FoodPrice, error) {
+			assert.Equal(t, []uuid.UUID{foodID}, ids)
+			return map[uuid.UUID]*domain.FoodPrice{foodID: {FoodID: foodID, Amount: 1000, Price: 4, UnitID: unitID}}, nil
+		},
+	}
+	unitSvc := &stubUnitService{
+		convertFn: func(amount float64, _, _ uuid.UUID) (float64, error) { return amount, nil },
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo, foodService: foodSvc, unitService: unitSvc})
+	estimate, err := svc.EstimatePrice(recipe.ID, hid)
 
 	require.NoError(t, err)
-	assert.False(t, imageDeleted, "shared images must not be deleted when a clone is removed")
+	assert.InDelta(t, 4.0, estimate.Total, 0.01) // 2 batches × 500 g at 4 per kg
+}
+
+func TestRecipeService_UpdateInstruction_ParsesTimersAndTemperatures(t *testing.T) {
+	tests := []struct {
+		text         string
+		timers       []domain.InstructionTimer
+		temperatures []domain.Temperature
+	}{
+		{
+			text:   "Simmer for 20–25 minutes, stirring occasionally.",
+			timers: []domain.InstructionTimer{{Duration: types.Duration(20 * time.Minute), MaxDuration: ptr(types.Duration(25 * time.Minute)), Text: "20–25 minutes"}},
+		},
+		{
+			text:         "Bake at 180°C / 350°F for 1 hour 15 minutes.",
+			timers:       []domain.InstructionTimer{{Duration: types.Duration(75 * time.Minute), Text: "1 hour 15 minutes"}},
+			temperatures: []domain.Temperature{{Value: 180, Unit: domain.Celsius}},
+		},
+		{
+			text:         "Preheat the oven to 400 degrees F. Rest for 30 mins.",
+			timers:       []domain.InstructionTimer{{Duration: types.Duration(30 * time.Minute), Text: "30 mins"}},
+			temperatures: []domain.Temperature{{Value: 400, Unit: domain.Fahrenheit}},
+		},
+		{
+			text:         "Bake at 350 degrees until golden.",
+			temperatures: []domain.Temperature{{Value: 350, Unit: domain.Fahrenheit}},
+		},
+		{
+			text: "Turn the dough 90 degrees and fold it again.",
+		},
+		{
+			text: "Wait a second, then rest the dough for half an hour.",
+		},
+		{
+			text:         "Roast at 200-220C, then lower the heat to 160°.",
+			temperatures: []domain.Temperature{{Value: 200, MaxValue: ptr(220.0), Unit: domain.Celsius}, {Value: 160, Unit: domain.Celsius}},
+		},
+		{
+			text: "Add 2 cups of flour and 3 eggs.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			hid := uuid.New()
+			var persisted *domain.RecipeInstruction
+			repo := &stubRecipeRepo{
+				byIDFn: func(id uuid.UUID) (*domain.Recipe, error) { return &domain.Recipe{ID: id, HouseholdID: &hid}, nil },
+				updateInstructionFn: func(i *domain.RecipeInstruction) error {
+					persisted = i
+					return nil
+				},
+			}
+
+			svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+			err := svc.UpdateInstruction(&domain.RecipeInstruction{ID: uuid.New(), RecipeID: uuid.New(), Text: tt.text}, hid)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.timers, persisted.Timers)
+			assert.Equal(t, tt.temperatures, persisted.Temperatures)
+		})
+	}
+}
+
+func TestRecipeService_ByIDPreload_ImperialUser_ConvertsTemperatures(t *testing.T) {
+	uid := uuid.New()
+	recipe := &domain.Recipe{
+		ID: uuid.New(),
+		Instructions: []*domain.RecipeInstruction{
+			{Text: "Bake at 180°C", Temperatures: []domain.Temperature{{Value: 180, Unit: domain.Celsius}}},
+		},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_ uuid.UUID, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return recipe, nil
+		},
+	}
+	userRepo := &stubUserRepo{byIDFn: func(id uuid.UUID) (*domain.User, error) {
+		assert.Equal(t, uid, id)
+		return &domain.User{ID: id, UnitSystem: domain.UnitSystemImperial}, nil
+	}}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo, userRepo: userRepo})
+	got, err := svc.ByIDPreload(recipe.ID, uid, uuid.New(), types.Preload("instructions"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Temperature{{Value: 355, Unit: domain.Fahrenheit}}, got.Instructions[0].Temperatures)
+}
+
+func TestRecipeService_Search_ImperialUser_ConvertsTemperatures(t *testing.T) {
+	uid := uuid.New()
+	recipes := []domain.Recipe{
+		{ID: uuid.New()},
+		{ID: uuid.New(), Instructions: []*domain.RecipeInstruction{
+			{Text: "Bake at 180°C", Temperatures: []domain.Temperature{{Value: 180, Unit: domain.Celsius}}},
+		}},
+	}
+	repo := &stubRecipeRepo{
+		searchFn: func(_, _ uuid.UUID, _ domain.RecipeSearchOptions) ([]domain.Recipe, int64, error) {
+			return recipes, int64(len(recipes)), nil
+		},
+	}
+	lookups := 0
+	userRepo := &stubUserRepo{byIDFn: func(id uuid.UUID) (*domain.User, error) {
+		lookups++
+		return &domain.User{ID: id, UnitSystem: domain.UnitSystemImperial}, nil
+	}}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo, userRepo: userRepo})
+	got, _, err := svc.Search(uid, uuid.New(), domain.RecipeSearchOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Temperature{{Value: 355, Unit: domain.Fahrenheit}}, got[1].Instructions[0].Temperatures)
+	assert.Equal(t, 1, lookups, "the unit system is loaded once for the page")
+}
+
+func TestRecipeService_ExpandIngredients_AmountInYieldUnit_ScalesSubRecipeByYield(t *testing.T) {
+	unitID := uuid.New()
+	stock := &domain.Recipe{
+		ID:        uuid.New(),
+		Yield:     ptr(1000.0),
+		YieldUnit: ptr("ml"),
+		Ingredients: []*domain.RecipeIngredient{
+			{RawText: "2 onions", Amount: ptr(2.0)},
+		},
+	}
+	recipe := &domain.Recipe{
+		ID: uuid.New(),
+		Ingredients: []*domain.RecipeIngredient{
+			{RawText: "250 ml stock", Amount: ptr(250.0), UnitID: &unitID, Unit: &domain.Unit{ID: unitID, Slug: "ml", Name: "milliliter"}, SubRecipeID: &stock.ID, SubRecipe: stock},
+		},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_ uuid.UUID, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return recipe, nil
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	got, err := svc.ExpandIngredients(recipe.ID, uuid.New(), domain.RecipeScale{Factor: 1})
+
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.InDelta(t, 0.5, *got[0].Amount, 0.001) // a quarter of the stock
+}
+
+func TestRecipeService_ExpandIngredients_ServingsOfNonServingYield_ScalesByYieldQuantity(t *testing.T) {
+	recipe := &domain.Recipe{
+		ID:        uuid.New(),
+		Yield:     ptr(12.0),
+		YieldUnit: ptr("cookies"),
+		Ingredients: []*domain.RecipeIngredient{
+			{RawText: "200 g flour", Amount: ptr(200.0)},
+		},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_ uuid.UUID, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return recipe, nil
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	got, err := svc.ExpandIngredients(recipe.ID, uuid.New(), domain.RecipeScale{Servings: 36})
+
+	require.NoError(t, err)
+	assert.InDelta(t, 600.0, *got[0].Amount, 0.001)
+}
+
+func TestRecipeService_EstimatePrice_YieldNotInServings_NoPerServing(t *testing.T) {
+	foodID := uuid.New()
+	unitID := uuid.New()
+	recipe := &domain.Recipe{
+		ID:          uuid.New(),
+		Yield:       ptr(2.0),
+		YieldUnit:   ptr("loaves"),
+		Ingredients: []*domain.RecipeIngredient{{FoodID: &foodID, Amount: ptr(1000.0), UnitID: &unitID}},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_ uuid.UUID, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return recipe, nil
+		},
+	}
+	foodSvc := &stubFoodService{
+		latestPricesFn: func(_ uuid.UUID, _ []uuid.UUID) (map[uuid.UUID]*domain.FoodPrice, error) {
+			return map[uuid.UUID]*domain.FoodPrice{foodID: {FoodID: foodID, Amount: 1000, Price: 2, UnitID: unitID}}, nil
+		},
+	}
+	unitSvc := &stubUnitService{
+		convertFn: func(amount float64, _, _ uuid.UUID) (float64, error) { return amount, nil },
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo, foodService: foodSvc, unitService: unitSvc})
+	estimate, err := svc.EstimatePrice(recipe.ID, uuid.New())
+
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, estimate.Total, 0.01)
+	assert.Nil(t, estimate.PerServing, "a price per loaf is not a price per serving")
+}
+
+func TestRecipeService_Export_JSONLD(t *testing.T) {
+	storage.SetDefault(storage.NewLocalStorage(t.TempDir(), "https://cdn.example.com/"))
+	t.Cleanup(func() { storage.SetDefault(nil) })
+
+	sectionID := uuid.New()
+	recipe := &domain.Recipe{
+		ID:        uuid.New(),
+		Name:      ptr("Pasta al pomodoro"),
+		PrepTime:  new(types.Duration(10 * time.Minute)),
+		TotalTime: new(types.Duration(90 * time.Minute)),
+		Yield:     ptr(4.0),
+		Images:    []*domain.Image{{Path: new(storage.Path("recipes/pasta.jpg"))}},
+		Author:    &domain.Author{Name: "Jane Doe"},
+		Publisher: &domain.Publisher{Name: "Example Kitchen"},
+		Nutrition: &domain.RecipeNutrition{Calories: ptr(520.0)},
+		Ingredients: []*domain.RecipeIngredient{
+			{RawText: "400 g spaghetti"},
+		},
+		Instructions: []*domain.RecipeInstruction{
+			{ID: sectionID, Order: 0, Title: ptr("For the sauce")},
+			{ID: uuid.New(), Order: 1, ParentID: &sectionID, Text: "Fry the garlic."},
+			{ID: uuid.New(), Order: 2, Text: "Serve."},
+		},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_, _, _ uuid.UUID, preload types.PreloadOptions) (*domain.Recipe, error) {
+			assert.True(t, preload.Has("all"))
+			return recipe, nil
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	data, err := svc.Export(recipe.ID, uuid.New(), uuid.New(), domain.ExportOptions{Format: domain.ExportFormatJSONLD})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@context": "https://schema.org",
+		"@type": "Recipe",
+		"name": "Pasta al pomodoro",
+		"image": [{"@type": "ImageObject", "url": "https://cdn.example.com/recipes/pasta.jpg"}],
+		"author": {"@type": "Person", "name": "Jane Doe"},
+		"publisher": {"@type": "Organization", "name": "Example Kitchen"},
+		"prepTime": "PT10M",
+		"totalTime": "PT1H30M",
+		"recipeYield": "4",
+		"recipeIngredient": ["400 g spaghetti"],
+		"recipeInstructions": [
+			{"@type": "HowToSection", "name": "For the sauce", "itemListElement": [{"@type": "HowToStep", "text": "Fry the garlic."}]},
+			{"@type": "HowToStep", "text": "Serve."}
+		],
+		"nutrition": {"@type": "NutritionInformation", "calories": 520}
+	}`, string(data))
+}
+
+func TestRecipeService_Export_UnknownFormat_ReturnsBadRequest(t *testing.T) {
+	svc := newTestRecipeService(recipeServiceDeps{repo: &stubRecipeRepo{}})
+	_, err := svc.Export(uuid.New(), uuid.New(), uuid.New(), domain.ExportOptions{Format: "docx"})
+
+	var sErr *sentinels.Error
+	require.ErrorAs(t, err, &sErr)
+	assert.Equal(t, 400, sErr.Status)
+}
+
+func TestRecipeService_Export_Markdown_ScalesAndConvertsUnits(t *testing.T) {
+	gram := &domain.Unit{ID: uuid.New(), Name: "g", BaseFactor: 1}
+	ounce := &domain.Unit{ID: uuid.New(), Name: "oz", Imperial: true, BaseFactor: 28.35}
+	sectionID := uuid.New()
+	recipe := &domain.Recipe{
+		ID:        uuid.New(),
+		Name:      ptr("Pancakes"),
+		Yield:     ptr(4.0),
+		YieldText: ptr("Serves 4"),
+		PrepTime:  new(types.Duration(10 * time.Minute)),
+		Text:      ptr("Best eaten warm."),
+		Ingredients: []*domain.RecipeIngredient{
+			{Amount: ptr(200.0), UnitID: &gram.ID, Unit: gram, Name: ptr("flour"), RawText: "200 g flour"},
+			{Amount: ptr(2.0), Name: ptr("eggs"), RawText: "2 eggs"},
+			{RawText: "maple syrup, to serve", Category: ptr("For the topping")},
+		},
+		Instructions: []*domain.RecipeInstruction{
+			{ID: sectionID, Order: 0, Title: ptr("Batter")},
+			{ID: uuid.New(), Order: 1, ParentID: &sectionID, Text: "Whisk everything."},
+			{ID: uuid.New(), Order: 2, Text: "Fry at 180°C until golden."},
+		},
+	}
+	repo := &stubRecipeRepo{
+		byIDPreloadFn: func(_, _, _ uuid.UUID, _ types.PreloadOptions) (*domain.Recipe, error) {
+			return recipe, nil
+		},
+	}
+	unitService := &stubUnitService{
+		bestUnitFn: func(_ float64, from uuid.UUID, imperial bool) (*domain.Unit, error) {
+			assert.Equal(t, gram.ID, from)
+			assert.True(t, imperial)
+			return ounce, nil
+		},
+		convertFn: func(amount float64, _, _ uuid.UUID) (float64, error) {
+			return amount / ounce.BaseFactor, nil
+		},
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo, unitService: unitService})
+	data, err := svc.Export(recipe.ID, uuid.New(), uuid.New(), domain.ExportOptions{
+		Format:     domain.ExportFormatMarkdown,
+		Scale:      domain.RecipeScale{Servings: 8},
+		UnitSystem: new(domain.UnitSystemImperial),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, `# Pancakes
+
+**Yield:** 8 · **Prep:** 10 min
+
+## Ingredients
+
+- 14 oz flour
+- 4 eggs
+
+### For the topping
+- maple syrup, to serve
+
+## Instructions
+
+### Batter
+
+1. Whisk everything.
+
+1. Fry at 180°C (355°F) until golden.
+
+## Notes
+
+Best eaten warm.
+`, string(data))
+}
+
+func TestRecipeService_Similar_OtherHousehold_ReturnsForbidden(t *testing.T) {
+	other := uuid.New()
+	repo := &stubRecipeRepo{
+		byIDFn: func(id uuid.UUID) (*domain.Recipe, error) { return &domain.Recipe{ID: id, HouseholdID: &other}, nil },
+	}
+
+	svc := newTestRecipeService(recipeServiceDeps{repo: repo})
+	_, err := svc.Similar(uuid.New(), uuid.New(), 10)
+	assert.ErrorIs(t, err, sentinels.ErrForbidden)
 }
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"borscht.app/smetana/domain"
+)
+
+var (
+	// yieldRe matches "4", "12-16 cookies", "Makes 2 x 20cm cakes" and "Serves 4 (as a main)".
+	yieldRe = regexp.MustCompile(`(?i)^\s*(serves|servings:?|makes|yields?:?|about)?\s*(\d+(?:[.,]\d+)?)(?:\s*(?:-|–|—|to)\s*(\d+(?:[.,]\d+)?))?\s*(?:x\s+)?([^(]*)`)
+	// servingWords are yield units that count servings.
+	servingWords = map[string]bool{
+		"serving": true, "servings": true, "portion": true, "portions": true,
+		"person": true, "persons": true, "people": true, "pax": true,
+	}
+)
+
+// applyYield sets the structured yield of a recipe from the yield as written in the source. The raw text is
+// always kept, the quantity and unit only when the text starts with a number.
+func applyYield(recipe *domain.Recipe, raw string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return
+	}
+	recipe.YieldText = &raw
+
+	m := yieldRe.FindStringSubmatch(raw)
+	if m == nil {
+		return
+	}
+	quantity, err := strconv.ParseFloat(strings.Replace(m[2], ",", ".", 1), 64)
+	if err != nil || quantity <= 0 {
+		return
+	}
+	recipe.Yield = &quantity
+	if m[3] != "" {
+		if maxQuantity, err := strconv.ParseFloat(strings.Replace(m[3], ",", ".", 1), 64); err == nil && maxQuantity > quantity {
+			recipe.YieldMax = &maxQuantity
+		}
+	}
+
+	unit := strings.Trim(m[4], " \t.,;:")
+	if unit == "" || strings.EqualFold(m[1], "serves") || strings.HasPrefix(strings.ToLower(m[1]), "servings") {
+		return
+	}
+	if first, _, _ := strings.Cut(strings.ToLower(unit), " "); servingWords[first] {
+		return
+	}
+	recipe.YieldUnit = &unit
+}
+
+// yieldMatchesUnit reports whether an ingredient unit is the unit a recipe yield is counted in,
+// e.g. "500 ml" of a sub-recipe that yields "1000 ml".
+func yieldMatchesUnit(recipe *domain.Recipe, unit *domain.Unit) bool {
+	if recipe.YieldUnit == nil || unit == nil {
+		return false
+	}
+	yieldUnit := strings.ToLower(*recipe.YieldUnit)
+	name := strings.ToLower(unit.Name)
+	return yieldUnit == strings.ToLower(unit.Slug) || yieldUnit == name || yieldUnit == name+"s"
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+)
+
+type scrapeRuleService struct {
+	repo           domain.ScrapeRuleRepository
+	scraperService domain.ScraperService
+}
+
+func NewScrapeRuleService(repo domain.ScrapeRuleRepository, scraperService domain.ScraperService) domain.ScrapeRuleService {
+	return &scrapeRuleService{
+		repo:           repo,
+		scraperService: scraperService,
+	}
+}
+
+func (s *scrapeRuleService) ByID(id uuid.UUID) (*domain.ScrapeRule, error) {
+	return s.repo.ByID(id)
+}
+
+func (s *scrapeRuleService) List() ([]domain.ScrapeRule, error) {
+	return s.repo.List()
+}
+
+func (s *scrapeRuleService) Create(rule *domain.ScrapeRule) error {
+	if err := s.validate(rule); err != nil {
+		return err
+	}
+	if err := s.repo.Create(rule); err != nil {
+		return fmt.Errorf("create scrape rule: %w", err)
+	}
+	return nil
+}
+
+func (s *scrapeRuleService) Update(rule *domain.ScrapeRule) error {
+	if err := s.validate(rule); err != nil {
+		return err
+	}
+	if err := s.repo.Update(rule); err != nil {
+		return fmt.Errorf("update scrape rule: %w", err)
+	}
+	return nil
+}
+
+func (s *scrapeRuleService) Delete(id uuid.UUID) error {
+	return s.repo.Delete(id)
+}
+
+func (s *scrapeRuleService) Test(ctx context.Context, id uuid.UUID, html []byte, url string) (*domain.Recipe, error) {
+	rule, err := s.repo.ByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("test scrape rule: %w", err)
+	}
+	return s.scraperService.ScrapeHtmlWithRule(ctx, html, url, rule)
+}
+
+func (s *scrapeRuleService) validate(rule *domain.ScrapeRule) error {
+	rule.Domain = normalizeRuleDomain(rule.Domain)
+	if fields := validateScrapeRule(rule); len(fields) > 0 {
+		err := sentinels.BadRequest("Invalid scrape rule")
+		err.Fields = fields
+		return err
+	}
+
+	existing, err := s.repo.ByDomains([]string{rule.Domain})
+	if err != nil {
+		return fmt.Errorf("validate scrape rule (lookup domain): %w", err)
+	}
+	for _, other := range existing {
+		if other.ID != rule.ID {
+			return sentinels.ErrAlreadyExists
+		}
+	}
+	return nil
+}
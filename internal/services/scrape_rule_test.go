@@ -0,0 +1,67 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/services"
+)
+
+type stubScrapeRuleRepo struct {
+	domain.ScrapeRuleRepository
+	rules   []domain.ScrapeRule
+	created []*domain.ScrapeRule
+}
+
+func (r *stubScrapeRuleRepo) ByDomains(domains []string) ([]domain.ScrapeRule, error) {
+	var found []domain.ScrapeRule
+	for _, rule := range r.rules {
+		for _, d := range domains {
+			if rule.Domain == d {
+				found = append(found, rule)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *stubScrapeRuleRepo) Create(rule *domain.ScrapeRule) error {
+	r.created = append(r.created, rule)
+	return nil
+}
+
+func TestScrapeRuleService_Create_NormalizesDomain(t *testing.T) {
+	repo := &stubScrapeRuleRepo{}
+	svc := services.NewScrapeRuleService(repo, &stubScraperService{})
+
+	rule := &domain.ScrapeRule{Domain: "https://www.Example.com/", Title: "h1.title"}
+	require.NoError(t, svc.Create(rule))
+	require.Len(t, repo.created, 1)
+	assert.Equal(t, "example.com", repo.created[0].Domain)
+}
+
+func TestScrapeRuleService_Create_RejectsInvalidRule(t *testing.T) {
+	repo := &stubScrapeRuleRepo{}
+	svc := services.NewScrapeRuleService(repo, &stubScraperService{})
+
+	err := svc.Create(&domain.ScrapeRule{Domain: "example.com", Ingredients: "$.recipe[", IngredientCleanup: []string{"(unclosed"}})
+
+	var se *sentinels.Error
+	require.ErrorAs(t, err, &se)
+	assert.Contains(t, se.Fields, "ingredients")
+	assert.Contains(t, se.Fields, "ingredient_cleanup.0")
+	assert.Empty(t, repo.created)
+}
+
+func TestScrapeRuleService_Create_DuplicateDomain(t *testing.T) {
+	repo := &stubScrapeRuleRepo{rules: []domain.ScrapeRule{{ID: uuid.New(), Domain: "example.com"}}}
+	svc := services.NewScrapeRuleService(repo, &stubScraperService{})
+
+	err := svc.Create(&domain.ScrapeRule{Domain: "www.example.com"})
+	assert.ErrorIs(t, err, sentinels.ErrAlreadyExists)
+}
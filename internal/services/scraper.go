@@ -17,12 +17,14 @@ import (
 type scraperService struct {
 	provider ScraperProvider
 	mapper   *scraperMapper
+	rules    domain.ScrapeRuleRepository
 }
 
-func NewScraperService(provider ScraperProvider, parser IngredientParser) domain.ScraperService {
+func NewScraperService(provider ScraperProvider, parser IngredientParser, rules domain.ScrapeRuleRepository) domain.ScraperService {
 	return &scraperService{
 		provider: provider,
 		mapper:   newScraperMapper(parser),
+		rules:    rules,
 	}
 }
 
@@ -34,6 +36,11 @@ func defaultRequestOptions(ctx context.Context) krip.RequestOptions {
 }
 
 func (s *scraperService) ScrapeUrl(ctx context.Context, url string, requestedType string) (*domain.ScrapeResult, error) {
+	rule, err := s.ruleFor(url)
+	if err != nil {
+		return nil, err
+	}
+
 	scrapeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
@@ -47,11 +54,105 @@ func (s *scraperService) ScrapeUrl(ctx context.Context, url string, requestedTyp
 	if err != nil {
 		return nil, fmt.Errorf("scrape url (fetch input): %w", err)
 	}
+	return s.scrapeInput(data, url, requestedType, options, rule)
+}
+
+// ScrapeHtml scrapes a page the client already fetched, e.g. one behind a login. Requests the scrapers make
+// on their own still go through the safe HTTP client.
+func (s *scraperService) ScrapeHtml(ctx context.Context, content []byte, url string, requestedType string) (*domain.ScrapeResult, error) {
+	rule, err := s.ruleFor(url)
+	if err != nil {
+		return nil, err
+	}
+
+	scrapeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	options := krip.FeedOptions{
+		ScrapeOptions: krip.ScrapeOptions{
+			RequestOptions: defaultRequestOptions(scrapeCtx),
+		},
+	}
+
+	data, err := s.provider.HtmlInput(content, url, options.ScrapeOptions)
+	if err != nil {
+		return nil, sentinels.BadRequest("unable to read the page html")
+	}
+	return s.scrapeInput(data, url, requestedType, options, rule)
+}
+
+// ScrapeHtmlWithRule scrapes a saved page with a rule that may not be stored yet. The rule applies even if disabled.
+func (s *scraperService) ScrapeHtmlWithRule(ctx context.Context, content []byte, url string, rule *domain.ScrapeRule) (*domain.Recipe, error) {
+	scrapeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	options := krip.ScrapeOptions{RequestOptions: defaultRequestOptions(scrapeCtx)}
+	data, err := s.provider.HtmlInput(content, url, options)
+	if err != nil {
+		return nil, sentinels.BadRequest("unable to read the page html")
+	}
 
+	kripRecipe := &krip.Recipe{}
+	if err := s.scrapeRecipe(data, kripRecipe, options, rule); err != nil && kripRecipe.Name == "" {
+		return nil, fmt.Errorf("scrape html with rule: %w", err)
+	}
+	return s.mapper.toRecipe(kripRecipe), nil
+}
+
+// ruleFor returns the scrape rule of the most specific domain of url, or an error if scraping it is disabled.
+// Rules failing to load only log a warning, krip alone still gets most sites right.
+func (s *scraperService) ruleFor(url string) (*domain.ScrapeRule, error) {
+	domains := ruleDomains(url)
+	if s.rules == nil || len(domains) == 0 {
+		return nil, nil
+	}
+
+	rules, err := s.rules.ByDomains(domains)
+	if err != nil {
+		log.Warnw("failed to load scrape rules", "url", url, "error", err.Error())
+		return nil, nil
+	}
+	for _, d := range domains {
+		for i := range rules {
+			if rules[i].Domain != d {
+				continue
+			}
+			if rules[i].Disabled {
+				return nil, sentinels.BadRequest(fmt.Sprintf("scraping %s is disabled", d))
+			}
+			return &rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// scrapeRecipe scrapes the page with krip and corrects the result with rule, if any.
+func (s *scraperService) scrapeRecipe(data *krip.DataInput, target *krip.Recipe, opts krip.ScrapeOptions, rule *domain.ScrapeRule) error {
+	var err error
+	if rule == nil || !rule.SkipKrip {
+		err = s.provider.Scrape(data, target, opts)
+	}
+	if rule == nil {
+		return err
+	}
+
+	applyScrapeRule(rule, data, target)
+	if target.Url == "" {
+		target.Url = data.Url
+	}
+	if err != nil && target.Name != "" && len(target.Ingredients) > 0 {
+		log.Infow("scrape rule recovered a failed scrape", "url", data.Url, "domain", rule.Domain, "error", err.Error())
+		return nil
+	}
+	return err
+}
+
+// scrapeInput detects whether the page is a recipe or a feed, or scrapes it as requestedType.
+func (s *scraperService) scrapeInput(data *krip.DataInput, url string, requestedType string, options krip.FeedOptions, rule *domain.ScrapeRule) (*domain.ScrapeResult, error) {
 	kripRecipe := &krip.Recipe{}
 	var recipeErr error
 	if requestedType == "" || requestedType == domain.ImportTypeAuto || requestedType == domain.ImportTypeRecipe {
-		if recipeErr = s.provider.Scrape(data, kripRecipe, options.ScrapeOptions); recipeErr != nil {
+		if recipeErr = s.scrapeRecipe(data, kripRecipe, options.ScrapeOptions, rule); recipeErr != nil {
 			log.Infow("failed to scrape recipe", "url", url, "error", recipeErr.Error())
 		}
 	}
@@ -93,19 +194,32 @@ func (s *scraperService) ScrapeUrl(ctx context.Context, url string, requestedTyp
 }
 
 func (s *scraperService) ScrapeRecipe(ctx context.Context, url string) (*domain.Recipe, error) {
+	rule, err := s.ruleFor(url)
+	if err != nil {
+		return nil, err
+	}
+
 	scrapeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	kripRecipe, err := s.provider.ScrapeUrl(url, krip.ScrapeOptions{
-		RequestOptions: defaultRequestOptions(scrapeCtx),
-	})
+	options := krip.ScrapeOptions{RequestOptions: defaultRequestOptions(scrapeCtx)}
+	data, err := s.provider.UrlInput(url, options)
 	if err != nil {
+		return nil, fmt.Errorf("scrape recipe (fetch input): %w", err)
+	}
+
+	kripRecipe := &krip.Recipe{}
+	if err := s.scrapeRecipe(data, kripRecipe, options, rule); err != nil {
 		return nil, fmt.Errorf("scrape recipe: %w", err)
 	}
 	return s.mapper.toRecipe(kripRecipe), nil
 }
 
 func (s *scraperService) ScrapeFeed(ctx context.Context, feed *domain.Feed, opts krip.FeedOptions) ([]*domain.Recipe, error) {
+	if _, err := s.ruleFor(feed.Url); err != nil {
+		return nil, err
+	}
+
 	scrapeCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
@@ -120,7 +234,12 @@ func (s *scraperService) ScrapeFeed(ctx context.Context, feed *domain.Feed, opts
 		recipes = append(recipes, s.mapper.toRecipe(entry))
 	}
 
-	// Back-populate the feed with scraped metadata.
+	s.populateFeed(feed, scrapedFeed)
+	return recipes, nil
+}
+
+// populateFeed back-populates the feed with scraped metadata.
+func (s *scraperService) populateFeed(feed *domain.Feed, scrapedFeed *krip.Feed) {
 	if scrapedFeed.Name != "" && feed.Name != scrapedFeed.Name {
 		feed.Name = scrapedFeed.Name
 	}
@@ -136,6 +255,4 @@ func (s *scraperService) ScrapeFeed(ctx context.Context, feed *domain.Feed, opts
 	if scrapedFeed.Discovered != nil {
 		feed.Discovered = scrapedFeed.Discovered
 	}
-
-	return recipes, nil
 }
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/borschtapp/krip"
+	kModel "github.com/borschtapp/krip/model"
+	"github.com/gofiber/fiber/v3/log"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/utils"
+)
+
+// defaultFeedEntriesScrape is how many new entries of a feed are scraped when the options set no limit, as in krip.
+const defaultFeedEntriesScrape = 20
+
+// stubEntryFilter keeps every feed entry with a name and url, the scraped ones are held to the caller's filter.
+var stubEntryFilter = krip.RecipeFilter{
+	OptionalImage:        true,
+	OptionalPublisher:    true,
+	OptionalIngredients:  true,
+	OptionalInstructions: true,
+}
+
+// feedValidators are what a feed is requested conditionally with: the validators of the last response and a hash
+// of its body, for servers that ignore conditional requests.
+type feedValidators struct {
+	etag         string
+	lastModified string
+	contentHash  string
+}
+
+// apply stores the validators on the feed, a 304 response without validators keeps the stored ones.
+func (v feedValidators) apply(feed *domain.Feed) {
+	if v.etag != "" {
+		feed.ETag = v.etag
+	}
+	if v.lastModified != "" {
+		feed.LastModified = v.lastModified
+	}
+	if v.contentHash != "" {
+		feed.ContentHash = v.contentHash
+	}
+}
+
+// conditionalClient records the status and validators of the responses it receives.
+type conditionalClient struct {
+	kModel.HTTPClient
+	status     int
+	validators feedValidators
+}
+
+func (c *conditionalClient) Do(req *http.Request) (*http.Response, error) {
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	c.status = res.StatusCode
+	c.validators.etag = res.Header.Get("ETag")
+	c.validators.lastModified = res.Header.Get("Last-Modified")
+	return res, nil
+}
+
+func (s *scraperService) ScrapeFeedChanges(ctx context.Context, feed *domain.Feed, opts krip.FeedOptions, isKnown func(url string) bool) ([]*domain.Recipe, bool, error) {
+	if _, err := s.ruleFor(feed.Url); err != nil {
+		return nil, false, err
+	}
+
+	scrapeCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	opts.RequestOptions = defaultRequestOptions(scrapeCtx)
+	data, validators, err := s.fetchFeed(feed, opts.ScrapeOptions)
+	if err != nil {
+		return nil, false, fmt.Errorf("scrape feed changes (fetch): %w", err)
+	}
+	if data == nil {
+		validators.apply(feed)
+		return nil, true, nil
+	}
+
+	stubOpts := opts
+	stubOpts.SkipEntriesScrape = true
+	stubOpts.RecipeFilter = stubEntryFilter
+	scrapedFeed := &krip.Feed{}
+	if err := s.provider.ScrapeFeed(data, scrapedFeed, stubOpts); err != nil {
+		return nil, false, fmt.Errorf("scrape feed changes: %w", err)
+	}
+
+	limit := opts.MaxEntriesForScrape
+	switch {
+	case limit == 0:
+		limit = defaultFeedEntriesScrape
+	case limit < 0:
+		limit = len(scrapedFeed.Entries)
+	}
+
+	// Known entries are skipped before they are scraped, the rest stay stubs past the limit, like in krip.
+	recipes := make([]*domain.Recipe, 0, min(len(scrapedFeed.Entries), limit))
+	seen := make(map[string]bool, len(scrapedFeed.Entries))
+	truncated := false
+	for _, entry := range scrapedFeed.Entries {
+		url := utils.NormalizeURL(entry.Url)
+		if url == "" || seen[url] || isKnown(url) {
+			continue
+		}
+		seen[url] = true
+
+		if limit > 0 {
+			limit--
+			if err := s.scrapeEntry(entry, opts.ScrapeOptions); err != nil {
+				// like the entries past the limit, retried on the next fetch
+				log.Warnw("failed to scrape feed entry", "feed", feed.ID, "url", entry.Url, "error", err.Error())
+				truncated = true
+				continue
+			}
+		} else {
+			truncated = true
+		}
+		if entry.Validate(opts.RecipeFilter) != nil {
+			continue
+		}
+		recipes = append(recipes, s.mapper.toRecipe(entry))
+	}
+
+	s.populateFeed(feed, scrapedFeed)
+	if !truncated {
+		// otherwise the next fetch would be answered 304 and the entries past the limit never scraped
+		validators.apply(feed)
+	}
+	return recipes, false, nil
+}
+
+func (s *scraperService) scrapeEntry(entry *krip.Recipe, opts krip.ScrapeOptions) error {
+	input, err := s.provider.UrlInput(entry.Url, opts)
+	if err != nil {
+		return err
+	}
+	return s.provider.Scrape(input, entry, opts)
+}
+
+// fetchFeed requests the feed conditionally on its stored validators. The input is nil when the server answered 304
+// Not Modified or the body hashes to the one of the last fetch.
+func (s *scraperService) fetchFeed(feed *domain.Feed, opts krip.ScrapeOptions) (*krip.DataInput, feedValidators, error) {
+	headers := opts.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	if feed.ETag != "" {
+		headers.Set("If-None-Match", feed.ETag)
+	}
+	if feed.LastModified != "" {
+		headers.Set("If-Modified-Since", feed.LastModified)
+	}
+	client := &conditionalClient{HTTPClient: opts.HttpClient}
+	opts.Headers, opts.HttpClient = headers, client
+
+	data, err := s.provider.UrlInput(feed.Url, opts)
+	if client.status == http.StatusNotModified {
+		return nil, client.validators, nil
+	}
+	if err != nil {
+		return nil, feedValidators{}, err
+	}
+
+	sum := sha256.Sum256([]byte(data.Text))
+	client.validators.contentHash = hex.EncodeToString(sum[:])
+	if client.validators.contentHash == feed.ContentHash {
+		return nil, client.validators, nil
+	}
+	return data, client.validators, nil
+}
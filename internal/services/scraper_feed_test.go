@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/borschtapp/krip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+)
+
+func noneKnown(string) bool { return false }
+
+func TestScraperService_ScrapeFeedChanges_NotModified(t *testing.T) {
+	service, mockProvider := newTestScraperService(t)
+	feed := &domain.Feed{Url: "https://example.com/feed", ETag: `"v1"`, LastModified: "Mon, 05 Oct 2026 10:00:00 GMT"}
+
+	mockProvider.On("UrlInput", feed.Url, mock.Anything).Run(func(args mock.Arguments) {
+		opts := args.Get(1).(krip.ScrapeOptions)
+		assert.Equal(t, `"v1"`, opts.Headers.Get("If-None-Match"))
+		assert.Equal(t, "Mon, 05 Oct 2026 10:00:00 GMT", opts.Headers.Get("If-Modified-Since"))
+
+		client := opts.HttpClient.(*conditionalClient)
+		client.status = http.StatusNotModified
+		client.validators.etag = `"v2"`
+	}).Return(nil, errors.New("invalid status 304"))
+
+	recipes, unchanged, err := service.ScrapeFeedChanges(context.Background(), feed, krip.FeedOptions{}, noneKnown)
+
+	require.NoError(t, err)
+	assert.True(t, unchanged)
+	assert.Empty(t, recipes)
+	assert.Equal(t, `"v2"`, feed.ETag)
+	assert.Equal(t, "Mon, 05 Oct 2026 10:00:00 GMT", feed.LastModified, "a 304 without Last-Modified keeps the stored one")
+	mockProvider.AssertNotCalled(t, "ScrapeFeed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScraperService_ScrapeFeedChanges_SameContentHash(t *testing.T) {
+	service, mockProvider := newTestScraperService(t)
+	feed := &domain.Feed{Url: "https://example.com/feed"}
+
+	mockProvider.On("UrlInput", feed.Url, mock.Anything).Return(&krip.DataInput{Url: feed.Url, Text: "<rss/>"}, nil)
+	mockProvider.On("ScrapeFeed", mock.Anything, mock.Anything, mock.Anything).Return(&krip.Feed{}, nil)
+
+	_, unchanged, err := service.ScrapeFeedChanges(context.Background(), feed, krip.FeedOptions{}, noneKnown)
+	require.NoError(t, err)
+	assert.False(t, unchanged)
+	require.NotEmpty(t, feed.ContentHash)
+
+	_, unchanged, err = service.ScrapeFeedChanges(context.Background(), feed, krip.FeedOptions{}, noneKnown)
+	require.NoError(t, err)
+	assert.True(t, unchanged, "a server ignoring conditional requests is caught by the content hash")
+	mockProvider.AssertNumberOfCalls(t, "ScrapeFeed", 1)
+}
+
+func TestScraperService_ScrapeFeedChanges_PastTheLimit_KeepsValidators(t *testing.T) {
+	service, mockProvider := newTestScraperService(t)
+	feed := &domain.Feed{Url: "https://example.com/feed", ETag: `"v1"`}
+	data := &krip.DataInput{Url: feed.Url, Text: "<rss/>"}
+
+	mockProvider.On("UrlInput", feed.Url, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(krip.ScrapeOptions).HttpClient.(*conditionalClient).validators.etag = `"v2"`
+	}).Return(data, nil)
+	mockProvider.On("ScrapeFeed", data, mock.Anything, mock.Anything).Return(&krip.Feed{Url: feed.Url, Entries: []*krip.Recipe{
+		{Name: "First", Url: "https://example.com/first"},
+		{Name: "Second", Url: "https://example.com/second"},
+	}}, nil)
+	mockProvider.On("UrlInput", "https://example.com/first", mock.Anything).Return(&krip.DataInput{}, nil)
+	mockProvider.On("Scrape", mock.Anything, mock.Anything, mock.Anything).Return(&krip.Recipe{}, nil)
+
+	_, _, err := service.ScrapeFeedChanges(context.Background(), feed, krip.FeedOptions{MaxEntriesForScrape: 1}, noneKnown)
+
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, feed.ETag, "the entries past the limit are scraped on the next fetch")
+	assert.Empty(t, feed.ContentHash)
+	mockProvider.AssertNotCalled(t, "UrlInput", "https://example.com/second", mock.Anything)
+}
+
+func TestScraperService_ScrapeFeedChanges_SkipsKnownEntriesBeforeScraping(t *testing.T) {
+	service, mockProvider := newTestScraperService(t)
+	feed := &domain.Feed{Url: "https://example.com/feed"}
+	data := &krip.DataInput{Url: feed.Url, Text: "<rss/>"}
+
+	mockProvider.On("UrlInput", feed.Url, mock.Anything).Return(data, nil)
+	mockProvider.On("ScrapeFeed", data, mock.Anything, mock.MatchedBy(func(opts krip.FeedOptions) bool {
+		return opts.SkipEntriesScrape
+	})).Return(&krip.Feed{Url: feed.Url, Name: "Example", Entries: []*krip.Recipe{
+		{Name: "Known", Url: "https://example.com/known"},
+		{Name: "No URL"},
+		{Name: "New", Url: "https://example.com/new"},
+	}}, nil)
+
+	entryData := &krip.DataInput{Url: "https://example.com/new"}
+	mockProvider.On("UrlInput", "https://example.com/new", mock.Anything).Return(entryData, nil)
+	mockProvider.On("Scrape", entryData, mock.Anything, mock.Anything).Return(&krip.Recipe{
+		Name:         "New",
+		Url:          "https://example.com/new",
+		Images:       []*krip.ImageObject{{Url: "https://example.com/new.jpg"}},
+		Publisher:    &krip.Organization{Name: "Example"},
+		Ingredients:  []*krip.PropertyValue{{Name: "2 beets"}},
+		Instructions: []*krip.HowToSection{{HowToStep: krip.HowToStep{Text: "Boil"}}},
+	}, nil)
+
+	recipes, unchanged, err := service.ScrapeFeedChanges(context.Background(), feed, krip.FeedOptions{}, func(url string) bool {
+		return url == "https://example.com/known"
+	})
+
+	require.NoError(t, err)
+	assert.False(t, unchanged)
+	require.Len(t, recipes, 1)
+	assert.Equal(t, "New", *recipes[0].Name)
+	assert.Equal(t, "Example", feed.Name)
+	assert.NotEmpty(t, feed.ContentHash)
+	mockProvider.AssertNotCalled(t, "UrlInput", "https://example.com/known", mock.Anything)
+	mockProvider.AssertNumberOfCalls(t, "Scrape", 1)
+}
+
+func TestScraperService_ScrapeFeedChanges_EntryScrapeFails_KeepsValidators(t *testing.T) {
+	service, mockProvider := newTestScraperService(t)
+	feed := &domain.Feed{Url: "https://example.com/feed", ETag: `"v1"`}
+	data := &krip.DataInput{Url: feed.Url, Text: "<rss/>"}
+
+	mockProvider.On("UrlInput", feed.Url, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(krip.ScrapeOptions).HttpClient.(*conditionalClient).validators.etag = `"v2"`
+	}).Return(data, nil)
+	mockProvider.On("ScrapeFeed", data, mock.Anything, mock.Anything).Return(&krip.Feed{Url: feed.Url, Entries: []*krip.Recipe{
+		{Name: "Flaky", Url: "https://example.com/flaky"},
+	}}, nil)
+	mockProvider.On("UrlInput", "https://example.com/flaky", mock.Anything).Return(nil, errors.New("timeout"))
+
+	recipes, _, err := service.ScrapeFeedChanges(context.Background(), feed, krip.FeedOptions{}, noneKnown)
+
+	require.NoError(t, err)
+	assert.Empty(t, recipes, "the stub of a failed entry is not imported")
+	assert.Equal(t, `"v1"`, feed.ETag, "the failed entry is scraped again on the next fetch")
+	assert.Empty(t, feed.ContentHash)
+}
+
+func TestScraperService_ScrapeFeedChanges_RecipeWithNoURL_IsSkipped(t *testing.T) {
+	// Recipes without a URL cannot be deduplicated and must be skipped to avoid importing the same recipe repeatedly.
+	service, mockProvider := newTestScraperService(t)
+	feed := &domain.Feed{Url: "https://example.com/feed"}
+	data := &krip.DataInput{Url: feed.Url, Text: "<rss/>"}
+
+	mockProvider.On("UrlInput", feed.Url, mock.Anything).Return(data, nil)
+	mockProvider.On("ScrapeFeed", data, mock.Anything, mock.Anything).Return(&krip.Feed{Url: feed.Url, Entries: []*krip.Recipe{
+		{Name: "No URL", Ingredients: []*krip.PropertyValue{{Name: "2 beets"}}, Instructions: []*krip.HowToSection{{HowToStep: krip.HowToStep{Text: "Boil"}}}},
+	}}, nil)
+
+	recipes, _, err := service.ScrapeFeedChanges(context.Background(), feed, krip.FeedOptions{}, func(string) bool {
+		t.Fatal("an entry without URL is skipped before the lookup")
+		return false
+	})
+
+	require.NoError(t, err)
+	assert.Empty(t, recipes)
+	mockProvider.AssertNotCalled(t, "Scrape", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -66,11 +66,7 @@ func (m *scraperMapper) toRecipe(kripRecipe *krip.Recipe) *domain.Recipe {
 	m.addTaxonomies(recipe, domain.TaxonomyTypeCategory, kripRecipe.Categories)
 	m.addTaxonomies(recipe, domain.TaxonomyTypeCuisine, kripRecipe.Cuisines)
 	m.addTaxonomies(recipe, domain.TaxonomyTypeKeyword, kripRecipe.Keywords)
-	if kripRecipe.Yield != "" {
-		if yield := kUtils.FindInt(kripRecipe.Yield); yield > 0 {
-			recipe.Yield = &yield
-		}
-	}
+	applyYield(recipe, kripRecipe.Yield)
 	if kripRecipe.Nutrition != nil {
 		recipe.Nutrition = &domain.RecipeNutrition{
 			ServingSize: kripRecipe.Nutrition.ServingSize,
@@ -145,11 +141,15 @@ func (m *scraperMapper) toRecipe(kripRecipe *krip.Recipe) *domain.Recipe {
 		recipe.Equipment = append(recipe.Equipment, eq)
 	}
 	for _, item := range kripRecipe.Instructions {
+		var section *domain.RecipeInstruction
 		if item.Text != "" || item.Name != "" {
-			recipe.Instructions = append(recipe.Instructions, m.toInstruction(&item.HowToStep))
+			section = m.toInstruction(&item.HowToStep)
+			recipe.Instructions = append(recipe.Instructions, section)
 		}
 		for _, step := range item.Steps {
-			recipe.Instructions = append(recipe.Instructions, m.toInstruction(step))
+			ins := m.toInstruction(step)
+			ins.Parent = section // resolved to ParentID once the section has an ID
+			recipe.Instructions = append(recipe.Instructions, ins)
 		}
 	}
 	m.enrichIngredients(recipe.Ingredients, kripRecipe.Language)
@@ -254,6 +254,9 @@ func (m *scraperMapper) toIngredient(item *krip.PropertyValue) *domain.RecipeIng
 	if item.Image != "" && ing.Food != nil {
 		ing.Food.Images = []*domain.Image{{SourceURL: item.Image}}
 	}
+	if item.Category != "" {
+		ing.Category = &item.Category
+	}
 
 	return ing
 }
@@ -2,13 +2,16 @@ package services
 
 import (
 	"testing"
+	"time"
 
 	"github.com/borschtapp/kapusta"
 	"github.com/borschtapp/krip"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/storage"
 )
 
 func TestScraperMapper_ToRecipe(t *testing.T) {
@@ -42,6 +45,33 @@ func TestScraperMapper_ToRecipe(t *testing.T) {
 	assert.Equal(t, "Mix it", recipe.Instructions[0].Text)
 }
 
+func TestScraperMapper_ToRecipe_ParsesYield(t *testing.T) {
+	tests := []struct {
+		yield    string
+		quantity *float64
+		max      *float64
+		unit     *string
+	}{
+		{yield: "4", quantity: new(4.0)},
+		{yield: "Serves 4 (as a main)", quantity: new(4.0)},
+		{yield: "6 servings", quantity: new(6.0)},
+		{yield: "Makes 12-16 cookies", quantity: new(12.0), max: new(16.0), unit: new("cookies")},
+		{yield: "1 loaf", quantity: new(1.0), unit: new("loaf")},
+		{yield: "2 x 20cm cakes", quantity: new(2.0), unit: new("20cm cakes")},
+		{yield: "a big pot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.yield, func(t *testing.T) {
+			recipe := newScraperMapper(&KripProvider{}).toRecipe(&krip.Recipe{Yield: tt.yield})
+
+			assert.Equal(t, tt.quantity, recipe.Yield)
+			assert.Equal(t, tt.max, recipe.YieldMax)
+			assert.Equal(t, tt.unit, recipe.YieldUnit)
+			assert.Equal(t, tt.yield, *recipe.YieldText)
+		})
+	}
+}
+
 func TestScraperMapper_EnrichIngredient(t *testing.T) {
 	mockProvider := &mockScraperProvider{}
 	parsed := kapusta.Ingredient{Amount: 200, Unit: "g", Name: "sugar"}
@@ -59,3 +89,61 @@ func TestScraperMapper_EnrichIngredient(t *testing.T) {
 	assert.Equal(t, "sugar", *ing.Name)
 	mockProvider.AssertExpectations(t)
 }
+
+func TestScraperMapper_ToRecipe_ExportRoundTrip(t *testing.T) {
+	provider := &mockScraperProvider{}
+	provider.On("ParseIngredient", mock.Anything, mock.Anything).Return(kapusta.Ingredient{}, nil)
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	imported := &krip.Recipe{
+		Url:           "https://example.com/recipes/pasta",
+		Name:          "Pasta al pomodoro",
+		Description:   "Weeknight pasta.",
+		Language:      "en",
+		Images:        []*krip.ImageObject{{Url: "https://example.com/pasta.jpg", Width: 1200, Height: 800}},
+		Author:        &krip.Person{Name: "Jane Doe", Url: "https://example.com/jane"},
+		Publisher:     &krip.Organization{Name: "Example Kitchen", Url: "https://example.com"},
+		PrepTime:      "PT10M",
+		CookTime:      "PT20M",
+		TotalTime:     "PT30M",
+		Difficulty:    "Easy",
+		CookingMethod: "Boiling",
+		Diets:         []string{"Vegetarian"},
+		Categories:    []string{"Main course"},
+		Cuisines:      []string{"Italian"},
+		Keywords:      []string{"quick"},
+		Yield:         "Serves 4",
+		Ingredients: []*krip.PropertyValue{
+			{Name: "400 g spaghetti"},
+			{Name: "2 cloves garlic, sliced"},
+		},
+		Equipment: []*krip.HowToTool{{Name: "Large pot"}},
+		Instructions: []*krip.HowToSection{
+			{
+				HowToStep: krip.HowToStep{Name: "For the sauce"},
+				Steps:     []*krip.HowToStep{{Text: "Fry the garlic."}, {Text: "Add the tomatoes and simmer."}},
+			},
+			{HowToStep: krip.HowToStep{Text: "Toss with the pasta and serve."}},
+		},
+		Nutrition:     &krip.NutritionInformation{ServingSize: "1 plate", Calories: new(520.0), ProteinContent: new(18.0)},
+		Rating:        &krip.AggregateRating{ReviewCount: 3, RatingCount: 12, RatingValue: 4.5},
+		Video:         &krip.VideoObject{Name: "How to", ContentUrl: "https://example.com/pasta.mp4"},
+		DatePublished: &published,
+	}
+
+	recipe := newScraperMapper(provider).toRecipe(imported)
+	// what ImportRecipe and the repository assign on persist
+	for i, ins := range recipe.Instructions {
+		ins.ID = uuid.New()
+		ins.Order = uint8(i)
+	}
+	for _, ins := range recipe.Instructions {
+		if ins.Parent != nil {
+			ins.ParentID = &ins.Parent.ID
+		}
+	}
+	for _, image := range recipe.Images {
+		image.Path = new(storage.Path(image.SourceURL))
+	}
+
+	assert.Equal(t, imported, toSchemaRecipe(recipe))
+}
@@ -1,14 +1,19 @@
 package services
 
 import (
+	"bytes"
+	"fmt"
+
 	"github.com/borschtapp/kapusta"
 	"github.com/borschtapp/krip"
 	"github.com/borschtapp/krip/scraper"
+	"golang.org/x/net/html"
 )
 
 // ScraperProvider wraps krip calls, so they can be easily mocked
 type ScraperProvider interface {
 	UrlInput(url string, opts krip.ScrapeOptions) (*krip.DataInput, error)
+	HtmlInput(content []byte, url string, opts krip.ScrapeOptions) (*krip.DataInput, error)
 	Scrape(data *krip.DataInput, target *krip.Recipe, opts krip.ScrapeOptions) error
 	ScrapeFeed(data *krip.DataInput, target *krip.Feed, opts krip.FeedOptions) error
 	ScrapeUrl(url string, opts krip.ScrapeOptions) (*krip.Recipe, error)
@@ -30,6 +35,23 @@ func (p *KripProvider) UrlInput(url string, opts krip.ScrapeOptions) (*krip.Data
 	return scraper.UrlInput(url, opts)
 }
 
+// HtmlInput reads a page fetched by someone else, url is where it was fetched from.
+func (p *KripProvider) HtmlInput(content []byte, url string, opts krip.ScrapeOptions) (*krip.DataInput, error) {
+	root, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse html tree: %w", err)
+	}
+
+	input, err := scraper.NodeInput(root, url, krip.ScrapeOptions{SkipMetaUrl: true})
+	if err != nil {
+		return nil, err
+	}
+
+	input.Text = string(content)
+	input.RequestOptions = opts.RequestOptions
+	return input, nil
+}
+
 func (p *KripProvider) Scrape(data *krip.DataInput, target *krip.Recipe, opts krip.ScrapeOptions) error {
 	return scraper.Scrape(data, target, opts)
 }
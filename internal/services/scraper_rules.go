@@ -0,0 +1,372 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/borschtapp/krip"
+	"github.com/gofiber/fiber/v3/log"
+
+	"borscht.app/smetana/domain"
+)
+
+// ruleDomains returns the host of rawURL and its parent domains, most specific first, the candidates for a rule.
+func ruleDomains(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	var domains []string
+	for {
+		domains = append(domains, host)
+		_, parent, found := strings.Cut(host, ".")
+		if !found || !strings.Contains(parent, ".") {
+			return domains
+		}
+		host = parent
+	}
+}
+
+// normalizeRuleDomain accepts a domain the way admins paste it, e.g. with a scheme or a www. prefix.
+func normalizeRuleDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if strings.Contains(domain, "://") {
+		if u, err := url.Parse(domain); err == nil {
+			domain = u.Hostname()
+		}
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(domain, "/"), "www.")
+}
+
+// validateScrapeRule checks that every selector and cleanup expression of the rule compiles.
+func validateScrapeRule(rule *domain.ScrapeRule) map[string]string {
+	fields := map[string]string{}
+	if rule.Domain == "" || strings.ContainsAny(rule.Domain, "/ ") || !strings.Contains(rule.Domain, ".") {
+		fields["domain"] = "must be a domain name, e.g. example.com"
+	}
+	for field, selector := range map[string]string{
+		"title":        rule.Title,
+		"ingredients":  rule.Ingredients,
+		"instructions": rule.Instructions,
+		"image":        rule.Image,
+	} {
+		if err := validateSelector(selector); err != nil {
+			fields[field] = err.Error()
+		}
+	}
+	for i, expr := range rule.IngredientCleanup {
+		if _, err := regexp.Compile(expr); err != nil {
+			fields["ingredient_cleanup."+strconv.Itoa(i)] = err.Error()
+		}
+	}
+	return fields
+}
+
+func validateSelector(selector string) error {
+	switch {
+	case selector == "":
+		return nil
+	case strings.HasPrefix(selector, "$"):
+		_, err := parseJsonPath(selector)
+		return err
+	default:
+		_, err := cascadia.ParseGroup(selector)
+		return err
+	}
+}
+
+// applyScrapeRule corrects a recipe scraped by krip with what the selectors of the rule find on the page.
+func applyScrapeRule(rule *domain.ScrapeRule, data *krip.DataInput, recipe *krip.Recipe) {
+	if data.Document != nil {
+		if title := firstValue(selectValues(data, rule.Title, false)); title != "" {
+			recipe.Name = title
+		}
+		if image := firstValue(selectValues(data, rule.Image, true)); image != "" {
+			if base, err := url.Parse(data.Url); err == nil {
+				if ref, err := base.Parse(image); err == nil {
+					image = ref.String()
+				}
+			}
+			recipe.Images = []*krip.ImageObject{{Url: image}}
+		}
+		if lines := selectValues(data, rule.Ingredients, false); len(lines) > 0 {
+			recipe.Ingredients = make([]*krip.PropertyValue, 0, len(lines))
+			for _, line := range lines {
+				recipe.Ingredients = append(recipe.Ingredients, &krip.PropertyValue{Name: line})
+			}
+		}
+		if steps := selectValues(data, rule.Instructions, false); len(steps) > 0 {
+			recipe.Instructions = make([]*krip.HowToSection, 0, len(steps))
+			for _, step := range steps {
+				recipe.Instructions = append(recipe.Instructions, &krip.HowToSection{HowToStep: krip.HowToStep{Text: step}})
+			}
+		}
+	}
+
+	if len(rule.IngredientCleanup) > 0 {
+		cleanup := make([]*regexp.Regexp, 0, len(rule.IngredientCleanup))
+		for _, expr := range rule.IngredientCleanup {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				log.Warnw("invalid ingredient cleanup in scrape rule", "domain", rule.Domain, "expr", expr, "error", err.Error())
+				continue
+			}
+			cleanup = append(cleanup, re)
+		}
+		recipe.Ingredients = slices.DeleteFunc(recipe.Ingredients, func(item *krip.PropertyValue) bool {
+			for _, re := range cleanup {
+				item.Name = re.ReplaceAllString(item.Name, "")
+			}
+			item.Name = strings.Join(strings.Fields(item.Name), " ")
+			return item.Name == "" && item.Value == "" && item.UnitText == ""
+		})
+	}
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// selectValues returns the text of every match of selector, or its link when link is set (e.g. an image).
+func selectValues(data *krip.DataInput, selector string, link bool) []string {
+	if selector == "" {
+		return nil
+	}
+	if strings.HasPrefix(selector, "$") {
+		return selectJsonValues(data.Document, selector)
+	}
+
+	var values []string
+	data.Document.Find(selector).Each(func(_ int, el *goquery.Selection) {
+		var value string
+		if link {
+			for _, attr := range []string{"content", "data-src", "src", "href"} {
+				if value = el.AttrOr(attr, ""); value != "" {
+					break
+				}
+			}
+		} else if goquery.NodeName(el) == "meta" {
+			value = el.AttrOr("content", "")
+		} else {
+			value = el.Text()
+		}
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			values = append(values, value)
+		}
+	})
+	return values
+}
+
+// selectJsonValues evaluates a JSON path on the JSON scripts of the page and returns the values of the first that matches.
+func selectJsonValues(doc *goquery.Document, selector string) []string {
+	path, err := parseJsonPath(selector)
+	if err != nil {
+		return nil
+	}
+
+	var values []string
+	doc.Find(`script[type="application/ld+json"], script[type="application/json"]`).EachWithBreak(func(_ int, el *goquery.Selection) bool {
+		var root any
+		if err := json.Unmarshal([]byte(el.Text()), &root); err != nil {
+			return true
+		}
+		for _, node := range path.eval(root) {
+			values = appendJsonText(values, node)
+		}
+		return len(values) == 0
+	})
+	return values
+}
+
+// appendJsonText appends the text of a JSON value, objects such as a HowToStep contribute their text, name or url.
+func appendJsonText(values []string, node any) []string {
+	switch v := node.(type) {
+	case string:
+		if v = strings.Join(strings.Fields(v), " "); v != "" {
+			values = append(values, v)
+		}
+	case float64:
+		values = append(values, strconv.FormatFloat(v, 'f', -1, 64))
+	case []any:
+		for _, item := range v {
+			values = appendJsonText(values, item)
+		}
+	case map[string]any:
+		for _, key := range []string{"text", "name", "url"} {
+			if field, ok := v[key]; ok {
+				return appendJsonText(values, field)
+			}
+		}
+	}
+	return values
+}
+
+// jsonPathStep is one step of a JSON path: a key, an index, or every child with *, searched at any depth after "..".
+type jsonPathStep struct {
+	key       string
+	index     int
+	isIndex   bool
+	wildcard  bool
+	recursive bool
+}
+
+type jsonPath []jsonPathStep
+
+// parseJsonPath parses the subset of JSON path that is useful on recipe pages: $.a.b, $..a, $.a[0], $.a[*] and $['a'].
+func parseJsonPath(selector string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(selector, "$")
+	if !ok {
+		return nil, errors.New("json path must start with $")
+	}
+
+	var path jsonPath
+	for rest != "" {
+		var step jsonPathStep
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			step.recursive = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "["):
+		default:
+			return nil, fmt.Errorf("unexpected %q in json path", rest)
+		}
+
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, errors.New("unclosed [ in json path")
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				step.wildcard = true
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				step.key = inner[1 : len(inner)-1]
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q in json path", inner)
+				}
+				step.index, step.isIndex = index, true
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			if name == "" {
+				return nil, errors.New("empty key in json path")
+			}
+			if strings.ContainsFunc(name, unicode.IsSpace) {
+				return nil, fmt.Errorf("key %q has spaces, quote it as ['key']", name)
+			}
+			if name == "*" {
+				step.wildcard = true
+			} else {
+				step.key = name
+			}
+		}
+		path = append(path, step)
+	}
+	return path, nil
+}
+
+func (p jsonPath) eval(root any) []any {
+	nodes := []any{root}
+	for _, step := range p {
+		var next []any
+		for _, node := range nodes {
+			if step.recursive {
+				for _, descendant := range jsonDescendants(node, nil) {
+					next = append(next, step.match(descendant, false)...)
+				}
+			} else {
+				next = append(next, step.match(node, true)...)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// match returns the children of node the step selects. A key applied to an array selects it on each element,
+// so that paths work whether a page has one JSON-LD object or a list (or @graph) of them.
+func (s jsonPathStep) match(node any, throughArrays bool) []any {
+	switch v := node.(type) {
+	case map[string]any:
+		if s.wildcard {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			children := make([]any, 0, len(keys))
+			for _, key := range keys {
+				children = append(children, v[key])
+			}
+			return children
+		}
+		if child, ok := v[s.key]; ok && !s.isIndex {
+			return []any{child}
+		}
+	case []any:
+		switch {
+		case s.wildcard:
+			return v
+		case s.isIndex:
+			index := s.index
+			if index < 0 {
+				index += len(v)
+			}
+			if index >= 0 && index < len(v) {
+				return []any{v[index]}
+			}
+		case throughArrays:
+			var children []any
+			for _, item := range v {
+				children = append(children, s.match(item, false)...)
+			}
+			return children
+		}
+	}
+	return nil
+}
+
+// jsonDescendants returns node and everything nested in it, depth first.
+func jsonDescendants(node any, acc []any) []any {
+	acc = append(acc, node)
+	switch v := node.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			acc = jsonDescendants(v[key], acc)
+		}
+	case []any:
+		for _, item := range v {
+			acc = jsonDescendants(item, acc)
+		}
+	}
+	return acc
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/borschtapp/krip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+)
+
+const scrapeRulePage = `<html><head>
+<meta property="og:image" content="/images/borscht.jpg">
+<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [
+	{"@type": "WebPage", "name": "Home"},
+	{"@type": "Recipe", "name": "Borscht", "recipeIngredient": ["2 beets (affiliate link)", "1 onion"],
+	 "recipeInstructions": [{"@type": "HowToStep", "text": "Grate the beets."}, {"@type": "HowToStep", "text": "Simmer."}]}
+]}</script>
+</head><body>
+<h1 class="title">  Ukrainian
+	Borscht </h1>
+<div class="method"><p>Chop everything.</p><p>Cook for an hour.</p></div>
+</body></html>`
+
+func scrapeRuleInput(t *testing.T) *krip.DataInput {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(scrapeRulePage))
+	require.NoError(t, err)
+	return &krip.DataInput{Url: "https://example.com/recipes/borscht", Document: doc}
+}
+
+func TestApplyScrapeRule_CssSelectors(t *testing.T) {
+	recipe := &krip.Recipe{Name: "Home", Ingredients: []*krip.PropertyValue{{Name: "2 beets"}}}
+	rule := &domain.ScrapeRule{
+		Title:        "h1.title",
+		Instructions: ".method p",
+		Image:        "meta[property='og:image']",
+	}
+
+	applyScrapeRule(rule, scrapeRuleInput(t), recipe)
+
+	assert.Equal(t, "Ukrainian Borscht", recipe.Name)
+	require.Len(t, recipe.Images, 1)
+	assert.Equal(t, "https://example.com/images/borscht.jpg", recipe.Images[0].Url)
+	require.Len(t, recipe.Instructions, 2)
+	assert.Equal(t, "Cook for an hour.", recipe.Instructions[1].Text)
+	assert.Equal(t, "2 beets", recipe.Ingredients[0].Name, "fields without a selector keep what krip scraped")
+}
+
+func TestApplyScrapeRule_JsonPathsAndCleanup(t *testing.T) {
+	recipe := &krip.Recipe{}
+	rule := &domain.ScrapeRule{
+		Title:             "$..[1].name",
+		Ingredients:       "$..recipeIngredient[*]",
+		Instructions:      "$['@graph'][1].recipeInstructions",
+		IngredientCleanup: []string{`\(affiliate link\)`},
+	}
+
+	applyScrapeRule(rule, scrapeRuleInput(t), recipe)
+
+	assert.Equal(t, "Borscht", recipe.Name)
+	require.Len(t, recipe.Ingredients, 2)
+	assert.Equal(t, "2 beets", recipe.Ingredients[0].Name)
+	require.Len(t, recipe.Instructions, 2)
+	assert.Equal(t, "Grate the beets.", recipe.Instructions[0].Text)
+}
+
+func TestParseJsonPath_Invalid(t *testing.T) {
+	for _, selector := range []string{"recipe", "$.", "$[x]", "$.a[0", "$.a b"} {
+		_, err := parseJsonPath(selector)
+		assert.Error(t, err, selector)
+	}
+}
+
+func TestRuleDomains(t *testing.T) {
+	assert.Equal(t, []string{"recipes.example.co.uk", "example.co.uk", "co.uk"}, ruleDomains("https://www.Recipes.example.co.uk/a"))
+	assert.Equal(t, []string{"example.com"}, ruleDomains("https://example.com"))
+	assert.Nil(t, ruleDomains("not a url"))
+}
+
+func TestValidateScrapeRule(t *testing.T) {
+	fields := validateScrapeRule(&domain.ScrapeRule{
+		Domain:            "example.com",
+		Title:             "h1[",
+		Ingredients:       "$.recipeIngredient",
+		IngredientCleanup: []string{"ok", "(unclosed"},
+	})
+	assert.Contains(t, fields, "title")
+	assert.Contains(t, fields, "ingredient_cleanup.1")
+	assert.NotContains(t, fields, "ingredients")
+	assert.NotContains(t, fields, "domain")
+}
@@ -2,14 +2,18 @@ package services
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/borschtapp/kapusta"
 	"github.com/borschtapp/krip"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
 )
 
 type mockScraperProvider struct {
@@ -24,6 +28,14 @@ func (m *mockScraperProvider) UrlInput(url string, opts krip.ScrapeOptions) (*kr
 	return args.Get(0).(*krip.DataInput), args.Error(1)
 }
 
+func (m *mockScraperProvider) HtmlInput(content []byte, url string, opts krip.ScrapeOptions) (*krip.DataInput, error) {
+	args := m.Called(content, url, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*krip.DataInput), args.Error(1)
+}
+
 func (m *mockScraperProvider) Scrape(data *krip.DataInput, target *krip.Recipe, opts krip.ScrapeOptions) error {
 	args := m.Called(data, target, opts)
 	if recipe := args.Get(0); recipe != nil {
@@ -65,7 +77,7 @@ func newTestScraperService(t *testing.T) (*scraperService, *mockScraperProvider)
 	t.Helper()
 	p := &mockScraperProvider{}
 	p.On("ParseIngredient", mock.Anything, mock.Anything).Return(kapusta.Ingredient{}, nil).Maybe()
-	svc := NewScraperService(p, p).(*scraperService)
+	svc := NewScraperService(p, p, nil).(*scraperService)
 	return svc, p
 }
 
@@ -115,3 +127,77 @@ func TestScraperService_ScrapeUrl_Feed(t *testing.T) {
 	assert.Equal(t, "Test Feed", res.Feed.Name)
 	mockProvider.AssertExpectations(t)
 }
+
+func TestScraperService_ScrapeHtml_UsesClientPage(t *testing.T) {
+	service, mockProvider := newTestScraperService(t)
+
+	url := "https://example.com/members/recipe"
+	page := []byte("<html><body>Members only</body></html>")
+	data := &krip.DataInput{}
+	recipe := &krip.Recipe{
+		Url:          url,
+		Name:         "Members Recipe",
+		Ingredients:  []*krip.PropertyValue{{Name: "Ingredient 1"}},
+		Instructions: []*krip.HowToSection{{HowToStep: krip.HowToStep{Text: "Step 1"}}},
+	}
+
+	mockProvider.On("HtmlInput", page, url, mock.MatchedBy(func(opts krip.ScrapeOptions) bool {
+		return opts.RequestOptions.HttpClient != nil // requests made while scraping still go through safeurl
+	})).Return(data, nil)
+	mockProvider.On("Scrape", data, mock.Anything, mock.Anything).Return(recipe, nil)
+
+	res, err := service.ScrapeHtml(context.Background(), page, url, domain.ImportTypeRecipe)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PageTypeRecipe, res.Type)
+	assert.Equal(t, url, *res.Recipe.SourceUrl)
+	mockProvider.AssertNotCalled(t, "UrlInput", mock.Anything, mock.Anything)
+	mockProvider.AssertExpectations(t)
+}
+
+type fakeScrapeRuleRepo struct {
+	domain.ScrapeRuleRepository
+	rules []domain.ScrapeRule
+}
+
+func (r *fakeScrapeRuleRepo) ByDomains(domains []string) ([]domain.ScrapeRule, error) {
+	var found []domain.ScrapeRule
+	for _, rule := range r.rules {
+		if slices.Contains(domains, rule.Domain) {
+			found = append(found, rule)
+		}
+	}
+	return found, nil
+}
+
+func TestScraperService_ScrapeUrl_DisabledDomain(t *testing.T) {
+	service, mockProvider := newTestScraperService(t)
+	service.rules = &fakeScrapeRuleRepo{rules: []domain.ScrapeRule{{Domain: "example.com", Disabled: true}}}
+
+	_, err := service.ScrapeUrl(context.Background(), "https://www.recipes.example.com/borscht", domain.ImportTypeAuto)
+
+	assert.ErrorIs(t, err, sentinels.BadRequest(""))
+	mockProvider.AssertNotCalled(t, "UrlInput", mock.Anything, mock.Anything)
+}
+
+func TestScraperService_ScrapeRecipe_AppliesMostSpecificRule(t *testing.T) {
+	service, mockProvider := newTestScraperService(t)
+	service.rules = &fakeScrapeRuleRepo{rules: []domain.ScrapeRule{
+		{Domain: "example.com", Disabled: true},
+		{Domain: "recipes.example.com", SkipKrip: true, Title: "h1", Ingredients: "ul.ingredients li"},
+	}}
+
+	url := "https://recipes.example.com/borscht"
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<h1>Borscht</h1><ul class="ingredients"><li>2 beets</li><li>1 onion</li></ul>`))
+	assert.NoError(t, err)
+	data := &krip.DataInput{Url: url, Document: doc}
+	mockProvider.On("UrlInput", url, mock.Anything).Return(data, nil)
+
+	recipe, err := service.ScrapeRecipe(context.Background(), url)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Borscht", *recipe.Name)
+	assert.Equal(t, url, *recipe.SourceUrl)
+	assert.Len(t, recipe.Ingredients, 2)
+	mockProvider.AssertNotCalled(t, "Scrape", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -14,14 +14,15 @@ import (
 )
 
 type shoppingListService struct {
-	repo        domain.ShoppingListRepository
-	parser      IngredientParser
-	foodService domain.FoodService
-	unitService domain.UnitService
+	repo          domain.ShoppingListRepository
+	parser        IngredientParser
+	foodService   domain.FoodService
+	unitService   domain.UnitService
+	recipeService domain.RecipeService
 }
 
-func NewShoppingListService(repo domain.ShoppingListRepository, parser IngredientParser, foodService domain.FoodService, unitService domain.UnitService) domain.ShoppingListService {
-	return &shoppingListService{repo: repo, parser: parser, foodService: foodService, unitService: unitService}
+func NewShoppingListService(repo domain.ShoppingListRepository, parser IngredientParser, foodService domain.FoodService, unitService domain.UnitService, recipeService domain.RecipeService) domain.ShoppingListService {
+	return &shoppingListService{repo: repo, parser: parser, foodService: foodService, unitService: unitService, recipeService: recipeService}
 }
 
 // ensureOwned fetches a list by ID and verifies household ownership.
@@ -153,6 +154,32 @@ func (s *shoppingListService) AddItems(ctx context.Context, items []*domain.Shop
 	return nil
 }
 
+func (s *shoppingListService) AddRecipe(ctx context.Context, recipeID uuid.UUID, scale domain.RecipeScale, listID uuid.UUID, householdID uuid.UUID) ([]*domain.ShoppingItem, error) {
+	ingredients, err := s.recipeService.ExpandIngredients(recipeID, householdID, scale)
+	if err != nil {
+		return nil, fmt.Errorf("add recipe (expand ingredients): %w", err)
+	}
+
+	items := make([]*domain.ShoppingItem, 0, len(ingredients))
+	for _, ing := range ingredients {
+		item := &domain.ShoppingItem{Amount: ing.Amount, UnitID: ing.UnitID, FoodID: ing.FoodID, Text: ing.RawText}
+		if ing.Name != nil {
+			item.Text = *ing.Name
+		} else if ing.Food != nil {
+			item.Text = ing.Food.Name
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	if err := s.AddItems(ctx, items, listID, householdID); err != nil {
+		return nil, fmt.Errorf("add recipe: %w", err)
+	}
+	return items, nil
+}
+
 // parseItemText uses kapusta to extract amount, food, and unit from raw text.
 func (s *shoppingListService) parseItemText(ctx context.Context, item *domain.ShoppingItem) {
 	parsed, err := s.parser.ParseIngredient(item.Text, kapusta.IngredientOptions{})
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v3/log"
+	"github.com/google/uuid"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+)
+
+// trashPurgeBatch caps how many expired items PurgeExpired loads per round trip.
+const trashPurgeBatch = 100
+
+type trashService struct {
+	repo         domain.TrashRepository
+	recipeRepo   domain.RecipeRepository
+	imageService domain.ImageService
+}
+
+func NewTrashService(repo domain.TrashRepository, recipeRepo domain.RecipeRepository, imageService domain.ImageService) domain.TrashService {
+	return &trashService{repo: repo, recipeRepo: recipeRepo, imageService: imageService}
+}
+
+func (s *trashService) List(householdID uuid.UUID, offset, limit int) ([]domain.TrashItem, int64, error) {
+	items, total, err := s.repo.List(householdID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list: %w", err)
+	}
+	return items, total, nil
+}
+
+func (s *trashService) Restore(itemType domain.TrashItemType, id uuid.UUID, householdID uuid.UUID) error {
+	if !itemType.Valid() {
+		return sentinels.BadRequest("unknown trash item type: " + string(itemType))
+	}
+	if err := s.repo.Restore(itemType, id, householdID); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	return nil
+}
+
+func (s *trashService) Purge(itemType domain.TrashItemType, id uuid.UUID, householdID uuid.UUID) error {
+	if !itemType.Valid() {
+		return sentinels.BadRequest("unknown trash item type: " + string(itemType))
+	}
+	item, err := s.repo.ByID(itemType, id, householdID)
+	if err != nil {
+		return fmt.Errorf("purge (fetch item): %w", err)
+	}
+	if err := s.purge(item); err != nil {
+		return fmt.Errorf("purge: %w", err)
+	}
+	return nil
+}
+
+func (s *trashService) PurgeExpired(retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+	purged := 0
+	// failed items would be returned again, so they are skipped for the rest of the run
+	var failed []uuid.UUID
+	for {
+		items, err := s.repo.Expired(cutoff, failed, trashPurgeBatch)
+		if err != nil {
+			return purged, fmt.Errorf("purge expired (list): %w", err)
+		}
+
+		for i := range items {
+			if err := s.purge(&items[i]); err != nil {
+				log.Warnw("failed to purge trashed item", "type", items[i].Type, "id", items[i].ID, "error", err.Error())
+				failed = append(failed, items[i].ID)
+				continue
+			}
+			purged++
+		}
+
+		if len(items) < trashPurgeBatch {
+			return purged, nil
+		}
+	}
+}
+
+// purge permanently deletes a trashed item. Recipe images live in storage, not behind
+// a foreign key, so they are removed explicitly once the recipe row is gone.
+func (s *trashService) purge(item *domain.TrashItem) error {
+	var images []*domain.Image
+	if item.Type == domain.TrashRecipe {
+		recipe, err := s.recipeRepo.TrashedByID(item.ID)
+		if err != nil {
+			return fmt.Errorf("fetch recipe: %w", err)
+		}
+		// clones share their images with the global parent recipe
+		if recipe.ParentID == nil {
+			images = recipe.Images
+		}
+	}
+
+	if err := s.repo.Purge(item.Type, item.ID, item.HouseholdID); err != nil {
+		return fmt.Errorf("persist: %w", err)
+	}
+
+	for _, image := range images {
+		if err := s.imageService.Delete(image.ID); err != nil {
+			log.Warnw("failed to delete image", "image_id", image.ID, "error", err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package services_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"borscht.app/smetana/domain"
+	"borscht.app/smetana/internal/sentinels"
+	"borscht.app/smetana/internal/services"
+)
+
+func TestTrashService_Purge_OwnRecipe_DeletesImages(t *testing.T) {
+	hid := uuid.New()
+	recipeID := uuid.New()
+	imageID := uuid.New()
+
+	trashRepo := &stubTrashRepo{
+		byIDFn: func(itemType domain.TrashItemType, id, _ uuid.UUID) (*domain.TrashItem, error) {
+			return &domain.TrashItem{ID: id, Type: itemType, HouseholdID: hid}, nil
+		},
+		purgeFn: func(itemType domain.TrashItemType, id, householdID uuid.UUID) error {
+			assert.Equal(t, domain.TrashRecipe, itemType)
+			assert.Equal(t, recipeID, id)
+			assert.Equal(t, hid, householdID)
+			return nil
+		},
+	}
+	recipeRepo := &stubRecipeRepo{
+		trashedByIDFn: func(id uuid.UUID) (*domain.Recipe, error) {
+			return &domain.Recipe{ID: id, HouseholdID: &hid, Images: []*domain.Image{{ID: imageID}}}, nil
+		},
+	}
+	var deleted []uuid.UUID
+	imgSvc := &stubImageService{
+		deleteFn: func(id uuid.UUID) error {
+			deleted = append(deleted, id)
+			return nil
+		},
+	}
+
+	svc := services.NewTrashService(trashRepo, recipeRepo, imgSvc)
+	err := svc.Purge(domain.TrashRecipe, recipeID, hid)
+
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{imageID}, deleted)
+}
+
+func TestTrashService_Purge_GlobalRecipeClone_DoesNotDeleteSharedImages(t *testing.T) {
+	// A household's clone of a global recipe shares images with the parent and other clones.
+	hid := uuid.New()
+	parentID := uuid.New()
+
+	trashRepo := &stubTrashRepo{
+		byIDFn: func(itemType domain.TrashItemType, id, _ uuid.UUID) (*domain.TrashItem, error) {
+			return &domain.TrashItem{ID: id, Type: itemType, HouseholdID: hid}, nil
+		},
+		purgeFn: func(_ domain.TrashItemType, _, _ uuid.UUID) error { return nil },
+	}
+	recipeRepo := &stubRecipeRepo{
+		trashedByIDFn: func(id uuid.UUID) (*domain.Recipe, error) {
+			return &domain.Recipe{ID: id, ParentID: &parentID, HouseholdID: &hid, Images: []*domain.Image{{ID: uuid.New()}}}, nil
+		},
+	}
+	imgSvc := &stubImageService{
+		deleteFn: func(_ uuid.UUID) error {
+			t.Fatal("shared images must not be deleted when a clone is purged")
+			return nil
+		},
+	}
+
+	svc := services.NewTrashService(trashRepo, recipeRepo, imgSvc)
+	require.NoError(t, svc.Purge(domain.TrashRecipe, uuid.New(), hid))
+}
+
+func TestTrashService_Purge_RepoFailure_KeepsImages(t *testing.T) {
+	hid := uuid.New()
+
+	trashRepo := &stubTrashRepo{
+		byIDFn: func(itemType domain.TrashItemType, id, _ uuid.UUID) (*domain.TrashItem, error) {
+			return &domain.TrashItem{ID: id, Type: itemType, HouseholdID: hid}, nil
+		},
+		purgeFn: func(_ domain.TrashItemType, _, _ uuid.UUID) error { return assert.AnError },
+	}
+	recipeRepo := &stubRecipeRepo{
+		trashedByIDFn: func(id uuid.UUID) (*domain.Recipe, error) {
+			return &domain.Recipe{ID: id, HouseholdID: &hid, Images: []*domain.Image{{ID: uuid.New()}}}, nil
+		},
+	}
+	imgSvc := &stubImageService{
+		deleteFn: func(_ uuid.UUID) error {
+			t.Fatal("images must not be deleted when the recipe row could not be purged")
+			return nil
+		},
+	}
+
+	svc := services.NewTrashService(trashRepo, recipeRepo, imgSvc)
+	err := svc.Purge(domain.TrashRecipe, uuid.New(), hid)
+
+	require.ErrorIs(t, err, assert.AnError)
+}
+
+func TestTrashService_Restore_UnknownType_ReturnsBadRequest(t *testing.T) {
+	svc := services.NewTrashService(&stubTrashRepo{}, &stubRecipeRepo{}, &stubImageService{})
+
+	err := svc.Restore("pantry", uuid.New(), uuid.New())
+
+	var sentinel *sentinels.Error
+	require.ErrorAs(t, err, &sentinel)
+	assert.Equal(t, 400, sentinel.Status)
+}
+
+func TestTrashService_PurgeExpired_PurgesAllItemsOlderThanRetention(t *testing.T) {
+	hid := uuid.New()
+	items := []domain.TrashItem{
+		{ID: uuid.New(), Type: domain.TrashCollection, HouseholdID: hid},
+		{ID: uuid.New(), Type: domain.TrashMealPlan, HouseholdID: hid},
+	}
+
+	var cutoff time.Time
+	trashRepo := &stubTrashRepo{
+		expiredFn: func(before time.Time, _ []uuid.UUID, _ int) ([]domain.TrashItem, error) {
+			cutoff = before
+			return items, nil
+		},
+	}
+	var purged []uuid.UUID
+	trashRepo.purgeFn = func(_ domain.TrashItemType, id, householdID uuid.UUID) error {
+		assert.Equal(t, hid, householdID)
+		purged = append(purged, id)
+		return nil
+	}
+
+	svc := services.NewTrashService(trashRepo, &stubRecipeRepo{}, &stubImageService{})
+	count, err := svc.PurgeExpired(7 * 24 * time.Hour)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []uuid.UUID{items[0].ID, items[1].ID}, purged)
+	assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), cutoff, time.Minute)
+}
+
+func TestTrashService_PurgeExpired_FailedItem_SkippedAndPurgingContinues(t *testing.T) {
+	hid := uuid.New()
+	stuck := domain.TrashItem{ID: uuid.New(), Type: domain.TrashShoppingList, HouseholdID: hid}
+	remaining := 150
+	var excluded [][]uuid.UUID
+	trashRepo := &stubTrashRepo{
+		expiredFn: func(_ time.Time, exclude []uuid.UUID, limit int) ([]domain.TrashItem, error) {
+			excluded = append(excluded, slices.Clone(exclude))
+			var batch []domain.TrashItem
+			if !slices.Contains(exclude, stuck.ID) {
+				batch = append(batch, stuck)
+			}
+			for len(batch) < limit && remaining > 0 {
+				remaining--
+				batch = append(batch, domain.TrashItem{ID: uuid.New(), Type: domain.TrashShoppingList, HouseholdID: hid})
+			}
+			return batch, nil
+		},
+		purgeFn: func(_ domain.TrashItemType, id, _ uuid.UUID) error {
+			if id == stuck.ID {
+				return assert.AnError
+			}
+			return nil
+		},
+	}
+
+	svc := services.NewTrashService(trashRepo, &stubRecipeRepo{}, &stubImageService{})
+	count, err := svc.PurgeExpired(time.Hour)
+
+	require.NoError(t, err)
+	assert.Equal(t, 150, count, "an item failing to purge must not block the ones after it")
+	require.Len(t, excluded, 2)
+	assert.Empty(t, excluded[0])
+	assert.Equal(t, []uuid.UUID{stuck.ID}, excluded[1], "a failed item is not fetched again in the same run")
+}
@@ -39,6 +39,13 @@ func (s *unitService) FindOrCreate(unit *domain.Unit) error {
 	return nil
 }
 
+func (s *unitService) Find(unit *domain.Unit) error {
+	if err := s.repo.Find(unit); err != nil {
+		return fmt.Errorf("find: %w", err)
+	}
+	return nil
+}
+
 func (s *unitService) Merge(keepID, mergeID uuid.UUID) error {
 	if keepID == mergeID {
 		return sentinels.BadRequest("cannot merge a unit into itself")
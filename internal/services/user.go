@@ -31,7 +31,7 @@ func (s *userService) ByID(id uuid.UUID, requesterID uuid.UUID) (*domain.User, e
 }
 
 // Update fetches the user, applies the non-nil patches, and persists the result.
-func (s *userService) Update(id uuid.UUID, requesterID uuid.UUID, name, email, currentPassword, newPassword *string) (*domain.User, error) {
+func (s *userService) Update(id uuid.UUID, requesterID uuid.UUID, name, email, currentPassword, newPassword *string, unitSystem *domain.UnitSystem) (*domain.User, error) {
 	if id != requesterID {
 		return nil, sentinels.ErrForbidden
 	}
@@ -57,6 +57,9 @@ func (s *userService) Update(id uuid.UUID, requesterID uuid.UUID, name, email, c
 	if name != nil {
 		user.Name = *name
 	}
+	if unitSystem != nil {
+		user.UnitSystem = *unitSystem
+	}
 	if err := s.repo.Update(user); err != nil {
 		return nil, fmt.Errorf("update (persist): %w", err)
 	}
@@ -58,6 +58,17 @@ func (s *LocalStorage) Save(path string, content io.Reader, size int64, contentT
 	return err
 }
 
+func (s *LocalStorage) Open(path string) (io.ReadCloser, error) {
+	// Clean and validate path to prevent directory traversal
+	fullPath := filepath.Join(s.Root, filepath.Clean(path))
+	relPath, err := filepath.Rel(s.Root, fullPath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return nil, errors.New("invalid path: directory traversal attempt")
+	}
+
+	return os.Open(fullPath) // #nosec G304 - path is validated above
+}
+
 func (s *LocalStorage) Delete(path string) error {
 	// Clean and validate path to prevent directory traversal
 	fullPath := filepath.Join(s.Root, filepath.Clean(path))
@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"io/fs"
 	"time"
 
 	"borscht.app/smetana/internal/utils"
@@ -60,6 +62,17 @@ func (s *S3Storage) Save(path string, content io.Reader, size int64, contentType
 	return err
 }
 
+func (s *S3Storage) Open(path string) (io.ReadCloser, error) {
+	data, err := s.store.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fs.ErrNotExist // the fiber storage reports missing keys as empty values
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
 func (s *S3Storage) Delete(path string) error {
 	return s.store.Delete(path)
 }
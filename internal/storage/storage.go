@@ -11,6 +11,8 @@ import (
 // FileStorage is the interface for persisting and retrieving binary files.
 type FileStorage interface {
 	Save(path string, content io.Reader, size int64, contentType string) error
+	// Open reads back a file previously written with Save.
+	Open(path string) (io.ReadCloser, error)
 	Delete(path string) error
 	GetBaseURL() string
 }
@@ -91,3 +91,8 @@ func DurationFromISO8601(iso string) (Duration, error) {
 	}
 	return Duration(d.ToTimeDuration()), nil
 }
+
+// ISO8601 formats the duration as an ISO 8601 duration string (e.g., "PT1H20M")
+func (d Duration) ISO8601() string {
+	return duration.Format(time.Duration(d))
+}
@@ -228,3 +228,32 @@ func TestDuration_RoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestDuration_ISO8601(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Duration
+		expected string
+	}{
+		{
+			name:     "minutes",
+			input:    Duration(20 * time.Minute),
+			expected: "PT20M",
+		},
+		{
+			name:     "hours and minutes",
+			input:    Duration(90 * time.Minute),
+			expected: "PT1H30M",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.input.ISO8601())
+
+			parsed, err := DurationFromISO8601(tt.input.ISO8601())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input, parsed)
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"borscht.app/smetana/internal/configs"
 	"borscht.app/smetana/internal/database"
 	"borscht.app/smetana/internal/handlers"
+	"borscht.app/smetana/internal/middlewares"
 	"borscht.app/smetana/internal/routes"
 	"borscht.app/smetana/internal/storage"
 	"borscht.app/smetana/internal/utils"
@@ -84,6 +85,7 @@ func main() {
 	app.Use(recover.New(configs.RecoverConfig()))
 	app.Use(helmet.New())
 	app.Use(etag.New())
+	app.Use(middlewares.BodyLimit(configs.BodyLimit(), configs.ImportFileLimit(), "/api/v1/import/file"))
 
 	if utils.GetenvBool("ENABLE_LIMITER", false) {
 		app.Use(limiter.New())
@@ -106,7 +108,7 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	if err := routes.RegisterApiRoutes(ctx, apiGroup, storageCfg.Storage, db); err != nil {
+	if err := routes.RegisterApiRoutes(ctx, apiGroup, storageCfg.Storage, storageCfg.Private, db); err != nil {
 		log.Fatalw("failed to register api routes", "error", err.Error())
 	}
 